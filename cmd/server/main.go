@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/weprodev/wpd-message-gateway/internal/app"
 )
@@ -33,10 +38,28 @@ func main() {
 	router := application.Router.Setup()
 
 	port := resolvePort(cfg)
-	log.Printf("Gateway server listening on :%s", port)
+	server := &http.Server{Addr: ":" + port, Handler: router}
 
-	if err := http.ListenAndServe(":"+port, router); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	go func() {
+		log.Printf("Gateway server listening on :%s", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	log.Printf("Shutting down...")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown error: %v", err)
+	}
+	if err := application.Close(ctx); err != nil {
+		log.Printf("Shutdown error: %v", err)
 	}
 }
 
@@ -2,12 +2,18 @@ package app
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
 )
 
 // Config represents the application configuration.
@@ -17,12 +23,28 @@ type Config struct {
 	DevBox      DevBoxConfig   `yaml:"devbox"`
 	Providers   ProviderConfig `yaml:"providers"`
 	Mailpit     MailpitConfig  `yaml:"mailpit,omitempty"`
+	Retry       RetryConfig    `yaml:"retry,omitempty"`
+	Breaker     BreakerConfig  `yaml:"circuit_breaker,omitempty"`
+	Queue       QueueConfig    `yaml:"queue,omitempty"`
+	Ledger      LedgerConfig   `yaml:"ledger,omitempty"`
+	Events      EventsConfig   `yaml:"events,omitempty"`
+
+	Idempotency IdempotencyConfig `yaml:"idempotency,omitempty"`
+	Templates   TemplatesConfig   `yaml:"templates,omitempty"`
+	Auth        AuthConfig        `yaml:"auth,omitempty"`
+
+	// Tenants have their own providers, used instead of Providers for
+	// requests made for them.
+	Tenants map[string]TenantConfig `yaml:"tenants,omitempty"`
 
 	// Parsed provider configs - using registry types as single source of truth
 	EmailProviders map[string]registry.EmailConfig `yaml:"-"`
 	SMSProviders   map[string]registry.SMSConfig   `yaml:"-"`
 	PushProviders  map[string]registry.PushConfig  `yaml:"-"`
 	ChatProviders  map[string]registry.ChatConfig  `yaml:"-"`
+
+	// tenant is set on the configuration returned by ForTenant.
+	tenant string
 }
 
 // MailpitConfig holds SMTP forwarding configuration.
@@ -30,9 +52,214 @@ type MailpitConfig struct {
 	Enabled bool `yaml:"enabled,omitempty"`
 }
 
+// RetryConfig holds the default retry policy and per-channel overrides.
+type RetryConfig struct {
+	RetryPolicyConfig `yaml:",inline"`
+	Email             *RetryPolicyConfig `yaml:"email,omitempty"`
+	SMS               *RetryPolicyConfig `yaml:"sms,omitempty"`
+	Push              *RetryPolicyConfig `yaml:"push,omitempty"`
+	Chat              *RetryPolicyConfig `yaml:"chat,omitempty"`
+}
+
+// RetryPolicyConfig holds retry settings. Unset fields use the defaults.
+type RetryPolicyConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts,omitempty"`
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty"`
+	MaxBackoff     time.Duration `yaml:"max_backoff,omitempty"`
+	Multiplier     float64       `yaml:"multiplier,omitempty"`
+	Jitter         float64       `yaml:"jitter,omitempty"`
+	RetryTimeouts  bool          `yaml:"retry_timeouts,omitempty"`
+}
+
+func (p *RetryPolicyConfig) policy() *resilience.RetryPolicy {
+	if p == nil {
+		return nil
+	}
+	return &resilience.RetryPolicy{
+		MaxAttempts:    p.MaxAttempts,
+		InitialBackoff: p.InitialBackoff,
+		MaxBackoff:     p.MaxBackoff,
+		Multiplier:     p.Multiplier,
+		Jitter:         p.Jitter,
+		RetryTimeouts:  p.RetryTimeouts,
+	}
+}
+
+// RetryPolicies returns the configured retry policies.
+func (c *Config) RetryPolicies() resilience.RetryPolicies {
+	return resilience.RetryPolicies{
+		Default: *c.Retry.RetryPolicyConfig.policy(),
+		Email:   c.Retry.Email.policy(),
+		SMS:     c.Retry.SMS.policy(),
+		Push:    c.Retry.Push.policy(),
+		Chat:    c.Retry.Chat.policy(),
+	}
+}
+
+// BreakerConfig holds circuit breaker settings shared by all providers.
+// Unset fields use the defaults.
+type BreakerConfig struct {
+	Disabled         bool          `yaml:"disabled,omitempty"`
+	FailureThreshold int           `yaml:"failure_threshold,omitempty"`
+	Cooldown         time.Duration `yaml:"cooldown,omitempty"`
+	HalfOpenMaxCalls int           `yaml:"half_open_max_calls,omitempty"`
+	SuccessThreshold int           `yaml:"success_threshold,omitempty"`
+}
+
+// BreakerSettings returns the configured circuit breaker settings.
+func (c *Config) BreakerSettings() resilience.BreakerSettings {
+	return resilience.BreakerSettings{
+		FailureThreshold: c.Breaker.FailureThreshold,
+		Cooldown:         c.Breaker.Cooldown,
+		HalfOpenMaxCalls: c.Breaker.HalfOpenMaxCalls,
+		SuccessThreshold: c.Breaker.SuccessThreshold,
+	}
+}
+
+// QueueConfig holds the durable queue used by async sends.
+type QueueConfig struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	Dir     string `yaml:"dir,omitempty"`
+	Workers int    `yaml:"workers,omitempty"`
+}
+
+// QueueDir returns the queue directory, defaulting to data/queue.
+func (c *Config) QueueDir() string {
+	if c.Queue.Dir == "" {
+		return "data/queue"
+	}
+	return c.Queue.Dir
+}
+
+// LedgerConfig holds message ledger settings. Without a dir the ledger is
+// kept in memory only.
+type LedgerConfig struct {
+	Dir        string        `yaml:"dir,omitempty"`
+	Retention  time.Duration `yaml:"retention,omitempty"`
+	MaxRecords int           `yaml:"max_records,omitempty"`
+}
+
+// IdempotencyConfig holds Idempotency-Key settings. Keys are kept in memory
+// only: after a restart, or on another replica, a repeated key sends again.
+type IdempotencyConfig struct {
+	// TTL is how long a key's first result is replayed. Defaults to 24h.
+	TTL time.Duration `yaml:"ttl,omitempty"`
+}
+
+// TemplatesConfig holds message template storage. Without a dir templates
+// are kept in memory only.
+type TemplatesConfig struct {
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// EventsConfig holds the applications notified of message events.
+type EventsConfig struct {
+	// Dir holds undelivered events. Defaults to data/events.
+	Dir string `yaml:"dir,omitempty"`
+	// Retry is the default redelivery policy for subscribers.
+	Retry       RetryPolicyConfig       `yaml:"retry,omitempty"`
+	Subscribers []EventSubscriberConfig `yaml:"subscribers,omitempty"`
+}
+
+// EventSubscriberConfig holds one event subscriber.
+type EventSubscriberConfig struct {
+	Name   string             `yaml:"name"`
+	URL    string             `yaml:"url"`
+	Secret string             `yaml:"secret,omitempty"`
+	Events []string           `yaml:"events,omitempty"`
+	Retry  *RetryPolicyConfig `yaml:"retry,omitempty"`
+}
+
+// EventsDir returns the dead letter directory, defaulting to data/events.
+func (c *Config) EventsDir() string {
+	if c.Events.Dir == "" {
+		return "data/events"
+	}
+	return c.Events.Dir
+}
+
+// EventSubscribers returns the configured event subscribers with their
+// retry policies merged over the events default.
+func (c *Config) EventSubscribers() []service.Subscriber {
+	base := c.Events.Retry.policy().Merge(service.DefaultEventRetryPolicy())
+
+	subscribers := make([]service.Subscriber, 0, len(c.Events.Subscribers))
+	for _, s := range c.Events.Subscribers {
+		retry := base
+		if s.Retry != nil {
+			retry = s.Retry.policy().Merge(base)
+		}
+		events := make([]contracts.EventType, len(s.Events))
+		for i, e := range s.Events {
+			events[i] = contracts.EventType(e)
+		}
+		subscribers = append(subscribers, service.Subscriber{
+			Name:   s.Name,
+			URL:    s.URL,
+			Secret: s.Secret,
+			Events: events,
+			Retry:  retry,
+		})
+	}
+	return subscribers
+}
+
+// AuthConfig holds the API keys. Without keys the API is open.
+type AuthConfig struct {
+	APIKeys []APIKeyConfig `yaml:"api_keys,omitempty"`
+}
+
+// APIKeyConfig holds one API key.
+type APIKeyConfig struct {
+	ID string `yaml:"id"`
+	// Hash is the hex SHA-256 of the key, never the key itself.
+	Hash   string   `yaml:"hash"`
+	Scopes []string `yaml:"scopes"`
+	// Tenant binds the key to a tenant.
+	Tenant string `yaml:"tenant,omitempty"`
+}
+
+// APIKeys returns the configured API keys.
+func (c *Config) APIKeys() []service.APIKey {
+	keys := make([]service.APIKey, 0, len(c.Auth.APIKeys))
+	for _, k := range c.Auth.APIKeys {
+		keys = append(keys, service.APIKey{ID: k.ID, Hash: k.Hash, Scopes: k.Scopes, Tenant: k.Tenant})
+	}
+	return keys
+}
+
+// TenantConfig holds a tenant's default providers, failover chains and
+// provider credentials. A tenant does not inherit the global providers.
+type TenantConfig struct {
+	Providers ProviderConfig `yaml:"providers"`
+}
+
+// TenantIDs returns the configured tenants in order.
+func (c *Config) TenantIDs() []string {
+	return slices.Sorted(maps.Keys(c.Tenants))
+}
+
+// ForTenant returns the configuration with the tenant's providers in place
+// of the global ones.
+func (c *Config) ForTenant(id string) *Config {
+	t := *c
+	t.Providers = c.Tenants[id].Providers
+	t.Tenants = nil
+	t.EmailProviders = make(map[string]registry.EmailConfig)
+	t.SMSProviders = make(map[string]registry.SMSConfig)
+	t.PushProviders = make(map[string]registry.PushConfig)
+	t.ChatProviders = make(map[string]registry.ChatConfig)
+	t.tenant = id
+	t.parseProviderConfigs()
+	return &t
+}
+
 // ServerConfig holds server configuration.
 type ServerConfig struct {
 	Port int `yaml:"port"`
+	// CORSOrigins are the origins browsers may call the API from. Without
+	// any, cross-origin requests are not allowed.
+	CORSOrigins []string `yaml:"cors_origins,omitempty"`
 }
 
 // DevBoxConfig holds devbox configuration.
@@ -44,6 +271,7 @@ type DevBoxConfig struct {
 // ProviderConfig holds provider configuration.
 type ProviderConfig struct {
 	Defaults ProviderDefaults          `yaml:"defaults"`
+	Failover ProviderFailover          `yaml:"failover"`
 	Email    map[string]EmailConfigMap `yaml:"email"`
 	SMS      map[string]SMSConfigMap   `yaml:"sms"`
 	Push     map[string]PushConfigMap  `yaml:"push"`
@@ -58,6 +286,14 @@ type ProviderDefaults struct {
 	Chat  string `yaml:"chat"`
 }
 
+// ProviderFailover holds the ordered providers tried after the default fails.
+type ProviderFailover struct {
+	Email []string `yaml:"email"`
+	SMS   []string `yaml:"sms"`
+	Push  []string `yaml:"push"`
+	Chat  []string `yaml:"chat"`
+}
+
 type EmailConfigMap map[string]string
 type SMSConfigMap map[string]string
 type PushConfigMap map[string]string
@@ -68,6 +304,29 @@ func (c *Config) DefaultSMSProvider() string   { return c.Providers.Defaults.SMS
 func (c *Config) DefaultPushProvider() string  { return c.Providers.Defaults.Push }
 func (c *Config) DefaultChatProvider() string  { return c.Providers.Defaults.Chat }
 
+func (c *Config) EmailFailover() []string { return c.Providers.Failover.Email }
+func (c *Config) SMSFailover() []string   { return c.Providers.Failover.SMS }
+func (c *Config) PushFailover() []string  { return c.Providers.Failover.Push }
+func (c *Config) ChatFailover() []string  { return c.Providers.Failover.Chat }
+
+// CommonProviderConfig returns the shared settings of a provider configured
+// under any channel.
+func (c *Config) CommonProviderConfig(name string) (registry.CommonConfig, bool) {
+	if cfg, ok := c.EmailProviders[name]; ok {
+		return cfg.CommonConfig, true
+	}
+	if cfg, ok := c.SMSProviders[name]; ok {
+		return cfg.CommonConfig, true
+	}
+	if cfg, ok := c.PushProviders[name]; ok {
+		return cfg.CommonConfig, true
+	}
+	if cfg, ok := c.ChatProviders[name]; ok {
+		return cfg.CommonConfig, true
+	}
+	return registry.CommonConfig{}, false
+}
+
 // LoadConfig loads configuration from a YAML file.
 func LoadConfig(path string) (*Config, error) {
 	if path == "" {
@@ -123,8 +382,29 @@ func (c *Config) applyEnvOverrides() {
 			c.Providers.Defaults.Push = val
 		case "MESSAGE_DEFAULT_CHAT_PROVIDER":
 			c.Providers.Defaults.Chat = val
+		case "MESSAGE_EMAIL_FAILOVER":
+			c.Providers.Failover.Email = splitList(val)
+		case "MESSAGE_SMS_FAILOVER":
+			c.Providers.Failover.SMS = splitList(val)
+		case "MESSAGE_PUSH_FAILOVER":
+			c.Providers.Failover.Push = splitList(val)
+		case "MESSAGE_CHAT_FAILOVER":
+			c.Providers.Failover.Chat = splitList(val)
+		case "MESSAGE_CORS_ORIGINS":
+			c.Server.CORSOrigins = splitList(val)
+		}
+	}
+}
+
+// splitList parses a comma-separated environment value.
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
+	return items
 }
 
 // parseProviderConfigs converts raw map configs into typed configs.
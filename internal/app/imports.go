@@ -4,6 +4,20 @@ package app
 
 import (
 	// Built-in providers
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/apns"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/discord"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/firebase"
 	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/mailgun"
 	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/memory"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/onesignal"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/plivo"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/sendgrid"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/ses"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/slack"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/smtp"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/telegram"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/twilio"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/vonage"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/webhook"
+	_ "github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/whatsapp"
 )
@@ -1,6 +1,8 @@
 package app
 
 import (
+	"fmt"
+
 	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
 	"github.com/weprodev/wpd-message-gateway/internal/core/port"
 )
@@ -25,6 +27,7 @@ func (f *ProviderFactory) CreateEmailProvider(name string) (port.EmailSender, er
 	}
 
 	cfg := f.cfg.EmailProviders[name]
+	cfg.Tenant = f.cfg.tenant
 	mailpit := registry.MailpitConfig{Enabled: f.cfg.Mailpit.Enabled}
 	return factory(cfg, mailpit)
 }
@@ -36,7 +39,9 @@ func (f *ProviderFactory) CreateSMSProvider(name string) (port.SMSSender, error)
 		return nil, err
 	}
 
-	return factory(f.cfg.SMSProviders[name])
+	cfg := f.cfg.SMSProviders[name]
+	cfg.Tenant = f.cfg.tenant
+	return factory(cfg)
 }
 
 // CreatePushProvider creates a push provider by name.
@@ -46,7 +51,9 @@ func (f *ProviderFactory) CreatePushProvider(name string) (port.PushSender, erro
 		return nil, err
 	}
 
-	return factory(f.cfg.PushProviders[name])
+	cfg := f.cfg.PushProviders[name]
+	cfg.Tenant = f.cfg.tenant
+	return factory(cfg)
 }
 
 // CreateChatProvider creates a chat provider by name.
@@ -56,5 +63,23 @@ func (f *ProviderFactory) CreateChatProvider(name string) (port.ChatSender, erro
 		return nil, err
 	}
 
-	return factory(f.cfg.ChatProviders[name])
+	cfg := f.cfg.ChatProviders[name]
+	cfg.Tenant = f.cfg.tenant
+	return factory(cfg)
+}
+
+// CreateWebhookParser creates a provider's webhook parser from the provider's
+// config, whichever channel it is configured under.
+func (f *ProviderFactory) CreateWebhookParser(name string) (port.WebhookParser, error) {
+	factory, err := registry.GetWebhookParserFactory(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, ok := f.cfg.CommonProviderConfig(name)
+	if !ok {
+		return nil, fmt.Errorf("no configuration found for provider: %s", name)
+	}
+	cfg.Tenant = f.cfg.tenant
+	return factory(cfg)
 }
@@ -5,6 +5,7 @@ package registry
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/weprodev/wpd-message-gateway/internal/core/port"
@@ -22,6 +23,9 @@ type CommonConfig struct {
 	Region    string
 	BaseURL   string
 	Extra     map[string]string
+	// Tenant is the tenant the provider sends for, or "" for the global
+	// providers.
+	Tenant string
 }
 
 // EmailConfig holds email provider configuration.
@@ -64,12 +68,16 @@ type PushProviderFactory func(cfg PushConfig) (port.PushSender, error)
 // ChatProviderFactory creates a chat provider from config.
 type ChatProviderFactory func(cfg ChatConfig) (port.ChatSender, error)
 
+// WebhookParserFactory creates a provider's webhook parser from its config.
+type WebhookParserFactory func(cfg CommonConfig) (port.WebhookParser, error)
+
 var (
-	emailFactories = make(map[string]EmailProviderFactory)
-	smsFactories   = make(map[string]SMSProviderFactory)
-	pushFactories  = make(map[string]PushProviderFactory)
-	chatFactories  = make(map[string]ChatProviderFactory)
-	mu             sync.RWMutex
+	emailFactories   = make(map[string]EmailProviderFactory)
+	smsFactories     = make(map[string]SMSProviderFactory)
+	pushFactories    = make(map[string]PushProviderFactory)
+	chatFactories    = make(map[string]ChatProviderFactory)
+	webhookFactories = make(map[string]WebhookParserFactory)
+	mu               sync.RWMutex
 )
 
 // RegisterEmailProvider registers an email provider factory.
@@ -101,6 +109,14 @@ func RegisterChatProvider(name string, factory ChatProviderFactory) {
 	chatFactories[name] = factory
 }
 
+// RegisterWebhookParser registers a factory for a provider's delivery-status
+// webhook parser.
+func RegisterWebhookParser(name string, factory WebhookParserFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+	webhookFactories[name] = factory
+}
+
 // GetEmailFactory returns an email provider factory by name.
 func GetEmailFactory(name string) (EmailProviderFactory, error) {
 	mu.RLock()
@@ -180,3 +196,28 @@ func IsChatProviderRegistered(name string) bool {
 	_, ok := chatFactories[name]
 	return ok
 }
+
+// GetWebhookParserFactory returns a webhook parser factory by provider name.
+func GetWebhookParserFactory(name string) (WebhookParserFactory, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	factory, exists := webhookFactories[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown webhook provider: %s (not registered)", name)
+	}
+	return factory, nil
+}
+
+// WebhookParserNames returns the providers with a registered webhook parser, sorted.
+func WebhookParserNames() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(webhookFactories))
+	for name := range webhookFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
 )
 
 // ValidateConfig validates required configuration.
@@ -48,6 +49,35 @@ func ValidateConfig(cfg *Config) error {
 		)
 	}
 
+	failover := []struct {
+		channel    string
+		names      []string
+		registered func(string) bool
+	}{
+		{"email", cfg.EmailFailover(), registry.IsEmailProviderRegistered},
+		{"sms", cfg.SMSFailover(), registry.IsSMSProviderRegistered},
+		{"push", cfg.PushFailover(), registry.IsPushProviderRegistered},
+		{"chat", cfg.ChatFailover(), registry.IsChatProviderRegistered},
+	}
+	for _, f := range failover {
+		for _, name := range f.names {
+			if !f.registered(name) {
+				return fmt.Errorf("invalid configuration: providers.failover.%s (unknown provider: %s)", f.channel, name)
+			}
+		}
+	}
+
+	for _, id := range cfg.TenantIDs() {
+		if err := validateTenant(id, cfg.ForTenant(id)); err != nil {
+			return err
+		}
+	}
+	for _, key := range cfg.Auth.APIKeys {
+		if _, ok := cfg.Tenants[key.Tenant]; key.Tenant != "" && !ok {
+			return fmt.Errorf("invalid configuration: auth.api_keys %s (unknown tenant: %s)", key.ID, key.Tenant)
+		}
+	}
+
 	// If ALL providers are missing, that's an error
 	if len(missingProviders) == 4 {
 		return fmt.Errorf(
@@ -68,3 +98,41 @@ func ValidateConfig(cfg *Config) error {
 
 	return nil
 }
+
+// validateTenant checks a tenant's ID and providers. A tenant needs a
+// default provider for at least one channel.
+func validateTenant(id string, cfg *Config) error {
+	if !validTenantID(id) {
+		return fmt.Errorf("invalid configuration: tenants.%s (IDs may only contain letters, digits, '-' and '_')", id)
+	}
+
+	channels := []struct {
+		channel    string
+		chain      []string
+		registered func(string) bool
+	}{
+		{"email", service.ProviderChain(cfg.DefaultEmailProvider(), cfg.EmailFailover()), registry.IsEmailProviderRegistered},
+		{"sms", service.ProviderChain(cfg.DefaultSMSProvider(), cfg.SMSFailover()), registry.IsSMSProviderRegistered},
+		{"push", service.ProviderChain(cfg.DefaultPushProvider(), cfg.PushFailover()), registry.IsPushProviderRegistered},
+		{"chat", service.ProviderChain(cfg.DefaultChatProvider(), cfg.ChatFailover()), registry.IsChatProviderRegistered},
+	}
+	configured := false
+	for _, c := range channels {
+		for _, name := range c.chain {
+			if !c.registered(name) {
+				return fmt.Errorf("invalid configuration: tenants.%s.providers %s (unknown provider: %s)", id, c.channel, name)
+			}
+		}
+		configured = configured || len(c.chain) > 0
+	}
+	if !configured {
+		return fmt.Errorf("invalid configuration: tenants.%s has no default providers", id)
+	}
+	return nil
+}
+
+func validTenantID(id string) bool {
+	return id != "" && !strings.ContainsFunc(id, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	})
+}
@@ -1,12 +1,25 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
 	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/deadletter"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/eventhook"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/htmlmail"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/idempotency"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/ledger"
 	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/memory"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/queue"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/schedule"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/template"
 	"github.com/weprodev/wpd-message-gateway/internal/presentation"
 	"github.com/weprodev/wpd-message-gateway/internal/presentation/handler"
 )
@@ -16,7 +29,20 @@ type Application struct {
 	Config         *Config
 	GatewayService *service.GatewayService
 	MemoryStore    *memory.Store
+	Breakers       *resilience.Breakers
+	AsyncSender    *service.AsyncSender
+	Scheduler      *service.Scheduler
+	Events         *service.EventDispatcher
+	Templates      *service.TemplateService
 	Router         *presentation.Router
+
+	queue         port.Queue
+	scheduleStore port.ScheduleStore
+	failedJobs    port.FailedJobStore
+	ledger        port.Ledger
+	deadLetters   port.DeadLetterStore
+	templates     port.TemplateStore
+	idempotency   port.IdempotencyStore
 }
 
 func Wire(cfg *Config) (*Application, error) {
@@ -24,12 +50,119 @@ func Wire(cfg *Config) (*Application, error) {
 	registry := service.NewRegistry()
 	factory := NewProviderFactory(cfg)
 
-	if err := initializeDefaultProviders(cfg, factory, registry); err != nil {
+	var breakers *resilience.Breakers
+	if !cfg.Breaker.Disabled {
+		breakers = resilience.NewBreakers(cfg.BreakerSettings())
+	}
+
+	if err := initializeDefaultProviders(cfg, factory, registry, breakers, ""); err != nil {
 		return nil, fmt.Errorf("failed to initialize providers: %w", err)
 	}
 
-	gatewaySvc := service.NewGatewayService(cfg, registry)
-	gatewayHandler := handler.NewGatewayHandler(gatewaySvc)
+	apiKeys, err := service.NewAPIKeys(cfg.APIKeys())
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth configuration: %w", err)
+	}
+	var auth *handler.Auth
+	if apiKeys.Enabled() {
+		auth = handler.NewAuth(apiKeys)
+		log.Printf("API keys: %d", len(cfg.Auth.APIKeys))
+	} else {
+		log.Printf("API keys: none configured, the API is open")
+	}
+
+	messageLedger, err := ledger.Open(ledger.Options{
+		Dir:        cfg.Ledger.Dir,
+		Retention:  cfg.Ledger.Retention,
+		MaxRecords: cfg.Ledger.MaxRecords,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+
+	// Services record through recordLedger, which also publishes message
+	// events when subscribers are configured.
+	var (
+		recordLedger port.Ledger = messageLedger
+		events       *service.EventDispatcher
+		deadLetters  port.DeadLetterStore
+	)
+	if subscribers := cfg.EventSubscribers(); len(subscribers) > 0 {
+		store, err := deadletter.Open(cfg.EventsDir())
+		if err != nil {
+			return nil, fmt.Errorf("failed to open dead letter store: %w", err)
+		}
+		events, err = service.NewEventDispatcher(eventhook.New(), store, subscribers)
+		if err != nil {
+			_ = store.Close()
+			return nil, fmt.Errorf("invalid event configuration: %w", err)
+		}
+		events.Start()
+		deadLetters = store
+		recordLedger = service.NewPublishingLedger(messageLedger, events)
+		log.Printf("Event subscribers: %d (dead letters in %s)", len(subscribers), cfg.EventsDir())
+	}
+
+	gatewaySvc := service.NewGatewayService(cfg, registry, recordLedger)
+	for _, id := range cfg.TenantIDs() {
+		tenantCfg := cfg.ForTenant(id)
+		tenantRegistry := service.NewRegistry()
+		if err := initializeDefaultProviders(tenantCfg, NewProviderFactory(tenantCfg), tenantRegistry, breakers, id); err != nil {
+			return nil, fmt.Errorf("failed to initialize providers of tenant %s: %w", id, err)
+		}
+		gatewaySvc.AddTenant(id, tenantCfg, tenantRegistry)
+	}
+
+	var (
+		asyncSender   *service.AsyncSender
+		scheduler     *service.Scheduler
+		sendQueue     port.Queue
+		scheduleStore port.ScheduleStore
+		failedJobs    port.FailedJobStore
+	)
+	if cfg.Queue.Enabled {
+		q, err := queue.Open(cfg.QueueDir())
+		if err != nil {
+			return nil, fmt.Errorf("failed to open queue: %w", err)
+		}
+		store, err := schedule.Open(cfg.QueueDir())
+		if err != nil {
+			_ = q.Close()
+			return nil, fmt.Errorf("failed to open schedule store: %w", err)
+		}
+		failed, err := queue.OpenFailed(cfg.QueueDir())
+		if err != nil {
+			_ = q.Close()
+			_ = store.Close()
+			return nil, fmt.Errorf("failed to open failed job store: %w", err)
+		}
+		sendQueue, scheduleStore, failedJobs = q, store, failed
+
+		asyncSender = service.NewAsyncSender(gatewaySvc, q, failed, cfg.Queue.Workers)
+		asyncSender.Start()
+		scheduler = service.NewScheduler(store, q, recordLedger)
+		scheduler.Start()
+		log.Printf("Async queue: %s (%d pending)", cfg.QueueDir(), q.Len())
+	}
+
+	templateStore, err := template.Open(cfg.Templates.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open template store: %w", err)
+	}
+	templateSvc := service.NewTemplateService(templateStore)
+
+	idempotencyStore := idempotency.NewMemoryStore()
+	idempotencySvc := service.NewIdempotency(idempotencyStore, cfg.Idempotency.TTL)
+	gatewayHandler := handler.NewGatewayHandler(gatewaySvc, asyncSender, scheduler, idempotencySvc, templateSvc)
+	templateHandler := handler.NewTemplateHandler(templateSvc)
+	messageHandler := handler.NewMessageHandler(gatewaySvc)
+
+	webhookSvc := service.NewWebhookService(recordLedger)
+	initializeWebhookParsers(factory, webhookSvc, "")
+	for _, id := range cfg.TenantIDs() {
+		initializeWebhookParsers(NewProviderFactory(cfg.ForTenant(id)), webhookSvc, id)
+	}
+	webhookHandler := handler.NewWebhookHandler(webhookSvc)
 
 	var devboxHandler *handler.DevBoxHandler
 	if cfg.DevBox.Enabled || cfg.Providers.Defaults.Email == "memory" {
@@ -37,64 +170,182 @@ func Wire(cfg *Config) (*Application, error) {
 		devboxHandler = handler.NewDevBoxHandler(memoryStore, mailpitCfg)
 	}
 
-	router := presentation.NewRouter(gatewayHandler, devboxHandler)
+	breakerHandler := handler.NewBreakerHandler(breakers)
+
+	var (
+		scheduledHandler *handler.ScheduledHandler
+		queueHandler     *handler.QueueHandler
+	)
+	if scheduler != nil {
+		scheduledHandler = handler.NewScheduledHandler(scheduler)
+		queueHandler = handler.NewQueueHandler(asyncSender)
+	}
+
+	var tenancy *handler.Tenancy
+	if len(cfg.Tenants) > 0 {
+		tenancy = handler.NewTenancy(gatewaySvc.HasTenant)
+		log.Printf("Tenants: %s", strings.Join(cfg.TenantIDs(), ", "))
+	}
+
+	var eventHandler *handler.EventHandler
+	if events != nil {
+		eventHandler = handler.NewEventHandler(events)
+	}
+
+	router := presentation.NewRouter(
+		gatewayHandler,
+		devboxHandler,
+		breakerHandler,
+		scheduledHandler,
+		queueHandler,
+		messageHandler,
+		webhookHandler,
+		eventHandler,
+		templateHandler,
+		auth,
+		tenancy,
+		cfg.Server.CORSOrigins,
+	)
 
 	return &Application{
 		Config:         cfg,
 		GatewayService: gatewaySvc,
 		MemoryStore:    memoryStore,
+		Breakers:       breakers,
+		AsyncSender:    asyncSender,
+		Scheduler:      scheduler,
+		Events:         events,
+		Templates:      templateSvc,
 		Router:         router,
+		queue:          sendQueue,
+		scheduleStore:  scheduleStore,
+		failedJobs:     failedJobs,
+		ledger:         messageLedger,
+		deadLetters:    deadLetters,
+		templates:      templateStore,
+		idempotency:    idempotencyStore,
 	}, nil
 }
 
-func initializeDefaultProviders(cfg *Config, factory *ProviderFactory, registry *service.Registry) error {
-	if name := cfg.DefaultEmailProvider(); name != "" {
+// Close stops the scheduler and the async workers, waiting for sends in
+// progress, then the event dispatcher, and closes the stores and ledger.
+// Every component is closed even if another fails; the errors are joined.
+func (a *Application) Close(ctx context.Context) error {
+	var errs []error
+	if a.AsyncSender != nil {
+		if err := a.Scheduler.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop scheduler: %w", err))
+		}
+		if err := a.AsyncSender.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop async workers: %w", err))
+		}
+		errs = append(errs, a.scheduleStore.Close(), a.failedJobs.Close(), a.queue.Close())
+	}
+	if a.Events != nil {
+		if err := a.Events.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop event dispatcher: %w", err))
+		}
+		errs = append(errs, a.deadLetters.Close())
+	}
+	errs = append(errs, a.idempotency.Close(), a.templates.Close(), a.ledger.Close())
+	return errors.Join(errs...)
+}
+
+// initializeDefaultProviders registers each channel's default provider and
+// its failover providers, wrapped with retries and, unless breakers is nil,
+// a circuit breaker. Email providers are also wrapped with body rendering.
+// A tenant's providers have circuit breakers of their own.
+func initializeDefaultProviders(cfg *Config, factory *ProviderFactory, registry *service.Registry, breakers *resilience.Breakers, tenant string) error {
+	retry := cfg.RetryPolicies()
+
+	for _, name := range service.ProviderChain(cfg.DefaultEmailProvider(), cfg.EmailFailover()) {
 		provider, err := factory.CreateEmailProvider(name)
 		if err != nil && !isUnknownProviderError(err) {
-			return fmt.Errorf("failed to initialize email provider %s: %w", name, err)
+			return fmt.Errorf("failed to initialize email provider %s: %w", tenantProvider(tenant, name), err)
 		}
 		if provider != nil {
-			registry.RegisterEmailProvider(name, provider)
-			log.Printf("Registered email provider: %s", name)
+			var sender port.EmailSender = resilience.NewRetryEmailSender(provider, retry.For("email"))
+			if breakers != nil {
+				sender = resilience.NewBreakerEmailSender(sender, breakers.Get("email", tenantProvider(tenant, name)))
+			}
+			registry.RegisterEmailProvider(name, htmlmail.NewEmailSender(sender))
+			log.Printf("Registered email provider: %s", tenantProvider(tenant, name))
 		}
 	}
 
-	if name := cfg.DefaultSMSProvider(); name != "" {
+	for _, name := range service.ProviderChain(cfg.DefaultSMSProvider(), cfg.SMSFailover()) {
 		provider, err := factory.CreateSMSProvider(name)
 		if err != nil && !isUnknownProviderError(err) {
-			return fmt.Errorf("failed to initialize SMS provider %s: %w", name, err)
+			return fmt.Errorf("failed to initialize SMS provider %s: %w", tenantProvider(tenant, name), err)
 		}
 		if provider != nil {
-			registry.RegisterSMSProvider(name, provider)
-			log.Printf("Registered SMS provider: %s", name)
+			var sender port.SMSSender = resilience.NewRetrySMSSender(provider, retry.For("sms"))
+			if breakers != nil {
+				sender = resilience.NewBreakerSMSSender(sender, breakers.Get("sms", tenantProvider(tenant, name)))
+			}
+			registry.RegisterSMSProvider(name, sender)
+			log.Printf("Registered SMS provider: %s", tenantProvider(tenant, name))
 		}
 	}
 
-	if name := cfg.DefaultPushProvider(); name != "" {
+	for _, name := range service.ProviderChain(cfg.DefaultPushProvider(), cfg.PushFailover()) {
 		provider, err := factory.CreatePushProvider(name)
 		if err != nil && !isUnknownProviderError(err) {
-			return fmt.Errorf("failed to initialize push provider %s: %w", name, err)
+			return fmt.Errorf("failed to initialize push provider %s: %w", tenantProvider(tenant, name), err)
 		}
 		if provider != nil {
-			registry.RegisterPushProvider(name, provider)
-			log.Printf("Registered push provider: %s", name)
+			var sender port.PushSender = resilience.NewRetryPushSender(provider, retry.For("push"))
+			if breakers != nil {
+				sender = resilience.NewBreakerPushSender(sender, breakers.Get("push", tenantProvider(tenant, name)))
+			}
+			registry.RegisterPushProvider(name, sender)
+			log.Printf("Registered push provider: %s", tenantProvider(tenant, name))
 		}
 	}
 
-	if name := cfg.DefaultChatProvider(); name != "" {
+	for _, name := range service.ProviderChain(cfg.DefaultChatProvider(), cfg.ChatFailover()) {
 		provider, err := factory.CreateChatProvider(name)
 		if err != nil && !isUnknownProviderError(err) {
-			return fmt.Errorf("failed to initialize chat provider %s: %w", name, err)
+			return fmt.Errorf("failed to initialize chat provider %s: %w", tenantProvider(tenant, name), err)
 		}
 		if provider != nil {
-			registry.RegisterChatProvider(name, provider)
-			log.Printf("Registered chat provider: %s", name)
+			var sender port.ChatSender = resilience.NewRetryChatSender(provider, retry.For("chat"))
+			if breakers != nil {
+				sender = resilience.NewBreakerChatSender(sender, breakers.Get("chat", tenantProvider(tenant, name)))
+			}
+			registry.RegisterChatProvider(name, sender)
+			log.Printf("Registered chat provider: %s", tenantProvider(tenant, name))
 		}
 	}
 
 	return nil
 }
 
+// initializeWebhookParsers registers a webhook parser for each configured
+// provider of tenant that has one. A provider missing its webhook secret is
+// skipped.
+func initializeWebhookParsers(factory *ProviderFactory, webhooks *service.WebhookService, tenant string) {
+	for _, name := range registry.WebhookParserNames() {
+		parser, err := factory.CreateWebhookParser(name)
+		if err != nil {
+			if !isUnknownProviderError(err) {
+				log.Printf("Webhooks disabled for %s: %v", tenantProvider(tenant, name), err)
+			}
+			continue
+		}
+		webhooks.RegisterTenantParser(tenant, name, parser)
+		log.Printf("Registered webhook parser: %s", tenantProvider(tenant, name))
+	}
+}
+
+// tenantProvider names a tenant's provider in logs and circuit breakers.
+func tenantProvider(tenant, name string) string {
+	if tenant == "" {
+		return name
+	}
+	return tenant + "/" + name
+}
+
 func isUnknownProviderError(err error) bool {
 	if err == nil {
 		return false
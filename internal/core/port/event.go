@@ -0,0 +1,45 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// ErrDeadLetterNotFound is returned when there is no dead letter with an ID.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// EventPublisher receives message lifecycle events for delivery to subscribers.
+type EventPublisher interface {
+	// Publish must not block on delivery.
+	Publish(ctx context.Context, event *contracts.Event)
+}
+
+// EventDeliverer posts an event to a subscriber's URL, signed with secret.
+type EventDeliverer interface {
+	Deliver(ctx context.Context, url, secret string, event *contracts.Event) error
+}
+
+// DeadLetter is an event that could not be delivered to a subscriber.
+type DeadLetter struct {
+	ID         string           `json:"id"`
+	Subscriber string           `json:"subscriber"`
+	Event      *contracts.Event `json:"event"`
+	Attempts   int              `json:"attempts"`
+	Error      string           `json:"error"`
+	FailedAt   time.Time        `json:"failed_at"`
+}
+
+// DeadLetterStore defines the contract for keeping undelivered events.
+type DeadLetterStore interface {
+	// Save inserts the dead letter, or replaces the one with the same ID.
+	Save(ctx context.Context, letter *DeadLetter) error
+	// Get returns ErrDeadLetterNotFound if there is no dead letter with the ID.
+	Get(ctx context.Context, id string) (*DeadLetter, error)
+	// List returns the dead letters, oldest first.
+	List(ctx context.Context) ([]*DeadLetter, error)
+	Delete(ctx context.Context, id string) error
+	Close() error
+}
@@ -0,0 +1,31 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// IdempotencyRecord is the stored outcome of the first request with a key.
+type IdempotencyRecord struct {
+	Key string
+	// RequestHash identifies the request the key was first used with.
+	RequestHash string
+	// Result is nil while the first request is still in progress.
+	Result    *contracts.SendResult
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// IdempotencyStore defines the contract for keeping idempotency records
+// until they expire.
+type IdempotencyStore interface {
+	// Reserve stores the record unless an unexpired record with its key
+	// exists. It returns that existing record and false, or nil and true.
+	Reserve(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, bool, error)
+	// Save replaces the record with the same key.
+	Save(ctx context.Context, record *IdempotencyRecord) error
+	Delete(ctx context.Context, key string) error
+	Close() error
+}
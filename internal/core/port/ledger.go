@@ -0,0 +1,39 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// ErrMessageNotFound is returned when the ledger has no record of a message.
+var ErrMessageNotFound = errors.New("message not found")
+
+// MessageFilter selects ledger records. Zero fields match everything.
+type MessageFilter struct {
+	Channel   string
+	Provider  string
+	Status    contracts.MessageStatus
+	Recipient string
+	APIKey    string
+	Tenant    string
+	Since     time.Time
+	Until     time.Time
+	// Limit caps the number of records returned, newest first.
+	Limit int
+}
+
+// Ledger defines the contract for recording the state of sent messages.
+type Ledger interface {
+	// Save inserts the record, or replaces the record with the same ID.
+	Save(ctx context.Context, record *contracts.MessageRecord) error
+	// Get returns ErrMessageNotFound if there is no record with the ID.
+	Get(ctx context.Context, id string) (*contracts.MessageRecord, error)
+	// FindByProviderID looks a record up by the provider's message ID.
+	// An empty provider matches any provider.
+	FindByProviderID(ctx context.Context, provider, providerMessageID string) (*contracts.MessageRecord, error)
+	List(ctx context.Context, filter MessageFilter) ([]*contracts.MessageRecord, error)
+	Close() error
+}
@@ -0,0 +1,60 @@
+package port
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrQueueClosed is returned by Queue operations after Close.
+var ErrQueueClosed = errors.New("queue closed")
+
+// Job is a message waiting to be sent in the background.
+type Job struct {
+	ID        string          `json:"id"`
+	Channel   string          `json:"channel"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	// APIKey is the ID of the API key the job was queued with, and
+	// Providers the providers that key restricts the channel to.
+	APIKey    string   `json:"api_key,omitempty"`
+	Providers []string `json:"providers,omitempty"`
+	// Tenant is the tenant whose providers send the job.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Queue defines the contract for a durable FIFO of jobs.
+//
+// Delivery is at least once: a job that was dequeued but not acknowledged
+// when the process stopped is delivered again after a restart.
+type Queue interface {
+	Enqueue(ctx context.Context, job *Job) error
+	// Dequeue blocks until a job is available, ctx is done or the queue is closed.
+	Dequeue(ctx context.Context) (*Job, error)
+	Ack(ctx context.Context, id string) error
+	// Nack returns a dequeued job to the back of the queue to be delivered again.
+	Nack(ctx context.Context, id string) error
+	Close() error
+}
+
+// FailedJob is a queued job whose send failed, kept until it is requeued
+// or discarded.
+type FailedJob struct {
+	Job
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// FailedJobStore defines the contract for keeping failed jobs.
+type FailedJobStore interface {
+	// Save inserts the job, or replaces the job with the same ID.
+	Save(ctx context.Context, job *FailedJob) error
+	// Get returns ErrJobNotFound if there is no job with the ID.
+	Get(ctx context.Context, id string) (*FailedJob, error)
+	// List returns the failed jobs, oldest failure first.
+	List(ctx context.Context) ([]*FailedJob, error)
+	// Delete returns ErrJobNotFound if there is no job with the ID.
+	Delete(ctx context.Context, id string) error
+	Close() error
+}
@@ -0,0 +1,28 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrJobNotFound is returned when no pending job has the given ID.
+var ErrJobNotFound = errors.New("job not found")
+
+// ScheduledJob is a job waiting for its send time.
+type ScheduledJob struct {
+	Job
+	SendAt time.Time `json:"send_at"`
+}
+
+// ScheduleStore defines the contract for durable storage of scheduled jobs.
+type ScheduleStore interface {
+	// Save inserts the job, or replaces the job with the same ID.
+	Save(ctx context.Context, job *ScheduledJob) error
+	// Get returns ErrJobNotFound if there is no job with the ID.
+	Get(ctx context.Context, id string) (*ScheduledJob, error)
+	List(ctx context.Context) ([]*ScheduledJob, error)
+	// Delete returns ErrJobNotFound if there is no job with the ID.
+	Delete(ctx context.Context, id string) error
+	Close() error
+}
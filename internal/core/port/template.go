@@ -0,0 +1,27 @@
+package port
+
+import (
+	"context"
+	"errors"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// ErrTemplateNotFound is returned when no stored template matches.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// TemplateStore defines the contract for keeping message templates. Stored
+// versions are never changed.
+type TemplateStore interface {
+	// Save stores a new version. The name, locale and version must be unique.
+	Save(ctx context.Context, template *contracts.Template) error
+	// Get returns a version of the template in the locale, or the latest
+	// version when version is zero.
+	Get(ctx context.Context, name, locale string, version int) (*contracts.Template, error)
+	// List returns every version of every template, ordered by name,
+	// locale and version.
+	List(ctx context.Context) ([]*contracts.Template, error)
+	// Delete removes every version of the template in every locale.
+	Delete(ctx context.Context, name string) error
+	Close() error
+}
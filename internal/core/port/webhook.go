@@ -0,0 +1,28 @@
+package port
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// ErrInvalidSignature is returned when a webhook request fails verification.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// WebhookRequest is an inbound webhook call from a provider.
+type WebhookRequest struct {
+	// URL is the full URL the provider called, which some providers sign.
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// WebhookParser verifies and decodes a provider's delivery-status webhooks.
+type WebhookParser interface {
+	// Verify returns ErrInvalidSignature unless the provider signed the request.
+	Verify(req *WebhookRequest) error
+	// Parse returns the events in the request. Events the gateway does not
+	// track, such as provider-internal retries, are left out.
+	Parse(req *WebhookRequest) ([]contracts.DeliveryEvent, error)
+}
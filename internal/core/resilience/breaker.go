@@ -0,0 +1,310 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// Default circuit breaker settings.
+const (
+	DefaultFailureThreshold = 5
+	DefaultCooldown         = 30 * time.Second
+	DefaultHalfOpenMaxCalls = 1
+	DefaultSuccessThreshold = 1
+)
+
+// ErrCircuitOpen is wrapped by the error returned when a breaker rejects a call.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+// Breaker states. The numeric values are exported as the state metric.
+const (
+	StateClosed BreakerState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerSettings controls when a breaker opens and how it recovers.
+// Zero fields take the Default* values.
+type BreakerSettings struct {
+	// FailureThreshold is the number of consecutive failures that opens the breaker.
+	FailureThreshold int
+	// Cooldown is how long the breaker stays open before allowing trial calls.
+	Cooldown time.Duration
+	// HalfOpenMaxCalls is the number of concurrent trial calls allowed when half-open.
+	HalfOpenMaxCalls int
+	// SuccessThreshold is the number of successful trial calls that closes the breaker.
+	SuccessThreshold int
+}
+
+// DefaultBreakerSettings returns the settings used when nothing is configured.
+func DefaultBreakerSettings() BreakerSettings {
+	return BreakerSettings{
+		FailureThreshold: DefaultFailureThreshold,
+		Cooldown:         DefaultCooldown,
+		HalfOpenMaxCalls: DefaultHalfOpenMaxCalls,
+		SuccessThreshold: DefaultSuccessThreshold,
+	}
+}
+
+// Merge returns s with zero fields taken from base.
+func (s BreakerSettings) Merge(base BreakerSettings) BreakerSettings {
+	if s.FailureThreshold == 0 {
+		s.FailureThreshold = base.FailureThreshold
+	}
+	if s.Cooldown == 0 {
+		s.Cooldown = base.Cooldown
+	}
+	if s.HalfOpenMaxCalls == 0 {
+		s.HalfOpenMaxCalls = base.HalfOpenMaxCalls
+	}
+	if s.SuccessThreshold == 0 {
+		s.SuccessThreshold = base.SuccessThreshold
+	}
+	return s
+}
+
+// BreakerStatus is a point-in-time view of a breaker.
+type BreakerStatus struct {
+	Channel             string     `json:"channel"`
+	Provider            string     `json:"provider"`
+	State               string     `json:"state"`
+	StateCode           int        `json:"-"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	Successes           uint64     `json:"successes"`
+	Failures            uint64     `json:"failures"`
+	Rejected            uint64     `json:"rejected"`
+	Opens               uint64     `json:"opens"`
+}
+
+// CircuitBreaker stops calling a failing provider for a cooldown period.
+//
+// Only failures that say something about the provider's health count:
+// outages, rate limits, network errors and timeouts, including the caller's
+// deadline running out. A rejected message counts as a healthy response, and
+// a call the caller canceled is not counted as a failure.
+type CircuitBreaker struct {
+	channel  string
+	provider string
+	settings BreakerSettings
+	now      func() time.Time
+
+	mu                  sync.Mutex
+	state               BreakerState
+	consecutiveFailures int
+	halfOpenInFlight    int
+	halfOpenSuccesses   int
+	openedAt            time.Time
+
+	successes uint64
+	failures  uint64
+	rejected  uint64
+	opens     uint64
+}
+
+// NewCircuitBreaker creates a closed breaker for a channel's provider.
+func NewCircuitBreaker(channel, provider string, settings BreakerSettings) *CircuitBreaker {
+	return &CircuitBreaker{
+		channel:  channel,
+		provider: provider,
+		settings: settings.Merge(DefaultBreakerSettings()),
+		now:      time.Now,
+	}
+}
+
+// Execute calls send unless the breaker is open, and records the outcome.
+func (b *CircuitBreaker) Execute(ctx context.Context, send func(ctx context.Context) (*contracts.SendResult, error)) (*contracts.SendResult, error) {
+	trial, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := send(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// The caller gave up; that says nothing about the provider.
+		b.release(trial)
+		return result, err
+	}
+	b.record(trial, providerFailed(err))
+	return result, err
+}
+
+// providerFailed reports whether err is a transient failure of the provider,
+// whether or not it is worth retrying.
+func providerFailed(err error) bool {
+	return err != nil && (pkgerrors.IsRetryable(err) || isTimeout(err))
+}
+
+// allow reports whether a call may proceed and whether it is a half-open trial.
+func (b *CircuitBreaker) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if open := b.now().Sub(b.openedAt); open < b.settings.Cooldown {
+			b.rejected++
+			return false, b.openError(b.settings.Cooldown - open)
+		}
+		b.state = StateHalfOpen
+		b.halfOpenInFlight = 0
+		b.halfOpenSuccesses = 0
+	}
+
+	if b.state == StateHalfOpen {
+		if b.halfOpenInFlight >= b.settings.HalfOpenMaxCalls {
+			b.rejected++
+			return false, b.openError(0)
+		}
+		b.halfOpenInFlight++
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// release frees a half-open trial slot without recording an outcome.
+func (b *CircuitBreaker) release(trial bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if trial && b.state == StateHalfOpen {
+		b.halfOpenInFlight--
+	}
+}
+
+func (b *CircuitBreaker) record(trial, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if trial && b.state == StateHalfOpen {
+		b.halfOpenInFlight--
+	}
+
+	if failed {
+		b.failures++
+		b.consecutiveFailures++
+		if (trial && b.state == StateHalfOpen) || (b.state == StateClosed && b.consecutiveFailures >= b.settings.FailureThreshold) {
+			b.trip()
+		}
+		return
+	}
+
+	b.successes++
+	b.consecutiveFailures = 0
+	if trial && b.state == StateHalfOpen {
+		b.halfOpenSuccesses++
+		if b.halfOpenSuccesses >= b.settings.SuccessThreshold {
+			b.state = StateClosed
+		}
+	}
+}
+
+func (b *CircuitBreaker) trip() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.opens++
+}
+
+// openError is the error of a rejected call. retryAfter is what is left of
+// the cooldown, or 0 while half-open trials are running.
+func (b *CircuitBreaker) openError(retryAfter time.Duration) error {
+	perr := pkgerrors.NewProviderError(b.provider, "skipped", 0, ErrCircuitOpen)
+	perr.Kind = pkgerrors.ErrUnavailable
+	perr.RetryAfter = retryAfter
+	return perr
+}
+
+// Status returns the breaker's current state and counters.
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		Channel:             b.channel,
+		Provider:            b.provider,
+		State:               b.state.String(),
+		StateCode:           int(b.state),
+		ConsecutiveFailures: b.consecutiveFailures,
+		Successes:           b.successes,
+		Failures:            b.failures,
+		Rejected:            b.rejected,
+		Opens:               b.opens,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// Breakers holds one circuit breaker per channel and provider.
+type Breakers struct {
+	settings BreakerSettings
+
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewBreakers creates an empty breaker set sharing the given settings.
+func NewBreakers(settings BreakerSettings) *Breakers {
+	return &Breakers{
+		settings: settings,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Get returns the breaker for a channel's provider, creating it if needed.
+func (s *Breakers) Get(channel, provider string) *CircuitBreaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := channel + "/" + provider
+	b, ok := s.breakers[key]
+	if !ok {
+		b = NewCircuitBreaker(channel, provider, s.settings)
+		s.breakers[key] = b
+	}
+	return b
+}
+
+// Statuses returns every breaker's status, sorted by channel and provider.
+func (s *Breakers) Statuses() []BreakerStatus {
+	s.mu.Lock()
+	breakers := make([]*CircuitBreaker, 0, len(s.breakers))
+	for _, b := range s.breakers {
+		breakers = append(breakers, b)
+	}
+	s.mu.Unlock()
+
+	statuses := make([]BreakerStatus, len(breakers))
+	for i, b := range breakers {
+		statuses[i] = b.Status()
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Channel != statuses[j].Channel {
+			return statuses[i].Channel < statuses[j].Channel
+		}
+		return statuses[i].Provider < statuses[j].Provider
+	})
+	return statuses
+}
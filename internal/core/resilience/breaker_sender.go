@@ -0,0 +1,100 @@
+package resilience
+
+import (
+	"context"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// BreakerEmailSender guards a port.EmailSender with a circuit breaker.
+type BreakerEmailSender struct {
+	next    port.EmailSender
+	breaker *CircuitBreaker
+}
+
+// NewBreakerEmailSender wraps next with breaker.
+func NewBreakerEmailSender(next port.EmailSender, breaker *CircuitBreaker) *BreakerEmailSender {
+	return &BreakerEmailSender{next: next, breaker: breaker}
+}
+
+// Send sends the email, failing fast while the breaker is open.
+func (s *BreakerEmailSender) Send(ctx context.Context, email *contracts.Email) (*contracts.SendResult, error) {
+	return s.breaker.Execute(ctx, func(ctx context.Context) (*contracts.SendResult, error) {
+		return s.next.Send(ctx, email)
+	})
+}
+
+// Name returns the wrapped provider's name.
+func (s *BreakerEmailSender) Name() string {
+	return s.next.Name()
+}
+
+// BreakerSMSSender guards a port.SMSSender with a circuit breaker.
+type BreakerSMSSender struct {
+	next    port.SMSSender
+	breaker *CircuitBreaker
+}
+
+// NewBreakerSMSSender wraps next with breaker.
+func NewBreakerSMSSender(next port.SMSSender, breaker *CircuitBreaker) *BreakerSMSSender {
+	return &BreakerSMSSender{next: next, breaker: breaker}
+}
+
+// Send sends the SMS, failing fast while the breaker is open.
+func (s *BreakerSMSSender) Send(ctx context.Context, sms *contracts.SMS) (*contracts.SendResult, error) {
+	return s.breaker.Execute(ctx, func(ctx context.Context) (*contracts.SendResult, error) {
+		return s.next.Send(ctx, sms)
+	})
+}
+
+// Name returns the wrapped provider's name.
+func (s *BreakerSMSSender) Name() string {
+	return s.next.Name()
+}
+
+// BreakerPushSender guards a port.PushSender with a circuit breaker.
+type BreakerPushSender struct {
+	next    port.PushSender
+	breaker *CircuitBreaker
+}
+
+// NewBreakerPushSender wraps next with breaker.
+func NewBreakerPushSender(next port.PushSender, breaker *CircuitBreaker) *BreakerPushSender {
+	return &BreakerPushSender{next: next, breaker: breaker}
+}
+
+// Send sends the notification, failing fast while the breaker is open.
+func (s *BreakerPushSender) Send(ctx context.Context, notification *contracts.PushNotification) (*contracts.SendResult, error) {
+	return s.breaker.Execute(ctx, func(ctx context.Context) (*contracts.SendResult, error) {
+		return s.next.Send(ctx, notification)
+	})
+}
+
+// Name returns the wrapped provider's name.
+func (s *BreakerPushSender) Name() string {
+	return s.next.Name()
+}
+
+// BreakerChatSender guards a port.ChatSender with a circuit breaker.
+type BreakerChatSender struct {
+	next    port.ChatSender
+	breaker *CircuitBreaker
+}
+
+// NewBreakerChatSender wraps next with breaker.
+func NewBreakerChatSender(next port.ChatSender, breaker *CircuitBreaker) *BreakerChatSender {
+	return &BreakerChatSender{next: next, breaker: breaker}
+}
+
+// Send sends the message, failing fast while the breaker is open.
+func (s *BreakerChatSender) Send(ctx context.Context, message *contracts.ChatMessage) (*contracts.SendResult, error) {
+	return s.breaker.Execute(ctx, func(ctx context.Context) (*contracts.SendResult, error) {
+		return s.next.Send(ctx, message)
+	})
+}
+
+// Name returns the wrapped provider's name.
+func (s *BreakerChatSender) Name() string {
+	return s.next.Name()
+}
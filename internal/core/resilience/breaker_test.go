@@ -0,0 +1,103 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestCircuitBreaker_Execute(t *testing.T) {
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		err          error
+		wantFailures uint64
+	}{
+		{"success", context.Background(), nil, 0},
+		{"outage", context.Background(), pkgerrors.NewProviderError("test", "send", http.StatusServiceUnavailable, errors.New("down")), 1},
+		{"rate limited", context.Background(), pkgerrors.NewProviderError("test", "send", http.StatusTooManyRequests, errors.New("slow down")), 1},
+		{"connection lost mid-request", context.Background(), transportError(errors.New("connection reset by peer")), 1},
+		{"network timeout", context.Background(), transportError(timeoutError{}), 1},
+		{"caller deadline exceeded", expired, fmt.Errorf("test: failed to send: %w", context.DeadlineExceeded), 1},
+		{"caller canceled", canceled, fmt.Errorf("test: failed to send: %w", context.Canceled), 0},
+		{"rejected message", context.Background(), pkgerrors.NewProviderError("test", "send", http.StatusBadRequest, errors.New("bad")), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker("email", "test", BreakerSettings{FailureThreshold: 1})
+			_, _ = b.Execute(tt.ctx, func(context.Context) (*contracts.SendResult, error) {
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return &contracts.SendResult{}, nil
+			})
+
+			status := b.Status()
+			if status.Failures != tt.wantFailures {
+				t.Errorf("failures = %d, want %d", status.Failures, tt.wantFailures)
+			}
+			if wantOpen := tt.wantFailures > 0; (status.State == "open") != wantOpen {
+				t.Errorf("state = %s, want open %v", status.State, wantOpen)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_OpenErrorRetryAfter(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker("email", "test", BreakerSettings{FailureThreshold: 1, Cooldown: time.Minute})
+	b.now = func() time.Time { return now }
+
+	outage := pkgerrors.NewProviderError("test", "send", http.StatusServiceUnavailable, errors.New("down"))
+	_, _ = b.Execute(context.Background(), func(context.Context) (*contracts.SendResult, error) { return nil, outage })
+
+	now = now.Add(20 * time.Second)
+	_, err := b.Execute(context.Background(), func(context.Context) (*contracts.SendResult, error) {
+		t.Fatal("open breaker let a call through")
+		return nil, nil
+	})
+	var perr *pkgerrors.ProviderError
+	if !errors.As(err, &perr) || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error = %v, want an open circuit ProviderError", err)
+	}
+	if perr.RetryAfter != 40*time.Second {
+		t.Errorf("RetryAfter = %v, want the 40s left of the cooldown", perr.RetryAfter)
+	}
+}
+
+func TestCircuitBreaker_CanceledTrialIsNotAnOutcome(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker("email", "test", BreakerSettings{FailureThreshold: 1, Cooldown: time.Minute, HalfOpenMaxCalls: 1})
+	b.now = func() time.Time { return now }
+
+	outage := pkgerrors.NewProviderError("test", "send", http.StatusServiceUnavailable, errors.New("down"))
+	_, _ = b.Execute(context.Background(), func(context.Context) (*contracts.SendResult, error) { return nil, outage })
+
+	now = now.Add(time.Minute)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = b.Execute(canceled, func(ctx context.Context) (*contracts.SendResult, error) { return nil, ctx.Err() })
+
+	if status := b.Status(); status.State != "half-open" || status.Successes != 0 || status.Failures != 1 {
+		t.Fatalf("status after canceled trial = %+v", status)
+	}
+	if _, err := b.Execute(context.Background(), func(context.Context) (*contracts.SendResult, error) {
+		return &contracts.SendResult{}, nil
+	}); err != nil {
+		t.Fatalf("trial slot was not released: %v", err)
+	}
+	if status := b.Status(); status.State != "closed" {
+		t.Errorf("state = %s, want closed", status.State)
+	}
+}
@@ -0,0 +1,215 @@
+// Package resilience provides decorators that make provider calls tolerant of
+// transient failures.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// Default retry policy values.
+const (
+	DefaultMaxAttempts    = 3
+	DefaultInitialBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff     = 5 * time.Second
+	DefaultMultiplier     = 2.0
+	DefaultJitter         = 0.2
+)
+
+// MetaRetries is the SendResult.Meta key holding how many retries a send needed.
+const MetaRetries = "retries"
+
+// RetryPolicy controls how a failed provider call is retried.
+// Zero fields take the Default* values; MaxAttempts of 1 disables retries.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomises each backoff by up to ±Jitter (0..1) of its value.
+	// A negative value disables jitter.
+	Jitter float64
+	// RetryTimeouts also retries sends that timed out or lost their
+	// connection after the request was written. The provider may already
+	// have accepted such a message, so only enable it for providers that
+	// deduplicate. Set on a base policy, it is inherited by Merge.
+	RetryTimeouts bool
+}
+
+// DefaultRetryPolicy returns the policy used when nothing is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Multiplier:     DefaultMultiplier,
+		Jitter:         DefaultJitter,
+	}
+}
+
+// Merge returns p with zero fields taken from base.
+func (p RetryPolicy) Merge(base RetryPolicy) RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = base.MaxAttempts
+	}
+	if p.InitialBackoff == 0 {
+		p.InitialBackoff = base.InitialBackoff
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = base.MaxBackoff
+	}
+	if p.Multiplier == 0 {
+		p.Multiplier = base.Multiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = base.Jitter
+	}
+	if !p.RetryTimeouts {
+		p.RetryTimeouts = base.RetryTimeouts
+	}
+	return p
+}
+
+// Backoff returns the wait before the given retry (1 for the first retry).
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		d *= p.Multiplier
+		if d >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if limit := float64(p.MaxBackoff); limit > 0 && d > limit {
+		d = limit
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// RetryPolicies holds a default policy and optional per-channel overrides.
+type RetryPolicies struct {
+	Default RetryPolicy
+	Email   *RetryPolicy
+	SMS     *RetryPolicy
+	Push    *RetryPolicy
+	Chat    *RetryPolicy
+}
+
+// For returns the effective policy for a channel ("email", "sms", "push" or "chat").
+func (r RetryPolicies) For(channel string) RetryPolicy {
+	base := r.Default.Merge(DefaultRetryPolicy())
+
+	var override *RetryPolicy
+	switch channel {
+	case "email":
+		override = r.Email
+	case "sms":
+		override = r.SMS
+	case "push":
+		override = r.Push
+	case "chat":
+		override = r.Chat
+	}
+	if override == nil {
+		return base
+	}
+	return override.Merge(base)
+}
+
+// IsRetryable reports whether err is worth retrying against the same provider:
+// rate limits and outages the provider answered with, and network failures
+// before the request was sent. Timeouts and connections lost mid-request are
+// not retryable, since the provider may already have accepted the message.
+// Nothing is retryable once ctx is done.
+func IsRetryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if isConnectError(err) {
+		return true
+	}
+	if isTimeout(err) {
+		return false
+	}
+	var perr *pkgerrors.ProviderError
+	if errors.As(err, &perr) && perr.StatusCode == 0 {
+		// No response: the request may have been written.
+		return false
+	}
+	return pkgerrors.IsRetryable(err)
+}
+
+// ShouldRetry reports whether err is retryable under p: IsRetryable, plus
+// timeouts and lost connections when RetryTimeouts is set.
+func (p RetryPolicy) ShouldRetry(ctx context.Context, err error) bool {
+	if IsRetryable(ctx, err) {
+		return true
+	}
+	if !p.RetryTimeouts || err == nil || ctx.Err() != nil {
+		return false
+	}
+	return isTimeout(err) || pkgerrors.IsRetryable(err)
+}
+
+// isConnectError reports whether err happened while connecting, before
+// anything was sent.
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Retry calls send until it succeeds, fails permanently, the policy's
+// attempts are used up, or ctx is done. It does not start a wait that would
+// outlast ctx's deadline.
+func Retry(ctx context.Context, policy RetryPolicy, send func(ctx context.Context) (*contracts.SendResult, error)) (*contracts.SendResult, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := send(ctx)
+		if err == nil {
+			if attempt > 1 {
+				if result.Meta == nil {
+					result.Meta = make(map[string]string, 1)
+				}
+				result.Meta[MetaRetries] = strconv.Itoa(attempt - 1)
+			}
+			return result, nil
+		}
+
+		if attempt >= policy.MaxAttempts || !policy.ShouldRetry(ctx, err) {
+			return nil, err
+		}
+
+		wait := policy.Backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package resilience
+
+import (
+	"context"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// RetryEmailSender retries a port.EmailSender according to a RetryPolicy.
+type RetryEmailSender struct {
+	next   port.EmailSender
+	policy RetryPolicy
+}
+
+// NewRetryEmailSender wraps next with retries.
+func NewRetryEmailSender(next port.EmailSender, policy RetryPolicy) *RetryEmailSender {
+	return &RetryEmailSender{next: next, policy: policy}
+}
+
+// Send sends the email, retrying transient failures.
+func (s *RetryEmailSender) Send(ctx context.Context, email *contracts.Email) (*contracts.SendResult, error) {
+	return Retry(ctx, s.policy, func(ctx context.Context) (*contracts.SendResult, error) {
+		return s.next.Send(ctx, email)
+	})
+}
+
+// Name returns the wrapped provider's name.
+func (s *RetryEmailSender) Name() string {
+	return s.next.Name()
+}
+
+// RetrySMSSender retries a port.SMSSender according to a RetryPolicy.
+type RetrySMSSender struct {
+	next   port.SMSSender
+	policy RetryPolicy
+}
+
+// NewRetrySMSSender wraps next with retries.
+func NewRetrySMSSender(next port.SMSSender, policy RetryPolicy) *RetrySMSSender {
+	return &RetrySMSSender{next: next, policy: policy}
+}
+
+// Send sends the SMS, retrying transient failures.
+func (s *RetrySMSSender) Send(ctx context.Context, sms *contracts.SMS) (*contracts.SendResult, error) {
+	return Retry(ctx, s.policy, func(ctx context.Context) (*contracts.SendResult, error) {
+		return s.next.Send(ctx, sms)
+	})
+}
+
+// Name returns the wrapped provider's name.
+func (s *RetrySMSSender) Name() string {
+	return s.next.Name()
+}
+
+// RetryPushSender retries a port.PushSender according to a RetryPolicy.
+type RetryPushSender struct {
+	next   port.PushSender
+	policy RetryPolicy
+}
+
+// NewRetryPushSender wraps next with retries.
+func NewRetryPushSender(next port.PushSender, policy RetryPolicy) *RetryPushSender {
+	return &RetryPushSender{next: next, policy: policy}
+}
+
+// Send sends the notification, retrying transient failures.
+func (s *RetryPushSender) Send(ctx context.Context, notification *contracts.PushNotification) (*contracts.SendResult, error) {
+	return Retry(ctx, s.policy, func(ctx context.Context) (*contracts.SendResult, error) {
+		return s.next.Send(ctx, notification)
+	})
+}
+
+// Name returns the wrapped provider's name.
+func (s *RetryPushSender) Name() string {
+	return s.next.Name()
+}
+
+// RetryChatSender retries a port.ChatSender according to a RetryPolicy.
+type RetryChatSender struct {
+	next   port.ChatSender
+	policy RetryPolicy
+}
+
+// NewRetryChatSender wraps next with retries.
+func NewRetryChatSender(next port.ChatSender, policy RetryPolicy) *RetryChatSender {
+	return &RetryChatSender{next: next, policy: policy}
+}
+
+// Send sends the message, retrying transient failures.
+func (s *RetryChatSender) Send(ctx context.Context, message *contracts.ChatMessage) (*contracts.SendResult, error) {
+	return Retry(ctx, s.policy, func(ctx context.Context) (*contracts.SendResult, error) {
+		return s.next.Send(ctx, message)
+	})
+}
+
+// Name returns the wrapped provider's name.
+func (s *RetryChatSender) Name() string {
+	return s.next.Name()
+}
@@ -0,0 +1,104 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// timeoutError is a net.Error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// transportError wraps err the way providers report a request that got no response.
+func transportError(err error) error {
+	perr := pkgerrors.NewProviderError("test", "failed to send", 0, err)
+	perr.Kind = pkgerrors.ErrUnavailable
+	return perr
+}
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	tests := []struct {
+		name              string
+		ctx               context.Context
+		err               error
+		want              bool
+		wantRetryTimeouts bool
+	}{
+		{"nil", context.Background(), nil, false, false},
+		{"rate limited", context.Background(), pkgerrors.NewProviderError("test", "send", http.StatusTooManyRequests, errors.New("slow down")), true, true},
+		{"outage", context.Background(), pkgerrors.NewProviderError("test", "send", http.StatusServiceUnavailable, errors.New("down")), true, true},
+		{"rejected", context.Background(), pkgerrors.NewProviderError("test", "send", http.StatusBadRequest, errors.New("bad")), false, false},
+		{"connection refused", context.Background(), transportError(dialErr), true, true},
+		{"dns failure", context.Background(), transportError(&net.DNSError{Err: "no such host", Name: "api.example.com"}), true, true},
+		{"connection lost mid-request", context.Background(), transportError(readErr), false, true},
+		{"network timeout", context.Background(), transportError(timeoutError{}), false, true},
+		{"provider deadline", context.Background(), fmt.Errorf("test: failed to send: %w", context.DeadlineExceeded), false, true},
+		{"caller canceled", canceled, transportError(dialErr), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (RetryPolicy{}).ShouldRetry(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("ShouldRetry() = %v, want %v", got, tt.want)
+			}
+			if got := (RetryPolicy{RetryTimeouts: true}).ShouldRetry(tt.ctx, tt.err); got != tt.wantRetryTimeouts {
+				t.Errorf("ShouldRetry() with RetryTimeouts = %v, want %v", got, tt.wantRetryTimeouts)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	outage := pkgerrors.NewProviderError("test", "send", http.StatusBadGateway, errors.New("bad gateway"))
+	timeout := transportError(timeoutError{})
+
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		errs         []error
+		wantErr      bool
+		wantAttempts int
+		wantRetries  string
+	}{
+		{"success", RetryPolicy{}, nil, false, 1, ""},
+		{"outage then success", RetryPolicy{}, []error{outage}, false, 2, "1"},
+		{"attempts used up", RetryPolicy{}, []error{outage, outage, outage, outage}, true, 3, ""},
+		{"timeout not retried", RetryPolicy{}, []error{timeout}, true, 1, ""},
+		{"timeout retried when enabled", RetryPolicy{RetryTimeouts: true}, []error{timeout}, false, 2, "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.policy
+			policy.MaxAttempts, policy.InitialBackoff, policy.Jitter = 3, 1, -1
+			attempts := 0
+			result, err := Retry(context.Background(), policy.Merge(DefaultRetryPolicy()), func(context.Context) (*contracts.SendResult, error) {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return nil, tt.errs[attempts-1]
+				}
+				return &contracts.SendResult{ID: "1"}, nil
+			})
+			if (err != nil) != tt.wantErr || attempts != tt.wantAttempts {
+				t.Fatalf("error = %v after %d attempts, want error %v after %d", err, attempts, tt.wantErr, tt.wantAttempts)
+			}
+			if err == nil && result.Meta[MetaRetries] != tt.wantRetries {
+				t.Errorf("retries = %q, want %q", result.Meta[MetaRetries], tt.wantRetries)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// API key errors.
+var (
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrForbidden     = errors.New("API key is not allowed to do this")
+)
+
+// Scopes that are not a channel.
+const (
+	// ScopeAll allows everything.
+	ScopeAll = "*"
+	// ScopeAdmin allows managing templates, scheduled messages, dead letters
+	// and reading the ledger.
+	ScopeAdmin = "admin"
+)
+
+// APIKey is a credential for the HTTP API. Only the SHA-256 hash of the key
+// is kept.
+type APIKey struct {
+	// ID names the key in the ledger and logs. It is not secret.
+	ID string
+	// Hash is the hex-encoded SHA-256 of the key.
+	Hash string
+	// Scopes are "*", "admin", a channel such as "sms", or a channel and
+	// provider such as "sms:twilio". A key with provider scopes for a
+	// channel sends only through those providers, failing over in the
+	// order given, instead of the channel's default chain.
+	Scopes []string
+	// Tenant, when set, binds the key to a tenant: its requests are sent
+	// with the tenant's providers.
+	Tenant string
+
+	all      bool
+	admin    bool
+	channels map[string][]string
+}
+
+// HashAPIKey returns the hash stored for key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CanSend reports whether the key may send on channel. A nil key, when API
+// keys are not configured, may send on every channel.
+func (k *APIKey) CanSend(channel string) bool {
+	return k.allows(channel, "")
+}
+
+// IsAdmin reports whether the key has the admin scope. A nil key is admin.
+func (k *APIKey) IsAdmin() bool {
+	return k == nil || k.all || k.admin
+}
+
+// providers returns the providers the key restricts channel to, or nil
+// when it may use the channel's default chain.
+func (k *APIKey) providers(channel string) []string {
+	if k == nil {
+		return nil
+	}
+	return k.channels[channel]
+}
+
+// allows reports whether the key may send on channel, through provider
+// when one is given.
+func (k *APIKey) allows(channel, provider string) bool {
+	if k == nil || k.all {
+		return true
+	}
+	restricted, ok := k.channels[channel]
+	if !ok {
+		return false
+	}
+	return provider == "" || restricted == nil || slices.Contains(restricted, provider)
+}
+
+// id returns the key's ID, or "" for a nil key.
+func (k *APIKey) id() string {
+	if k == nil {
+		return ""
+	}
+	return k.ID
+}
+
+// parseScopes checks the key's scopes and indexes them by channel. A
+// channel scope without a provider lifts the provider restriction.
+func (k *APIKey) parseScopes() error {
+	k.channels = make(map[string][]string)
+	for _, scope := range k.Scopes {
+		channel, provider, _ := strings.Cut(strings.TrimSpace(scope), ":")
+		switch channel {
+		case ScopeAll:
+			k.all = true
+			continue
+		case ScopeAdmin:
+			k.admin = true
+			continue
+		case "email", "sms", "push", "chat":
+		default:
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+
+		restricted, seen := k.channels[channel]
+		switch {
+		case provider == "":
+			k.channels[channel] = nil
+		case (!seen || restricted != nil) && !slices.Contains(restricted, provider):
+			k.channels[channel] = append(restricted, provider)
+		}
+	}
+	return nil
+}
+
+// APIKeys authenticates API keys.
+type APIKeys struct {
+	byHash map[string]*APIKey
+}
+
+// NewAPIKeys checks the keys' hashes and scopes. Key IDs must be unique.
+func NewAPIKeys(keys []APIKey) (*APIKeys, error) {
+	a := &APIKeys{byHash: make(map[string]*APIKey, len(keys))}
+	ids := make(map[string]bool, len(keys))
+	for i := range keys {
+		key := keys[i]
+		if key.ID == "" {
+			return nil, fmt.Errorf("API key %d: id is required", i+1)
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("API key %s: duplicate id", key.ID)
+		}
+		ids[key.ID] = true
+
+		key.Hash = strings.ToLower(strings.TrimSpace(key.Hash))
+		if b, err := hex.DecodeString(key.Hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("API key %s: hash must be a hex SHA-256", key.ID)
+		}
+		if _, dup := a.byHash[key.Hash]; dup {
+			return nil, fmt.Errorf("API key %s: same key as another id", key.ID)
+		}
+		if err := key.parseScopes(); err != nil {
+			return nil, fmt.Errorf("API key %s: %w", key.ID, err)
+		}
+		a.byHash[key.Hash] = &key
+	}
+	return a, nil
+}
+
+// Enabled reports whether any keys are configured. Without keys the API is
+// open.
+func (a *APIKeys) Enabled() bool {
+	return a != nil && len(a.byHash) > 0
+}
+
+// Authenticate returns the key whose hash matches key, or ErrInvalidAPIKey.
+func (a *APIKeys) Authenticate(key string) (*APIKey, error) {
+	if key == "" || a == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	found, ok := a.byHash[HashAPIKey(key)]
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	return found, nil
+}
+
+type apiKeyContextKey struct{}
+
+// WithAPIKey returns a context carrying the API key a request was made with.
+// Sends made with the context are limited to the key's scopes and recorded
+// in the ledger under its ID.
+func WithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// APIKeyFrom returns the API key of the context, or nil.
+func APIKeyFrom(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*APIKey)
+	return key
+}
+
+// jobAPIKey returns the key a job was queued with, limited to the providers
+// it was restricted to then, or nil for a job queued without one.
+func jobAPIKey(id, channel string, providers []string) *APIKey {
+	if id == "" {
+		return nil
+	}
+	return &APIKey{ID: id, channels: map[string][]string{channel: providers}}
+}
+
+// authorize checks that the context's key may send on channel, through
+// provider when one is given.
+func authorize(ctx context.Context, channel, provider string) error {
+	if APIKeyFrom(ctx).allows(channel, provider) {
+		return nil
+	}
+	if provider != "" {
+		return fmt.Errorf("%w: %s via %s", ErrForbidden, channel, provider)
+	}
+	return fmt.Errorf("%w: %s", ErrForbidden, channel)
+}
+
+// providerChain returns the providers to send through on channel: the
+// context key's providers when it restricts the channel, or else the
+// default provider and its failovers.
+func providerChain(ctx context.Context, channel, defaultProvider string, failover []string) []string {
+	if restricted := APIKeyFrom(ctx).providers(channel); restricted != nil {
+		return restricted
+	}
+	return ProviderChain(defaultProvider, failover)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeyScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		// allows maps "channel" or "channel:provider" to whether it is allowed.
+		allows        map[string]bool
+		wantAdmin     bool
+		wantProviders map[string][]string
+	}{
+		{
+			name:      "all",
+			scopes:    []string{"*"},
+			allows:    map[string]bool{"email": true, "sms:twilio": true, "chat:slack": true},
+			wantAdmin: true,
+		},
+		{
+			name:      "admin only",
+			scopes:    []string{"admin"},
+			allows:    map[string]bool{"email": false, "sms:twilio": false},
+			wantAdmin: true,
+		},
+		{
+			name:   "channel",
+			scopes: []string{"sms"},
+			allows: map[string]bool{"sms": true, "sms:twilio": true, "sms:vonage": true, "email": false},
+		},
+		{
+			name:          "channel and provider",
+			scopes:        []string{"sms:twilio", "sms:vonage"},
+			allows:        map[string]bool{"sms": true, "sms:twilio": true, "sms:vonage": true, "sms:plivo": false, "push": false},
+			wantProviders: map[string][]string{"sms": {"twilio", "vonage"}},
+		},
+		{
+			name:   "channel lifts the provider restriction",
+			scopes: []string{"sms:twilio", "sms"},
+			allows: map[string]bool{"sms:plivo": true},
+		},
+		{
+			name:          "duplicate provider scope",
+			scopes:        []string{"email:ses", " email:ses "},
+			allows:        map[string]bool{"email:ses": true, "email:sendgrid": false},
+			wantProviders: map[string][]string{"email": {"ses"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := &APIKey{ID: "k", Scopes: tt.scopes}
+			if err := key.parseScopes(); err != nil {
+				t.Fatal(err)
+			}
+
+			for scope, want := range tt.allows {
+				channel, provider, _ := strings.Cut(scope, ":")
+				if got := key.allows(channel, provider); got != want {
+					t.Errorf("allows(%q, %q) = %v, want %v", channel, provider, got, want)
+				}
+			}
+			if got := key.IsAdmin(); got != tt.wantAdmin {
+				t.Errorf("IsAdmin() = %v, want %v", got, tt.wantAdmin)
+			}
+			for channel, want := range tt.wantProviders {
+				if got := key.providers(channel); fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("providers(%q) = %v, want %v", channel, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNilAPIKeyAllowsEverything(t *testing.T) {
+	var key *APIKey
+	if !key.CanSend("email") || !key.IsAdmin() || key.providers("sms") != nil {
+		t.Error("a nil key, without keys configured, must allow everything")
+	}
+}
+
+func TestNewAPIKeys(t *testing.T) {
+	hash := HashAPIKey("secret")
+
+	tests := []struct {
+		name    string
+		keys    []APIKey
+		wantErr bool
+	}{
+		{"valid", []APIKey{{ID: "a", Hash: hash, Scopes: []string{"email"}}}, false},
+		{"upper-case hash", []APIKey{{ID: "a", Hash: strings.ToUpper(hash)}}, false},
+		{"missing id", []APIKey{{Hash: hash}}, true},
+		{"duplicate id", []APIKey{{ID: "a", Hash: hash}, {ID: "a", Hash: HashAPIKey("other")}}, true},
+		{"same key twice", []APIKey{{ID: "a", Hash: hash}, {ID: "b", Hash: hash}}, true},
+		{"bad hash", []APIKey{{ID: "a", Hash: "abc"}}, true},
+		{"unknown scope", []APIKey{{ID: "a", Hash: hash, Scopes: []string{"fax"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAPIKeys(tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIKeysAuthenticate(t *testing.T) {
+	keys, err := NewAPIKeys([]APIKey{{ID: "app", Hash: HashAPIKey("secret"), Scopes: []string{"sms"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := keys.Authenticate("secret")
+	if err != nil || key.ID != "app" {
+		t.Errorf("Authenticate(secret) = %v, %v; want app", key, err)
+	}
+	for _, token := range []string{"", "wrong"} {
+		if _, err := keys.Authenticate(token); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("Authenticate(%q) error = %v, want ErrInvalidAPIKey", token, err)
+		}
+	}
+}
+
+func TestAuthorizeAndProviderChain(t *testing.T) {
+	key := &APIKey{ID: "k", Scopes: []string{"sms:vonage", "sms:twilio"}}
+	if err := key.parseScopes(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithAPIKey(context.Background(), key)
+
+	if err := authorize(ctx, "email", ""); !errors.Is(err, ErrForbidden) {
+		t.Errorf("authorize(email) error = %v, want ErrForbidden", err)
+	}
+	if err := authorize(ctx, "sms", "plivo"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("authorize(sms via plivo) error = %v, want ErrForbidden", err)
+	}
+	if err := authorize(ctx, "sms", "twilio"); err != nil {
+		t.Errorf("authorize(sms via twilio) error = %v", err)
+	}
+
+	// The key's providers replace the configured chain, in scope order.
+	if got := providerChain(ctx, "sms", "plivo", []string{"twilio"}); fmt.Sprint(got) != "[vonage twilio]" {
+		t.Errorf("providerChain = %v, want [vonage twilio]", got)
+	}
+	if got := providerChain(context.Background(), "sms", "plivo", []string{"twilio"}); fmt.Sprint(got) != "[plivo twilio]" {
+		t.Errorf("providerChain without a key = %v, want [plivo twilio]", got)
+	}
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+const (
+	// DefaultWorkers is the worker pool size used when none is configured.
+	DefaultWorkers = 4
+
+	// asyncSendTimeout bounds a single background send, including retries and failover.
+	asyncSendTimeout = 2 * time.Minute
+
+	// Bounds of the pause before a worker dequeues again after a queue error.
+	dequeueMinBackoff = 100 * time.Millisecond
+	dequeueMaxBackoff = 10 * time.Second
+)
+
+// ErrAsyncDisabled is returned when a message is queued but no queue is configured.
+var ErrAsyncDisabled = errors.New("async sending is not enabled")
+
+// ErrInvalidMessage is returned when a message to queue or schedule lacks
+// what every provider of its channel needs, so it is rejected up front
+// rather than failing in the background.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// AsyncSender queues messages durably and sends them in the background
+// with a pool of workers. Jobs whose send fails are kept in a failed job
+// store until they are requeued or discarded.
+type AsyncSender struct {
+	service *GatewayService
+	queue   port.Queue
+	failed  port.FailedJobStore
+	workers int
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewAsyncSender creates an AsyncSender. Call Start to begin processing.
+// failed may be nil to drop jobs whose send failed.
+func NewAsyncSender(svc *GatewayService, queue port.Queue, failed port.FailedJobStore, workers int) *AsyncSender {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	return &AsyncSender{
+		service: svc,
+		queue:   queue,
+		failed:  failed,
+		workers: workers,
+	}
+}
+
+// Enqueue stores the message for background sending through the channel's
+// default provider and failover chain, or the providers an API key in ctx
+// restricts it to. It returns the job ID.
+func (a *AsyncSender) Enqueue(ctx context.Context, channel string, message any) (string, error) {
+	job, err := newJob(ctx, channel, message)
+	if err != nil {
+		return "", err
+	}
+	// Record before queueing, so a worker never finds the message missing.
+	recordQueued(ctx, a.service.ledger, job, message, nil)
+	if err := a.queue.Enqueue(ctx, job); err != nil {
+		err = fmt.Errorf("failed to queue %s message: %w", channel, err)
+		recordFailed(ctx, a.service.ledger, job.ID, err)
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// newJob validates and encodes a message for the channel into a job with a
+// new ID, carrying the API key in ctx so the job is sent within its scopes.
+func newJob(ctx context.Context, channel string, message any) (*port.Job, error) {
+	switch channel {
+	case "email", "sms", "push", "chat":
+	default:
+		return nil, fmt.Errorf("unknown channel: %s", channel)
+	}
+	if err := authorize(ctx, channel, ""); err != nil {
+		return nil, err
+	}
+	if err := validateMessage(message); err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s message: %w", channel, err)
+	}
+
+	key := APIKeyFrom(ctx)
+	return &port.Job{
+		ID:        newMessageID(),
+		Channel:   channel,
+		Payload:   payload,
+		CreatedAt: time.Now().UTC(),
+		APIKey:    key.id(),
+		Providers: key.providers(channel),
+		Tenant:    TenantFrom(ctx),
+	}, nil
+}
+
+// validateMessage checks a message for what every provider of its channel
+// needs: recipients and something to send. Provider-specific rules are
+// still checked when the message is sent.
+func validateMessage(message any) error {
+	var problem string
+	switch m := message.(type) {
+	case *contracts.Email:
+		switch {
+		case len(m.To) == 0:
+			problem = "no recipients specified"
+		case m.Subject == "" && m.HTML == "" && m.PlainText == "" && m.Markdown == "" && m.MJML == "" && m.Template == nil:
+			problem = "no subject or content specified"
+		}
+	case *contracts.SMS:
+		switch {
+		case len(m.To) == 0:
+			problem = "no recipients specified"
+		case m.Message == "" && m.Template == nil:
+			problem = "no message specified"
+		}
+	case *contracts.PushNotification:
+		if len(m.DeviceTokens) == 0 && len(m.ExternalIDs) == 0 && len(m.Segments) == 0 {
+			problem = "no device tokens, external IDs or segments specified"
+		}
+	case *contracts.ChatMessage:
+		if m.Message == "" && m.MediaURL == "" && m.TemplateID == "" && m.Template == nil {
+			problem = "no message, media or template specified"
+		}
+	}
+	if problem != "" {
+		return fmt.Errorf("%w: %s", ErrInvalidMessage, problem)
+	}
+	return nil
+}
+
+// Start launches the worker pool.
+func (a *AsyncSender) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+
+	for i := 0; i < a.workers; i++ {
+		a.wg.Add(1)
+		go a.work(ctx)
+	}
+}
+
+// Stop stops taking new jobs and waits for sends in progress to finish,
+// or for ctx to be done. Jobs not yet sent stay in the queue.
+func (a *AsyncSender) Stop(ctx context.Context) error {
+	if a.cancel != nil {
+		a.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *AsyncSender) work(ctx context.Context) {
+	defer a.wg.Done()
+
+	backoff := dequeueMinBackoff
+	for {
+		job, err := a.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, port.ErrQueueClosed) {
+				return
+			}
+			log.Printf("Queue error, retrying in %s: %v", backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, dequeueMaxBackoff)
+			continue
+		}
+		backoff = dequeueMinBackoff
+
+		a.process(job)
+	}
+}
+
+// process sends one job and acknowledges it. Retries and failover have
+// already run when a send fails, so the job is moved to the failed job
+// store rather than queued again.
+func (a *AsyncSender) process(job *port.Job) {
+	// Sends in progress are allowed to finish on shutdown, so the
+	// context is not derived from the worker's.
+	ctx, cancel := context.WithTimeout(context.Background(), asyncSendTimeout)
+	defer cancel()
+
+	result, err := a.dispatch(ctx, job)
+	if err != nil {
+		log.Printf("Async %s %s failed: %v", job.Channel, job.ID, err)
+		if !a.keepFailed(ctx, job, err) {
+			// Returned to the queue, the job is sent again instead of
+			// being lost.
+			if err := a.queue.Nack(ctx, job.ID); err != nil {
+				log.Printf("Failed to return %s to the queue: %v", job.ID, err)
+			}
+			return
+		}
+	} else {
+		log.Printf("Async %s %s sent: %s", job.Channel, job.ID, result.ID)
+	}
+
+	if err := a.queue.Ack(ctx, job.ID); err != nil {
+		log.Printf("Failed to acknowledge %s: %v", job.ID, err)
+	}
+}
+
+// keepFailed stores a job whose send failed and reports whether it may be
+// acknowledged.
+func (a *AsyncSender) keepFailed(ctx context.Context, job *port.Job, err error) bool {
+	if a.failed == nil {
+		return true
+	}
+	failed := &port.FailedJob{Job: *job, Error: err.Error(), FailedAt: time.Now().UTC()}
+	if err := a.failed.Save(ctx, failed); err != nil {
+		log.Printf("Failed to keep failed job %s: %v", job.ID, err)
+		return false
+	}
+	return true
+}
+
+// FailedJobs returns the jobs whose send failed, oldest failure first.
+func (a *AsyncSender) FailedJobs(ctx context.Context) ([]*port.FailedJob, error) {
+	if a.failed == nil {
+		return nil, nil
+	}
+	jobs, err := a.failed.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := jobs[:0]
+	for _, job := range jobs {
+		if VisibleTo(ctx, job.Tenant) {
+			visible = append(visible, job)
+		}
+	}
+	return visible, nil
+}
+
+// failedJob returns a failed job, or port.ErrJobNotFound if it belongs to
+// another tenant than the one in ctx.
+func (a *AsyncSender) failedJob(ctx context.Context, id string) (*port.FailedJob, error) {
+	if a.failed == nil {
+		return nil, port.ErrJobNotFound
+	}
+	job, err := a.failed.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !VisibleTo(ctx, job.Tenant) {
+		return nil, port.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// Requeue queues a failed job again and removes it from the failed jobs.
+// It returns port.ErrJobNotFound if there is no failed job with the ID.
+func (a *AsyncSender) Requeue(ctx context.Context, id string) error {
+	failed, err := a.failedJob(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	updateRecord(ctx, a.service.ledger, id, func(r *contracts.MessageRecord) {
+		r.Error = ""
+		r.SetStatus(contracts.StatusQueued, time.Now().UTC(), "requeued")
+	})
+	if err := a.queue.Enqueue(ctx, &failed.Job); err != nil {
+		return fmt.Errorf("failed to requeue %s: %w", id, err)
+	}
+	return a.failed.Delete(ctx, id)
+}
+
+// RequeueAll requeues every failed job, oldest first, and returns how many
+// were queued.
+func (a *AsyncSender) RequeueAll(ctx context.Context) (int, error) {
+	jobs, err := a.FailedJobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	requeued := 0
+	for _, job := range jobs {
+		err := a.Requeue(ctx, job.ID)
+		switch {
+		case err == nil:
+			requeued++
+		case errors.Is(err, port.ErrJobNotFound):
+			// Requeued or discarded concurrently.
+		default:
+			return requeued, err
+		}
+	}
+	return requeued, nil
+}
+
+// Discard removes a failed job without sending it.
+func (a *AsyncSender) Discard(ctx context.Context, id string) error {
+	if _, err := a.failedJob(ctx, id); err != nil {
+		return err
+	}
+	return a.failed.Delete(ctx, id)
+}
+
+func (a *AsyncSender) dispatch(ctx context.Context, job *port.Job) (*contracts.SendResult, error) {
+	if key := jobAPIKey(job.APIKey, job.Channel, job.Providers); key != nil {
+		ctx = WithAPIKey(ctx, key)
+	}
+	if job.Tenant != "" {
+		ctx = WithTenant(ctx, job.Tenant)
+	}
+	switch job.Channel {
+	case "email":
+		return decodeAndSend(ctx, job, a.service.sendEmail)
+	case "sms":
+		return decodeAndSend(ctx, job, a.service.sendSMS)
+	case "push":
+		return decodeAndSend(ctx, job, a.service.sendPush)
+	case "chat":
+		return decodeAndSend(ctx, job, a.service.sendChat)
+	default:
+		return nil, fmt.Errorf("unknown channel: %s", job.Channel)
+	}
+}
+
+func decodeAndSend[M any](
+	ctx context.Context,
+	job *port.Job,
+	send func(context.Context, string, *M) (*contracts.SendResult, error),
+) (*contracts.SendResult, error) {
+	var message M
+	if err := json.Unmarshal(job.Payload, &message); err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	return send(ctx, job.ID, &message)
+}
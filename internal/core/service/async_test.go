@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/queue"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// eventually fails the test if cond is not true within a second.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func openTestQueue(t *testing.T, dir string) *queue.FileQueue {
+	t.Helper()
+	q, err := queue.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = q.Close() })
+	return q
+}
+
+func stopSender(t *testing.T, a *AsyncSender) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := a.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+// flakyFailedStore fails the first saves with saveErrs.
+type flakyFailedStore struct {
+	port.FailedJobStore
+
+	mu       sync.Mutex
+	saveErrs []error
+}
+
+func (s *flakyFailedStore) Save(ctx context.Context, job *port.FailedJob) error {
+	s.mu.Lock()
+	if len(s.saveErrs) > 0 {
+		err := s.saveErrs[0]
+		s.saveErrs = s.saveErrs[1:]
+		s.mu.Unlock()
+		return err
+	}
+	s.mu.Unlock()
+	return s.FailedJobStore.Save(ctx, job)
+}
+
+func TestAsyncSenderProcess(t *testing.T) {
+	rejected := &pkgerrors.ProviderError{Provider: "fake", Message: "bad number", Kind: pkgerrors.ErrRejected}
+	diskFull := errors.New("disk full")
+
+	tests := []struct {
+		name       string
+		sendErrs   []error
+		noFailed   bool
+		saveErrs   []error
+		wantCalls  int
+		wantSent   int
+		wantFailed int
+	}{
+		{name: "sent job is acknowledged", wantCalls: 1, wantSent: 1},
+		{name: "failed job is kept", sendErrs: []error{rejected}, wantCalls: 1, wantFailed: 1},
+		{name: "failed job without a failed store is dropped", sendErrs: []error{rejected}, noFailed: true, wantCalls: 1},
+		{
+			name:       "failed job that cannot be kept is sent again",
+			sendErrs:   []error{rejected, rejected},
+			saveErrs:   []error{diskFull},
+			wantCalls:  2,
+			wantFailed: 1,
+		},
+		{
+			name:      "failed job that cannot be kept is sent again until it succeeds",
+			sendErrs:  []error{rejected},
+			saveErrs:  []error{diskFull},
+			wantCalls: 2,
+			wantSent:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			provider := &fakeSMS{name: "fake", errs: tt.sendErrs}
+			q := openTestQueue(t, dir)
+
+			var failed port.FailedJobStore
+			var store *queue.FailedStore
+			if !tt.noFailed {
+				var err error
+				if store, err = queue.OpenFailed(dir); err != nil {
+					t.Fatal(err)
+				}
+				failed = &flakyFailedStore{FailedJobStore: store, saveErrs: tt.saveErrs}
+			}
+
+			a := NewAsyncSender(newTestService(provider), q, failed, 1)
+			a.Start()
+			if _, err := a.Enqueue(ctx, "sms", testSMS()); err != nil {
+				t.Fatal(err)
+			}
+			eventually(t, "the job to be acknowledged", func() bool {
+				return provider.Calls() == tt.wantCalls && q.Len() == 0
+			})
+			stopSender(t, a)
+
+			if got := provider.Sent(); got != tt.wantSent {
+				t.Errorf("sent = %d, want %d", got, tt.wantSent)
+			}
+			if store != nil {
+				jobs, _ := store.List(ctx)
+				if len(jobs) != tt.wantFailed {
+					t.Errorf("failed jobs = %d, want %d", len(jobs), tt.wantFailed)
+				}
+			}
+			_ = q.Close()
+			if n := openTestQueue(t, dir).Len(); n != 0 {
+				t.Errorf("jobs after reopen = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestAsyncSenderSendsQueuedJobsAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	provider := &fakeSMS{name: "fake"}
+	svc := newTestService(provider)
+
+	q := openTestQueue(t, dir)
+	// Not started: the jobs stay queued when the process stops.
+	before := NewAsyncSender(svc, q, nil, 1)
+	for i := 0; i < 3; i++ {
+		if _, err := before.Enqueue(ctx, "sms", testSMS()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = q.Close()
+
+	q = openTestQueue(t, dir)
+	after := NewAsyncSender(svc, q, nil, 2)
+	after.Start()
+	eventually(t, "the queued jobs to be sent", func() bool {
+		return provider.Sent() == 3 && q.Len() == 0
+	})
+	stopSender(t, after)
+}
+
+func TestAsyncSenderRejectsInvalidMessages(t *testing.T) {
+	a := NewAsyncSender(newTestService(&fakeSMS{name: "fake"}), openTestQueue(t, t.TempDir()), nil, 1)
+
+	noRecipients := testSMS()
+	noRecipients.To = nil
+	if _, err := a.Enqueue(context.Background(), "sms", noRecipients); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("Enqueue without recipients error = %v, want ErrInvalidMessage", err)
+	}
+	if _, err := a.Enqueue(context.Background(), "fax", testSMS()); err == nil {
+		t.Error("Enqueue on an unknown channel succeeded")
+	}
+}
+
+func TestAsyncSenderFailedJobs(t *testing.T) {
+	tests := []struct {
+		name       string
+		ctx        func() context.Context
+		action     func(ctx context.Context, a *AsyncSender, id string) error
+		wantErr    error
+		wantSent   int
+		wantFailed int
+	}{
+		{
+			name:     "requeue sends the job again",
+			action:   func(ctx context.Context, a *AsyncSender, id string) error { return a.Requeue(ctx, id) },
+			wantSent: 1,
+		},
+		{
+			name: "requeue all sends every job",
+			action: func(ctx context.Context, a *AsyncSender, id string) error {
+				n, err := a.RequeueAll(ctx)
+				if err == nil && n != 1 {
+					return errors.New("requeued the wrong number of jobs")
+				}
+				return err
+			},
+			wantSent: 1,
+		},
+		{
+			name:   "discard drops the job",
+			action: func(ctx context.Context, a *AsyncSender, id string) error { return a.Discard(ctx, id) },
+		},
+		{
+			name:       "unknown job is not found",
+			action:     func(ctx context.Context, a *AsyncSender, id string) error { return a.Requeue(ctx, "missing") },
+			wantErr:    port.ErrJobNotFound,
+			wantFailed: 1,
+		},
+		{
+			name:       "another tenant's job is not found",
+			ctx:        func() context.Context { return WithTenant(context.Background(), "acme") },
+			action:     func(ctx context.Context, a *AsyncSender, id string) error { return a.Discard(ctx, id) },
+			wantErr:    port.ErrJobNotFound,
+			wantFailed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			rejected := &pkgerrors.ProviderError{Provider: "fake", Message: "down", Kind: pkgerrors.ErrRejected}
+			provider := &fakeSMS{name: "fake", errs: []error{rejected}}
+			q := openTestQueue(t, dir)
+			failed, err := queue.OpenFailed(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a := NewAsyncSender(newTestService(provider), q, failed, 1)
+			a.Start()
+			defer stopSender(t, a)
+			id, err := a.Enqueue(ctx, "sms", testSMS())
+			if err != nil {
+				t.Fatal(err)
+			}
+			eventually(t, "the job to fail", func() bool {
+				jobs, _ := failed.List(ctx)
+				return len(jobs) == 1 && q.Len() == 0
+			})
+
+			actx := ctx
+			if tt.ctx != nil {
+				actx = tt.ctx()
+			}
+			if err := tt.action(actx, a, id); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			eventually(t, "the queue to empty", func() bool {
+				return provider.Sent() == tt.wantSent && q.Len() == 0
+			})
+
+			jobs, err := a.FailedJobs(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(jobs) != tt.wantFailed {
+				t.Errorf("failed jobs = %d, want %d", len(jobs), tt.wantFailed)
+			}
+		})
+	}
+}
+
+// brokenQueue fails every Dequeue.
+type brokenQueue struct {
+	port.Queue
+	dequeues atomic.Int32
+}
+
+func (q *brokenQueue) Dequeue(ctx context.Context) (*port.Job, error) {
+	q.dequeues.Add(1)
+	return nil, errors.New("disk error")
+}
+
+func TestAsyncSenderBacksOffOnQueueErrors(t *testing.T) {
+	q := &brokenQueue{}
+	a := NewAsyncSender(newTestService(), q, nil, 1)
+	a.Start()
+	time.Sleep(250 * time.Millisecond)
+	stopSender(t, a)
+
+	// 0ms, 100ms and 300ms with the default backoff.
+	if n := q.dequeues.Load(); n > 4 {
+		t.Errorf("Dequeue called %d times in 250ms, want it to back off", n)
+	}
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// eventBufferSize is the number of events held per subscriber while earlier
+// ones are delivered. Events beyond it are dead-lettered.
+const eventBufferSize = 1000
+
+// recordLockStripes is the number of locks publishingLedger spreads record
+// IDs over.
+const recordLockStripes = 64
+
+// Event dispatch errors.
+var (
+	ErrSubscriberNotFound  = errors.New("event subscriber not found")
+	ErrEventDeliveryFailed = errors.New("event delivery failed")
+)
+
+// DefaultEventRetryPolicy returns the redelivery policy for subscribers
+// that do not set their own: 5 attempts over about 15 seconds. Timeouts are
+// retried too; subscribers deduplicate on the delivery ID.
+func DefaultEventRetryPolicy() resilience.RetryPolicy {
+	return resilience.RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Multiplier:     resilience.DefaultMultiplier,
+		Jitter:         resilience.DefaultJitter,
+		RetryTimeouts:  true,
+	}
+}
+
+// Subscriber is an application endpoint notified of message events.
+type Subscriber struct {
+	// Name identifies the subscriber in dead letters.
+	Name string
+	URL  string
+	// Secret signs each delivery. Signing is skipped when empty.
+	Secret string
+	// Events limits the event types sent. Empty sends all of them.
+	Events []contracts.EventType
+	// Retry controls redelivery of failed posts. Zero fields take the
+	// DefaultEventRetryPolicy values.
+	Retry resilience.RetryPolicy
+}
+
+type subscription struct {
+	Subscriber
+	events chan *contracts.Event
+}
+
+var _ port.EventPublisher = (*EventDispatcher)(nil)
+
+// EventDispatcher delivers message events to subscribers in the background,
+// retrying failed posts with backoff and keeping the events that still fail
+// as dead letters to be replayed.
+//
+// Events waiting for delivery are held in memory only: Stop dead-letters
+// the ones it cannot deliver in time, but those pending when the process
+// dies are lost. Dead letters are persisted.
+type EventDispatcher struct {
+	deliverer   port.EventDeliverer
+	deadLetters port.DeadLetterStore
+	subs        []*subscription
+	byName      map[string]*subscription
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.RWMutex
+	stopped bool
+}
+
+// NewEventDispatcher creates an EventDispatcher. Call Start to begin delivering.
+func NewEventDispatcher(deliverer port.EventDeliverer, deadLetters port.DeadLetterStore, subscribers []Subscriber) (*EventDispatcher, error) {
+	d := &EventDispatcher{
+		deliverer:   deliverer,
+		deadLetters: deadLetters,
+		byName:      make(map[string]*subscription, len(subscribers)),
+	}
+	for _, sub := range subscribers {
+		if sub.Name == "" {
+			return nil, errors.New("event subscriber name is required")
+		}
+		if sub.URL == "" {
+			return nil, fmt.Errorf("event subscriber %s: url is required", sub.Name)
+		}
+		for _, eventType := range sub.Events {
+			if !eventType.Valid() {
+				return nil, fmt.Errorf("event subscriber %s: unknown event type: %s", sub.Name, eventType)
+			}
+		}
+		if _, ok := d.byName[sub.Name]; ok {
+			return nil, fmt.Errorf("duplicate event subscriber: %s", sub.Name)
+		}
+		sub.Retry = sub.Retry.Merge(DefaultEventRetryPolicy())
+
+		s := &subscription{Subscriber: sub, events: make(chan *contracts.Event, eventBufferSize)}
+		d.subs = append(d.subs, s)
+		d.byName[sub.Name] = s
+	}
+	return d, nil
+}
+
+// Start launches a delivery worker per subscriber.
+func (d *EventDispatcher) Start() {
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	for _, sub := range d.subs {
+		d.wg.Add(1)
+		go d.work(sub)
+	}
+}
+
+// Stop stops accepting events and waits for the pending ones to be
+// delivered. Once ctx is done, undelivered events are dead-lettered.
+func (d *EventDispatcher) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.stopped {
+		d.stopped = true
+		for _, sub := range d.subs {
+			close(sub.events)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		if d.cancel != nil {
+			d.cancel()
+		}
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Publish queues the event for each subscriber that wants its type.
+func (d *EventDispatcher) Publish(ctx context.Context, event *contracts.Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.stopped {
+		return
+	}
+	for _, sub := range d.subs {
+		if len(sub.Events) > 0 && !slices.Contains(sub.Events, event.Type) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			d.deadLetter(sub, event, 0, errors.New("subscriber backlog full"))
+		}
+	}
+}
+
+// DeadLetters returns the undelivered events, oldest first. A non-empty
+// subscriber limits them to that subscriber's, and a tenant in ctx to the
+// tenant's messages.
+func (d *EventDispatcher) DeadLetters(ctx context.Context, subscriber string) ([]*port.DeadLetter, error) {
+	letters, err := d.deadLetters.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := letters[:0]
+	for _, letter := range letters {
+		if (subscriber == "" || letter.Subscriber == subscriber) && VisibleTo(ctx, letterTenant(letter)) {
+			matched = append(matched, letter)
+		}
+	}
+	return matched, nil
+}
+
+// deadLetterFor returns a dead letter, or port.ErrDeadLetterNotFound if it is
+// about another tenant's message than the one in ctx.
+func (d *EventDispatcher) deadLetterFor(ctx context.Context, id string) (*port.DeadLetter, error) {
+	letter, err := d.deadLetters.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !VisibleTo(ctx, letterTenant(letter)) {
+		return nil, port.ErrDeadLetterNotFound
+	}
+	return letter, nil
+}
+
+// letterTenant returns the tenant of a dead letter's message.
+func letterTenant(letter *port.DeadLetter) string {
+	if letter.Event == nil || letter.Event.Message == nil {
+		return ""
+	}
+	return letter.Event.Message.Tenant
+}
+
+// Replay posts a dead letter to its subscriber once more. On success the dead
+// letter is removed; on failure it is kept with the new error and the
+// returned error wraps ErrEventDeliveryFailed.
+func (d *EventDispatcher) Replay(ctx context.Context, id string) error {
+	letter, err := d.deadLetterFor(ctx, id)
+	if err != nil {
+		return err
+	}
+	sub, ok := d.byName[letter.Subscriber]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSubscriberNotFound, letter.Subscriber)
+	}
+
+	if err := d.deliverer.Deliver(ctx, sub.URL, sub.Secret, letter.Event); err != nil {
+		letter.Attempts++
+		letter.Error = err.Error()
+		letter.FailedAt = time.Now().UTC()
+		if saveErr := d.deadLetters.Save(ctx, letter); saveErr != nil {
+			log.Printf("Failed to update dead letter %s: %v", letter.ID, saveErr)
+		}
+		return fmt.Errorf("%w: %v", ErrEventDeliveryFailed, err)
+	}
+	return d.deadLetters.Delete(ctx, id)
+}
+
+// ReplayAll replays the dead letters, oldest first, limited to subscriber
+// when it is not empty. It returns how many were delivered and how many
+// failed again.
+func (d *EventDispatcher) ReplayAll(ctx context.Context, subscriber string) (replayed, failed int, err error) {
+	letters, err := d.DeadLetters(ctx, subscriber)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, letter := range letters {
+		err := d.Replay(ctx, letter.ID)
+		switch {
+		case err == nil:
+			replayed++
+		case errors.Is(err, ErrEventDeliveryFailed), errors.Is(err, ErrSubscriberNotFound):
+			failed++
+		case errors.Is(err, port.ErrDeadLetterNotFound):
+			// Replayed or discarded concurrently.
+		default:
+			return replayed, failed, err
+		}
+	}
+	return replayed, failed, nil
+}
+
+// Discard removes a dead letter without delivering it.
+func (d *EventDispatcher) Discard(ctx context.Context, id string) error {
+	if _, err := d.deadLetterFor(ctx, id); err != nil {
+		return err
+	}
+	return d.deadLetters.Delete(ctx, id)
+}
+
+func (d *EventDispatcher) work(sub *subscription) {
+	defer d.wg.Done()
+
+	for event := range sub.events {
+		d.deliver(sub, event)
+	}
+}
+
+// deliver posts the event, retrying transient failures per the subscriber's
+// policy, and dead-letters it if it cannot be delivered.
+func (d *EventDispatcher) deliver(sub *subscription, event *contracts.Event) {
+	policy := sub.Retry
+	for attempt := 1; ; attempt++ {
+		err := d.deliverer.Deliver(d.ctx, sub.URL, sub.Secret, event)
+		if err == nil {
+			return
+		}
+
+		if attempt >= policy.MaxAttempts || !policy.ShouldRetry(d.ctx, err) {
+			d.deadLetter(sub, event, attempt, err)
+			return
+		}
+
+		timer := time.NewTimer(policy.Backoff(attempt))
+		select {
+		case <-d.ctx.Done():
+			timer.Stop()
+			d.deadLetter(sub, event, attempt, err)
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (d *EventDispatcher) deadLetter(sub *subscription, event *contracts.Event, attempts int, err error) {
+	log.Printf("Event %s %s to %s failed after %d attempts: %v", event.Type, event.ID, sub.Name, attempts, err)
+
+	letter := &port.DeadLetter{
+		ID:         uuid.New().String(),
+		Subscriber: sub.Name,
+		Event:      event,
+		Attempts:   attempts,
+		Error:      err.Error(),
+		FailedAt:   time.Now().UTC(),
+	}
+	if err := d.deadLetters.Save(context.Background(), letter); err != nil {
+		log.Printf("Failed to save dead letter for event %s: %v", event.ID, err)
+	}
+}
+
+// publishingLedger is a port.Ledger that publishes an event whenever a saved
+// record's status changes to one with an event type.
+type publishingLedger struct {
+	port.Ledger
+	publisher port.EventPublisher
+
+	// locks serialise saves of the same record, so concurrent saves cannot
+	// both see the old status and publish the event twice.
+	locks [recordLockStripes]sync.Mutex
+}
+
+// NewPublishingLedger wraps ledger to publish message events to publisher.
+func NewPublishingLedger(ledger port.Ledger, publisher port.EventPublisher) port.Ledger {
+	return &publishingLedger{Ledger: ledger, publisher: publisher}
+}
+
+// Save saves the record and, if its status changed, publishes the event for it.
+func (l *publishingLedger) Save(ctx context.Context, record *contracts.MessageRecord) error {
+	mu := l.lock(record.ID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	eventType, publish := contracts.EventTypeForStatus(record.Status)
+	if publish {
+		if prev, err := l.Ledger.Get(ctx, record.ID); err == nil && prev.Status == record.Status {
+			publish = false
+		}
+	}
+
+	if err := l.Ledger.Save(ctx, record); err != nil {
+		return err
+	}
+	if !publish {
+		return nil
+	}
+
+	// Publish the stored copy: the caller may keep changing its record.
+	saved, err := l.Ledger.Get(ctx, record.ID)
+	if err != nil {
+		log.Printf("Failed to publish %s for %s: %v", eventType, record.ID, err)
+		return nil
+	}
+	l.publisher.Publish(ctx, &contracts.Event{
+		ID:      uuid.New().String(),
+		Type:    eventType,
+		Time:    saved.UpdatedAt,
+		Message: saved,
+	})
+	return nil
+}
+
+// lock returns the lock for a record ID.
+func (l *publishingLedger) lock(id string) *sync.Mutex {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return &l.locks[h.Sum32()%recordLockStripes]
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/deadletter"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// fakeDeliverer fails each URL's deliveries with its errs in turn, then
+// succeeds.
+type fakeDeliverer struct {
+	mu    sync.Mutex
+	errs  map[string][]error
+	calls map[string]int
+}
+
+func (d *fakeDeliverer) Deliver(ctx context.Context, url, secret string, event *contracts.Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.calls == nil {
+		d.calls = make(map[string]int)
+	}
+	d.calls[url]++
+	if errs := d.errs[url]; len(errs) > 0 {
+		d.errs[url] = errs[1:]
+		return errs[0]
+	}
+	return nil
+}
+
+func (d *fakeDeliverer) Calls(url string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.calls[url]
+}
+
+// fastRetry retries three times without waiting long.
+var fastRetry = resilience.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+func openDeadLetters(t *testing.T) *deadletter.FileStore {
+	t.Helper()
+	store, err := deadletter.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func testEvent(eventType contracts.EventType, tenant string) *contracts.Event {
+	return &contracts.Event{
+		ID:      "evt-1",
+		Type:    eventType,
+		Time:    time.Now().UTC(),
+		Message: &contracts.MessageRecord{ID: "msg-1", Tenant: tenant},
+	}
+}
+
+func TestEventDispatcherDeliver(t *testing.T) {
+	unavailable := providerErr(pkgerrors.ErrUnavailable)
+	rejected := providerErr(pkgerrors.ErrRejected)
+
+	tests := []struct {
+		name         string
+		events       []contracts.EventType
+		errs         []error
+		wantCalls    int
+		wantAttempts int // of the dead letter; 0 for none
+	}{
+		{name: "delivered", wantCalls: 1},
+		{name: "retried until delivered", errs: []error{unavailable, unavailable}, wantCalls: 3},
+		{name: "dead-lettered after the last attempt", errs: []error{unavailable, unavailable, unavailable}, wantCalls: 3, wantAttempts: 3},
+		{name: "permanent failure is not retried", errs: []error{rejected}, wantCalls: 1, wantAttempts: 1},
+		{name: "event type not subscribed to", events: []contracts.EventType{contracts.EventMessageDelivered}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliverer := &fakeDeliverer{errs: map[string][]error{"https://app/hook": tt.errs}}
+			letters := openDeadLetters(t)
+			d, err := NewEventDispatcher(deliverer, letters, []Subscriber{
+				{Name: "app", URL: "https://app/hook", Events: tt.events, Retry: fastRetry},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			d.Start()
+
+			d.Publish(context.Background(), testEvent(contracts.EventMessageSent, ""))
+			if err := d.Stop(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := deliverer.Calls("https://app/hook"); got != tt.wantCalls {
+				t.Errorf("deliveries = %d, want %d", got, tt.wantCalls)
+			}
+			list, _ := letters.List(context.Background())
+			if tt.wantAttempts == 0 {
+				if len(list) != 0 {
+					t.Errorf("dead letters = %d, want none", len(list))
+				}
+				return
+			}
+			if len(list) != 1 || list[0].Attempts != tt.wantAttempts || list[0].Subscriber != "app" || list[0].Error == "" {
+				t.Errorf("dead letters = %+v, want one for app after %d attempts", list, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestEventDispatcherBacklogFull(t *testing.T) {
+	deliverer := &fakeDeliverer{}
+	letters := openDeadLetters(t)
+	d, err := NewEventDispatcher(deliverer, letters, []Subscriber{{Name: "app", URL: "https://app/hook"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Not started, so nothing drains the backlog.
+	for range eventBufferSize + 2 {
+		d.Publish(context.Background(), testEvent(contracts.EventMessageSent, ""))
+	}
+
+	list, _ := letters.List(context.Background())
+	if len(list) != 2 {
+		t.Fatalf("dead letters = %d, want the 2 events past the backlog", len(list))
+	}
+	if list[0].Attempts != 0 {
+		t.Errorf("attempts = %d, want 0 for an event never sent", list[0].Attempts)
+	}
+}
+
+func TestEventDispatcherStopsAccepting(t *testing.T) {
+	deliverer := &fakeDeliverer{}
+	d, err := NewEventDispatcher(deliverer, openDeadLetters(t), []Subscriber{{Name: "app", URL: "https://app/hook"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Start()
+	if err := d.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Publish(context.Background(), testEvent(contracts.EventMessageSent, ""))
+	if got := deliverer.Calls("https://app/hook"); got != 0 {
+		t.Errorf("deliveries after Stop = %d, want 0", got)
+	}
+}
+
+func TestEventDispatcherReplay(t *testing.T) {
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		subscriber string
+		errs       []error
+		wantErr    error
+		wantLeft   int
+		wantTries  int // attempts recorded on the dead letter left
+	}{
+		{name: "delivered", subscriber: "app"},
+		{name: "delivered for the letter's tenant", ctx: WithTenant(context.Background(), "shop"), subscriber: "app"},
+		{name: "fails again", subscriber: "app", errs: []error{errors.New("down")}, wantErr: ErrEventDeliveryFailed, wantLeft: 1, wantTries: 4},
+		{name: "subscriber removed", subscriber: "gone", wantErr: ErrSubscriberNotFound, wantLeft: 1, wantTries: 3},
+		{name: "another tenant's letter", ctx: WithTenant(context.Background(), "acme"), subscriber: "app", wantErr: port.ErrDeadLetterNotFound, wantLeft: 1, wantTries: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			deliverer := &fakeDeliverer{errs: map[string][]error{"https://app/hook": tt.errs}}
+			letters := openDeadLetters(t)
+			d, err := NewEventDispatcher(deliverer, letters, []Subscriber{{Name: "app", URL: "https://app/hook"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			letter := &port.DeadLetter{ID: "dl-1", Subscriber: tt.subscriber, Event: testEvent(contracts.EventMessageSent, "shop"), Attempts: 3, Error: "timeout"}
+			if err := letters.Save(ctx, letter); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := d.Replay(ctx, "dl-1"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			list, _ := letters.List(context.Background())
+			if len(list) != tt.wantLeft {
+				t.Fatalf("dead letters = %d, want %d", len(list), tt.wantLeft)
+			}
+			if tt.wantLeft > 0 && list[0].Attempts != tt.wantTries {
+				t.Errorf("attempts = %d, want %d", list[0].Attempts, tt.wantTries)
+			}
+		})
+	}
+}
+
+func TestEventDispatcherReplayAll(t *testing.T) {
+	ctx := context.Background()
+	deliverer := &fakeDeliverer{errs: map[string][]error{"https://ops/hook": {errors.New("down")}}}
+	letters := openDeadLetters(t)
+	d, err := NewEventDispatcher(deliverer, letters, []Subscriber{
+		{Name: "app", URL: "https://app/hook"},
+		{Name: "ops", URL: "https://ops/hook"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, sub := range []string{"app", "app", "ops", "gone"} {
+		letter := &port.DeadLetter{ID: fmt.Sprint("dl-", i), Subscriber: sub, Event: testEvent(contracts.EventMessageSent, ""), FailedAt: time.Now().Add(time.Duration(i) * time.Second)}
+		if err := letters.Save(ctx, letter); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replayed, failed, err := d.ReplayAll(ctx, "app")
+	if err != nil || replayed != 2 || failed != 0 {
+		t.Errorf("ReplayAll(app) = %d, %d, %v; want 2 replayed", replayed, failed, err)
+	}
+	replayed, failed, err = d.ReplayAll(ctx, "")
+	if err != nil || replayed != 0 || failed != 2 {
+		t.Errorf("ReplayAll() = %d, %d, %v; want 2 failed", replayed, failed, err)
+	}
+	if list, _ := letters.List(ctx); len(list) != 2 {
+		t.Errorf("dead letters = %d, want the 2 that failed", len(list))
+	}
+}
+
+// recordingPublisher records the published event types.
+type recordingPublisher struct {
+	mu    sync.Mutex
+	types []contracts.EventType
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event *contracts.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.types = append(p.types, event.Type)
+}
+
+func (p *recordingPublisher) published() []contracts.EventType {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]contracts.EventType(nil), p.types...)
+}
+
+func TestPublishingLedger(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []contracts.MessageStatus
+		want     []contracts.EventType
+	}{
+		{name: "queued is not published", statuses: []contracts.MessageStatus{contracts.StatusQueued}},
+		{
+			name:     "each change is published",
+			statuses: []contracts.MessageStatus{contracts.StatusQueued, contracts.StatusSent, contracts.StatusDelivered},
+			want:     []contracts.EventType{contracts.EventMessageSent, contracts.EventMessageDelivered},
+		},
+		{
+			name:     "saving the same status again is not",
+			statuses: []contracts.MessageStatus{contracts.StatusSent, contracts.StatusSent},
+			want:     []contracts.EventType{contracts.EventMessageSent},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			l := NewPublishingLedger(openTestLedger(t), publisher)
+
+			record := &contracts.MessageRecord{ID: "msg-1", Channel: "sms", CreatedAt: time.Now()}
+			for _, status := range tt.statuses {
+				record.Status = status
+				if err := l.Save(context.Background(), record); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := publisher.published(); !slices.Equal(got, tt.want) {
+				t.Errorf("published %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishingLedgerConcurrentSaves(t *testing.T) {
+	publisher := &recordingPublisher{}
+	l := NewPublishingLedger(openTestLedger(t), publisher)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record := &contracts.MessageRecord{ID: "msg-1", Channel: "sms", Status: contracts.StatusDelivered, CreatedAt: time.Now()}
+			if err := l.Save(context.Background(), record); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := publisher.published(); len(got) != 1 {
+		t.Errorf("published %v, want message.delivered once", got)
+	}
+}
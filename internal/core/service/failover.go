@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// sender is the Send method shared by every channel's port.
+type sender[M any] interface {
+	Send(ctx context.Context, message M) (*contracts.SendResult, error)
+}
+
+// FailoverError is returned when a failover chain ends without a successful send.
+// It unwraps to the last provider's error, so error kinds still match with errors.Is.
+type FailoverError struct {
+	Channel  string
+	Attempts []contracts.Attempt
+	Err      error
+}
+
+func (e *FailoverError) Error() string {
+	names := make([]string, len(e.Attempts))
+	for i, a := range e.Attempts {
+		names[i] = a.Provider
+	}
+	return fmt.Sprintf("%s: send failed after trying %s: %v", e.Channel, strings.Join(names, ", "), e.Err)
+}
+
+// Unwrap returns the last provider's error.
+func (e *FailoverError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderChain returns the ordered providers to try: the default first, then
+// the failover providers, without blanks or duplicates.
+func ProviderChain(defaultProvider string, failover []string) []string {
+	chain := make([]string, 0, len(failover)+1)
+	seen := make(map[string]bool, len(failover)+1)
+	for _, name := range append([]string{defaultProvider}, failover...) {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		chain = append(chain, name)
+	}
+	return chain
+}
+
+// firstProvider returns the provider a chain starts with, or "".
+func firstProvider(chain []string) string {
+	if len(chain) == 0 {
+		return ""
+	}
+	return chain[0]
+}
+
+// shouldFailover reports whether the next provider might succeed where this
+// one failed: transient outages, rate limits, connection failures, an open
+// circuit breaker, bad credentials or a provider that is not registered.
+// Errors about the message itself stop the chain, and so do timeouts and lost
+// connections after the message may have been accepted, unless policy
+// retries them.
+func shouldFailover(ctx context.Context, policy resilience.RetryPolicy, err error) bool {
+	var notFound *ProviderNotFoundError
+	return policy.ShouldRetry(ctx, err) ||
+		errors.Is(err, resilience.ErrCircuitOpen) ||
+		errors.Is(err, pkgerrors.ErrUnauthorized) ||
+		errors.As(err, &notFound)
+}
+
+// sendWithFailover tries each provider in chain until one succeeds or fails
+// with an error that another provider would not fix.
+//
+// The winning provider is recorded in SendResult.Meta[contracts.MetaProvider].
+// With more than one provider in the chain, every attempt is listed in
+// SendResult.Attempts, or in the returned *FailoverError.
+func sendWithFailover[M any](
+	ctx context.Context,
+	channel string,
+	chain []string,
+	policy resilience.RetryPolicy,
+	lookup func(name string) (sender[M], error),
+	message M,
+) (*contracts.SendResult, error) {
+	if len(chain) == 0 {
+		return nil, NewProviderNotFoundError(channel, "default (none configured)")
+	}
+
+	attempts := make([]contracts.Attempt, 0, len(chain))
+	var lastErr error
+
+	for _, name := range chain {
+		if lastErr != nil && ctx.Err() != nil {
+			break
+		}
+
+		start := time.Now()
+		result, err := send(ctx, name, lookup, message)
+		attempt := contracts.Attempt{
+			Provider:   name,
+			DurationMS: time.Since(start).Milliseconds(),
+		}
+
+		if err == nil {
+			attempts = append(attempts, attempt)
+			if result == nil {
+				result = &contracts.SendResult{}
+			}
+			if result.Meta == nil {
+				result.Meta = make(map[string]string, 1)
+			}
+			result.Meta[contracts.MetaProvider] = name
+			if len(chain) > 1 {
+				result.Attempts = attempts
+			}
+			return result, nil
+		}
+
+		attempt.Error = err.Error()
+		attempts = append(attempts, attempt)
+		lastErr = err
+
+		if !shouldFailover(ctx, policy, err) {
+			break
+		}
+	}
+
+	if len(chain) == 1 {
+		return nil, lastErr
+	}
+	return nil, &FailoverError{Channel: channel, Attempts: attempts, Err: lastErr}
+}
+
+func send[M any](ctx context.Context, name string, lookup func(string) (sender[M], error), message M) (*contracts.SendResult, error) {
+	provider, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Send(ctx, message)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func providerErr(kind error) error {
+	return &pkgerrors.ProviderError{Provider: "fake", Message: "failed", StatusCode: 500, Kind: kind}
+}
+
+func TestSendWithFailover(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	// lostErr is a connection lost after the request was written: the
+	// provider may have accepted the message.
+	lostErr := &pkgerrors.ProviderError{Provider: "fake", Message: "failed", Kind: pkgerrors.ErrUnavailable, Err: errors.New("EOF")}
+	openErr := &pkgerrors.ProviderError{Provider: "fake", Message: "skipped", Kind: pkgerrors.ErrUnavailable, Err: resilience.ErrCircuitOpen}
+
+	tests := []struct {
+		name string
+		// errs are the errors of the chain's providers, in order; a
+		// provider after the last entry succeeds.
+		errs         []error
+		chain        []string
+		policy       resilience.RetryPolicy
+		wantProvider string
+		wantAttempts []string
+		wantErr      error
+	}{
+		{name: "single provider succeeds", chain: []string{"a"}, wantProvider: "a"},
+		{name: "first of two succeeds", chain: []string{"a", "b"}, wantProvider: "a", wantAttempts: []string{"a"}},
+		{name: "outage fails over", errs: []error{providerErr(pkgerrors.ErrUnavailable)}, chain: []string{"a", "b"}, wantProvider: "b", wantAttempts: []string{"a", "b"}},
+		{name: "rate limit fails over", errs: []error{providerErr(pkgerrors.ErrRateLimited)}, chain: []string{"a", "b"}, wantProvider: "b", wantAttempts: []string{"a", "b"}},
+		{name: "bad credentials fail over", errs: []error{providerErr(pkgerrors.ErrUnauthorized)}, chain: []string{"a", "b"}, wantProvider: "b", wantAttempts: []string{"a", "b"}},
+		{name: "connection failure fails over", errs: []error{fmt.Errorf("smtp: %w", dialErr)}, chain: []string{"a", "b"}, wantProvider: "b", wantAttempts: []string{"a", "b"}},
+		{name: "open circuit breaker fails over", errs: []error{openErr}, chain: []string{"a", "b"}, wantProvider: "b", wantAttempts: []string{"a", "b"}},
+		{name: "unregistered provider fails over", chain: []string{"missing", "b"}, wantProvider: "b", wantAttempts: []string{"missing", "b"}},
+		{
+			name:         "invalid recipient stops the chain",
+			errs:         []error{providerErr(pkgerrors.ErrInvalidRecipient)},
+			chain:        []string{"a", "b"},
+			wantAttempts: []string{"a"},
+			wantErr:      pkgerrors.ErrInvalidRecipient,
+		},
+		{
+			name:         "rejected message stops the chain",
+			errs:         []error{providerErr(pkgerrors.ErrRejected)},
+			chain:        []string{"a", "b"},
+			wantAttempts: []string{"a"},
+			wantErr:      pkgerrors.ErrRejected,
+		},
+		{
+			name:         "timeout stops the chain",
+			errs:         []error{fmt.Errorf("fake: %w", context.DeadlineExceeded)},
+			chain:        []string{"a", "b"},
+			wantAttempts: []string{"a"},
+			wantErr:      context.DeadlineExceeded,
+		},
+		{
+			name:         "lost connection after the write stops the chain",
+			errs:         []error{lostErr},
+			chain:        []string{"a", "b"},
+			wantAttempts: []string{"a"},
+			wantErr:      pkgerrors.ErrUnavailable,
+		},
+		{
+			name:         "retried timeouts fail over",
+			errs:         []error{fmt.Errorf("fake: %w", context.DeadlineExceeded)},
+			chain:        []string{"a", "b"},
+			policy:       resilience.RetryPolicy{RetryTimeouts: true},
+			wantProvider: "b",
+			wantAttempts: []string{"a", "b"},
+		},
+		{
+			name:         "retried lost connections fail over",
+			errs:         []error{lostErr},
+			chain:        []string{"a", "b"},
+			policy:       resilience.RetryPolicy{RetryTimeouts: true},
+			wantProvider: "b",
+			wantAttempts: []string{"a", "b"},
+		},
+		{
+			name:         "every provider fails",
+			errs:         []error{providerErr(pkgerrors.ErrUnavailable), providerErr(pkgerrors.ErrRateLimited)},
+			chain:        []string{"a", "b"},
+			wantAttempts: []string{"a", "b"},
+			wantErr:      pkgerrors.ErrRateLimited,
+		},
+		{
+			name:    "single provider failure is returned as is",
+			errs:    []error{providerErr(pkgerrors.ErrUnavailable)},
+			chain:   []string{"a"},
+			wantErr: pkgerrors.ErrUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make(map[string]*fakeSMS)
+			for i, name := range tt.chain {
+				if name == "missing" {
+					continue
+				}
+				p := &fakeSMS{name: name}
+				if i < len(tt.errs) {
+					p.errs = []error{tt.errs[i]}
+				}
+				providers[name] = p
+			}
+			lookup := func(name string) (sender[*contracts.SMS], error) {
+				p, ok := providers[name]
+				if !ok {
+					return nil, NewProviderNotFoundError("sms", name)
+				}
+				return p, nil
+			}
+
+			result, err := sendWithFailover(context.Background(), "sms", tt.chain, tt.policy, lookup, testSMS())
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				var ferr *FailoverError
+				if isFailover := errors.As(err, &ferr); isFailover != (len(tt.chain) > 1) {
+					t.Fatalf("error is a FailoverError = %v, want %v", isFailover, len(tt.chain) > 1)
+				}
+				if ferr != nil {
+					if got := attemptProviders(ferr.Attempts); got != fmt.Sprint(tt.wantAttempts) {
+						t.Errorf("attempts = %s, want %v", got, tt.wantAttempts)
+					}
+					if ferr.Attempts[len(ferr.Attempts)-1].Error == "" {
+						t.Error("failed attempt has no error")
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got := result.Meta[contracts.MetaProvider]; got != tt.wantProvider {
+				t.Errorf("provider = %q, want %q", got, tt.wantProvider)
+			}
+			if tt.wantAttempts == nil {
+				if result.Attempts != nil {
+					t.Errorf("attempts = %v, want none for a single provider", result.Attempts)
+				}
+			} else if got := attemptProviders(result.Attempts); got != fmt.Sprint(tt.wantAttempts) {
+				t.Errorf("attempts = %s, want %v", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func attemptProviders(attempts []contracts.Attempt) string {
+	names := make([]string, len(attempts))
+	for i, a := range attempts {
+		names[i] = a.Provider
+	}
+	return fmt.Sprint(names)
+}
+
+func TestSendWithFailoverEmptyChain(t *testing.T) {
+	lookup := func(name string) (sender[*contracts.SMS], error) { return nil, nil }
+	_, err := sendWithFailover(context.Background(), "sms", nil, resilience.RetryPolicy{}, lookup, testSMS())
+
+	var notFound *ProviderNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("error = %v, want a ProviderNotFoundError", err)
+	}
+}
+
+// nilResultSMS succeeds without a result.
+type nilResultSMS struct{}
+
+func (nilResultSMS) Send(context.Context, *contracts.SMS) (*contracts.SendResult, error) {
+	return nil, nil
+}
+
+func TestSendWithFailoverNilResult(t *testing.T) {
+	lookup := func(name string) (sender[*contracts.SMS], error) { return nilResultSMS{}, nil }
+	result, err := sendWithFailover(context.Background(), "sms", []string{"a"}, resilience.RetryPolicy{}, lookup, testSMS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Meta[contracts.MetaProvider]; got != "a" {
+		t.Errorf("provider = %q, want a", got)
+	}
+}
+
+func TestSendWithFailoverStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	first := &fakeSMS{name: "a", errs: []error{providerErr(pkgerrors.ErrUnavailable)}}
+	second := &fakeSMS{name: "b"}
+	lookup := func(name string) (sender[*contracts.SMS], error) {
+		if name == "a" {
+			cancel()
+			return first, nil
+		}
+		return second, nil
+	}
+
+	if _, err := sendWithFailover(ctx, "sms", []string{"a", "b"}, resilience.RetryPolicy{}, lookup, testSMS()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if second.Calls() != 0 {
+		t.Error("failed over after the caller went away")
+	}
+}
+
+func TestGatewayServiceFailover(t *testing.T) {
+	primary := &fakeSMS{name: "primary", errs: []error{providerErr(pkgerrors.ErrUnavailable)}}
+	backup := &fakeSMS{name: "backup"}
+	svc := newTestService(primary, backup)
+
+	result, err := svc.SendSMS(context.Background(), testSMS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Meta[contracts.MetaProvider]; got != "backup" {
+		t.Errorf("provider = %q, want backup", got)
+	}
+	if len(result.Attempts) != 2 || result.Attempts[0].Error == "" {
+		t.Errorf("attempts = %+v, want a failed primary and the backup", result.Attempts)
+	}
+}
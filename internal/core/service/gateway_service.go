@@ -3,15 +3,20 @@ package service
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
 	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
 )
 
 // GatewayService handles provider registration and message dispatching.
 type GatewayService struct {
-	config   GatewayConfig
-	registry *Registry
+	global *tenant
+	ledger port.Ledger
+
+	mu      sync.RWMutex
+	tenants map[string]*tenant
 }
 
 // GatewayConfig holds the configuration needed by the service.
@@ -20,37 +25,75 @@ type GatewayConfig interface {
 	DefaultSMSProvider() string
 	DefaultPushProvider() string
 	DefaultChatProvider() string
+
+	// Failover providers are tried, in order, after the default provider
+	// fails with a retryable error.
+	EmailFailover() []string
+	SMSFailover() []string
+	PushFailover() []string
+	ChatFailover() []string
+
+	// RetryPolicies decide, per channel, whether a timeout or lost
+	// connection after the message may have been sent fails over too.
+	RetryPolicies() resilience.RetryPolicies
 }
 
-// NewGatewayService creates a new GatewayService.
-func NewGatewayService(cfg GatewayConfig, registry *Registry) *GatewayService {
+// NewGatewayService creates a new GatewayService. ledger may be nil to
+// skip recording sends.
+func NewGatewayService(cfg GatewayConfig, registry *Registry, ledger port.Ledger) *GatewayService {
 	return &GatewayService{
-		config:   cfg,
-		registry: registry,
+		global:  &tenant{config: cfg, registry: registry},
+		ledger:  ledger,
+		tenants: make(map[string]*tenant),
 	}
 }
 
-// SendEmail sends an email using the default provider.
+// SendEmail sends an email using the default provider, falling back
+// to the configured failover providers.
 func (s *GatewayService) SendEmail(ctx context.Context, email *contracts.Email) (*contracts.SendResult, error) {
-	provider, err := s.Email()
+	return s.sendEmail(ctx, newMessageID(), email)
+}
+
+// sendEmail sends through the failover chain, recording the outcome under id.
+// The ctx tenant's providers are used; an API key in ctx may restrict the
+// chain to its own.
+func (s *GatewayService) sendEmail(ctx context.Context, id string, email *contracts.Email) (*contracts.SendResult, error) {
+	if err := authorize(ctx, "email", ""); err != nil {
+		return nil, err
+	}
+	t, err := s.tenantFor(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return provider.Send(ctx, email)
+	chain := providerChain(ctx, "email", t.config.DefaultEmailProvider(), t.config.EmailFailover())
+	return s.track(ctx, id, "email", firstProvider(chain), email, func() (*contracts.SendResult, error) {
+		return sendWithFailover(ctx, "email", chain, t.config.RetryPolicies().For("email"), func(name string) (sender[*contracts.Email], error) {
+			return t.emailProvider(name)
+		}, email)
+	})
 }
 
 // SendEmailWith sends an email using a specific provider.
 func (s *GatewayService) SendEmailWith(ctx context.Context, providerName string, email *contracts.Email) (*contracts.SendResult, error) {
-	provider, err := s.EmailProvider(providerName)
+	if err := authorize(ctx, "email", providerName); err != nil {
+		return nil, err
+	}
+	t, err := s.tenantFor(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return provider.Send(ctx, email)
+	return s.track(ctx, newMessageID(), "email", providerName, email, func() (*contracts.SendResult, error) {
+		provider, err := t.emailProvider(providerName)
+		if err != nil {
+			return nil, err
+		}
+		return provider.Send(ctx, email)
+	})
 }
 
 // Email returns the default email provider.
 func (s *GatewayService) Email() (port.EmailSender, error) {
-	providerName := s.config.DefaultEmailProvider()
+	providerName := s.global.config.DefaultEmailProvider()
 	if providerName == "" {
 		return nil, NewProviderNotFoundError("email", "default (none configured)")
 	}
@@ -59,39 +102,60 @@ func (s *GatewayService) Email() (port.EmailSender, error) {
 
 // EmailProvider returns a specific email provider by name.
 func (s *GatewayService) EmailProvider(name string) (port.EmailSender, error) {
-	provider, ok := s.registry.GetEmailProvider(name)
-	if !ok {
-		return nil, NewProviderNotFoundError("email", name)
-	}
-	return provider, nil
+	return s.global.emailProvider(name)
 }
 
 // RegisterEmailProvider registers a custom email provider.
 func (s *GatewayService) RegisterEmailProvider(name string, provider port.EmailSender) {
-	s.registry.RegisterEmailProvider(name, provider)
+	s.global.registry.RegisterEmailProvider(name, provider)
 }
 
-// SendSMS sends an SMS using the default provider.
+// SendSMS sends an SMS using the default provider, falling back
+// to the configured failover providers.
 func (s *GatewayService) SendSMS(ctx context.Context, sms *contracts.SMS) (*contracts.SendResult, error) {
-	provider, err := s.SMS()
+	return s.sendSMS(ctx, newMessageID(), sms)
+}
+
+// sendSMS sends through the failover chain, recording the outcome under id.
+// The ctx tenant's providers are used; an API key in ctx may restrict the
+// chain to its own.
+func (s *GatewayService) sendSMS(ctx context.Context, id string, sms *contracts.SMS) (*contracts.SendResult, error) {
+	if err := authorize(ctx, "sms", ""); err != nil {
+		return nil, err
+	}
+	t, err := s.tenantFor(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return provider.Send(ctx, sms)
+	chain := providerChain(ctx, "sms", t.config.DefaultSMSProvider(), t.config.SMSFailover())
+	return s.track(ctx, id, "sms", firstProvider(chain), sms, func() (*contracts.SendResult, error) {
+		return sendWithFailover(ctx, "sms", chain, t.config.RetryPolicies().For("sms"), func(name string) (sender[*contracts.SMS], error) {
+			return t.smsProvider(name)
+		}, sms)
+	})
 }
 
 // SendSMSWith sends an SMS using a specific provider.
 func (s *GatewayService) SendSMSWith(ctx context.Context, providerName string, sms *contracts.SMS) (*contracts.SendResult, error) {
-	provider, err := s.SMSProvider(providerName)
+	if err := authorize(ctx, "sms", providerName); err != nil {
+		return nil, err
+	}
+	t, err := s.tenantFor(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return provider.Send(ctx, sms)
+	return s.track(ctx, newMessageID(), "sms", providerName, sms, func() (*contracts.SendResult, error) {
+		provider, err := t.smsProvider(providerName)
+		if err != nil {
+			return nil, err
+		}
+		return provider.Send(ctx, sms)
+	})
 }
 
 // SMS returns the default SMS provider.
 func (s *GatewayService) SMS() (port.SMSSender, error) {
-	providerName := s.config.DefaultSMSProvider()
+	providerName := s.global.config.DefaultSMSProvider()
 	if providerName == "" {
 		return nil, NewProviderNotFoundError("sms", "default (none configured)")
 	}
@@ -100,39 +164,60 @@ func (s *GatewayService) SMS() (port.SMSSender, error) {
 
 // SMSProvider returns a specific SMS provider by name.
 func (s *GatewayService) SMSProvider(name string) (port.SMSSender, error) {
-	provider, ok := s.registry.GetSMSProvider(name)
-	if !ok {
-		return nil, NewProviderNotFoundError("sms", name)
-	}
-	return provider, nil
+	return s.global.smsProvider(name)
 }
 
 // RegisterSMSProvider registers a custom SMS provider.
 func (s *GatewayService) RegisterSMSProvider(name string, provider port.SMSSender) {
-	s.registry.RegisterSMSProvider(name, provider)
+	s.global.registry.RegisterSMSProvider(name, provider)
 }
 
-// SendPush sends a push notification using the default provider.
+// SendPush sends a push notification using the default provider, falling back
+// to the configured failover providers.
 func (s *GatewayService) SendPush(ctx context.Context, notification *contracts.PushNotification) (*contracts.SendResult, error) {
-	provider, err := s.Push()
+	return s.sendPush(ctx, newMessageID(), notification)
+}
+
+// sendPush sends through the failover chain, recording the outcome under id.
+// The ctx tenant's providers are used; an API key in ctx may restrict the
+// chain to its own.
+func (s *GatewayService) sendPush(ctx context.Context, id string, notification *contracts.PushNotification) (*contracts.SendResult, error) {
+	if err := authorize(ctx, "push", ""); err != nil {
+		return nil, err
+	}
+	t, err := s.tenantFor(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return provider.Send(ctx, notification)
+	chain := providerChain(ctx, "push", t.config.DefaultPushProvider(), t.config.PushFailover())
+	return s.track(ctx, id, "push", firstProvider(chain), notification, func() (*contracts.SendResult, error) {
+		return sendWithFailover(ctx, "push", chain, t.config.RetryPolicies().For("push"), func(name string) (sender[*contracts.PushNotification], error) {
+			return t.pushProvider(name)
+		}, notification)
+	})
 }
 
 // SendPushWith sends a push notification using a specific provider.
 func (s *GatewayService) SendPushWith(ctx context.Context, providerName string, notification *contracts.PushNotification) (*contracts.SendResult, error) {
-	provider, err := s.PushProvider(providerName)
+	if err := authorize(ctx, "push", providerName); err != nil {
+		return nil, err
+	}
+	t, err := s.tenantFor(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return provider.Send(ctx, notification)
+	return s.track(ctx, newMessageID(), "push", providerName, notification, func() (*contracts.SendResult, error) {
+		provider, err := t.pushProvider(providerName)
+		if err != nil {
+			return nil, err
+		}
+		return provider.Send(ctx, notification)
+	})
 }
 
 // Push returns the default push provider.
 func (s *GatewayService) Push() (port.PushSender, error) {
-	providerName := s.config.DefaultPushProvider()
+	providerName := s.global.config.DefaultPushProvider()
 	if providerName == "" {
 		return nil, NewProviderNotFoundError("push", "default (none configured)")
 	}
@@ -141,39 +226,60 @@ func (s *GatewayService) Push() (port.PushSender, error) {
 
 // PushProvider returns a specific push provider by name.
 func (s *GatewayService) PushProvider(name string) (port.PushSender, error) {
-	provider, ok := s.registry.GetPushProvider(name)
-	if !ok {
-		return nil, NewProviderNotFoundError("push", name)
-	}
-	return provider, nil
+	return s.global.pushProvider(name)
 }
 
 // RegisterPushProvider registers a custom push provider.
 func (s *GatewayService) RegisterPushProvider(name string, provider port.PushSender) {
-	s.registry.RegisterPushProvider(name, provider)
+	s.global.registry.RegisterPushProvider(name, provider)
 }
 
-// SendChat sends a chat message using the default provider.
+// SendChat sends a chat message using the default provider, falling back
+// to the configured failover providers.
 func (s *GatewayService) SendChat(ctx context.Context, message *contracts.ChatMessage) (*contracts.SendResult, error) {
-	provider, err := s.Chat()
+	return s.sendChat(ctx, newMessageID(), message)
+}
+
+// sendChat sends through the failover chain, recording the outcome under id.
+// The ctx tenant's providers are used; an API key in ctx may restrict the
+// chain to its own.
+func (s *GatewayService) sendChat(ctx context.Context, id string, message *contracts.ChatMessage) (*contracts.SendResult, error) {
+	if err := authorize(ctx, "chat", ""); err != nil {
+		return nil, err
+	}
+	t, err := s.tenantFor(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return provider.Send(ctx, message)
+	chain := providerChain(ctx, "chat", t.config.DefaultChatProvider(), t.config.ChatFailover())
+	return s.track(ctx, id, "chat", firstProvider(chain), message, func() (*contracts.SendResult, error) {
+		return sendWithFailover(ctx, "chat", chain, t.config.RetryPolicies().For("chat"), func(name string) (sender[*contracts.ChatMessage], error) {
+			return t.chatProvider(name)
+		}, message)
+	})
 }
 
 // SendChatWith sends a chat message using a specific provider.
 func (s *GatewayService) SendChatWith(ctx context.Context, providerName string, message *contracts.ChatMessage) (*contracts.SendResult, error) {
-	provider, err := s.ChatProvider(providerName)
+	if err := authorize(ctx, "chat", providerName); err != nil {
+		return nil, err
+	}
+	t, err := s.tenantFor(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return provider.Send(ctx, message)
+	return s.track(ctx, newMessageID(), "chat", providerName, message, func() (*contracts.SendResult, error) {
+		provider, err := t.chatProvider(providerName)
+		if err != nil {
+			return nil, err
+		}
+		return provider.Send(ctx, message)
+	})
 }
 
 // Chat returns the default chat provider.
 func (s *GatewayService) Chat() (port.ChatSender, error) {
-	providerName := s.config.DefaultChatProvider()
+	providerName := s.global.config.DefaultChatProvider()
 	if providerName == "" {
 		return nil, NewProviderNotFoundError("chat", "default (none configured)")
 	}
@@ -182,16 +288,12 @@ func (s *GatewayService) Chat() (port.ChatSender, error) {
 
 // ChatProvider returns a specific chat provider by name.
 func (s *GatewayService) ChatProvider(name string) (port.ChatSender, error) {
-	provider, ok := s.registry.GetChatProvider(name)
-	if !ok {
-		return nil, NewProviderNotFoundError("chat", name)
-	}
-	return provider, nil
+	return s.global.chatProvider(name)
 }
 
 // RegisterChatProvider registers a custom chat provider.
 func (s *GatewayService) RegisterChatProvider(name string, provider port.ChatSender) {
-	s.registry.RegisterChatProvider(name, provider)
+	s.global.registry.RegisterChatProvider(name, provider)
 }
 
 type ProviderNotFoundError struct {
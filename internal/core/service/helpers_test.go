@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// testConfig is a GatewayConfig with an SMS default and failover chain.
+type testConfig struct {
+	sms         string
+	smsFailover []string
+}
+
+func (c testConfig) DefaultEmailProvider() string { return "" }
+func (c testConfig) DefaultSMSProvider() string   { return c.sms }
+func (c testConfig) DefaultPushProvider() string  { return "" }
+func (c testConfig) DefaultChatProvider() string  { return "" }
+func (c testConfig) EmailFailover() []string      { return nil }
+func (c testConfig) SMSFailover() []string        { return c.smsFailover }
+func (c testConfig) PushFailover() []string       { return nil }
+func (c testConfig) ChatFailover() []string       { return nil }
+func (c testConfig) RetryPolicies() resilience.RetryPolicies {
+	return resilience.RetryPolicies{}
+}
+
+// fakeSMS is an SMS provider that fails with errs in turn, then succeeds.
+type fakeSMS struct {
+	name string
+
+	mu    sync.Mutex
+	errs  []error
+	sent  []*contracts.SMS
+	calls int
+}
+
+func (f *fakeSMS) Name() string { return f.name }
+
+func (f *fakeSMS) Send(ctx context.Context, sms *contracts.SMS) (*contracts.SendResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	f.sent = append(f.sent, sms)
+	return &contracts.SendResult{ID: f.name + "-id", StatusCode: 200}, nil
+}
+
+func (f *fakeSMS) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *fakeSMS) Sent() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent)
+}
+
+// newTestService returns a service whose SMS default is the first provider
+// and whose failover chain is the rest.
+func newTestService(providers ...*fakeSMS) *GatewayService {
+	registry := NewRegistry()
+	cfg := testConfig{}
+	for i, p := range providers {
+		registry.RegisterSMSProvider(p.name, p)
+		if i == 0 {
+			cfg.sms = p.name
+		} else {
+			cfg.smsFailover = append(cfg.smsFailover, p.name)
+		}
+	}
+	return NewGatewayService(cfg, registry, nil)
+}
+
+func testSMS() *contracts.SMS {
+	return &contracts.SMS{To: []string{"+15550100"}, Message: "hello"}
+}
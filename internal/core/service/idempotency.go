@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+const (
+	// DefaultIdempotencyTTL is how long a key's result is kept when no TTL is configured.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	// MaxIdempotencyKeyLength bounds the keys clients may send.
+	MaxIdempotencyKeyLength = 255
+)
+
+// Idempotency errors.
+var (
+	ErrIdempotencyKeyTooLong  = fmt.Errorf("idempotency key is longer than %d characters", MaxIdempotencyKeyLength)
+	ErrIdempotencyKeyReused   = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInFlight = errors.New("a request with this idempotency key is still in progress")
+)
+
+// Idempotency makes repeated requests with the same key return the first
+// request's result instead of sending the message again.
+type Idempotency struct {
+	store port.IdempotencyStore
+	ttl   time.Duration
+}
+
+// NewIdempotency creates an Idempotency keeping results in store for ttl,
+// or DefaultIdempotencyTTL when ttl is zero.
+func NewIdempotency(store port.IdempotencyStore, ttl time.Duration) *Idempotency {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &Idempotency{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+// Do runs send unless key was used before. request describes what is being
+// sent and is hashed to detect a key reused for a different request, which
+// fails with ErrIdempotencyKeyReused. A repeated request gets the first
+// result, with replayed set. A failed send is forgotten, so the request can
+// be retried with the same key. An empty key, or a nil Idempotency, always sends.
+// Keys are scoped to the tenant and API key in ctx, so callers cannot see
+// or block each other's requests.
+func (i *Idempotency) Do(
+	ctx context.Context,
+	key string,
+	request any,
+	send func(context.Context) (*contracts.SendResult, error),
+) (result *contracts.SendResult, replayed bool, err error) {
+	if i == nil || key == "" {
+		result, err := send(ctx)
+		return result, false, err
+	}
+	if len(key) > MaxIdempotencyKeyLength {
+		return nil, false, ErrIdempotencyKeyTooLong
+	}
+
+	hash, err := requestHash(request)
+	if err != nil {
+		return nil, false, err
+	}
+
+	storeKey := TenantFrom(ctx) + "/" + APIKeyFrom(ctx).id() + "/" + key
+	now := time.Now().UTC()
+	record := &port.IdempotencyRecord{
+		Key:         storeKey,
+		RequestHash: hash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(i.ttl),
+	}
+	existing, reserved, err := i.store.Reserve(ctx, record)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if !reserved {
+		switch {
+		case existing.RequestHash != hash:
+			return nil, false, ErrIdempotencyKeyReused
+		case existing.Result == nil:
+			return nil, false, ErrIdempotencyKeyInFlight
+		default:
+			return existing.Result, true, nil
+		}
+	}
+
+	result, err = send(ctx)
+	if err != nil {
+		if err := i.store.Delete(ctx, storeKey); err != nil {
+			log.Printf("Failed to release idempotency key: %v", err)
+		}
+		return nil, false, err
+	}
+
+	record.Result = result
+	if err := i.store.Save(ctx, record); err != nil {
+		log.Printf("Failed to store idempotent result: %v", err)
+	}
+	return result, false, nil
+}
+
+// requestHash returns the hex SHA-256 of the request's JSON encoding.
+func requestHash(request any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
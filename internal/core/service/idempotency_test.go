@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/idempotency"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+func TestIdempotencyDo(t *testing.T) {
+	sendErr := errors.New("provider down")
+	app := &APIKey{ID: "app"}
+	other := &APIKey{ID: "other"}
+
+	tests := []struct {
+		name string
+		// first is the earlier request with the key, second the repeat.
+		first, second idempotentCall
+		wantErr       error
+		wantReplayed  bool
+		wantSends     int
+	}{
+		{
+			name:         "same request is replayed",
+			first:        idempotentCall{request: "a"},
+			second:       idempotentCall{request: "a"},
+			wantReplayed: true,
+			wantSends:    1,
+		},
+		{
+			name:      "different request is rejected",
+			first:     idempotentCall{request: "a"},
+			second:    idempotentCall{request: "b"},
+			wantErr:   ErrIdempotencyKeyReused,
+			wantSends: 1,
+		},
+		{
+			name:      "failed request is forgotten",
+			first:     idempotentCall{request: "a", err: sendErr},
+			second:    idempotentCall{request: "a"},
+			wantSends: 2,
+		},
+		{
+			name:      "failed request may be retried with another body",
+			first:     idempotentCall{request: "a", err: sendErr},
+			second:    idempotentCall{request: "b"},
+			wantSends: 2,
+		},
+		{
+			name:      "key of another tenant",
+			first:     idempotentCall{request: "a", tenant: "shop"},
+			second:    idempotentCall{request: "a", tenant: "acme"},
+			wantSends: 2,
+		},
+		{
+			name:      "key of another API key",
+			first:     idempotentCall{request: "a", key: app},
+			second:    idempotentCall{request: "b", key: other},
+			wantSends: 2,
+		},
+		{
+			name:         "key of the same tenant and API key",
+			first:        idempotentCall{request: "a", tenant: "shop", key: app},
+			second:       idempotentCall{request: "a", tenant: "shop", key: app},
+			wantReplayed: true,
+			wantSends:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewIdempotency(idempotency.NewMemoryStore(), time.Hour)
+			sends := 0
+
+			_, _, _ = tt.first.do(i, "order-1", &sends)
+			result, replayed, err := tt.second.do(i, "order-1", &sends)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if sends != tt.wantSends {
+				t.Errorf("sends = %d, want %d", sends, tt.wantSends)
+			}
+			if tt.wantReplayed && result.ID != "first" {
+				t.Errorf("replayed result ID = %q, want the first", result.ID)
+			}
+		})
+	}
+}
+
+// idempotentCall is a request made with Idempotency.Do.
+type idempotentCall struct {
+	request string
+	tenant  string
+	key     *APIKey
+	err     error
+}
+
+func (c idempotentCall) do(i *Idempotency, key string, sends *int) (*contracts.SendResult, bool, error) {
+	ctx := WithTenant(context.Background(), c.tenant)
+	if c.key != nil {
+		ctx = WithAPIKey(ctx, c.key)
+	}
+	return i.Do(ctx, key, c.request, func(context.Context) (*contracts.SendResult, error) {
+		*sends++
+		if c.err != nil {
+			return nil, c.err
+		}
+		if *sends == 1 {
+			return &contracts.SendResult{ID: "first"}, nil
+		}
+		return &contracts.SendResult{ID: "second"}, nil
+	})
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	i := NewIdempotency(idempotency.NewMemoryStore(), time.Hour)
+	ctx := context.Background()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := i.Do(ctx, "order-1", "a", func(context.Context) (*contracts.SendResult, error) {
+			close(started)
+			<-release
+			return &contracts.SendResult{ID: "first"}, nil
+		})
+		done <- err
+	}()
+	<-started
+
+	_, _, err := i.Do(ctx, "order-1", "a", func(context.Context) (*contracts.SendResult, error) {
+		t.Error("sent while the first request is in flight")
+		return nil, nil
+	})
+	if !errors.Is(err, ErrIdempotencyKeyInFlight) {
+		t.Errorf("error = %v, want ErrIdempotencyKeyInFlight", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	result, replayed, err := i.Do(ctx, "order-1", "a", nil)
+	if err != nil || !replayed || result.ID != "first" {
+		t.Errorf("after the first request: %+v, replayed %v, error %v; want the first result replayed", result, replayed, err)
+	}
+}
+
+func TestIdempotencyAlwaysSends(t *testing.T) {
+	tests := []struct {
+		name      string
+		i         *Idempotency
+		key       string
+		wantErr   error
+		wantSends int
+	}{
+		{name: "without a key", i: NewIdempotency(idempotency.NewMemoryStore(), 0), wantSends: 2},
+		{name: "without idempotency", i: nil, key: "order-1", wantSends: 2},
+		{name: "key too long", i: NewIdempotency(idempotency.NewMemoryStore(), 0), key: strings.Repeat("k", MaxIdempotencyKeyLength+1), wantErr: ErrIdempotencyKeyTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sends := 0
+			for range 2 {
+				_, replayed, err := tt.i.Do(context.Background(), tt.key, "a", func(context.Context) (*contracts.SendResult, error) {
+					sends++
+					return &contracts.SendResult{}, nil
+				})
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if replayed {
+					t.Error("replayed without a usable key")
+				}
+			}
+			if sends != tt.wantSends {
+				t.Errorf("sends = %d, want %d", sends, tt.wantSends)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// newMessageID returns a new gateway message ID.
+func newMessageID() string {
+	return uuid.New().String()
+}
+
+// Message returns the ledger record for a gateway message ID, or for a
+// provider's message ID. A tenant in ctx sees only its own messages, and an
+// API key without the admin scope only the messages sent with it.
+func (s *GatewayService) Message(ctx context.Context, id string) (*contracts.MessageRecord, error) {
+	if s.ledger == nil {
+		return nil, port.ErrMessageNotFound
+	}
+	record, err := s.ledger.Get(ctx, id)
+	if errors.Is(err, port.ErrMessageNotFound) {
+		record, err = s.ledger.FindByProviderID(ctx, "", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tenant := TenantFrom(ctx); tenant != "" && record.Tenant != tenant {
+		return nil, port.ErrMessageNotFound
+	}
+	if key := APIKeyFrom(ctx); !key.IsAdmin() && record.APIKey != key.ID {
+		return nil, port.ErrMessageNotFound
+	}
+	return record, nil
+}
+
+// Messages returns the ledger records matching the filter, newest first. It
+// is limited like Message by the tenant and API key in ctx.
+func (s *GatewayService) Messages(ctx context.Context, filter port.MessageFilter) ([]*contracts.MessageRecord, error) {
+	if s.ledger == nil {
+		return []*contracts.MessageRecord{}, nil
+	}
+	if tenant := TenantFrom(ctx); tenant != "" {
+		filter.Tenant = tenant
+	}
+	if key := APIKeyFrom(ctx); !key.IsAdmin() {
+		filter.APIKey = key.ID
+	}
+	return s.ledger.List(ctx, filter)
+}
+
+// track runs send and records the outcome in the ledger under id. provider
+// is the expected provider; the one that delivered is taken from the result.
+// The message ID is added to the result's Meta. Ledger failures are logged,
+// never returned: a message that was sent must not be reported as failed.
+func (s *GatewayService) track(
+	ctx context.Context,
+	id, channel, provider string,
+	message any,
+	send func() (*contracts.SendResult, error),
+) (*contracts.SendResult, error) {
+	if s.ledger == nil {
+		return send()
+	}
+
+	record := loadRecord(ctx, s.ledger, id, channel, message)
+	if record.APIKey == "" {
+		record.APIKey = APIKeyFrom(ctx).id()
+	}
+	if record.Tenant == "" {
+		record.Tenant = TenantFrom(ctx)
+	}
+	result, err := send()
+	now := time.Now().UTC()
+
+	record.Provider = provider
+	if err != nil {
+		var failover *FailoverError
+		if errors.As(err, &failover) && len(failover.Attempts) > 0 {
+			record.Attempts = failover.Attempts
+			record.Provider = failover.Attempts[len(failover.Attempts)-1].Provider
+		}
+		record.Error = err.Error()
+		record.SetStatus(contracts.StatusFailed, now, "")
+	} else {
+		if name := result.Meta[contracts.MetaProvider]; name != "" {
+			record.Provider = name
+		}
+		record.ProviderMessageID = result.ID
+		record.RecipientMessageIDs = recipientMessageIDs(result)
+		record.Attempts = result.Attempts
+		record.Error = ""
+		record.SetStatus(contracts.StatusSent, now, "")
+
+		if result.Meta == nil {
+			result.Meta = make(map[string]string, 1)
+		}
+		result.Meta[contracts.MetaMessageID] = id
+	}
+
+	saveRecord(ctx, s.ledger, record)
+	return result, err
+}
+
+// recordQueued records a message accepted for background sending.
+func recordQueued(ctx context.Context, ledger port.Ledger, job *port.Job, message any, scheduledAt *time.Time) {
+	if ledger == nil {
+		return
+	}
+	record := newRecord(job.ID, job.Channel, message, job.CreatedAt)
+	record.APIKey = job.APIKey
+	record.Tenant = job.Tenant
+	record.ScheduledAt = scheduledAt
+	record.SetStatus(contracts.StatusQueued, job.CreatedAt, "")
+	saveRecord(ctx, ledger, record)
+}
+
+// recordFailed marks a message as failed before it reached a provider.
+func recordFailed(ctx context.Context, ledger port.Ledger, id string, err error) {
+	updateRecord(ctx, ledger, id, func(r *contracts.MessageRecord) {
+		r.Error = err.Error()
+		r.SetStatus(contracts.StatusFailed, time.Now().UTC(), "")
+	})
+}
+
+// updateRecord applies update to the record with id, if there is one.
+func updateRecord(ctx context.Context, ledger port.Ledger, id string, update func(*contracts.MessageRecord)) {
+	if ledger == nil {
+		return
+	}
+	record, err := ledger.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, port.ErrMessageNotFound) {
+			log.Printf("Ledger error: %v", err)
+		}
+		return
+	}
+	update(record)
+	saveRecord(ctx, ledger, record)
+}
+
+// loadRecord returns the record for a queued message, or a new one.
+func loadRecord(ctx context.Context, ledger port.Ledger, id, channel string, message any) *contracts.MessageRecord {
+	record, err := ledger.Get(ctx, id)
+	if err == nil {
+		return record
+	}
+	if !errors.Is(err, port.ErrMessageNotFound) {
+		log.Printf("Ledger error: %v", err)
+	}
+	return newRecord(id, channel, message, time.Now().UTC())
+}
+
+func newRecord(id, channel string, message any, createdAt time.Time) *contracts.MessageRecord {
+	return &contracts.MessageRecord{
+		ID:         id,
+		Channel:    channel,
+		Recipients: recipients(message),
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+	}
+}
+
+func saveRecord(ctx context.Context, ledger port.Ledger, record *contracts.MessageRecord) {
+	if err := ledger.Save(ctx, record); err != nil {
+		log.Printf("Failed to record %s %s: %v", record.Channel, record.ID, err)
+	}
+}
+
+// recipientMessageIDs collects the per-recipient IDs that multi-recipient
+// providers report in SendResult.Meta as "id:<recipient>".
+func recipientMessageIDs(result *contracts.SendResult) map[string]string {
+	var ids map[string]string
+	for key, value := range result.Meta {
+		to, ok := strings.CutPrefix(key, "id:")
+		if !ok || value == "" {
+			continue
+		}
+		if ids == nil {
+			ids = make(map[string]string)
+		}
+		ids[to] = value
+	}
+	return ids
+}
+
+// recipients lists everyone a message is addressed to.
+func recipients(message any) []string {
+	var to []string
+	switch m := message.(type) {
+	case *contracts.Email:
+		to = append(to, m.To...)
+		to = append(to, m.CC...)
+		to = append(to, m.BCC...)
+	case *contracts.SMS:
+		to = append(to, m.To...)
+	case *contracts.PushNotification:
+		to = append(to, m.DeviceTokens...)
+		to = append(to, m.ExternalIDs...)
+		to = append(to, m.Segments...)
+	case *contracts.ChatMessage:
+		to = append(to, m.To...)
+	}
+	return to
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// localeTag matches BCP 47 style tags such as "en", "pt-BR", "zh-Hant-TW"
+// or "pt_BR".
+var localeTag = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+
+// canonicalLocale returns locale in its canonical form: "pt_br" becomes
+// "pt-BR" and "zh-hant-tw" becomes "zh-Hant-TW". The empty default locale
+// is returned as is.
+func canonicalLocale(locale string) (string, error) {
+	if locale == "" {
+		return "", nil
+	}
+	if !localeTag.MatchString(locale) {
+		return "", fmt.Errorf("invalid locale %q", locale)
+	}
+
+	parts := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch p := parts[i]; {
+		case len(p) == 4 && isLetters(p):
+			// Script
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		case len(p) == 2 && isLetters(p), len(p) == 3 && !isLetters(p):
+			// Region
+			parts[i] = strings.ToUpper(p)
+		default:
+			parts[i] = strings.ToLower(p)
+		}
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// localeFallbacks returns the locales to look a template up in, most
+// specific first: the locale itself, each parent down to its language, and
+// the default locale. "zh-Hant-TW" gives zh-Hant-TW, zh-Hant, zh and "".
+func localeFallbacks(locale string) []string {
+	var fallbacks []string
+	for locale != "" {
+		fallbacks = append(fallbacks, locale)
+		i := strings.LastIndexByte(locale, '-')
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return append(fallbacks, "")
+}
+
+// localeLanguage returns the language of a canonical locale: "pt" for "pt-BR".
+func localeLanguage(locale string) string {
+	language, _, _ := strings.Cut(locale, "-")
+	return language
+}
+
+// localeRegion returns the region of a canonical locale, or "".
+func localeRegion(locale string) string {
+	parts := strings.Split(locale, "-")
+	for _, p := range parts[1:] {
+		if len(p) == 2 && isLetters(p) || len(p) == 3 && !isLetters(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
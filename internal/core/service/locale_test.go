@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+func TestCanonicalLocale(t *testing.T) {
+	tests := []struct {
+		locale  string
+		want    string
+		wantErr bool
+	}{
+		{locale: "", want: ""},
+		{locale: "en", want: "en"},
+		{locale: "EN", want: "en"},
+		{locale: "pt_br", want: "pt-BR"},
+		{locale: "PT-br", want: "pt-BR"},
+		{locale: "zh-hant-tw", want: "zh-Hant-TW"},
+		{locale: "es-419", want: "es-419"},
+		{locale: "de-CH-1996", want: "de-CH-1996"},
+		{locale: "sr_LATN", want: "sr-Latn"},
+		{locale: "e", wantErr: true},
+		{locale: "english", wantErr: true},
+		{locale: "en-", wantErr: true},
+		{locale: "en US", wantErr: true},
+		{locale: "../en", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			got, err := canonicalLocale(tt.locale)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("canonicalLocale(%q) = %q, want %q", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocaleFallbacks(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{locale: "", want: []string{""}},
+		{locale: "pt", want: []string{"pt", ""}},
+		{locale: "pt-BR", want: []string{"pt-BR", "pt", ""}},
+		{locale: "zh-Hant-TW", want: []string{"zh-Hant-TW", "zh-Hant", "zh", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := localeFallbacks(tt.locale); fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Errorf("localeFallbacks(%q) = %q, want %q", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateServiceRenderLocale(t *testing.T) {
+	s := newTemplateService(t,
+		&contracts.Template{Name: "total", SMS: "Total {{number .n}}, {{plural .items \"# item\" \"# items\"}}"},
+		&contracts.Template{Name: "total", Locale: "de", SMS: "Summe {{number .n}}"},
+		&contracts.Template{Name: "total", Locale: "pt", SMS: "Total {{number .n}}, {{plural .items \"# item\" \"# itens\"}}"},
+		&contracts.Template{Name: "total", Locale: "pt-PT", SMS: "Total PT {{number .n}}, {{plural .items \"# item\" \"# itens\"}}"},
+	)
+
+	tests := []struct {
+		name        string
+		locale      string
+		wantMessage string
+		wantLocale  string
+		wantErr     bool
+	}{
+		{name: "default", wantMessage: "Total 1,234.5, 0 items", wantLocale: ""},
+		{name: "exact locale", locale: "pt-PT", wantMessage: "Total PT 1.234,5, 0 itens", wantLocale: "pt-PT"},
+		{name: "locale in another form", locale: "pt_pt", wantMessage: "Total PT 1.234,5, 0 itens", wantLocale: "pt-PT"},
+		{name: "language", locale: "de", wantMessage: "Summe 1.234,5", wantLocale: "de"},
+		{name: "region falls back to the language and formats for the region", locale: "de-CH", wantMessage: "Summe 1'234.5", wantLocale: "de"},
+		{name: "plural rule of the requested region", locale: "pt-BR", wantMessage: "Total 1.234,5, 0 item", wantLocale: "pt"},
+		{name: "unknown language uses the default in English", locale: "fr-CA", wantMessage: "Total 1,234.5, 0 items", wantLocale: ""},
+		{name: "invalid locale", locale: "french", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sms := &contracts.SMS{Template: &contracts.TemplateRef{
+				Name:   "total",
+				Locale: tt.locale,
+				Data:   map[string]any{"n": 1234.5, "items": 0},
+			}}
+
+			err := s.Render(context.Background(), sms)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if sms.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", sms.Message, tt.wantMessage)
+			}
+			if sms.Template.Locale != tt.wantLocale {
+				t.Errorf("pinned locale = %q, want %q", sms.Template.Locale, tt.wantLocale)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// schedulerRetryInterval is how long to wait before retrying jobs that
+// could not be handed to the queue.
+const schedulerRetryInterval = 5 * time.Second
+
+// ErrSchedulingDisabled is returned when a message is scheduled but no scheduler is configured.
+var ErrSchedulingDisabled = errors.New("scheduled sending is not enabled")
+
+// Scheduler holds messages until their send time, then hands them to the
+// async queue. Pending jobs are persisted, so they fire after a restart;
+// jobs that came due while the gateway was down fire as soon as it starts.
+type Scheduler struct {
+	store  port.ScheduleStore
+	queue  port.Queue
+	ledger port.Ledger
+
+	// mu serialises firing with Cancel and Reschedule, so a cancelled
+	// job is never queued.
+	mu sync.Mutex
+	// handedOff holds jobs that were queued but could not be removed from
+	// the store, so they are not queued again while the removal is retried.
+	handedOff map[string]struct{}
+	wake      chan struct{}
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewScheduler creates a Scheduler. Call Start to begin firing jobs.
+// ledger may be nil to skip recording scheduled messages.
+func NewScheduler(store port.ScheduleStore, queue port.Queue, ledger port.Ledger) *Scheduler {
+	return &Scheduler{
+		store:     store,
+		queue:     queue,
+		ledger:    ledger,
+		handedOff: make(map[string]struct{}),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Schedule stores the message to be sent on the channel at sendAt.
+// The returned job's ID is also the ID of the queued send.
+func (s *Scheduler) Schedule(ctx context.Context, channel string, message any, sendAt time.Time) (*port.ScheduledJob, error) {
+	job, err := newJob(ctx, channel, message)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := &port.ScheduledJob{Job: *job, SendAt: sendAt.UTC()}
+	recordQueued(ctx, s.ledger, job, message, &scheduled.SendAt)
+	if err := s.store.Save(ctx, scheduled); err != nil {
+		err = fmt.Errorf("failed to schedule %s message: %w", channel, err)
+		recordFailed(ctx, s.ledger, job.ID, err)
+		return nil, err
+	}
+	s.signal()
+	return scheduled, nil
+}
+
+// Get returns a pending job. With a tenant in ctx, other tenants' jobs are
+// not found.
+func (s *Scheduler) Get(ctx context.Context, id string) (*port.ScheduledJob, error) {
+	job, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !VisibleTo(ctx, job.Tenant) {
+		return nil, port.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// List returns the pending jobs, earliest first, limited to the tenant in
+// ctx if there is one.
+func (s *Scheduler) List(ctx context.Context) ([]*port.ScheduledJob, error) {
+	jobs, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := jobs[:0]
+	for _, job := range jobs {
+		if VisibleTo(ctx, job.Tenant) {
+			visible = append(visible, job)
+		}
+	}
+	return visible, nil
+}
+
+// Cancel removes a pending job. It returns port.ErrJobNotFound if the job
+// does not exist or has already been queued for sending.
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.handedOff[id]; ok {
+		return port.ErrJobNotFound
+	}
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	if err := s.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	updateRecord(ctx, s.ledger, id, func(r *contracts.MessageRecord) {
+		r.SetStatus(contracts.StatusCancelled, time.Now().UTC(), "")
+	})
+	return nil
+}
+
+// Reschedule moves a pending job to a new send time. A time in the past
+// fires the job right away, like one passed to Schedule; the zero time is
+// rejected with ErrInvalidMessage.
+func (s *Scheduler) Reschedule(ctx context.Context, id string, sendAt time.Time) (*port.ScheduledJob, error) {
+	if sendAt.IsZero() {
+		return nil, fmt.Errorf("%w: no send time specified", ErrInvalidMessage)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.handedOff[id]; ok {
+		return nil, port.ErrJobNotFound
+	}
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	job.SendAt = sendAt.UTC()
+	if err := s.store.Save(ctx, job); err != nil {
+		return nil, err
+	}
+	updateRecord(ctx, s.ledger, id, func(r *contracts.MessageRecord) {
+		r.ScheduledAt = &job.SendAt
+		r.UpdatedAt = time.Now().UTC()
+	})
+	s.signal()
+	return job, nil
+}
+
+// Start launches the goroutine that fires due jobs.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.run(ctx)
+}
+
+// Stop stops firing jobs and waits for the goroutine to exit, or for ctx to be done.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+
+		next := s.fireDue(ctx)
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if !next.IsZero() {
+			timer.Reset(time.Until(next))
+		}
+	}
+}
+
+// fireDue queues every job whose send time has passed and returns the
+// send time of the next pending job, or the zero time if there is none.
+func (s *Scheduler) fireDue(ctx context.Context) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	retry := now.Add(schedulerRetryInterval)
+
+	jobs, err := s.store.List(ctx)
+	if err != nil {
+		log.Printf("Scheduler error: %v", err)
+		return retry
+	}
+
+	var next time.Time
+	for _, job := range jobs {
+		if job.SendAt.After(now) {
+			next = earliest(next, job.SendAt)
+			continue
+		}
+
+		// Queue first: if the gateway stops before the delete, the job
+		// is queued again on restart rather than lost.
+		if _, ok := s.handedOff[job.ID]; !ok {
+			if err := s.queue.Enqueue(ctx, &job.Job); err != nil {
+				log.Printf("Failed to queue scheduled %s %s: %v", job.Channel, job.ID, err)
+				next = earliest(next, retry)
+				continue
+			}
+		}
+		if err := s.store.Delete(ctx, job.ID); err != nil && !errors.Is(err, port.ErrJobNotFound) {
+			log.Printf("Failed to remove scheduled %s %s: %v", job.Channel, job.ID, err)
+			s.handedOff[job.ID] = struct{}{}
+			next = earliest(next, retry)
+			continue
+		}
+		delete(s.handedOff, job.ID)
+	}
+	return next
+}
+
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || b.Before(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/schedule"
+)
+
+// recordingQueue records enqueued job IDs.
+type recordingQueue struct {
+	port.Queue
+
+	mu  sync.Mutex
+	ids []string
+}
+
+func (q *recordingQueue) Enqueue(ctx context.Context, job *port.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ids = append(q.ids, job.ID)
+	return nil
+}
+
+func (q *recordingQueue) queued() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]string(nil), q.ids...)
+}
+
+// failingDeleteStore fails the first deletes with deleteErrs.
+type failingDeleteStore struct {
+	port.ScheduleStore
+
+	mu         sync.Mutex
+	deleteErrs []error
+}
+
+func (s *failingDeleteStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	if len(s.deleteErrs) > 0 {
+		err := s.deleteErrs[0]
+		s.deleteErrs = s.deleteErrs[1:]
+		s.mu.Unlock()
+		return err
+	}
+	s.mu.Unlock()
+	return s.ScheduleStore.Delete(ctx, id)
+}
+
+func openScheduleStore(t *testing.T, dir string) *schedule.FileStore {
+	t.Helper()
+	store, err := schedule.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func stopScheduler(t *testing.T, s *Scheduler) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+func TestSchedulerFiresAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	before := NewScheduler(openScheduleStore(t, dir), &recordingQueue{}, nil)
+	due, err := before.Schedule(ctx, "sms", testSMS(), time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	later, err := before.Schedule(ctx, "sms", testSMS(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopened as after a restart.
+	store := openScheduleStore(t, dir)
+	q := &recordingQueue{}
+	after := NewScheduler(store, q, nil)
+	after.Start()
+	defer stopScheduler(t, after)
+
+	eventually(t, "the due job to fire", func() bool { return len(q.queued()) == 1 })
+	if got := q.queued(); got[0] != due.ID {
+		t.Errorf("queued %v, want %s", got, due.ID)
+	}
+	jobs, _ := store.List(ctx)
+	if len(jobs) != 1 || jobs[0].ID != later.ID {
+		t.Errorf("pending jobs = %d, want only the later one", len(jobs))
+	}
+}
+
+func TestSchedulerFiresWhenDue(t *testing.T) {
+	ctx := context.Background()
+	q := &recordingQueue{}
+	s := NewScheduler(openScheduleStore(t, t.TempDir()), q, nil)
+	s.Start()
+	defer stopScheduler(t, s)
+
+	job, err := s.Schedule(ctx, "sms", testSMS(), time.Now().Add(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.queued()) != 0 {
+		t.Fatal("job fired before its send time")
+	}
+	eventually(t, "the job to fire", func() bool { return len(q.queued()) == 1 })
+	if _, err := s.Get(ctx, job.ID); !errors.Is(err, port.ErrJobNotFound) {
+		t.Errorf("Get after firing error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestSchedulerCancelAndReschedule(t *testing.T) {
+	later := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		ctx       func() context.Context
+		action    func(ctx context.Context, s *Scheduler, id string) error
+		wantErr   error
+		wantFired bool
+		wantLeft  int
+	}{
+		{
+			name:   "cancel removes the job",
+			action: func(ctx context.Context, s *Scheduler, id string) error { return s.Cancel(ctx, id) },
+		},
+		{
+			name:     "cancel of an unknown job",
+			action:   func(ctx context.Context, s *Scheduler, id string) error { return s.Cancel(ctx, "missing") },
+			wantErr:  port.ErrJobNotFound,
+			wantLeft: 1,
+		},
+		{
+			name:     "cancel of another tenant's job",
+			ctx:      func() context.Context { return WithTenant(context.Background(), "acme") },
+			action:   func(ctx context.Context, s *Scheduler, id string) error { return s.Cancel(ctx, id) },
+			wantErr:  port.ErrJobNotFound,
+			wantLeft: 1,
+		},
+		{
+			name: "reschedule to a later time keeps the job",
+			action: func(ctx context.Context, s *Scheduler, id string) error {
+				_, err := s.Reschedule(ctx, id, later.Add(time.Hour))
+				return err
+			},
+			wantLeft: 1,
+		},
+		{
+			name: "reschedule to the past fires the job",
+			action: func(ctx context.Context, s *Scheduler, id string) error {
+				_, err := s.Reschedule(ctx, id, time.Now().Add(-time.Second))
+				return err
+			},
+			wantFired: true,
+		},
+		{
+			name: "reschedule to the zero time is rejected",
+			action: func(ctx context.Context, s *Scheduler, id string) error {
+				_, err := s.Reschedule(ctx, id, time.Time{})
+				return err
+			},
+			wantErr:  ErrInvalidMessage,
+			wantLeft: 1,
+		},
+		{
+			name: "reschedule of an unknown job",
+			action: func(ctx context.Context, s *Scheduler, id string) error {
+				_, err := s.Reschedule(ctx, "missing", later)
+				return err
+			},
+			wantErr:  port.ErrJobNotFound,
+			wantLeft: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := openScheduleStore(t, t.TempDir())
+			q := &recordingQueue{}
+			s := NewScheduler(store, q, nil)
+			s.Start()
+			defer stopScheduler(t, s)
+
+			job, err := s.Schedule(ctx, "sms", testSMS(), later)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actx := ctx
+			if tt.ctx != nil {
+				actx = tt.ctx()
+			}
+			if err := tt.action(actx, s, job.ID); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantFired {
+				eventually(t, "the job to fire", func() bool { return len(q.queued()) == 1 })
+			} else if n := len(q.queued()); n != 0 {
+				t.Errorf("queued %d jobs, want none", n)
+			}
+			jobs, _ := store.List(ctx)
+			if len(jobs) != tt.wantLeft {
+				t.Errorf("pending jobs = %d, want %d", len(jobs), tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestSchedulerHandedOffJobIsNotQueuedTwice(t *testing.T) {
+	ctx := context.Background()
+	store := &failingDeleteStore{
+		ScheduleStore: openScheduleStore(t, t.TempDir()),
+		deleteErrs:    []error{errors.New("disk full")},
+	}
+	q := &recordingQueue{}
+	s := NewScheduler(store, q, nil)
+
+	job, err := s.Schedule(ctx, "sms", testSMS(), time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The job is queued but stays in the store until the delete succeeds.
+	if next := s.fireDue(ctx); next.IsZero() {
+		t.Error("no retry time after a failed delete")
+	}
+	if err := s.Cancel(ctx, job.ID); !errors.Is(err, port.ErrJobNotFound) {
+		t.Errorf("Cancel of a handed-off job error = %v, want ErrJobNotFound", err)
+	}
+	if _, err := s.Reschedule(ctx, job.ID, time.Now().Add(time.Hour)); !errors.Is(err, port.ErrJobNotFound) {
+		t.Errorf("Reschedule of a handed-off job error = %v, want ErrJobNotFound", err)
+	}
+
+	s.fireDue(ctx)
+	if got := q.queued(); len(got) != 1 {
+		t.Errorf("queued %v, want the job once", got)
+	}
+	if jobs, _ := store.List(ctx); len(jobs) != 0 {
+		t.Errorf("pending jobs = %d after retry, want 0", len(jobs))
+	}
+}
+
+func TestSchedulerCancelRacingFire(t *testing.T) {
+	ctx := context.Background()
+	q := &recordingQueue{}
+	s := NewScheduler(openScheduleStore(t, t.TempDir()), q, nil)
+
+	const n = 20
+	ids := make([]string, n)
+	for i := range ids {
+		job, err := s.Schedule(ctx, "sms", testSMS(), time.Now().Add(-time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = job.ID
+	}
+
+	cancelled := make(map[string]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.fireDue(ctx)
+	}()
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.Cancel(ctx, id)
+			switch {
+			case err == nil:
+				mu.Lock()
+				cancelled[id] = true
+				mu.Unlock()
+			case !errors.Is(err, port.ErrJobNotFound):
+				t.Errorf("Cancel %s: %v", id, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	queued := make(map[string]bool)
+	for _, id := range q.queued() {
+		queued[id] = true
+	}
+	for _, id := range ids {
+		if queued[id] == cancelled[id] {
+			t.Errorf("job %s: queued %v, cancelled %v; want exactly one", id, queued[id], cancelled[id])
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"regexp"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// Template errors.
+var (
+	ErrInvalidTemplate = errors.New("invalid template")
+	ErrTemplateRender  = errors.New("failed to render template")
+)
+
+// templateName restricts names to what fits in a URL path segment.
+var templateName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// TemplateService stores versioned message templates and renders messages
+// from them.
+type TemplateService struct {
+	store port.TemplateStore
+
+	// create serialises version numbering.
+	create sync.Mutex
+}
+
+// NewTemplateService creates a TemplateService.
+func NewTemplateService(store port.TemplateStore) *TemplateService {
+	return &TemplateService{
+		store: store,
+	}
+}
+
+// Create checks that every part of the template parses and stores it as the
+// next version of its name in its locale.
+func (s *TemplateService) Create(ctx context.Context, template *contracts.Template) (*contracts.Template, error) {
+	if !templateName.MatchString(template.Name) {
+		return nil, fmt.Errorf("%w: name must be letters, digits, '.', '_' or '-'", ErrInvalidTemplate)
+	}
+	locale, err := canonicalLocale(template.Locale)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	parts := templateParts(template)
+	empty := true
+	for _, part := range parts {
+		if part.source == "" {
+			continue
+		}
+		empty = false
+		if err := part.parse(templateFuncs(locale)); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, part.name, err)
+		}
+	}
+	if empty {
+		return nil, fmt.Errorf("%w: no content", ErrInvalidTemplate)
+	}
+
+	s.create.Lock()
+	defer s.create.Unlock()
+
+	version := 1
+	latest, err := s.store.Get(ctx, template.Name, locale, 0)
+	switch {
+	case err == nil:
+		version = latest.Version + 1
+	case !errors.Is(err, port.ErrTemplateNotFound):
+		return nil, err
+	}
+
+	stored := *template
+	stored.Locale = locale
+	stored.Version = version
+	stored.CreatedAt = time.Now().UTC()
+	if err := s.store.Save(ctx, &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// Get returns a version of a template in a locale, or its latest version
+// when version is zero.
+func (s *TemplateService) Get(ctx context.Context, name, locale string, version int) (*contracts.Template, error) {
+	locale, err := canonicalLocale(locale)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return s.store.Get(ctx, name, locale, version)
+}
+
+// Versions returns every version of a template in every locale.
+func (s *TemplateService) Versions(ctx context.Context, name string) ([]*contracts.Template, error) {
+	all, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var versions []*contracts.Template
+	for _, t := range all {
+		if t.Name == name {
+			versions = append(versions, t)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, port.ErrTemplateNotFound
+	}
+	return versions, nil
+}
+
+// List returns the latest version of each template in each locale.
+func (s *TemplateService) List(ctx context.Context) ([]*contracts.Template, error) {
+	all, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// all is ordered by name, locale and version, so the latest version
+	// of each is the last before the name or locale changes.
+	latest := make([]*contracts.Template, 0, len(all))
+	for i, t := range all {
+		if i+1 < len(all) && all[i+1].Name == t.Name && all[i+1].Locale == t.Locale {
+			continue
+		}
+		latest = append(latest, t)
+	}
+	return latest, nil
+}
+
+// Delete removes every version of a template in every locale.
+func (s *TemplateService) Delete(ctx context.Context, name string) error {
+	return s.store.Delete(ctx, name)
+}
+
+// Render fills the empty content fields of a message that has a Template
+// from the stored template, and sets Template to a copy pinned to the
+// version and locale used. The template is looked up in Template.Locale,
+// then its parent locales down to the language, then the default locale.
+// Messages without a Template are left as they are.
+func (s *TemplateService) Render(ctx context.Context, message any) error {
+	switch m := message.(type) {
+	case *contracts.Email:
+		if m.Template == nil {
+			return nil
+		}
+		t, funcs, err := s.resolve(ctx, &m.Template)
+		if err != nil {
+			return err
+		}
+		if t.Subject == "" && t.HTML == "" && t.Markdown == "" && t.MJML == "" && t.Text == "" {
+			return fmt.Errorf("%w: %s has no email content", ErrTemplateRender, t.Name)
+		}
+		data := m.Template.Data
+		return errors.Join(
+			renderText(&m.Subject, "subject", t.Subject, data, funcs),
+			renderHTML(&m.HTML, "html", t.HTML, data, funcs),
+			renderText(&m.Markdown, "markdown", t.Markdown, data, funcs),
+			renderHTML(&m.MJML, "mjml", t.MJML, data, funcs),
+			renderText(&m.PlainText, "text", t.Text, data, funcs),
+		)
+
+	case *contracts.SMS:
+		if m.Template == nil {
+			return nil
+		}
+		t, funcs, err := s.resolve(ctx, &m.Template)
+		if err != nil {
+			return err
+		}
+		if t.SMS == "" {
+			return fmt.Errorf("%w: %s has no SMS content", ErrTemplateRender, t.Name)
+		}
+		return renderText(&m.Message, "sms", t.SMS, m.Template.Data, funcs)
+
+	case *contracts.PushNotification:
+		if m.Template == nil {
+			return nil
+		}
+		t, funcs, err := s.resolve(ctx, &m.Template)
+		if err != nil {
+			return err
+		}
+		if t.PushTitle == "" && t.PushBody == "" {
+			return fmt.Errorf("%w: %s has no push content", ErrTemplateRender, t.Name)
+		}
+		data := m.Template.Data
+		return errors.Join(
+			renderText(&m.Title, "push_title", t.PushTitle, data, funcs),
+			renderText(&m.Body, "push_body", t.PushBody, data, funcs),
+		)
+
+	case *contracts.ChatMessage:
+		if m.Template == nil {
+			return nil
+		}
+		t, funcs, err := s.resolve(ctx, &m.Template)
+		if err != nil {
+			return err
+		}
+		if t.Chat == "" {
+			return fmt.Errorf("%w: %s has no chat content", ErrTemplateRender, t.Name)
+		}
+		return renderText(&m.Message, "chat", t.Chat, m.Template.Data, funcs)
+
+	default:
+		return fmt.Errorf("unsupported message type %T", message)
+	}
+}
+
+// resolve loads the referenced template, falling back from its locale
+// unless a version is pinned, and replaces the reference with a copy pinned
+// to the version and locale loaded, so a reference shared with the caller
+// is not changed. It also returns the template functions, which format for
+// the requested locale when the template is in its language.
+func (s *TemplateService) resolve(ctx context.Context, ref **contracts.TemplateRef) (*contracts.Template, map[string]any, error) {
+	requested, err := canonicalLocale((*ref).Locale)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrTemplateRender, err)
+	}
+
+	// Each locale numbers its own versions, so a pinned version is only
+	// looked up in the locale it was pinned with.
+	locales := localeFallbacks(requested)
+	if (*ref).Version != 0 {
+		locales = []string{requested}
+	}
+	for _, locale := range locales {
+		t, err := s.store.Get(ctx, (*ref).Name, locale, (*ref).Version)
+		if errors.Is(err, port.ErrTemplateNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pinned := **ref
+		pinned.Version = t.Version
+		pinned.Locale = t.Locale
+		*ref = &pinned
+
+		// A "pt" template sent for "pt-BR" formats as pt-BR; the default
+		// template formats as English.
+		format := t.Locale
+		if format != "" {
+			format = requested
+		}
+		return t, templateFuncs(format), nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s", port.ErrTemplateNotFound, (*ref).Name)
+}
+
+// templatePart is one field of a template and how it is parsed.
+type templatePart struct {
+	name   string
+	source string
+	html   bool
+}
+
+func (p templatePart) parse(funcs map[string]any) error {
+	if p.html {
+		_, err := newHTMLTemplate(p.name, p.source, funcs)
+		return err
+	}
+	_, err := newTextTemplate(p.name, p.source, funcs)
+	return err
+}
+
+func templateParts(t *contracts.Template) []templatePart {
+	return []templatePart{
+		{name: "subject", source: t.Subject},
+		{name: "html", source: t.HTML, html: true},
+		{name: "markdown", source: t.Markdown},
+		{name: "mjml", source: t.MJML, html: true},
+		{name: "text", source: t.Text},
+		{name: "sms", source: t.SMS},
+		{name: "push_title", source: t.PushTitle},
+		{name: "push_body", source: t.PushBody},
+		{name: "chat", source: t.Chat},
+	}
+}
+
+func newTextTemplate(name, source string, funcs map[string]any) (*texttemplate.Template, error) {
+	return texttemplate.New(name).Option("missingkey=error").Funcs(funcs).Parse(source)
+}
+
+func newHTMLTemplate(name, source string, funcs map[string]any) (*htmltemplate.Template, error) {
+	return htmltemplate.New(name).Option("missingkey=error").Funcs(funcs).Parse(source)
+}
+
+// renderText executes source into dst unless dst is already set or there
+// is no source.
+func renderText(dst *string, name, source string, data, funcs map[string]any) error {
+	if *dst != "" || source == "" {
+		return nil
+	}
+	t, err := newTextTemplate(name, source, funcs)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrTemplateRender, name, err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return fmt.Errorf("%w: %v", ErrTemplateRender, err)
+	}
+	*dst = b.String()
+	return nil
+}
+
+// renderHTML is renderText with contextual HTML escaping of the data.
+func renderHTML(dst *string, name, source string, data, funcs map[string]any) error {
+	if *dst != "" || source == "" {
+		return nil
+	}
+	t, err := newHTMLTemplate(name, source, funcs)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrTemplateRender, name, err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return fmt.Errorf("%w: %v", ErrTemplateRender, err)
+	}
+	*dst = b.String()
+	return nil
+}
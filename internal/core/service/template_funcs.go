@@ -0,0 +1,392 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// templateFuncs returns the functions available in templates, formatting
+// for locale:
+//
+//	{{plural .count "# item" "# items"}}
+//	{{plural .count "one=# plik" "few=# pliki" "many=# plików" "other=# pliku"}}
+//	{{number .total 2}}
+//	{{date .due "long"}}
+//
+// The default locale formats as English.
+func templateFuncs(locale string) map[string]any {
+	f := localeFormat{language: localeLanguage(locale), region: localeRegion(locale)}
+	if f.language == "" {
+		f.language = "en"
+	}
+	return map[string]any{
+		"plural": f.plural,
+		"number": f.number,
+		"date":   f.date,
+	}
+}
+
+// localeFormat formats values for templates in one locale.
+type localeFormat struct {
+	language string
+	region   string
+}
+
+// pluralCategories are the CLDR plural categories.
+var pluralCategories = map[string]bool{
+	"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true,
+}
+
+// plural picks the form of forms for count, with "#" replaced by the
+// formatted count. Forms are either "one" and "other" forms in that order,
+// or "category=form" pairs using the CLDR categories zero, one, two, few,
+// many and other, where an exact count such as "0=no items" takes
+// precedence. A missing category uses "other".
+func (f localeFormat) plural(count any, forms ...string) (string, error) {
+	n, err := toFloat(count)
+	if err != nil {
+		return "", fmt.Errorf("plural: %w", err)
+	}
+	if len(forms) == 0 {
+		return "", fmt.Errorf("plural: no forms")
+	}
+
+	category := pluralCategory(f.language, f.region, n)
+
+	form := forms[len(forms)-1]
+	if keyed, ok := keyedForms(forms); ok {
+		var found bool
+		if form, found = keyed[strconv.FormatFloat(n, 'f', -1, 64)]; !found {
+			if form, found = keyed[category]; !found {
+				if form, found = keyed["other"]; !found {
+					return "", fmt.Errorf("plural: no form for %q", category)
+				}
+			}
+		}
+	} else if category == "one" {
+		form = forms[0]
+	}
+
+	number, _ := f.number(n)
+	return strings.ReplaceAll(form, "#", number), nil
+}
+
+// keyedForms parses "category=form" and "count=form" pairs, and reports
+// whether every form was one.
+func keyedForms(forms []string) (map[string]string, bool) {
+	keyed := make(map[string]string, len(forms))
+	for _, form := range forms {
+		key, value, ok := strings.Cut(form, "=")
+		if !ok {
+			return nil, false
+		}
+		if _, err := strconv.ParseFloat(key, 64); err != nil && !pluralCategories[key] {
+			return nil, false
+		}
+		keyed[key] = value
+	}
+	return keyed, true
+}
+
+// pluralCategory returns the CLDR cardinal plural category of n for the
+// languages with rules here. Others use the English rule.
+func pluralCategory(language, region string, n float64) string {
+	integer := n == math.Trunc(n)
+	i := int64(math.Abs(math.Trunc(n)))
+
+	switch language {
+	case "ja", "zh", "ko", "th", "vi", "id", "ms":
+		return "other"
+
+	case "fr", "hi":
+		if i == 0 || i == 1 {
+			return "one"
+		}
+		return "other"
+
+	case "pt":
+		if region == "PT" {
+			return pluralCategory("en", "", n)
+		}
+		if i == 0 || i == 1 {
+			return "one"
+		}
+		return "other"
+
+	case "ru", "uk", "be":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i%10 == 1 && i%100 != 11:
+			return "one"
+		case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+
+	case "pl":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i == 1:
+			return "one"
+		case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+
+	case "cs", "sk":
+		switch {
+		case !integer:
+			return "many"
+		case i == 1:
+			return "one"
+		case i >= 2 && i <= 4:
+			return "few"
+		default:
+			return "other"
+		}
+
+	case "ar":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i == 0:
+			return "zero"
+		case i == 1:
+			return "one"
+		case i == 2:
+			return "two"
+		case i%100 >= 3 && i%100 <= 10:
+			return "few"
+		case i%100 >= 11:
+			return "many"
+		default:
+			return "other"
+		}
+
+	default:
+		if integer && i == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
+
+// number formats n with the locale's digit grouping and decimal separator,
+// rounded to decimals places when given.
+func (f localeFormat) number(n any, decimals ...int) (string, error) {
+	v, err := toFloat(n)
+	if err != nil {
+		return "", fmt.Errorf("number: %w", err)
+	}
+	precision := -1
+	if len(decimals) > 0 {
+		precision = decimals[0]
+	}
+
+	s := strconv.FormatFloat(math.Abs(v), 'f', precision, 64)
+	whole, fraction, _ := strings.Cut(s, ".")
+
+	group, point := f.separators()
+	var b strings.Builder
+	if v < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(group)
+		}
+		b.WriteRune(d)
+	}
+	if fraction != "" {
+		b.WriteString(point)
+		b.WriteString(fraction)
+	}
+	return b.String(), nil
+}
+
+// separators returns the digit group and decimal separators. They are
+// ASCII, where CLDR has narrow spaces and typographic apostrophes, so that
+// numbers in an SMS do not force UCS-2 encoding.
+func (f localeFormat) separators() (group, point string) {
+	switch f.language {
+	case "de", "es", "it", "nl", "pt", "id", "tr", "da", "el", "ro", "hr", "sl", "sr", "vi":
+		if f.language == "de" && (f.region == "CH" || f.region == "LI") {
+			return "'", "."
+		}
+		return ".", ","
+	case "fr", "ru", "uk", "be", "pl", "cs", "sk", "sv", "fi", "nb", "no", "hu", "bg", "lt", "lv", "et":
+		return " ", ","
+	default:
+		return ",", "."
+	}
+}
+
+// monthNames holds month names as they appear in a long date, for the
+// languages that spell months out.
+var monthNames = map[string][12]string{
+	"en": {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	"de": {"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+	"fr": {"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+	"es": {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	"it": {"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+	"pt": {"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+	"nl": {"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+	"sv": {"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+	"pl": {"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"},
+	"ru": {"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+}
+
+// date formats t in the "short" (default) or "long" style of the locale, or
+// with a Go layout such as "2006-01-02 15:04". t is a time.Time, an RFC 3339
+// or YYYY-MM-DD string, or Unix seconds.
+func (f localeFormat) date(t any, style ...string) (string, error) {
+	tm, err := toTime(t)
+	if err != nil {
+		return "", fmt.Errorf("date: %w", err)
+	}
+
+	layout := "short"
+	if len(style) > 0 {
+		layout = style[0]
+	}
+	switch layout {
+	case "short":
+		return f.shortDate(tm), nil
+	case "long":
+		return f.longDate(tm), nil
+	default:
+		return tm.Format(layout), nil
+	}
+}
+
+func (f localeFormat) shortDate(t time.Time) string {
+	y, m, d := t.Date()
+	switch f.language {
+	case "en":
+		if f.region == "" || f.region == "US" {
+			return fmt.Sprintf("%d/%d/%d", m, d, y)
+		}
+		return fmt.Sprintf("%02d/%02d/%d", d, m, y)
+	case "ja", "zh":
+		return fmt.Sprintf("%d/%02d/%02d", y, m, d)
+	case "ko":
+		return fmt.Sprintf("%d. %d. %d.", y, m, d)
+	case "sv", "lt":
+		return fmt.Sprintf("%d-%02d-%02d", y, m, d)
+	case "nl":
+		return fmt.Sprintf("%02d-%02d-%d", d, m, y)
+	case "de", "ru", "uk", "be", "pl", "cs", "sk", "fi", "nb", "no", "tr", "ro", "bg", "et", "lv", "da":
+		return fmt.Sprintf("%02d.%02d.%d", d, m, y)
+	default:
+		return fmt.Sprintf("%02d/%02d/%d", d, m, y)
+	}
+}
+
+func (f localeFormat) longDate(t time.Time) string {
+	y, m, d := t.Date()
+	switch f.language {
+	case "ja", "zh":
+		return fmt.Sprintf("%d年%d月%d日", y, m, d)
+	case "ko":
+		return fmt.Sprintf("%d년 %d월 %d일", y, m, d)
+	}
+
+	names, ok := monthNames[f.language]
+	if !ok {
+		names = monthNames["en"]
+	}
+	month := names[m-1]
+	switch f.language {
+	case "de":
+		return fmt.Sprintf("%d. %s %d", d, month, y)
+	case "es", "pt":
+		return fmt.Sprintf("%d de %s de %d", d, month, y)
+	case "ru":
+		return fmt.Sprintf("%d %s %d г.", d, month, y)
+	case "en":
+		if f.region == "" || f.region == "US" {
+			return fmt.Sprintf("%s %d, %d", month, d, y)
+		}
+	}
+	return fmt.Sprintf("%d %s %d", d, month, y)
+}
+
+// toFloat converts template data, which is float64 when decoded from JSON,
+// to a number.
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int8:
+		return float64(n), nil
+	case int16:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint:
+		return float64(n), nil
+	case uint8:
+		return float64(n), nil
+	case uint16:
+		return float64(n), nil
+	case uint32:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case json.Number:
+		return n.Float64()
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", n)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("%v (%T) is not a number", v, v)
+	}
+}
+
+// toTime converts template data, which is a string when decoded from JSON,
+// to a time.
+func toTime(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, fmt.Errorf("nil time")
+		}
+		return *t, nil
+	case string:
+		if tm, err := time.Parse(time.RFC3339, t); err == nil {
+			return tm, nil
+		}
+		if tm, err := time.Parse(time.DateOnly, t); err == nil {
+			return tm, nil
+		}
+		return time.Time{}, fmt.Errorf("%q is not an RFC 3339 time or YYYY-MM-DD date", t)
+	default:
+		seconds, err := toFloat(v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%v (%T) is not a time", v, v)
+		}
+		return time.Unix(int64(seconds), 0).UTC(), nil
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/template"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+func newTemplateService(t *testing.T, templates ...*contracts.Template) *TemplateService {
+	t.Helper()
+	store, err := template.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewTemplateService(store)
+	for _, tmpl := range templates {
+		if _, err := s.Create(context.Background(), tmpl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestTemplateServiceCreate(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    *contracts.Template
+		wantErr     error
+		wantVersion int
+		wantLocale  string
+	}{
+		{name: "first version", template: &contracts.Template{Name: "welcome", SMS: "Hi {{.name}}"}, wantVersion: 1},
+		{name: "next version", template: &contracts.Template{Name: "receipt", SMS: "Paid"}, wantVersion: 3},
+		{name: "versions are per locale", template: &contracts.Template{Name: "receipt", Locale: "de", SMS: "Bezahlt"}, wantVersion: 1, wantLocale: "de"},
+		{name: "locale is canonicalised", template: &contracts.Template{Name: "receipt", Locale: "pt_br", SMS: "Pago"}, wantVersion: 1, wantLocale: "pt-BR"},
+		{name: "invalid name", template: &contracts.Template{Name: "a/b", SMS: "x"}, wantErr: ErrInvalidTemplate},
+		{name: "invalid locale", template: &contracts.Template{Name: "a", Locale: "english", SMS: "x"}, wantErr: ErrInvalidTemplate},
+		{name: "no content", template: &contracts.Template{Name: "a"}, wantErr: ErrInvalidTemplate},
+		{name: "text part does not parse", template: &contracts.Template{Name: "a", SMS: "{{.name"}, wantErr: ErrInvalidTemplate},
+		{name: "html part does not parse", template: &contracts.Template{Name: "a", HTML: "<p>{{if .x}}</p>"}, wantErr: ErrInvalidTemplate},
+		{name: "unknown function", template: &contracts.Template{Name: "a", SMS: "{{shout .name}}"}, wantErr: ErrInvalidTemplate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTemplateService(t,
+				&contracts.Template{Name: "receipt", SMS: "v1"},
+				&contracts.Template{Name: "receipt", SMS: "v2"},
+			)
+
+			got, err := s.Create(context.Background(), tt.template)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Version != tt.wantVersion || got.Locale != tt.wantLocale {
+				t.Errorf("created version %d in %q, want %d in %q", got.Version, got.Locale, tt.wantVersion, tt.wantLocale)
+			}
+		})
+	}
+}
+
+func TestTemplateServiceRenderPinsVersion(t *testing.T) {
+	s := newTemplateService(t,
+		&contracts.Template{Name: "receipt", SMS: "v1 {{.order}}"},
+		&contracts.Template{Name: "receipt", SMS: "v2 {{.order}}"},
+		&contracts.Template{Name: "receipt", Locale: "de", SMS: "de v1 {{.order}}"},
+	)
+
+	tests := []struct {
+		name        string
+		version     int
+		locale      string
+		wantMessage string
+		wantVersion int
+		wantErr     error
+	}{
+		{name: "latest", wantMessage: "v2 42", wantVersion: 2},
+		{name: "pinned", version: 1, wantMessage: "v1 42", wantVersion: 1},
+		{name: "unknown version", version: 3, wantErr: port.ErrTemplateNotFound},
+		{name: "pinned in a locale", version: 1, locale: "de", wantMessage: "de v1 42", wantVersion: 1},
+		{name: "version missing in the locale is not taken from the default", version: 2, locale: "de", wantErr: port.ErrTemplateNotFound},
+		{name: "pinned version does not fall back from the region", version: 1, locale: "de-CH", wantErr: port.ErrTemplateNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref := &contracts.TemplateRef{Name: "receipt", Version: tt.version, Locale: tt.locale, Data: map[string]any{"order": 42}}
+			sms := &contracts.SMS{To: []string{"+15550100"}, Template: ref}
+
+			err := s.Render(context.Background(), sms)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if sms.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", sms.Message, tt.wantMessage)
+			}
+			if sms.Template.Version != tt.wantVersion {
+				t.Errorf("pinned version = %d, want %d", sms.Template.Version, tt.wantVersion)
+			}
+			if ref.Version != tt.version {
+				t.Errorf("caller's reference changed to version %d", ref.Version)
+			}
+		})
+	}
+
+	// A message rendered with the latest version keeps it once a newer one
+	// is created, as queued and scheduled messages do.
+	sms := &contracts.SMS{Template: &contracts.TemplateRef{Name: "receipt", Data: map[string]any{"order": 1}}}
+	if err := s.Render(context.Background(), sms); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(context.Background(), &contracts.Template{Name: "receipt", SMS: "v3 {{.order}}"}); err != nil {
+		t.Fatal(err)
+	}
+	sms.Message = ""
+	if err := s.Render(context.Background(), sms); err != nil {
+		t.Fatal(err)
+	}
+	if sms.Message != "v2 1" {
+		t.Errorf("re-rendered message = %q, want the pinned v2", sms.Message)
+	}
+}
+
+func TestTemplateServiceRenderEmail(t *testing.T) {
+	s := newTemplateService(t, &contracts.Template{
+		Name:    "welcome",
+		Subject: "Hi {{.name}}",
+		HTML:    `<p>Hi {{.name}}</p><a href="/u/{{.name}}">profile</a>`,
+		Text:    "Hi {{.name}}",
+	})
+	data := map[string]any{"name": `Tom & "Jerry" <b>`}
+
+	tests := []struct {
+		name     string
+		email    *contracts.Email
+		data     map[string]any
+		want     contracts.Email
+		wantErr  error
+		template string
+	}{
+		{
+			name:  "html is escaped and text is not",
+			email: &contracts.Email{},
+			data:  data,
+			want: contracts.Email{
+				Subject:   `Hi Tom & "Jerry" <b>`,
+				HTML:      `<p>Hi Tom &amp; &#34;Jerry&#34; &lt;b&gt;</p><a href="/u/Tom%20&amp;%20%22Jerry%22%20%3cb%3e">profile</a>`,
+				PlainText: `Hi Tom & "Jerry" <b>`,
+			},
+		},
+		{
+			name:  "fields set by the caller are kept",
+			email: &contracts.Email{Subject: "Custom"},
+			data:  map[string]any{"name": "Ann"},
+			want:  contracts.Email{Subject: "Custom", HTML: `<p>Hi Ann</p><a href="/u/Ann">profile</a>`, PlainText: "Hi Ann"},
+		},
+		{
+			name:    "missing data",
+			email:   &contracts.Email{},
+			data:    map[string]any{},
+			wantErr: ErrTemplateRender,
+		},
+		{
+			name:     "template without email content",
+			email:    &contracts.Email{},
+			template: "sms-only",
+			wantErr:  ErrTemplateRender,
+		},
+	}
+	if _, err := s.Create(context.Background(), &contracts.Template{Name: "sms-only", SMS: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := tt.template
+			if name == "" {
+				name = "welcome"
+			}
+			tt.email.Template = &contracts.TemplateRef{Name: name, Data: tt.data}
+
+			err := s.Render(context.Background(), tt.email)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tt.email.Subject != tt.want.Subject || tt.email.HTML != tt.want.HTML || tt.email.PlainText != tt.want.PlainText {
+				t.Errorf("rendered subject %q, html %q, text %q; want %q, %q, %q",
+					tt.email.Subject, tt.email.HTML, tt.email.PlainText, tt.want.Subject, tt.want.HTML, tt.want.PlainText)
+			}
+		})
+	}
+}
+
+func TestTemplateServiceRenderWithoutTemplate(t *testing.T) {
+	s := newTemplateService(t)
+	sms := &contracts.SMS{Message: "as sent"}
+	if err := s.Render(context.Background(), sms); err != nil || sms.Message != "as sent" {
+		t.Errorf("Render() = %v, message %q; want the message untouched", err, sms.Message)
+	}
+	if err := s.Render(context.Background(), &contracts.SMS{Template: &contracts.TemplateRef{Name: "missing"}}); !errors.Is(err, port.ErrTemplateNotFound) {
+		t.Errorf("Render(missing) error = %v, want ErrTemplateNotFound", err)
+	}
+}
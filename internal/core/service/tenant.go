@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+// ErrUnknownTenant is returned for a tenant that is not configured.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// tenant is the provider configuration and registry a tenant's messages are
+// sent with. The default tenant, with an empty ID, is the global provider
+// configuration.
+type tenant struct {
+	config   GatewayConfig
+	registry *Registry
+}
+
+func (t *tenant) emailProvider(name string) (port.EmailSender, error) {
+	provider, ok := t.registry.GetEmailProvider(name)
+	if !ok {
+		return nil, NewProviderNotFoundError("email", name)
+	}
+	return provider, nil
+}
+
+func (t *tenant) smsProvider(name string) (port.SMSSender, error) {
+	provider, ok := t.registry.GetSMSProvider(name)
+	if !ok {
+		return nil, NewProviderNotFoundError("sms", name)
+	}
+	return provider, nil
+}
+
+func (t *tenant) pushProvider(name string) (port.PushSender, error) {
+	provider, ok := t.registry.GetPushProvider(name)
+	if !ok {
+		return nil, NewProviderNotFoundError("push", name)
+	}
+	return provider, nil
+}
+
+func (t *tenant) chatProvider(name string) (port.ChatSender, error) {
+	provider, ok := t.registry.GetChatProvider(name)
+	if !ok {
+		return nil, NewProviderNotFoundError("chat", name)
+	}
+	return provider, nil
+}
+
+// AddTenant adds a tenant with its own default providers, failover chains
+// and provider instances. Sends with a context from WithTenant(ctx, id) use
+// them instead of the global ones.
+func (s *GatewayService) AddTenant(id string, cfg GatewayConfig, registry *Registry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants[id] = &tenant{config: cfg, registry: registry}
+}
+
+// HasTenant reports whether id is a configured tenant. The empty ID, for
+// the global providers, always is.
+func (s *GatewayService) HasTenant(id string) bool {
+	if id == "" {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.tenants[id]
+	return ok
+}
+
+// tenantFor returns the tenant of ctx.
+func (s *GatewayService) tenantFor(ctx context.Context) (*tenant, error) {
+	id := TenantFrom(ctx)
+	if id == "" {
+		return s.global, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tenants[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTenant, id)
+	}
+	return t, nil
+}
+
+type tenantContextKey struct{}
+
+// WithTenant returns a context whose sends use the tenant's providers and
+// are recorded in the ledger under its ID.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, id)
+}
+
+// TenantFrom returns the tenant of the context, or "" for the global
+// providers.
+func TenantFrom(ctx context.Context) string {
+	id, _ := ctx.Value(tenantContextKey{}).(string)
+	return id
+}
+
+// VisibleTo reports whether something of tenant owner may be seen with
+// ctx: everything without a tenant in ctx, else only the tenant's own.
+func VisibleTo(ctx context.Context, owner string) bool {
+	tenant := TenantFrom(ctx)
+	return tenant == "" || owner == tenant
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGatewayServiceTenantRegistry(t *testing.T) {
+	global := &fakeSMS{name: "global"}
+	svc := newTestService(global)
+
+	shop := &fakeSMS{name: "shop"}
+	registry := NewRegistry()
+	registry.RegisterSMSProvider(shop.name, shop)
+	svc.AddTenant("shop", testConfig{sms: shop.name}, registry)
+
+	tests := []struct {
+		name         string
+		tenant       string
+		wantProvider *fakeSMS
+		wantErr      error
+	}{
+		{name: "no tenant uses the global providers", wantProvider: global},
+		{name: "tenant uses its own providers", tenant: "shop", wantProvider: shop},
+		{name: "unknown tenant", tenant: "acme", wantErr: ErrUnknownTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.tenant != "" {
+				ctx = WithTenant(ctx, tt.tenant)
+			}
+			globalSent, shopSent := global.Sent(), shop.Sent()
+
+			_, err := svc.SendSMS(ctx, testSMS())
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			gotGlobal, gotShop := global.Sent()-globalSent, shop.Sent()-shopSent
+			switch tt.wantProvider {
+			case global:
+				if gotGlobal != 1 || gotShop != 0 {
+					t.Errorf("sent global %d, shop %d; want only global", gotGlobal, gotShop)
+				}
+			case shop:
+				if gotGlobal != 0 || gotShop != 1 {
+					t.Errorf("sent global %d, shop %d; want only shop", gotGlobal, gotShop)
+				}
+			default:
+				if gotGlobal+gotShop != 0 {
+					t.Errorf("sent global %d, shop %d; want none", gotGlobal, gotShop)
+				}
+			}
+		})
+	}
+}
+
+func TestGatewayServiceHasTenant(t *testing.T) {
+	svc := newTestService()
+	svc.AddTenant("shop", testConfig{}, NewRegistry())
+
+	for id, want := range map[string]bool{"": true, "shop": true, "acme": false} {
+		if got := svc.HasTenant(id); got != want {
+			t.Errorf("HasTenant(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
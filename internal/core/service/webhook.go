@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// ErrWebhookNotConfigured is returned for a provider without a webhook parser.
+var ErrWebhookNotConfigured = errors.New("webhooks are not configured for this provider")
+
+// WebhookService applies providers' delivery-status webhooks to the ledger.
+type WebhookService struct {
+	ledger port.Ledger
+
+	mu      sync.RWMutex
+	parsers map[webhookParserKey]port.WebhookParser
+
+	// update serialises read-modify-write of ledger records, since a
+	// provider may post several events for one message at once.
+	update sync.Mutex
+}
+
+// NewWebhookService creates a WebhookService.
+func NewWebhookService(ledger port.Ledger) *WebhookService {
+	return &WebhookService{
+		ledger:  ledger,
+		parsers: make(map[webhookParserKey]port.WebhookParser),
+	}
+}
+
+// webhookParserKey identifies a provider's parser for a tenant, or for the
+// global providers when the tenant is empty.
+type webhookParserKey struct {
+	tenant   string
+	provider string
+}
+
+// RegisterParser registers the webhook parser for a provider.
+func (s *WebhookService) RegisterParser(provider string, parser port.WebhookParser) {
+	s.RegisterTenantParser("", provider, parser)
+}
+
+// RegisterTenantParser registers the webhook parser for a tenant's provider,
+// used for requests whose context has the tenant.
+func (s *WebhookService) RegisterTenantParser(tenant, provider string, parser port.WebhookParser) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parsers[webhookParserKey{tenant, provider}] = parser
+}
+
+// Handle verifies and parses a provider's webhook request and updates the
+// messages it reports on. The parser is the one of the tenant in ctx. It
+// returns the events that matched a message; events about messages the
+// ledger does not know are skipped.
+func (s *WebhookService) Handle(ctx context.Context, provider string, req *port.WebhookRequest) ([]contracts.DeliveryEvent, error) {
+	s.mu.RLock()
+	parser, ok := s.parsers[webhookParserKey{TenantFrom(ctx), provider}]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrWebhookNotConfigured
+	}
+
+	if err := parser.Verify(req); err != nil {
+		return nil, err
+	}
+	events, err := parser.Parse(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to parse webhook: %w", provider, err)
+	}
+
+	matched := make([]contracts.DeliveryEvent, 0, len(events))
+	for _, event := range events {
+		if event.Provider == "" {
+			event.Provider = provider
+		}
+		ok, err := s.apply(ctx, event)
+		if err != nil {
+			return matched, err
+		}
+		if ok {
+			matched = append(matched, event)
+		}
+	}
+	return matched, nil
+}
+
+// apply records the event on its message. It reports whether the message was found.
+func (s *WebhookService) apply(ctx context.Context, event contracts.DeliveryEvent) (bool, error) {
+	if s.ledger == nil {
+		return false, nil
+	}
+
+	s.update.Lock()
+	defer s.update.Unlock()
+
+	record, err := s.ledger.FindByProviderID(ctx, event.Provider, event.ProviderMessageID)
+	if errors.Is(err, port.ErrMessageNotFound) {
+		log.Printf("Webhook %s: no message with ID %s", event.Provider, event.ProviderMessageID)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if record.Tenant != TenantFrom(ctx) {
+		// Signed with another tenant's, or the global, webhook secret.
+		log.Printf("Webhook %s: message %s belongs to another tenant", event.Provider, record.ID)
+		return false, nil
+	}
+
+	applyEvent(record, event)
+	if err := s.ledger.Save(ctx, record); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// applyEvent appends the event to the record's history and moves its status
+// forward. Failures and bounces always win; a late delivery report does not
+// override them, and engagement events never change the status.
+func applyEvent(record *contracts.MessageRecord, event contracts.DeliveryEvent) {
+	detail := event.Reason
+	if event.Recipient != "" {
+		detail = event.Recipient
+		if event.Reason != "" {
+			detail += ": " + event.Reason
+		}
+	}
+
+	at := event.Time.UTC()
+	switch event.Status {
+	case contracts.StatusFailed, contracts.StatusBounced:
+		record.Status = event.Status
+	case contracts.StatusDelivered:
+		if record.Status == contracts.StatusQueued || record.Status == contracts.StatusSent {
+			record.Status = event.Status
+		}
+	case contracts.StatusSent:
+		if record.Status == contracts.StatusQueued {
+			record.Status = event.Status
+		}
+	}
+
+	record.UpdatedAt = at
+	record.Events = append(record.Events, contracts.MessageEvent{Status: event.Status, Time: at, Detail: detail})
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/ledger"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+func openTestLedger(t *testing.T) *ledger.Store {
+	t.Helper()
+	store, err := ledger.Open(ledger.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// saveSent saves a sent SMS record of tenant with the provider message ID pid.
+func saveSent(t *testing.T, store *ledger.Store, id, tenant, pid string) {
+	t.Helper()
+	now := time.Now().UTC()
+	record := &contracts.MessageRecord{
+		ID:                id,
+		Channel:           "sms",
+		Provider:          "twilio",
+		ProviderMessageID: pid,
+		Status:            contracts.StatusSent,
+		Tenant:            tenant,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := store.Save(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookServiceApplyTenant(t *testing.T) {
+	tests := []struct {
+		name        string
+		owner       string
+		tenant      string
+		wantApplied bool
+	}{
+		{name: "global message, global webhook", wantApplied: true},
+		{name: "tenant's message, its webhook", owner: "shop", tenant: "shop", wantApplied: true},
+		{name: "tenant's message, another tenant's webhook", owner: "shop", tenant: "acme"},
+		{name: "tenant's message, global webhook", owner: "shop"},
+		{name: "global message, tenant's webhook", tenant: "shop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := openTestLedger(t)
+			saveSent(t, store, "msg-1", tt.owner, "SM1")
+			svc := NewWebhookService(store)
+
+			ctx := WithTenant(context.Background(), tt.tenant)
+			applied, err := svc.apply(ctx, contracts.DeliveryEvent{
+				Provider:          "twilio",
+				ProviderMessageID: "SM1",
+				Status:            contracts.StatusDelivered,
+				Time:              time.Now(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if applied != tt.wantApplied {
+				t.Errorf("applied = %v, want %v", applied, tt.wantApplied)
+			}
+
+			record, err := store.Get(context.Background(), "msg-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantStatus := contracts.StatusSent
+			if tt.wantApplied {
+				wantStatus = contracts.StatusDelivered
+			}
+			if record.Status != wantStatus {
+				t.Errorf("status = %s, want %s", record.Status, wantStatus)
+			}
+		})
+	}
+}
+
+func TestApplyEvent(t *testing.T) {
+	tests := []struct {
+		from, event, want contracts.MessageStatus
+	}{
+		{contracts.StatusQueued, contracts.StatusSent, contracts.StatusSent},
+		{contracts.StatusQueued, contracts.StatusDelivered, contracts.StatusDelivered},
+		{contracts.StatusSent, contracts.StatusDelivered, contracts.StatusDelivered},
+		{contracts.StatusSent, contracts.StatusBounced, contracts.StatusBounced},
+		{contracts.StatusDelivered, contracts.StatusSent, contracts.StatusDelivered},
+		{contracts.StatusDelivered, contracts.StatusFailed, contracts.StatusFailed},
+		{contracts.StatusBounced, contracts.StatusDelivered, contracts.StatusBounced},
+		{contracts.StatusFailed, contracts.StatusSent, contracts.StatusFailed},
+		{contracts.StatusDelivered, contracts.StatusOpened, contracts.StatusDelivered},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" then "+string(tt.event), func(t *testing.T) {
+			record := &contracts.MessageRecord{Status: tt.from}
+			at := time.Date(2030, 1, 1, 9, 0, 0, 0, time.FixedZone("CET", 3600))
+
+			applyEvent(record, contracts.DeliveryEvent{Status: tt.event, Recipient: "+15550100", Reason: "error 30003", Time: at})
+
+			if record.Status != tt.want {
+				t.Errorf("status = %s, want %s", record.Status, tt.want)
+			}
+			if len(record.Events) != 1 {
+				t.Fatalf("events = %+v, want the event recorded", record.Events)
+			}
+			if e := record.Events[0]; e.Status != tt.event || e.Detail != "+15550100: error 30003" || e.Time.Location() != time.UTC {
+				t.Errorf("event = %+v", e)
+			}
+			if !record.UpdatedAt.Equal(at) {
+				t.Errorf("UpdatedAt = %v, want %v", record.UpdatedAt, at)
+			}
+		})
+	}
+}
+
+// fakeParser fails with verifyErr or parseErr, or returns events.
+type fakeParser struct {
+	verifyErr error
+	parseErr  error
+	events    []contracts.DeliveryEvent
+}
+
+func (p *fakeParser) Verify(req *port.WebhookRequest) error { return p.verifyErr }
+
+func (p *fakeParser) Parse(req *port.WebhookRequest) ([]contracts.DeliveryEvent, error) {
+	return p.events, p.parseErr
+}
+
+func TestWebhookServiceHandle(t *testing.T) {
+	errParse := errors.New("invalid form body")
+	delivered := func(pid string) contracts.DeliveryEvent {
+		return contracts.DeliveryEvent{ProviderMessageID: pid, Status: contracts.StatusDelivered, Time: time.Now()}
+	}
+
+	tests := []struct {
+		name        string
+		tenant      string
+		parser      *fakeParser
+		wantErr     error
+		wantMatched []string
+		wantStatus  contracts.MessageStatus
+	}{
+		{
+			name:        "known message is updated",
+			parser:      &fakeParser{events: []contracts.DeliveryEvent{delivered("SM1")}},
+			wantMatched: []string{"SM1"},
+			wantStatus:  contracts.StatusDelivered,
+		},
+		{
+			name:        "unknown message is skipped",
+			parser:      &fakeParser{events: []contracts.DeliveryEvent{delivered("SM404"), delivered("SM1")}},
+			wantMatched: []string{"SM1"},
+			wantStatus:  contracts.StatusDelivered,
+		},
+		{
+			name:       "bad signature",
+			parser:     &fakeParser{verifyErr: port.ErrInvalidSignature, events: []contracts.DeliveryEvent{delivered("SM1")}},
+			wantErr:    port.ErrInvalidSignature,
+			wantStatus: contracts.StatusSent,
+		},
+		{
+			name:       "unparsable request",
+			parser:     &fakeParser{parseErr: errParse},
+			wantErr:    errParse,
+			wantStatus: contracts.StatusSent,
+		},
+		{
+			name:       "provider without a parser",
+			wantErr:    ErrWebhookNotConfigured,
+			wantStatus: contracts.StatusSent,
+		},
+		{
+			name:       "tenant without a parser for the provider",
+			tenant:     "acme",
+			parser:     &fakeParser{events: []contracts.DeliveryEvent{delivered("SM1")}},
+			wantErr:    ErrWebhookNotConfigured,
+			wantStatus: contracts.StatusSent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := openTestLedger(t)
+			saveSent(t, store, "msg-1", "", "SM1")
+			svc := NewWebhookService(store)
+			if tt.parser != nil {
+				svc.RegisterParser("twilio", tt.parser)
+			}
+
+			ctx := WithTenant(context.Background(), tt.tenant)
+			matched, err := svc.Handle(ctx, "twilio", &port.WebhookRequest{})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			var pids []string
+			for _, e := range matched {
+				if e.Provider != "twilio" {
+					t.Errorf("event provider = %q, want twilio", e.Provider)
+				}
+				pids = append(pids, e.ProviderMessageID)
+			}
+			if fmt.Sprint(pids) != fmt.Sprint(tt.wantMatched) {
+				t.Errorf("matched %v, want %v", pids, tt.wantMatched)
+			}
+			record, err := store.Get(context.Background(), "msg-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", record.Status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		fill    func(io.Writer) error
+		wantErr bool
+		want    string
+	}{
+		{"replaces contents", func(w io.Writer) error { _, err := io.WriteString(w, "new"); return err }, false, "new"},
+		{"failed fill keeps old contents", func(w io.Writer) error {
+			_, _ = io.WriteString(w, "partial")
+			return errors.New("encode failed")
+		}, true, "old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.json")
+			if err := WriteFile(path, []byte("old")); err != nil {
+				t.Fatal(err)
+			}
+
+			err := Write(path, tt.fill)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("contents = %q (%v), want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Package deadletter provides implementations of port.DeadLetterStore.
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/atomicfile"
+)
+
+const fileName = "deadletters.json"
+
+var _ port.DeadLetterStore = (*FileStore)(nil)
+
+// FileStore is a port.DeadLetterStore that keeps dead letters in memory and,
+// when opened with a directory, writes the full set to a JSON file on every
+// change. Dead letters are expected to be few and short-lived.
+type FileStore struct {
+	path string
+
+	mu      sync.Mutex
+	letters map[string]*port.DeadLetter
+	closed  bool
+}
+
+// Open opens or creates the store in dir. An empty dir keeps dead letters
+// in memory only.
+func Open(dir string) (*FileStore, error) {
+	s := &FileStore{
+		letters: make(map[string]*port.DeadLetter),
+	}
+	if dir == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("deadletter: failed to create directory: %w", err)
+	}
+	s.path = filepath.Join(dir, fileName)
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("deadletter: failed to read %s: %w", s.path, err)
+	}
+	if len(data) > 0 {
+		var letters []*port.DeadLetter
+		if err := json.Unmarshal(data, &letters); err != nil {
+			return nil, fmt.Errorf("deadletter: failed to parse %s: %w", s.path, err)
+		}
+		for _, letter := range letters {
+			s.letters[letter.ID] = letter
+		}
+	}
+	return s, nil
+}
+
+// Save inserts or replaces the dead letter.
+func (s *FileStore) Save(ctx context.Context, letter *port.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("deadletter: store closed")
+	}
+
+	prev, existed := s.letters[letter.ID]
+	stored := *letter
+	s.letters[letter.ID] = &stored
+
+	if err := s.write(); err != nil {
+		if existed {
+			s.letters[letter.ID] = prev
+		} else {
+			delete(s.letters, letter.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns a copy of the dead letter with the ID.
+func (s *FileStore) Get(ctx context.Context, id string) (*port.DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letter, ok := s.letters[id]
+	if !ok {
+		return nil, port.ErrDeadLetterNotFound
+	}
+	stored := *letter
+	return &stored, nil
+}
+
+// List returns copies of all dead letters, oldest first.
+func (s *FileStore) List(ctx context.Context) ([]*port.DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sorted(), nil
+}
+
+// Delete removes the dead letter with the ID.
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letter, ok := s.letters[id]
+	if !ok {
+		return port.ErrDeadLetterNotFound
+	}
+	delete(s.letters, id)
+
+	if err := s.write(); err != nil {
+		s.letters[id] = letter
+		return err
+	}
+	return nil
+}
+
+// Close stops accepting changes.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	return nil
+}
+
+func (s *FileStore) sorted() []*port.DeadLetter {
+	letters := make([]*port.DeadLetter, 0, len(s.letters))
+	for _, letter := range s.letters {
+		stored := *letter
+		letters = append(letters, &stored)
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		if letters[i].FailedAt.Equal(letters[j].FailedAt) {
+			return letters[i].ID < letters[j].ID
+		}
+		return letters[i].FailedAt.Before(letters[j].FailedAt)
+	})
+	return letters
+}
+
+// write replaces the file with the current dead letters via atomicfile.
+// It does nothing for an in-memory store.
+func (s *FileStore) write() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.sorted())
+	if err != nil {
+		return fmt.Errorf("deadletter: failed to encode dead letters: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("deadletter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Package eventhook posts message lifecycle events to subscriber URLs.
+package eventhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/webhook"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	clientName     = "eventhook"
+	defaultTimeout = 10 * time.Second
+)
+
+// HeaderEvent carries the event type. Deliveries are signed like the webhook
+// chat provider's: see webhook.HeaderSignature and webhook.Sign.
+const HeaderEvent = "X-Gateway-Event"
+
+var _ port.EventDeliverer = (*Client)(nil)
+
+// Client implements port.EventDeliverer over HTTP.
+type Client struct {
+	client *http.Client
+}
+
+// New creates a new Client.
+func New() *Client {
+	return &Client{
+		client: &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// Deliver posts the event as JSON. Any 2xx response counts as delivered.
+// Network failures, 429 and 5xx responses are returned as retryable errors.
+func (c *Client) Deliver(ctx context.Context, url, secret string, event *contracts.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("eventhook: failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("eventhook: failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(event.Type))
+	req.Header.Set(webhook.HeaderDelivery, event.ID)
+	req.Header.Set(webhook.HeaderTimestamp, timestamp)
+	if secret != "" {
+		req.Header.Set(webhook.HeaderSignature, webhook.Sign(secret, timestamp, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("eventhook: failed to post event: %w", err)
+		}
+		perr := pkgerrors.NewProviderError(clientName, "failed to post event", 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		msg := strings.TrimSpace(string(respBody))
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		return pkgerrors.NewProviderError(clientName, "subscriber rejected event", resp.StatusCode, errors.New(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package gsm classifies SMS text as GSM-7 or UCS-2 and counts segments.
+package gsm
+
+import "unicode/utf16"
+
+// Encodings reported by Encode.
+const (
+	EncodingGSM7 = "GSM-7"
+	EncodingUCS2 = "UCS-2"
+)
+
+// Segment sizes per 3GPP TS 23.038; multipart messages lose room to the UDH.
+const (
+	gsm7Single = 160
+	gsm7Multi  = 153
+	ucs2Single = 70
+	ucs2Multi  = 67
+)
+
+// basic is the GSM 03.38 default alphabet.
+const basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// extension characters are sent as an escape plus one septet.
+const extension = "^{}\\[~]|€\f"
+
+var septets = func() map[rune]int {
+	m := make(map[rune]int, len(basic)+len(extension))
+	for _, r := range basic {
+		m[r] = 1
+	}
+	for _, r := range extension {
+		m[r] = 2
+	}
+	return m
+}()
+
+// IsGSM7 reports whether s can be sent with the GSM 7-bit default alphabet.
+func IsGSM7(s string) bool {
+	for _, r := range s {
+		if _, ok := septets[r]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode returns the encoding s must be sent with and the number of SMS
+// segments it occupies.
+func Encode(s string) (encoding string, segments int) {
+	if IsGSM7(s) {
+		n := 0
+		for _, r := range s {
+			n += septets[r]
+		}
+		return EncodingGSM7, count(n, gsm7Single, gsm7Multi)
+	}
+
+	n := len(utf16.Encode([]rune(s)))
+	return EncodingUCS2, count(n, ucs2Single, ucs2Multi)
+}
+
+func count(n, single, multi int) int {
+	if n <= single {
+		return 1
+	}
+	return (n + multi - 1) / multi
+}
@@ -0,0 +1,41 @@
+package gsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantEncoding string
+		wantSegments int
+	}{
+		{"empty", "", EncodingGSM7, 1},
+		{"ascii", "Hello", EncodingGSM7, 1},
+		{"gsm accents", "Ça été ñ", EncodingGSM7, 1},
+		{"single segment limit", strings.Repeat("a", 160), EncodingGSM7, 1},
+		{"multipart", strings.Repeat("a", 161), EncodingGSM7, 2},
+		{"multipart boundary", strings.Repeat("a", 306), EncodingGSM7, 2},
+		{"extension counts two septets", strings.Repeat("€", 80), EncodingGSM7, 1},
+		{"extension over limit", strings.Repeat("€", 81), EncodingGSM7, 2},
+		{"lowercase c cedilla is not GSM", "ç", EncodingUCS2, 1},
+		{"cyrillic", "Привет", EncodingUCS2, 1},
+		{"ucs2 single segment limit", strings.Repeat("ж", 70), EncodingUCS2, 1},
+		{"ucs2 multipart", strings.Repeat("ж", 71), EncodingUCS2, 2},
+		{"astral runes count two units", strings.Repeat("🚀", 35), EncodingUCS2, 1},
+		{"astral runes over limit", strings.Repeat("🚀", 36), EncodingUCS2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, segments := Encode(tt.text)
+			if encoding != tt.wantEncoding || segments != tt.wantSegments {
+				t.Errorf("Encode() = %s, %d; want %s, %d", encoding, segments, tt.wantEncoding, tt.wantSegments)
+			}
+			if got := IsGSM7(tt.text); got != (tt.wantEncoding == EncodingGSM7) {
+				t.Errorf("IsGSM7() = %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package htmlmail
+
+import (
+	"sort"
+	"strings"
+)
+
+// declaration is one "property: value" of a rule or style attribute.
+type declaration struct {
+	property  string
+	value     string
+	important bool
+}
+
+// compound is a simple selector such as "td", ".button" or "p.lead#intro".
+type compound struct {
+	tag     string
+	id      string
+	classes []string
+}
+
+// selector is a chain of compound selectors joined by descendant (' ') or
+// child ('>') combinators, rightmost last.
+type selector struct {
+	parts       []compound
+	combinators []byte
+	specificity int
+}
+
+// cssRule is a style rule that can be inlined.
+type cssRule struct {
+	selector     selector
+	declarations []declaration
+	order        int
+}
+
+// inlineCSS moves the rules of the document's <style> elements into the
+// style attributes of the elements they match. Rules that cannot be
+// inlined, such as @media queries and :hover, stay in a <style> element in
+// <head> for the clients that support them. Declarations already in a
+// style attribute take precedence. A <style data-embed> element is left as
+// it is.
+func inlineCSS(doc *node) {
+	var styles []*node
+	doc.walk(func(n *node) {
+		if n.typ != elementNode || n.tag != "style" {
+			return
+		}
+		if _, embed := n.attr("data-embed"); embed {
+			n.removeAttr("data-embed")
+			return
+		}
+		styles = append(styles, n)
+	})
+	if len(styles) == 0 {
+		return
+	}
+
+	var rules []cssRule
+	var kept []string
+	for _, style := range styles {
+		r, k := parseStylesheet(style.text(), len(rules))
+		rules = append(rules, r...)
+		kept = append(kept, k...)
+		style.parent.removeChild(style)
+	}
+
+	doc.walk(func(n *node) {
+		if n.typ != elementNode || inHead(n) {
+			return
+		}
+		applyRules(n, rules)
+	})
+
+	if len(kept) == 0 {
+		return
+	}
+	style := &node{typ: elementNode, tag: "style", attrs: []attr{{"type", "text/css"}}}
+	style.appendChild(&node{typ: textNode, data: strings.Join(kept, "\n")})
+	if head := doc.find("head"); head != nil {
+		head.appendChild(style)
+		return
+	}
+	doc.children = append([]*node{style}, doc.children...)
+	style.parent = doc
+}
+
+func inHead(n *node) bool {
+	for p := n; p != nil; p = p.parent {
+		if p.tag == "head" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRules sets n's style attribute to the matching declarations, in
+// cascade order: by importance, specificity and source order, with the
+// element's own style attribute above every rule that is not !important.
+func applyRules(n *node, rules []cssRule) {
+	type applied struct {
+		declaration
+		specificity int
+		order       int
+	}
+	var matched []applied
+	for _, rule := range rules {
+		if !rule.selector.matches(n) {
+			continue
+		}
+		for _, d := range rule.declarations {
+			matched = append(matched, applied{d, rule.selector.specificity, rule.order})
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+
+	own, _ := n.attr("style")
+	for _, d := range parseDeclarations(own) {
+		matched = append(matched, applied{d, 1 << 20, 1 << 20})
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if a.important != b.important {
+			return !a.important
+		}
+		if a.specificity != b.specificity {
+			return a.specificity < b.specificity
+		}
+		return a.order < b.order
+	})
+
+	values := make(map[string]string, len(matched))
+	var properties []string
+	for _, d := range matched {
+		if _, seen := values[d.property]; !seen {
+			properties = append(properties, d.property)
+		}
+		values[d.property] = d.value
+	}
+
+	var b strings.Builder
+	for _, p := range properties {
+		b.WriteString(p + ":" + values[p] + ";")
+	}
+	n.setAttr("style", b.String())
+}
+
+// parseStylesheet splits css into rules to inline, numbered from order,
+// and the text of the rules to keep in a <style> element.
+func parseStylesheet(css string, order int) (rules []cssRule, kept []string) {
+	css = stripComments(css)
+	for {
+		css = strings.TrimSpace(css)
+		open := strings.IndexByte(css, '{')
+		if strings.HasPrefix(css, "@") {
+			// Statements such as @import and @charset end at a semicolon.
+			if semi := strings.IndexByte(css, ';'); semi >= 0 && (open < 0 || semi < open) {
+				kept = append(kept, css[:semi+1])
+				css = css[semi+1:]
+				continue
+			}
+		}
+		if open < 0 {
+			return rules, kept
+		}
+		prelude := strings.TrimSpace(css[:open])
+		end := matchingBrace(css, open)
+		body := css[open+1 : end]
+		block := css[:min(end+1, len(css))]
+		css = css[min(end+1, len(css)):]
+
+		if strings.HasPrefix(prelude, "@") {
+			kept = append(kept, block)
+			continue
+		}
+
+		declarations := parseDeclarations(body)
+		for _, s := range strings.Split(prelude, ",") {
+			s = strings.TrimSpace(s)
+			sel, ok := parseSelector(s)
+			if !ok {
+				kept = append(kept, s+" {"+body+"}")
+				continue
+			}
+			rules = append(rules, cssRule{selector: sel, declarations: declarations, order: order})
+			order++
+		}
+	}
+}
+
+// matchingBrace returns the index of the brace closing the one at open, or
+// len(css) when it is unclosed.
+func matchingBrace(css string, open int) int {
+	depth := 0
+	for i := open; i < len(css); i++ {
+		switch css[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(css)
+}
+
+func stripComments(css string) string {
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			return css
+		}
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			return css[:start]
+		}
+		css = css[:start] + css[start+2+end+2:]
+	}
+}
+
+// parseDeclarations parses "a: b; c: d !important". Semicolons inside
+// quotes or parentheses, as in data: URLs, do not end a declaration.
+func parseDeclarations(s string) []declaration {
+	var declarations []declaration
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			switch c := s[i]; {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '"' || c == '\'':
+				quote = c
+				continue
+			case c == '(':
+				depth++
+				continue
+			case c == ')':
+				depth--
+				continue
+			case c != ';' || depth > 0:
+				continue
+			}
+		}
+
+		property, value, ok := strings.Cut(s[start:i], ":")
+		start = i + 1
+		property = strings.ToLower(strings.TrimSpace(property))
+		value = strings.TrimSpace(value)
+		if !ok || property == "" || value == "" {
+			continue
+		}
+		d := declaration{property: property, value: value}
+		if v, found := strings.CutSuffix(value, "!important"); found {
+			d.value, d.important = strings.TrimSpace(v), true
+		}
+		declarations = append(declarations, d)
+	}
+	return declarations
+}
+
+// parseSelector parses the selectors that can be matched without a
+// browser: type, class and ID selectors with descendant and child
+// combinators. It reports false for anything else, such as pseudo-classes.
+func parseSelector(s string) (selector, bool) {
+	if s == "" || strings.ContainsAny(s, ":[]+~()") {
+		return selector{}, false
+	}
+	s = strings.ReplaceAll(s, ">", " > ")
+
+	var sel selector
+	combinator := byte(' ')
+	for _, token := range strings.Fields(s) {
+		if token == ">" {
+			if len(sel.parts) == 0 {
+				return selector{}, false
+			}
+			combinator = '>'
+			continue
+		}
+		c, ok := parseCompound(token)
+		if !ok {
+			return selector{}, false
+		}
+		if len(sel.parts) > 0 {
+			sel.combinators = append(sel.combinators, combinator)
+		}
+		sel.parts = append(sel.parts, c)
+		combinator = ' '
+
+		if c.id != "" {
+			sel.specificity += 100
+		}
+		sel.specificity += 10 * len(c.classes)
+		if c.tag != "" && c.tag != "*" {
+			sel.specificity++
+		}
+	}
+	if len(sel.parts) == 0 || combinator == '>' {
+		return selector{}, false
+	}
+	return sel, true
+}
+
+func parseCompound(token string) (compound, bool) {
+	var c compound
+	i := 0
+	for i < len(token) && token[i] != '.' && token[i] != '#' {
+		i++
+	}
+	c.tag = strings.ToLower(token[:i])
+	for i < len(token) {
+		kind := token[i]
+		j := i + 1
+		for j < len(token) && token[j] != '.' && token[j] != '#' {
+			j++
+		}
+		name := token[i+1 : j]
+		if name == "" {
+			return compound{}, false
+		}
+		if kind == '#' {
+			c.id = name
+		} else {
+			c.classes = append(c.classes, name)
+		}
+		i = j
+	}
+	return c, true
+}
+
+// matches reports whether the selector matches n.
+func (s selector) matches(n *node) bool {
+	return s.matchFrom(n, len(s.parts)-1)
+}
+
+func (s selector) matchFrom(n *node, i int) bool {
+	if !s.parts[i].matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if s.combinators[i-1] == '>' {
+		p := n.parent
+		return p != nil && p.typ == elementNode && s.matchFrom(p, i-1)
+	}
+	for p := n.parent; p != nil && p.typ == elementNode; p = p.parent {
+		if s.matchFrom(p, i-1) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c compound) matches(n *node) bool {
+	if c.tag != "" && c.tag != "*" && c.tag != n.tag {
+		return false
+	}
+	if c.id != "" {
+		if id, _ := n.attr("id"); id != c.id {
+			return false
+		}
+	}
+	if len(c.classes) > 0 {
+		class, _ := n.attr("class")
+		have := strings.Fields(class)
+		for _, want := range c.classes {
+			found := false
+			for _, h := range have {
+				if h == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
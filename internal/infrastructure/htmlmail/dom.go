@@ -0,0 +1,338 @@
+package htmlmail
+
+import (
+	"html"
+	"strings"
+)
+
+// nodeType is the kind of a parsed node.
+type nodeType int
+
+const (
+	documentNode nodeType = iota
+	elementNode
+	textNode
+	commentNode
+	doctypeNode
+)
+
+// attr is an element attribute with its value unescaped.
+type attr struct {
+	key, val string
+}
+
+// node is a node of the lenient document tree built by parse. It is enough
+// for the markup used in email; it is not an HTML5 parser.
+type node struct {
+	typ nodeType
+	// tag is the lower-cased element name.
+	tag   string
+	attrs []attr
+	// data is the raw text of text, comment and doctype nodes.
+	data string
+
+	parent   *node
+	children []*node
+}
+
+// voidElements have no end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// rawTextElements hold text that is not markup.
+var rawTextElements = map[string]bool{
+	"script": true, "style": true, "mj-style": true,
+}
+
+func (n *node) attr(key string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.key == key {
+			return a.val, true
+		}
+	}
+	return "", false
+}
+
+func (n *node) attrOr(key, fallback string) string {
+	if v, ok := n.attr(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+func (n *node) setAttr(key, val string) {
+	for i, a := range n.attrs {
+		if a.key == key {
+			n.attrs[i].val = val
+			return
+		}
+	}
+	n.attrs = append(n.attrs, attr{key, val})
+}
+
+func (n *node) removeAttr(key string) {
+	for i, a := range n.attrs {
+		if a.key == key {
+			n.attrs = append(n.attrs[:i], n.attrs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (n *node) appendChild(c *node) {
+	c.parent = n
+	n.children = append(n.children, c)
+}
+
+func (n *node) removeChild(c *node) {
+	for i, child := range n.children {
+		if child == c {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			c.parent = nil
+			return
+		}
+	}
+}
+
+// find returns the first element with the tag, depth first.
+func (n *node) find(tag string) *node {
+	for _, c := range n.children {
+		if c.typ == elementNode && c.tag == tag {
+			return c
+		}
+		if found := c.find(tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// walk calls fn for n and every node below it, depth first.
+func (n *node) walk(fn func(*node)) {
+	fn(n)
+	for _, c := range n.children {
+		c.walk(fn)
+	}
+}
+
+// text returns the unescaped text below n.
+func (n *node) text() string {
+	var b strings.Builder
+	n.walk(func(c *node) {
+		if c.typ == textNode {
+			b.WriteString(html.UnescapeString(c.data))
+		}
+	})
+	return b.String()
+}
+
+// parse builds a document tree from markup. Unknown end tags are ignored
+// and unclosed elements are closed at the end of their parent.
+func parse(src string) *node {
+	doc := &node{typ: documentNode}
+	cur := doc
+
+	for len(src) > 0 {
+		lt := strings.IndexByte(src, '<')
+		if lt < 0 {
+			cur.appendChild(&node{typ: textNode, data: src})
+			break
+		}
+		if lt > 0 {
+			cur.appendChild(&node{typ: textNode, data: src[:lt]})
+			src = src[lt:]
+		}
+
+		switch {
+		case strings.HasPrefix(src, "<!--"):
+			end := strings.Index(src[4:], "-->")
+			if end < 0 {
+				cur.appendChild(&node{typ: commentNode, data: src[4:]})
+				return doc
+			}
+			cur.appendChild(&node{typ: commentNode, data: src[4 : 4+end]})
+			src = src[4+end+3:]
+
+		case strings.HasPrefix(src, "<!"), strings.HasPrefix(src, "<?"):
+			end := strings.IndexByte(src, '>')
+			if end < 0 {
+				return doc
+			}
+			if strings.HasPrefix(strings.ToLower(src), "<!doctype") {
+				cur.appendChild(&node{typ: doctypeNode, data: src[2:end]})
+			}
+			src = src[end+1:]
+
+		case strings.HasPrefix(src, "</"):
+			end := strings.IndexByte(src, '>')
+			if end < 0 {
+				return doc
+			}
+			tag := strings.ToLower(strings.TrimSpace(src[2:end]))
+			src = src[end+1:]
+			for n := cur; n != doc; n = n.parent {
+				if n.tag == tag {
+					cur = n.parent
+					break
+				}
+			}
+
+		default:
+			el, rest, selfClosing, ok := parseStartTag(src)
+			if !ok {
+				// A lone "<" is text.
+				cur.appendChild(&node{typ: textNode, data: "&lt;"})
+				src = src[1:]
+				continue
+			}
+			src = rest
+			cur.appendChild(el)
+
+			if rawTextElements[el.tag] && !selfClosing {
+				end := indexFold(src, "</"+el.tag)
+				if end < 0 {
+					end = len(src)
+				}
+				if end > 0 {
+					el.appendChild(&node{typ: textNode, data: src[:end]})
+				}
+				src = src[end:]
+				if gt := strings.IndexByte(src, '>'); gt >= 0 {
+					src = src[gt+1:]
+				}
+				continue
+			}
+			if !selfClosing && !voidElements[el.tag] {
+				cur = el
+			}
+		}
+	}
+	return doc
+}
+
+// parseStartTag parses "<tag attrs...>" at the start of src.
+func parseStartTag(src string) (el *node, rest string, selfClosing, ok bool) {
+	if len(src) < 2 || !isLetter(src[1]) {
+		return nil, src, false, false
+	}
+	i := 1
+	for i < len(src) && isNameChar(src[i]) {
+		i++
+	}
+	el = &node{typ: elementNode, tag: strings.ToLower(src[1:i])}
+
+	for {
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		if i >= len(src) {
+			return el, "", false, true
+		}
+		switch {
+		case src[i] == '>':
+			return el, src[i+1:], false, true
+		case strings.HasPrefix(src[i:], "/>"):
+			return el, src[i+2:], true, true
+		case src[i] == '/':
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(src) && !isSpace(src[i]) && src[i] != '=' && src[i] != '>' && !strings.HasPrefix(src[i:], "/>") {
+			i++
+		}
+		key := strings.ToLower(src[start:i])
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+
+		val := ""
+		if i < len(src) && src[i] == '=' {
+			i++
+			for i < len(src) && isSpace(src[i]) {
+				i++
+			}
+			if i < len(src) && (src[i] == '"' || src[i] == '\'') {
+				quote := src[i]
+				end := strings.IndexByte(src[i+1:], quote)
+				if end < 0 {
+					end = len(src) - i - 1
+				}
+				val = src[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(src) && !isSpace(src[i]) && src[i] != '>' {
+					i++
+				}
+				val = src[start:i]
+			}
+		}
+		if key != "" {
+			el.attrs = append(el.attrs, attr{key, html.UnescapeString(val)})
+		}
+	}
+}
+
+// render serialises the tree below n.
+func render(n *node) string {
+	var b strings.Builder
+	for _, c := range n.children {
+		renderNode(&b, c)
+	}
+	return b.String()
+}
+
+func renderNode(b *strings.Builder, n *node) {
+	switch n.typ {
+	case textNode:
+		b.WriteString(n.data)
+	case commentNode:
+		b.WriteString("<!--" + n.data + "-->")
+	case doctypeNode:
+		b.WriteString("<!" + n.data + ">")
+	case elementNode:
+		b.WriteString("<" + n.tag)
+		for _, a := range n.attrs {
+			b.WriteString(" " + a.key + `="` + html.EscapeString(a.val) + `"`)
+		}
+		b.WriteString(">")
+		if voidElements[n.tag] {
+			return
+		}
+		for _, c := range n.children {
+			renderNode(b, c)
+		}
+		b.WriteString("</" + n.tag + ">")
+	case documentNode:
+		for _, c := range n.children {
+			renderNode(b, c)
+		}
+	}
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isLetter(c) || c >= '0' && c <= '9' || c == '-' || c == ':' || c == '_'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// indexFold is strings.Index ignoring case.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
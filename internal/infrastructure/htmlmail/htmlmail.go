@@ -0,0 +1,119 @@
+// Package htmlmail renders email bodies for delivery. It turns Markdown and
+// MJML bodies into responsive HTML with the CSS inlined, and derives a plain
+// text alternative from the HTML when the sender gave none.
+package htmlmail
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// markdownLayout wraps rendered Markdown in a centred, single column layout
+// that narrows to the screen on mobile clients. Its rules are inlined,
+// except the media query.
+const markdownLayout = `<!doctype html>
+<html%s>
+<head>
+<meta http-equiv="Content-Type" content="text/html; charset=UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<style type="text/css">
+body { margin:0; padding:0; background-color:#f4f4f5; -webkit-text-size-adjust:100%%; -ms-text-size-adjust:100%%; }
+table { border-collapse:collapse; }
+.wrapper { width:100%%; background-color:#f4f4f5; }
+.container { width:100%%; max-width:600px; margin:0 auto; }
+.content { padding:32px; background-color:#ffffff; font-family:-apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; font-size:16px; line-height:1.5; color:#18181b; }
+h1 { margin:0 0 16px; font-size:28px; line-height:1.25; }
+h2 { margin:24px 0 12px; font-size:22px; line-height:1.3; }
+h3, h4, h5, h6 { margin:20px 0 8px; font-size:18px; line-height:1.4; }
+p { margin:0 0 16px; }
+a { color:#2563eb; }
+ul, ol { margin:0 0 16px; padding-left:24px; }
+blockquote { margin:0 0 16px; padding:0 16px; border-left:4px solid #e4e4e7; color:#52525b; }
+pre { margin:0 0 16px; padding:12px; background-color:#f4f4f5; white-space:pre-wrap; word-break:break-word; }
+code { font-family:Menlo, Consolas, monospace; font-size:14px; }
+hr { margin:24px 0; border:0; border-top:1px solid #e4e4e7; }
+img { max-width:100%%; height:auto; border:0; }
+.content table { width:100%%; margin:0 0 16px; }
+.content th, .content td { padding:6px 12px; border:1px solid #e4e4e7; text-align:left; }
+@media only screen and (max-width:620px) {
+  .content { padding:20px !important; }
+}
+</style>
+</head>
+<body>
+<table class="wrapper" role="presentation" border="0" cellpadding="0" cellspacing="0" width="100%%">
+<tr><td align="center">
+<table class="container" role="presentation" border="0" cellpadding="0" cellspacing="0" width="600">
+<tr><td class="content">
+%s</td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+`
+
+// Render fills in the HTML and PlainText of email. When HTML is empty it is
+// rendered from MJML or, failing that, Markdown. When PlainText is empty it
+// is derived from the HTML. HTML given by the sender is left as it is.
+func Render(email *contracts.Email) error {
+	var lang string
+	if email.Template != nil {
+		lang = email.Template.Locale
+	}
+
+	switch {
+	case email.HTML != "":
+	case strings.TrimSpace(email.MJML) != "":
+		body, err := mjml(email.MJML, email.Subject, lang)
+		if err != nil {
+			return err
+		}
+		email.HTML = body
+	case strings.TrimSpace(email.Markdown) != "":
+		email.HTML = renderMarkdown(email.Markdown, email.Subject, lang)
+	}
+
+	if email.PlainText == "" && email.HTML != "" {
+		email.PlainText = PlainText(email.HTML)
+	}
+	return nil
+}
+
+func renderMarkdown(src, title, lang string) string {
+	doc := parse(fmt.Sprintf(markdownLayout, attrs("lang", lang), html.EscapeString(title), markdown(src)))
+	inlineCSS(doc)
+	return render(doc)
+}
+
+// EmailSender renders the body of each email before passing it to the
+// wrapped port.EmailSender.
+type EmailSender struct {
+	next port.EmailSender
+}
+
+// NewEmailSender wraps next with body rendering.
+func NewEmailSender(next port.EmailSender) *EmailSender {
+	return &EmailSender{next: next}
+}
+
+// Send renders the email body and sends it. The caller's email is not
+// modified.
+func (s *EmailSender) Send(ctx context.Context, email *contracts.Email) (*contracts.SendResult, error) {
+	rendered := *email
+	if err := Render(&rendered); err != nil {
+		return nil, err
+	}
+	return s.next.Send(ctx, &rendered)
+}
+
+// Name returns the wrapped provider's name.
+func (s *EmailSender) Name() string {
+	return s.next.Name()
+}
@@ -0,0 +1,132 @@
+package htmlmail
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestRender_Golden renders each input in testdata and compares the HTML
+// and plain text with the .golden files beside it. Run with -update after
+// an intended change to the output, and review the diff.
+func TestRender_Golden(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		email func(src string) *contracts.Email
+	}{
+		{"markdown escaping", "markdown_escaping.md", markdownEmail},
+		{"markdown links", "markdown_links.md", markdownEmail},
+		{"markdown tables and lists", "markdown_tables.md", markdownEmail},
+		{"mjml sections and columns", "mjml_sections.mjml", func(src string) *contracts.Email {
+			return &contracts.Email{Subject: "Digest", MJML: src}
+		}},
+		{"plain text from html", "plain_text.html", func(src string) *contracts.Email {
+			return &contracts.Email{Subject: "Welcome", HTML: src}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := os.ReadFile(filepath.Join("testdata", tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			email := tt.email(string(src))
+			if err := Render(email); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			base := strings.TrimSuffix(tt.input, filepath.Ext(tt.input))
+			if email.Markdown != "" || email.MJML != "" {
+				golden(t, base+".html.golden", email.HTML)
+			}
+			golden(t, base+".txt.golden", email.PlainText)
+		})
+	}
+}
+
+func markdownEmail(src string) *contracts.Email {
+	return &contracts.Email{Subject: "Orders & <Returns>", Markdown: src}
+}
+
+// golden compares got with testdata/name, or rewrites the file with -update.
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the rendered output:\n%s", name, got)
+	}
+}
+
+func TestRender_KeepsSenderBodies(t *testing.T) {
+	email := &contracts.Email{HTML: "<p>Mine</p>", PlainText: "Also mine", Markdown: "# Ignored"}
+	if err := Render(email); err != nil {
+		t.Fatal(err)
+	}
+	if email.HTML != "<p>Mine</p>" || email.PlainText != "Also mine" {
+		t.Errorf("Render() changed the sender's bodies: %q %q", email.HTML, email.PlainText)
+	}
+}
+
+func TestRender_MJMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		mjml string
+	}{
+		{"no root", "<mj-body></mj-body>"},
+		{"no body", "<mjml><mj-head></mj-head></mjml>"},
+		{"unsupported head element", "<mjml><mj-head><mj-html-attributes /></mj-head><mj-body></mj-body></mjml>"},
+		{"unsupported component", "<mjml><mj-body><mj-section><mj-column><mj-carousel /></mj-column></mj-section></mj-body></mjml>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Render(&contracts.Email{MJML: tt.mjml}); err == nil {
+				t.Error("Render() error = nil, want an MJML error")
+			}
+		})
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/a?b=1", "https://example.com/a?b=1"},
+		{" HTTP://example.com ", "HTTP://example.com"},
+		{"mailto:a@example.com", "mailto:a@example.com"},
+		{"tel:+15551234567", "tel:+15551234567"},
+		{"cid:logo@example.com", "cid:logo@example.com"},
+		{"/relative/path", "/relative/path"},
+		{"#anchor", "#anchor"},
+		{"path/with:colon", "path/with:colon"},
+		{"javascript:alert(1)", "#"},
+		{"JavaScript:alert(1)", "#"},
+		{"data:text/html;base64,PHNjcmlwdD4=", "#"},
+		{"vbscript:msgbox", "#"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := safeURL(tt.url); got != tt.want {
+				t.Errorf("safeURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,608 @@
+package htmlmail
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// markdown renders the CommonMark constructs common in email: headings,
+// paragraphs, emphasis, links, images, lists, block quotes, code, rules and
+// GitHub-style tables and strikethrough. Raw HTML is escaped, not passed
+// through, so data rendered into a Markdown template cannot inject markup.
+func markdown(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"), false)
+	return b.String()
+}
+
+var (
+	atxHeading   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	thematic     = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fence        = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+	listItem     = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])( +|$)`)
+	setextH1     = regexp.MustCompile(`^ {0,3}=+[ \t]*$`)
+	setextH2     = regexp.MustCompile(`^ {0,3}-+[ \t]*$`)
+	tableDivider = regexp.MustCompile(`^ *\|? *:?-+:? *(?:\| *:?-+:? *)*\|? *$`)
+)
+
+// renderBlocks renders lines as block elements. In a tight list item,
+// paragraphs are written without <p>.
+func renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fence.MatchString(line):
+			i = renderFence(b, lines, i)
+
+		case atxHeading.MatchString(line):
+			m := atxHeading.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + inline(m[2]) + "</h" + level + ">\n")
+			i++
+
+		case thematic.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case isQuote(line):
+			var quoted []string
+			for ; i < len(lines) && isQuote(lines[i]); i++ {
+				q := strings.TrimLeft(lines[i], " ")[1:]
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted, false)
+			b.WriteString("</blockquote>\n")
+
+		case listItem.MatchString(line):
+			i = renderList(b, lines, i)
+
+		case i+1 < len(lines) && strings.Contains(line, "|") && tableDivider.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			i = renderTable(b, lines, i)
+
+		default:
+			i = renderParagraph(b, lines, i, tight)
+		}
+	}
+}
+
+func isQuote(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	return len(line)-len(trimmed) <= 3 && strings.HasPrefix(trimmed, ">")
+}
+
+// startsBlock reports whether line interrupts a paragraph.
+func startsBlock(line string) bool {
+	return fence.MatchString(line) || atxHeading.MatchString(line) || thematic.MatchString(line) ||
+		isQuote(line) || listItem.MatchString(line) && strings.TrimSpace(listItem.ReplaceAllString(line, "")) != ""
+}
+
+func renderParagraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	var text []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		if len(text) > 0 {
+			switch {
+			case setextH1.MatchString(line):
+				b.WriteString("<h1>" + inline(strings.Join(text, "\n")) + "</h1>\n")
+				return i + 1
+			case setextH2.MatchString(line):
+				b.WriteString("<h2>" + inline(strings.Join(text, "\n")) + "</h2>\n")
+				return i + 1
+			case startsBlock(line):
+				goto done
+			}
+		}
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+done:
+	content := inline(strings.Join(text, "\n"))
+	if tight {
+		b.WriteString(content + "\n")
+	} else {
+		b.WriteString("<p>" + content + "</p>\n")
+	}
+	return i
+}
+
+func renderFence(b *strings.Builder, lines []string, i int) int {
+	m := fence.FindStringSubmatch(lines[i])
+	marker, lang := m[1], m[2]
+	indent := len(lines[i]) - len(strings.TrimLeft(lines[i], " "))
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, marker) && strings.Trim(trimmed, marker[:1]) == "" {
+			i++
+			break
+		}
+		line := lines[i]
+		for j := 0; j < indent && strings.HasPrefix(line, " "); j++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+
+	b.WriteString("<pre><code")
+	if lang != "" {
+		b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	b.WriteString(">")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+// renderList renders the list starting at lines[i] and returns the index of
+// the line after it. Item content is the lines indented past the marker.
+func renderList(b *strings.Builder, lines []string, i int) int {
+	first := listItem.FindStringSubmatch(lines[i])
+	ordered := first[2][0] >= '0' && first[2][0] <= '9'
+	delimiter := first[2][len(first[2])-1]
+
+	type item struct{ lines []string }
+	var items []item
+	tight := true
+	sawBlank := false
+
+	for i < len(lines) {
+		m := listItem.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		if isOrdered := m[2][0] >= '0' && m[2][0] <= '9'; isOrdered != ordered || m[2][len(m[2])-1] != delimiter {
+			break
+		}
+		if sawBlank {
+			tight = false
+		}
+		sawBlank = false
+
+		width := len(m[0])
+		if m[3] == "" || len(m[3]) > 4 {
+			width = len(m[1]) + len(m[2]) + 1
+		}
+		content := []string{strings.TrimLeft(lines[i][min(width, len(lines[i])):], " ")}
+		if len(m[3]) > 4 {
+			content[0] = lines[i][width:]
+		}
+
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				sawBlank = true
+				content = append(content, "")
+				continue
+			}
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			if indent >= width {
+				if sawBlank {
+					tight = false
+				}
+				sawBlank = false
+				content = append(content, line[width:])
+				continue
+			}
+			if !sawBlank && !startsBlock(line) {
+				// Lazy continuation of the item's paragraph.
+				content = append(content, strings.TrimLeft(line, " "))
+				continue
+			}
+			break
+		}
+		for len(content) > 0 && content[len(content)-1] == "" {
+			content = content[:len(content)-1]
+		}
+		items = append(items, item{content})
+
+		if i < len(lines) && !listItem.MatchString(lines[i]) {
+			break
+		}
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag)
+	if start, _ := strconv.Atoi(strings.TrimRight(first[2], ".)")); ordered && start != 1 {
+		b.WriteString(` start="` + strconv.Itoa(start) + `"`)
+	}
+	b.WriteString(">\n")
+	for _, it := range items {
+		b.WriteString("<li>")
+		var inner strings.Builder
+		renderBlocks(&inner, it.lines, tight)
+		b.WriteString(strings.TrimSuffix(inner.String(), "\n"))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func renderTable(b *strings.Builder, lines []string, i int) int {
+	header := tableCells(lines[i])
+	var aligns []string
+	for _, cell := range tableCells(lines[i+1]) {
+		switch left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":"); {
+		case left && right:
+			aligns = append(aligns, "center")
+		case right:
+			aligns = append(aligns, "right")
+		case left:
+			aligns = append(aligns, "left")
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+
+	row := func(cells []string, tag string) {
+		b.WriteString("<tr>")
+		for j := range header {
+			cell := ""
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			b.WriteString("<" + tag)
+			if j < len(aligns) && aligns[j] != "" {
+				b.WriteString(` style="text-align:` + aligns[j] + `"`)
+			}
+			b.WriteString(">" + inline(cell) + "</" + tag + ">")
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n<thead>\n")
+	row(header, "th")
+	b.WriteString("</thead>\n<tbody>\n")
+	for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+		row(tableCells(lines[i]), "td")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+// tableCells splits a table row on unescaped pipes.
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for j := 0; j < len(line); j++ {
+		switch {
+		case line[j] == '\\' && j+1 < len(line) && line[j+1] == '|':
+			cell.WriteByte('|')
+			j++
+		case line[j] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[j])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+var (
+	bareURL   = regexp.MustCompile(`https?://[^\s<>"]*[^\s<>".,:;!?'")\]]`)
+	autolink  = regexp.MustCompile(`^<((?:https?|mailto):[^\s<>]+|[^\s<>@]+@[^\s<>@]+\.[^\s<>@]+)>`)
+	punctuate = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+)
+
+// inline renders the inline Markdown of a block's text.
+func inline(s string) string {
+	var b strings.Builder
+	var text strings.Builder
+	flush := func() {
+		writeText(&b, text.String())
+		text.Reset()
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(punctuate, s[i+1]) >= 0:
+			flush()
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			flush()
+			b.WriteString("<br>\n")
+			i += 2
+			continue
+
+		case c == '\n':
+			t := strings.TrimRight(text.String(), " ")
+			hard := len(text.String())-len(t) >= 2
+			text.Reset()
+			text.WriteString(t)
+			flush()
+			if hard {
+				b.WriteString("<br>\n")
+			} else {
+				b.WriteString("\n")
+			}
+			i++
+			continue
+
+		case c == '`':
+			run := countRun(s[i:], '`')
+			if end := strings.Index(s[i+run:], strings.Repeat("`", run)); end >= 0 {
+				flush()
+				code := strings.ReplaceAll(s[i+run:i+run+end], "\n", " ")
+				if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += run + end + run
+				continue
+			}
+			text.WriteString(s[i : i+run])
+			i += run
+			continue
+
+		case c == '<':
+			if m := autolink.FindStringSubmatch(s[i:]); m != nil {
+				flush()
+				href := m[1]
+				if !strings.Contains(href, ":") {
+					href = "mailto:" + href
+				}
+				b.WriteString(`<a href="` + html.EscapeString(safeURL(href)) + `">` + html.EscapeString(m[1]) + "</a>")
+				i += len(m[0])
+				continue
+			}
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if label, dest, title, n, ok := parseLink(s[i+1:]); ok {
+				flush()
+				b.WriteString(`<img src="` + html.EscapeString(safeURL(dest)) + `" alt="` + html.EscapeString(plainInline(label)) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">")
+				i += 1 + n
+				continue
+			}
+
+		case c == '[':
+			if label, dest, title, n, ok := parseLink(s[i:]); ok {
+				flush()
+				b.WriteString(`<a href="` + html.EscapeString(safeURL(dest)) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">" + inlineNoLinks(label) + "</a>")
+				i += n
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			if tag, inner, n, ok := parseEmphasis(s, i); ok {
+				flush()
+				b.WriteString("<" + tag + ">" + inline(inner) + "</" + tag + ">")
+				i += n
+				continue
+			}
+			run := countRun(s[i:], c)
+			text.WriteString(s[i : i+run])
+			i += run
+			continue
+		}
+
+		text.WriteByte(c)
+		i++
+	}
+	flush()
+	return b.String()
+}
+
+// inlineNoLinks renders link text, where bare URLs are not linked again.
+func inlineNoLinks(s string) string {
+	out := inline(s)
+	return stripAnchors(out)
+}
+
+// stripAnchors removes <a> tags added around bare URLs in link text.
+func stripAnchors(s string) string {
+	doc := parse(s)
+	doc.walk(func(n *node) {
+		for i := 0; i < len(n.children); i++ {
+			c := n.children[i]
+			if c.typ == elementNode && c.tag == "a" {
+				n.children = append(n.children[:i], append(c.children, n.children[i+1:]...)...)
+				for _, gc := range c.children {
+					gc.parent = n
+				}
+				i--
+			}
+		}
+	})
+	return render(doc)
+}
+
+// plainInline is the text of inline Markdown, for alt attributes.
+func plainInline(s string) string {
+	return parse(inline(s)).text()
+}
+
+// writeText escapes text and links the bare URLs in it.
+func writeText(b *strings.Builder, text string) {
+	last := 0
+	for _, m := range bareURL.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:m[0]]))
+		u := text[m[0]:m[1]]
+		b.WriteString(`<a href="` + html.EscapeString(u) + `">` + html.EscapeString(u) + "</a>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+}
+
+// parseLink parses "[label](dest "title")" at the start of s and returns its
+// parts and length.
+func parseLink(s string) (label, dest, title string, n int, ok bool) {
+	depth := 0
+	end := -1
+	for i := 0; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return "", "", "", 0, false
+	}
+	label = s[1:end]
+
+	rest := s[end+2:]
+	close := -1
+	depth = 1
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth == 0 {
+			close = i
+			break
+		}
+	}
+	if close < 0 {
+		return "", "", "", 0, false
+	}
+
+	inner := strings.TrimSpace(rest[:close])
+	if strings.HasPrefix(inner, "<") {
+		if gt := strings.IndexByte(inner, '>'); gt > 0 {
+			dest, inner = inner[1:gt], strings.TrimSpace(inner[gt+1:])
+		}
+	} else {
+		dest, inner, _ = strings.Cut(inner, " ")
+		inner = strings.TrimSpace(inner)
+	}
+	if len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0] {
+		title = inner[1 : len(inner)-1]
+	} else if inner != "" {
+		return "", "", "", 0, false
+	}
+	return label, dest, title, end + 2 + close + 1, true
+}
+
+// parseEmphasis parses emphasis opening at s[i]: "*em*", "**strong**",
+// "_em_", "__strong__" or "~~del~~". Underscores only open and close at
+// word boundaries, so snake_case is left alone.
+func parseEmphasis(s string, i int) (tag, inner string, n int, ok bool) {
+	c := s[i]
+	run := countRun(s[i:], c)
+	if c == '~' && run != 2 {
+		return "", "", 0, false
+	}
+	width := min(run, 2)
+	if c == '~' {
+		tag = "del"
+	} else if width == 2 {
+		tag = "strong"
+	} else {
+		tag = "em"
+	}
+
+	open := i + width
+	if open >= len(s) || isWhitespace(s[open]) {
+		return "", "", 0, false
+	}
+	if c == '_' && i > 0 && isWordChar(s[i-1]) {
+		return "", "", 0, false
+	}
+	if run == 3 && c != '~' {
+		// "***both***" is strong emphasis inside emphasis.
+		triple := strings.Repeat(string(c), 3)
+		end := strings.Index(s[i+3:], triple)
+		if end > 0 && !isWhitespace(s[i+3+end-1]) && (c != '_' || i+6+end >= len(s) || !isWordChar(s[i+6+end])) {
+			return "em", s[i+1 : i+5+end], end + 6, true
+		}
+	}
+
+	delim := strings.Repeat(string(c), width)
+	for j := open; j < len(s); j++ {
+		if s[j] == '`' {
+			if end := strings.IndexByte(s[j+1:], '`'); end >= 0 {
+				j += end + 1
+				continue
+			}
+		}
+		if !strings.HasPrefix(s[j:], delim) || isWhitespace(s[j-1]) || j == open {
+			continue
+		}
+		after := j + width
+		if width == 1 && after < len(s) && s[after] == c {
+			// Part of a longer run: "*a **b** c*".
+			j += countRun(s[j:], c) - 1
+			continue
+		}
+		if c == '_' && after < len(s) && isWordChar(s[after]) {
+			continue
+		}
+		return tag, s[open:j], after - i, true
+	}
+	return "", "", 0, false
+}
+
+func countRun(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t'
+}
+
+func isWordChar(c byte) bool {
+	return isLetter(c) || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// safeURL drops URLs with schemes that run code in a client.
+func safeURL(u string) string {
+	u = strings.TrimSpace(u)
+	scheme, _, found := strings.Cut(u, ":")
+	if !found || strings.ContainsAny(scheme, "/?#") {
+		return u
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "mailto", "tel", "cid":
+		return u
+	default:
+		return "#"
+	}
+}
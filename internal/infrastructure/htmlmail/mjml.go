@@ -0,0 +1,703 @@
+package htmlmail
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mjmlDefaults are the MJML default attributes of the supported components.
+var mjmlDefaults = map[string]map[string]string{
+	"mj-body": {
+		"width": "600px",
+	},
+	"mj-wrapper": {
+		"direction":  "ltr",
+		"padding":    "20px 0",
+		"text-align": "center",
+	},
+	"mj-section": {
+		"background-position": "top center",
+		"background-repeat":   "repeat",
+		"background-size":     "auto",
+		"direction":           "ltr",
+		"padding":             "20px 0",
+		"text-align":          "center",
+	},
+	"mj-column": {
+		"direction":      "ltr",
+		"vertical-align": "top",
+	},
+	"mj-text": {
+		"align":       "left",
+		"color":       "#000000",
+		"font-family": "Ubuntu, Helvetica, Arial, sans-serif",
+		"font-size":   "13px",
+		"line-height": "1",
+		"padding":     "10px 25px",
+	},
+	"mj-button": {
+		"align":            "center",
+		"background-color": "#414141",
+		"border":           "none",
+		"border-radius":    "3px",
+		"color":            "#ffffff",
+		"font-family":      "Ubuntu, Helvetica, Arial, sans-serif",
+		"font-size":        "13px",
+		"font-weight":      "normal",
+		"inner-padding":    "10px 25px",
+		"line-height":      "120%",
+		"padding":          "10px 25px",
+		"target":           "_blank",
+		"text-decoration":  "none",
+		"text-transform":   "none",
+		"vertical-align":   "middle",
+	},
+	"mj-image": {
+		"align":     "center",
+		"border":    "0",
+		"font-size": "13px",
+		"height":    "auto",
+		"padding":   "10px 25px",
+		"target":    "_blank",
+	},
+	"mj-divider": {
+		"align":        "center",
+		"border-color": "#000000",
+		"border-style": "solid",
+		"border-width": "4px",
+		"padding":      "10px 25px",
+		"width":        "100%",
+	},
+	"mj-spacer": {
+		"height": "20px",
+	},
+	"mj-table": {
+		"align":        "left",
+		"border":       "none",
+		"cellpadding":  "0",
+		"cellspacing":  "0",
+		"color":        "#000000",
+		"font-family":  "Ubuntu, Helvetica, Arial, sans-serif",
+		"font-size":    "13px",
+		"line-height":  "22px",
+		"padding":      "10px 25px",
+		"table-layout": "auto",
+		"width":        "100%",
+	},
+}
+
+// mjmlBaseCSS resets client styles, as the MJML compiler does.
+const mjmlBaseCSS = `#outlook a { padding:0; }
+body { margin:0;padding:0;-webkit-text-size-adjust:100%;-ms-text-size-adjust:100%; }
+table, td { border-collapse:collapse;mso-table-lspace:0pt;mso-table-rspace:0pt; }
+img { border:0;height:auto;line-height:100%;outline:none;text-decoration:none;-ms-interpolation-mode:bicubic; }
+p { display:block;margin:13px 0; }`
+
+// mjmlRenderer renders one MJML document.
+type mjmlRenderer struct {
+	// defaults holds mj-attributes by tag, including mj-all.
+	defaults map[string]map[string]string
+	// classes holds the mj-class definitions of mj-attributes.
+	classes    map[string]map[string]string
+	breakpoint string
+	// columns holds the responsive width of each column class.
+	columns map[string]string
+	b       strings.Builder
+}
+
+// mjml renders an MJML document to HTML. It supports mj-head with mj-title,
+// mj-preview, mj-style, mj-attributes, mj-font and mj-breakpoint, and in
+// mj-body the mj-wrapper, mj-section and mj-column layout with mj-text,
+// mj-button, mj-image, mj-divider, mj-spacer, mj-table and mj-raw content.
+// Other components are an error. title and lang are used when the
+// document has no mj-title or lang attribute.
+func mjml(src, title, lang string) (string, error) {
+	root := parse(src).find("mjml")
+	if root == nil {
+		return "", fmt.Errorf("mjml: no <mjml> root element")
+	}
+	body := root.find("mj-body")
+	if body == nil {
+		return "", fmt.Errorf("mjml: no <mj-body> element")
+	}
+
+	r := &mjmlRenderer{
+		defaults:   map[string]map[string]string{},
+		classes:    map[string]map[string]string{},
+		breakpoint: "480px",
+		columns:    map[string]string{},
+	}
+	lang = root.attrOr("lang", lang)
+
+	var preview string
+	var inlineStyles, styles, fonts []string
+	if head := root.find("mj-head"); head != nil {
+		for _, c := range head.children {
+			if c.typ != elementNode {
+				continue
+			}
+			switch c.tag {
+			case "mj-title":
+				title = strings.TrimSpace(c.text())
+			case "mj-preview":
+				preview = strings.TrimSpace(c.text())
+			case "mj-style":
+				if c.attrOr("inline", "") == "inline" {
+					inlineStyles = append(inlineStyles, c.text())
+				} else {
+					styles = append(styles, c.text())
+				}
+			case "mj-attributes":
+				r.readAttributes(c)
+			case "mj-font":
+				if href := c.attrOr("href", ""); href != "" {
+					fonts = append(fonts, href)
+				}
+			case "mj-breakpoint":
+				r.breakpoint = c.attrOr("width", r.breakpoint)
+			default:
+				return "", fmt.Errorf("mjml: <%s> is not supported in <mj-head>", c.tag)
+			}
+		}
+	}
+
+	width := pixels(r.get(body, "width"))
+	background := r.get(body, "background-color")
+
+	if preview != "" {
+		r.b.WriteString(`<div style="display:none;font-size:1px;color:#ffffff;line-height:1px;max-height:0px;max-width:0px;opacity:0;overflow:hidden;">` +
+			html.EscapeString(preview) + "</div>")
+	}
+	r.b.WriteString("<div" + attrs("class", r.get(body, "css-class"), "style", inlineStyle("background-color", background), "lang", lang) + ">")
+	for _, c := range body.children {
+		if err := r.bodyChild(c, width); err != nil {
+			return "", err
+		}
+	}
+	r.b.WriteString("</div>")
+
+	var doc strings.Builder
+	doc.WriteString("<!doctype html>\n")
+	doc.WriteString(`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office"` + attrs("lang", lang) + ">\n<head>\n")
+	doc.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	doc.WriteString(`<!--[if !mso]><!--><meta http-equiv="X-UA-Compatible" content="IE=edge"><!--<![endif]-->` + "\n")
+	doc.WriteString(`<meta http-equiv="Content-Type" content="text/html; charset=UTF-8">` + "\n")
+	doc.WriteString(`<meta name="viewport" content="width=device-width, initial-scale=1">` + "\n")
+	doc.WriteString("<style type=\"text/css\" data-embed>\n" + mjmlBaseCSS + "\n</style>\n")
+	doc.WriteString("<!--[if mso]><noscript><xml><o:OfficeDocumentSettings><o:AllowPNG/><o:PixelsPerInch>96</o:PixelsPerInch></o:OfficeDocumentSettings></xml></noscript><![endif]-->\n")
+	if len(fonts) > 0 {
+		doc.WriteString("<!--[if !mso]><!-->")
+		for _, href := range fonts {
+			doc.WriteString(`<link href="` + html.EscapeString(href) + `" rel="stylesheet" type="text/css">`)
+		}
+		doc.WriteString(`<style type="text/css" data-embed>`)
+		for _, href := range fonts {
+			doc.WriteString("@import url(" + href + ");")
+		}
+		doc.WriteString("</style><!--<![endif]-->\n")
+	}
+	if len(r.columns) > 0 {
+		doc.WriteString("<style type=\"text/css\" data-embed>\n@media only screen and (min-width:" + r.breakpoint + ") {\n")
+		classes := make([]string, 0, len(r.columns))
+		for class := range r.columns {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			doc.WriteString("." + class + " { width:" + r.columns[class] + " !important; max-width:" + r.columns[class] + "; }\n")
+		}
+		doc.WriteString("}\n</style>\n")
+	}
+	if len(styles) > 0 {
+		doc.WriteString("<style type=\"text/css\" data-embed>\n" + strings.Join(styles, "\n") + "\n</style>\n")
+	}
+	if len(inlineStyles) > 0 {
+		doc.WriteString("<style type=\"text/css\">\n" + strings.Join(inlineStyles, "\n") + "\n</style>\n")
+	}
+	doc.WriteString("</head>\n")
+	doc.WriteString(`<body style="word-spacing:normal;` + html.EscapeString(inlineStyle("background-color", background)) + `">`)
+	doc.WriteString(r.b.String())
+	doc.WriteString("</body>\n</html>\n")
+
+	tree := parse(doc.String())
+	inlineCSS(tree)
+	return render(tree), nil
+}
+
+// readAttributes records the defaults of an mj-attributes element.
+func (r *mjmlRenderer) readAttributes(n *node) {
+	for _, c := range n.children {
+		if c.typ != elementNode {
+			continue
+		}
+		target := r.defaults
+		key := c.tag
+		if c.tag == "mj-class" {
+			target, key = r.classes, c.attrOr("name", "")
+		}
+		if target[key] == nil {
+			target[key] = map[string]string{}
+		}
+		for _, a := range c.attrs {
+			if c.tag != "mj-class" || a.key != "name" {
+				target[key][a.key] = a.val
+			}
+		}
+	}
+}
+
+// get returns the attribute of n, looking in turn at the element, its
+// mj-class, the mj-attributes for its tag and mj-all, and the MJML
+// defaults.
+func (r *mjmlRenderer) get(n *node, key string) string {
+	if v, ok := n.attr(key); ok {
+		return v
+	}
+	classes := strings.Fields(n.attrOr("mj-class", ""))
+	for i := len(classes) - 1; i >= 0; i-- {
+		if v, ok := r.classes[classes[i]][key]; ok {
+			return v
+		}
+	}
+	if v, ok := r.defaults[n.tag][key]; ok {
+		return v
+	}
+	if v, ok := r.defaults["mj-all"][key]; ok {
+		return v
+	}
+	return mjmlDefaults[n.tag][key]
+}
+
+// padding returns n's padding and its left and right widths in pixels.
+func (r *mjmlRenderer) padding(n *node, key string) (css string, left, right float64) {
+	css = r.get(n, key)
+	fields := strings.Fields(css)
+	switch len(fields) {
+	case 1:
+		left, right = pixels(fields[0]), pixels(fields[0])
+	case 2, 3:
+		left, right = pixels(fields[1]), pixels(fields[1])
+	case 4:
+		left, right = pixels(fields[3]), pixels(fields[1])
+	}
+	if key == "padding" {
+		if v := r.get(n, "padding-left"); v != "" {
+			left = pixels(v)
+		}
+		if v := r.get(n, "padding-right"); v != "" {
+			right = pixels(v)
+		}
+	}
+	return css, left, right
+}
+
+func (r *mjmlRenderer) paddingStyle(n *node) string {
+	css, _, _ := r.padding(n, "padding")
+	return inlineStyle(
+		"padding", css,
+		"padding-top", r.get(n, "padding-top"),
+		"padding-right", r.get(n, "padding-right"),
+		"padding-bottom", r.get(n, "padding-bottom"),
+		"padding-left", r.get(n, "padding-left"),
+	)
+}
+
+func (r *mjmlRenderer) bodyChild(n *node, width float64) error {
+	switch {
+	case n.typ != elementNode:
+		return nil
+	case n.tag == "mj-section":
+		return r.section(n, width, nil)
+	case n.tag == "mj-wrapper":
+		return r.section(n, width, func(inner float64) error {
+			for _, c := range n.children {
+				if c.typ != elementNode {
+					continue
+				}
+				if c.tag != "mj-section" && c.tag != "mj-raw" {
+					return fmt.Errorf("mjml: <%s> is not supported in <mj-wrapper>", c.tag)
+				}
+				r.b.WriteString("<tr><td>")
+				if err := r.bodyChild(c, inner); err != nil {
+					return err
+				}
+				r.b.WriteString("</td></tr>")
+			}
+			return nil
+		})
+	case n.tag == "mj-raw":
+		r.b.WriteString(render(n))
+		return nil
+	default:
+		return fmt.Errorf("mjml: <%s> is not supported in <mj-body>", n.tag)
+	}
+}
+
+// section renders an mj-section, or an mj-wrapper when content is set.
+func (r *mjmlRenderer) section(n *node, width float64, content func(inner float64) error) error {
+	padding, left, right := r.padding(n, "padding")
+	inner := width - left - right
+	widthPx := formatNumber(width)
+
+	background := r.get(n, "background-color")
+	backgroundURL := r.get(n, "background-url")
+	if backgroundURL != "" {
+		background = strings.TrimSpace(fmt.Sprintf("%s url('%s') %s / %s %s", background, backgroundURL,
+			r.get(n, "background-position"), r.get(n, "background-size"), r.get(n, "background-repeat")))
+	}
+	fullWidth := r.get(n, "full-width") == "full-width"
+	backgroundStyle := inlineStyle("background", background, "background-color", r.get(n, "background-color"))
+	borderStyle := inlineStyle("border", r.get(n, "border"), "border-radius", r.get(n, "border-radius"))
+
+	if fullWidth {
+		r.b.WriteString(`<table align="center" border="0" cellpadding="0" cellspacing="0" role="presentation"` +
+			attrs("class", r.get(n, "css-class"), "background", backgroundURL, "style", backgroundStyle+"width:100%;") + "><tbody><tr><td>")
+		backgroundStyle, backgroundURL = "", ""
+	}
+	r.b.WriteString(`<!--[if mso | IE]><table align="center" border="0" cellpadding="0" cellspacing="0" style="width:` + widthPx + `px;" width="` + widthPx + `"><tr><td style="line-height:0px;font-size:0px;mso-line-height-rule:exactly;"><![endif]-->`)
+	class := ""
+	if !fullWidth {
+		class = r.get(n, "css-class")
+	}
+	r.b.WriteString("<div" + attrs("class", class, "style", "margin:0px auto;max-width:"+widthPx+"px;"+backgroundStyle+inlineStyle("border-radius", r.get(n, "border-radius"))) + ">")
+	r.b.WriteString(`<table align="center" border="0" cellpadding="0" cellspacing="0" role="presentation"` +
+		attrs("background", backgroundURL, "style", backgroundStyle+"width:100%;"+inlineStyle("border-radius", r.get(n, "border-radius"))) + "><tbody><tr>")
+	r.b.WriteString("<td" + attrs("style", borderStyle+inlineStyle(
+		"direction", r.get(n, "direction"),
+		"font-size", "0px",
+		"padding", padding,
+		"padding-top", r.get(n, "padding-top"),
+		"padding-right", r.get(n, "padding-right"),
+		"padding-bottom", r.get(n, "padding-bottom"),
+		"padding-left", r.get(n, "padding-left"),
+		"text-align", r.get(n, "text-align"),
+	)) + ">")
+
+	if content != nil {
+		r.b.WriteString(`<table border="0" cellpadding="0" cellspacing="0" role="presentation" width="100%"><tbody>`)
+		if err := content(inner); err != nil {
+			return err
+		}
+		r.b.WriteString("</tbody></table>")
+	} else if err := r.columnsOf(n, inner); err != nil {
+		return err
+	}
+
+	r.b.WriteString("</td></tr></tbody></table></div>")
+	r.b.WriteString(`<!--[if mso | IE]></td></tr></table><![endif]-->`)
+	if fullWidth {
+		r.b.WriteString("</td></tr></tbody></table>")
+	}
+	return nil
+}
+
+// columnsOf renders the columns of a section. Columns without a width
+// share the section equally with all of its columns, as in MJML.
+func (r *mjmlRenderer) columnsOf(section *node, width float64) error {
+	var columns []*node
+	for _, c := range section.children {
+		switch {
+		case c.typ != elementNode:
+		case c.tag == "mj-column", c.tag == "mj-raw":
+			columns = append(columns, c)
+		default:
+			return fmt.Errorf("mjml: <%s> is not supported in <mj-section>", c.tag)
+		}
+	}
+
+	var count int
+	for _, c := range columns {
+		if c.tag == "mj-column" {
+			count++
+		}
+	}
+
+	r.b.WriteString(`<!--[if mso | IE]><table role="presentation" border="0" cellpadding="0" cellspacing="0"><tr><![endif]-->`)
+	for _, c := range columns {
+		if c.tag == "mj-raw" {
+			r.b.WriteString(render(c))
+			continue
+		}
+		w := r.get(c, "width")
+		if w == "" {
+			w = formatNumber(100/float64(count)) + "%"
+		}
+		if err := r.column(c, w, width); err != nil {
+			return err
+		}
+	}
+	r.b.WriteString(`<!--[if mso | IE]></tr></table><![endif]-->`)
+	return nil
+}
+
+// column renders an mj-column of the given CSS width in a section box of
+// width pixels.
+func (r *mjmlRenderer) column(n *node, width string, box float64) error {
+	var px float64
+	var class string
+	if pct, ok := strings.CutSuffix(width, "%"); ok {
+		p, _ := strconv.ParseFloat(pct, 64)
+		px = box * p / 100
+		class = "mj-column-per-" + strings.ReplaceAll(formatNumber(p), ".", "-")
+	} else {
+		px = pixels(width)
+		class = "mj-column-px-" + strings.ReplaceAll(formatNumber(px), ".", "-")
+		width = formatNumber(px) + "px"
+	}
+	r.columns[class] = width
+
+	padding, left, right := r.padding(n, "padding")
+	inner := px - left - right
+	verticalAlign := r.get(n, "vertical-align")
+
+	r.b.WriteString(`<!--[if mso | IE]><td style="vertical-align:` + html.EscapeString(verticalAlign) + `;width:` + formatNumber(px) + `px;"><![endif]-->`)
+	r.b.WriteString("<div" + attrs(
+		"class", strings.TrimSpace(class+" mj-outlook-group-fix "+r.get(n, "css-class")),
+		"style", inlineStyle("font-size", "0px", "text-align", "left", "direction", r.get(n, "direction"),
+			"display", "inline-block", "vertical-align", verticalAlign, "width", "100%"),
+	) + ">")
+
+	columnStyle := inlineStyle(
+		"background-color", r.get(n, "background-color"),
+		"border", r.get(n, "border"),
+		"border-radius", r.get(n, "border-radius"),
+		"vertical-align", verticalAlign,
+	)
+	if padding != "" {
+		r.b.WriteString(`<table border="0" cellpadding="0" cellspacing="0" role="presentation" width="100%"><tbody><tr>`)
+		r.b.WriteString("<td" + attrs("style", columnStyle+r.paddingStyle(n)) + ">")
+		columnStyle = ""
+	}
+	r.b.WriteString(`<table border="0" cellpadding="0" cellspacing="0" role="presentation"` + attrs("style", columnStyle) + ` width="100%"><tbody>`)
+	for _, c := range n.children {
+		if err := r.content(c, inner); err != nil {
+			return err
+		}
+	}
+	r.b.WriteString("</tbody></table>")
+	if padding != "" {
+		r.b.WriteString("</td></tr></tbody></table>")
+	}
+	r.b.WriteString("</div>")
+	r.b.WriteString(`<!--[if mso | IE]></td><![endif]-->`)
+	return nil
+}
+
+// content renders a content component of a column width pixels wide.
+func (r *mjmlRenderer) content(n *node, width float64) error {
+	if n.typ != elementNode {
+		return nil
+	}
+	if n.tag == "mj-raw" {
+		r.b.WriteString(render(n))
+		return nil
+	}
+
+	_, left, right := r.padding(n, "padding")
+	inner := width - left - right
+
+	var component strings.Builder
+	switch n.tag {
+	case "mj-text":
+		component.WriteString("<div" + attrs("style", inlineStyle(
+			"font-family", r.get(n, "font-family"),
+			"font-size", r.get(n, "font-size"),
+			"font-style", r.get(n, "font-style"),
+			"font-weight", r.get(n, "font-weight"),
+			"letter-spacing", r.get(n, "letter-spacing"),
+			"line-height", r.get(n, "line-height"),
+			"text-align", r.get(n, "align"),
+			"text-decoration", r.get(n, "text-decoration"),
+			"text-transform", r.get(n, "text-transform"),
+			"color", r.get(n, "color"),
+			"height", r.get(n, "height"),
+		)) + ">" + render(n) + "</div>")
+
+	case "mj-button":
+		background := r.get(n, "background-color")
+		innerPadding := r.get(n, "inner-padding")
+		tag := "p"
+		if r.get(n, "href") != "" {
+			tag = "a"
+		}
+		component.WriteString(`<table border="0" cellpadding="0" cellspacing="0" role="presentation"` + attrs("style", inlineStyle(
+			"border-collapse", "separate",
+			"width", r.get(n, "width"),
+			"line-height", "100%",
+		)) + "><tbody><tr>")
+		component.WriteString(`<td align="center"` + attrs("bgcolor", background) + ` role="presentation"` + attrs("style", inlineStyle(
+			"border", r.get(n, "border"),
+			"border-radius", r.get(n, "border-radius"),
+			"cursor", "auto",
+			"font-style", r.get(n, "font-style"),
+			"height", r.get(n, "height"),
+			"mso-padding-alt", innerPadding,
+			"text-align", r.get(n, "text-align"),
+			"background", background,
+		)) + attrs("valign", r.get(n, "vertical-align")) + ">")
+		component.WriteString("<" + tag + attrs("href", safeURL(r.get(n, "href")), "rel", r.get(n, "rel"), "title", r.get(n, "title"), "style", inlineStyle(
+			"display", "inline-block",
+			"width", buttonWidth(r.get(n, "width"), innerPadding),
+			"background", background,
+			"color", r.get(n, "color"),
+			"font-family", r.get(n, "font-family"),
+			"font-size", r.get(n, "font-size"),
+			"font-style", r.get(n, "font-style"),
+			"font-weight", r.get(n, "font-weight"),
+			"line-height", r.get(n, "line-height"),
+			"letter-spacing", r.get(n, "letter-spacing"),
+			"margin", "0",
+			"text-decoration", r.get(n, "text-decoration"),
+			"text-transform", r.get(n, "text-transform"),
+			"padding", innerPadding,
+			"mso-padding-alt", "0px",
+			"border-radius", r.get(n, "border-radius"),
+		)))
+		if tag == "a" {
+			component.WriteString(attrs("target", r.get(n, "target")))
+		}
+		component.WriteString(">" + render(n) + "</" + tag + ">")
+		component.WriteString("</td></tr></tbody></table>")
+
+	case "mj-image":
+		width := inner
+		if w := r.get(n, "width"); w != "" {
+			width = math.Min(width, pixels(w))
+		}
+		fullWidth := r.get(n, "fluid-on-mobile") == "true"
+		img := "<img" + attrs(
+			"alt", r.get(n, "alt"),
+			"height", strings.TrimSuffix(r.get(n, "height"), "px"),
+			"src", safeURL(r.get(n, "src")),
+			"title", r.get(n, "title"),
+			"style", inlineStyle(
+				"border", r.get(n, "border"),
+				"border-radius", r.get(n, "border-radius"),
+				"display", "block",
+				"outline", "none",
+				"text-decoration", "none",
+				"height", r.get(n, "height"),
+				"width", "100%",
+				"font-size", r.get(n, "font-size"),
+			),
+			"width", formatNumber(width),
+		) + ">"
+		if href := r.get(n, "href"); href != "" {
+			img = "<a" + attrs("href", safeURL(href), "target", r.get(n, "target"), "rel", r.get(n, "rel"), "title", r.get(n, "title")) + ">" + img + "</a>"
+		}
+		tableWidth := ""
+		if fullWidth {
+			tableWidth = "100%"
+		}
+		component.WriteString(`<table border="0" cellpadding="0" cellspacing="0" role="presentation"` + attrs("style", inlineStyle(
+			"border-collapse", "collapse",
+			"border-spacing", "0px",
+			"width", tableWidth,
+		)) + "><tbody><tr><td" + attrs("style", "width:"+formatNumber(width)+"px;") + ">" + img + "</td></tr></tbody></table>")
+
+	case "mj-divider":
+		margin := "0px auto"
+		switch r.get(n, "align") {
+		case "left":
+			margin = "0px"
+		case "right":
+			margin = "0px 0px 0px auto"
+		}
+		component.WriteString("<p" + attrs("style", inlineStyle(
+			"border-top", r.get(n, "border-style")+" "+r.get(n, "border-width")+" "+r.get(n, "border-color"),
+			"font-size", "1px",
+			"margin", margin,
+			"width", r.get(n, "width"),
+		)) + "></p>")
+
+	case "mj-spacer":
+		height := r.get(n, "height")
+		component.WriteString("<div" + attrs("style", inlineStyle("height", height, "line-height", height)) + ">&#8202;</div>")
+
+	case "mj-table":
+		component.WriteString("<table" + attrs(
+			"cellpadding", r.get(n, "cellpadding"),
+			"cellspacing", r.get(n, "cellspacing"),
+			"width", strings.TrimSuffix(r.get(n, "width"), "px"),
+			"border", "0",
+			"style", inlineStyle(
+				"color", r.get(n, "color"),
+				"font-family", r.get(n, "font-family"),
+				"font-size", r.get(n, "font-size"),
+				"line-height", r.get(n, "line-height"),
+				"table-layout", r.get(n, "table-layout"),
+				"width", r.get(n, "width"),
+				"border", r.get(n, "border"),
+			),
+		) + ">" + render(n) + "</table>")
+
+	default:
+		return fmt.Errorf("mjml: <%s> is not supported in <mj-column>", n.tag)
+	}
+
+	r.b.WriteString("<tr><td" + attrs(
+		"align", r.get(n, "align"),
+		"class", r.get(n, "css-class"),
+		"style", inlineStyle("background", r.get(n, "container-background-color"), "font-size", "0px")+
+			r.paddingStyle(n)+"word-break:break-word;",
+	) + ">")
+	r.b.WriteString(component.String())
+	r.b.WriteString("</td></tr>")
+	return nil
+}
+
+// buttonWidth is the width of a button's link: its width without the inner
+// padding, as the padding is inside the link.
+func buttonWidth(width, innerPadding string) string {
+	if width == "" || !strings.HasSuffix(width, "px") {
+		return ""
+	}
+	fields := strings.Fields(innerPadding)
+	var left, right float64
+	switch len(fields) {
+	case 1:
+		left, right = pixels(fields[0]), pixels(fields[0])
+	case 2, 3:
+		left, right = pixels(fields[1]), pixels(fields[1])
+	case 4:
+		left, right = pixels(fields[3]), pixels(fields[1])
+	}
+	return formatNumber(pixels(width)-left-right) + "px"
+}
+
+// attrs renders key/value pairs as attributes, leaving out empty values.
+func attrs(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			b.WriteString(" " + pairs[i] + `="` + html.EscapeString(pairs[i+1]) + `"`)
+		}
+	}
+	return b.String()
+}
+
+// inlineStyle renders property/value pairs as declarations, leaving out empty
+// values.
+func inlineStyle(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if strings.TrimSpace(pairs[i+1]) != "" {
+			b.WriteString(pairs[i] + ":" + pairs[i+1] + ";")
+		}
+	}
+	return b.String()
+}
+
+// pixels parses a CSS length such as "600px" as pixels.
+func pixels(v string) float64 {
+	n, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "px"), 64)
+	return n
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(math.Round(n*10000)/10000, 'f', -1, 64)
+}
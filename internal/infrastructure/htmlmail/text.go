@@ -0,0 +1,228 @@
+package htmlmail
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// blockElements start on a new line in plain text.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"center": true, "dd": true, "div": true, "dl": true, "dt": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "tbody": true,
+	"thead": true, "tfoot": true, "tr": true, "ul": true,
+}
+
+// skippedElements have no readable text.
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "title": true, "template": true,
+}
+
+var (
+	spaceRun   = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	hiddenCSS  = regexp.MustCompile(`(?i)display\s*:\s*none|mso-hide\s*:\s*all`)
+)
+
+// PlainText derives a readable plain-text alternative from an HTML body:
+// paragraphs and headings separated by blank lines, list items marked,
+// links followed by their URL and images replaced by their alt text.
+// Hidden elements, such as preview text, are left out.
+func PlainText(body string) string {
+	w := &textWriter{}
+	w.node(parse(body))
+	lines := strings.Split(w.b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	text := blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// textWriter accumulates plain text, tracking the lines it ends.
+type textWriter struct {
+	b strings.Builder
+	// pre is the depth of <pre> elements, inside which whitespace is kept.
+	pre int
+	// quote is the depth of <blockquote> elements.
+	quote int
+	// lists is the depth of <ul> and <ol> elements.
+	lists int
+}
+
+func (w *textWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	if w.quote > 0 && w.atLineStart() {
+		w.b.WriteString(strings.Repeat("> ", w.quote))
+	}
+	w.b.WriteString(s)
+}
+
+func (w *textWriter) atLineStart() bool {
+	s := w.b.String()
+	return s == "" || strings.HasSuffix(s, "\n")
+}
+
+// newline ends the current line, unless it is already ended.
+func (w *textWriter) newline() {
+	if !w.atLineStart() {
+		w.b.WriteString("\n")
+	}
+}
+
+// blankLine ends the current paragraph.
+func (w *textWriter) blankLine() {
+	w.newline()
+	if s := w.b.String(); s != "" && !strings.HasSuffix(s, "\n\n") {
+		w.b.WriteString("\n")
+	}
+}
+
+func (w *textWriter) node(n *node) {
+	switch n.typ {
+	case textNode:
+		text := html.UnescapeString(n.data)
+		if w.pre == 0 {
+			text = spaceRun.ReplaceAllString(text, " ")
+			if w.atLineStart() || strings.HasSuffix(w.b.String(), " ") {
+				text = strings.TrimLeft(text, " ")
+			}
+		}
+		w.write(text)
+		return
+	case documentNode:
+		w.children(n)
+		return
+	case elementNode:
+	default:
+		return
+	}
+
+	if skippedElements[n.tag] {
+		return
+	}
+	if style, _ := n.attr("style"); hiddenCSS.MatchString(style) {
+		return
+	}
+
+	switch n.tag {
+	case "br":
+		w.trimTrailingSpace()
+		w.b.WriteString("\n")
+	case "hr":
+		w.blankLine()
+		w.write("---")
+		w.blankLine()
+	case "img":
+		if alt, _ := n.attr("alt"); alt != "" {
+			w.write(alt)
+		}
+	case "a":
+		w.link(n)
+	case "h1", "h2", "h3", "h4", "h5", "h6", "p", "table":
+		w.blankLine()
+		w.children(n)
+		w.blankLine()
+	case "pre":
+		w.blankLine()
+		w.pre++
+		w.children(n)
+		w.pre--
+		w.blankLine()
+	case "blockquote":
+		w.blankLine()
+		w.quote++
+		w.children(n)
+		w.quote--
+		w.blankLine()
+	case "ul", "ol":
+		w.list(n)
+	case "td", "th":
+		if !w.atLineStart() {
+			w.write(" ")
+		}
+		w.children(n)
+	default:
+		if blockElements[n.tag] {
+			w.newline()
+			w.children(n)
+			w.newline()
+			return
+		}
+		w.children(n)
+	}
+}
+
+func (w *textWriter) children(n *node) {
+	for _, c := range n.children {
+		w.node(c)
+	}
+}
+
+// link writes the link text followed by its URL, unless they are the same
+// or the link is within the page.
+func (w *textWriter) link(n *node) {
+	start := w.b.Len()
+	w.children(n)
+	text := strings.TrimSpace(w.b.String()[start:])
+
+	href, _ := n.attr("href")
+	href = strings.TrimSpace(href)
+	switch {
+	case href == "", strings.HasPrefix(href, "#"):
+	case text == "":
+		w.write(href)
+	case text != href && text != strings.TrimPrefix(href, "mailto:"):
+		w.write(" (" + href + ")")
+	}
+}
+
+// list writes a list with each item on its own line. Nested lists are
+// indented and not separated from their item by a blank line.
+func (w *textWriter) list(n *node) {
+	if w.lists == 0 {
+		w.blankLine()
+	}
+	w.lists++
+	defer func() { w.lists-- }()
+
+	indent := strings.Repeat("  ", w.lists-1)
+	number := 1
+	if start, err := strconv.Atoi(n.attrOr("start", "1")); err == nil {
+		number = start
+	}
+	for _, item := range n.children {
+		if item.typ != elementNode || item.tag != "li" {
+			continue
+		}
+		w.newline()
+		if n.tag == "ol" {
+			w.write(indent + strconv.Itoa(number) + ". ")
+			number++
+		} else {
+			w.write(indent + "- ")
+		}
+		w.children(item)
+	}
+	if w.lists == 1 {
+		w.blankLine()
+	} else {
+		w.newline()
+	}
+}
+
+func (w *textWriter) trimTrailingSpace() {
+	s := w.b.String()
+	if trimmed := strings.TrimRight(s, " "); len(trimmed) != len(s) {
+		w.b.Reset()
+		w.b.WriteString(trimmed)
+	}
+}
@@ -0,0 +1,94 @@
+// Package idempotency provides implementations of port.IdempotencyStore.
+package idempotency
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+// sweepInterval is how often expired records are dropped.
+const sweepInterval = time.Minute
+
+var _ port.IdempotencyStore = (*MemoryStore)(nil)
+
+// MemoryStore is a port.IdempotencyStore held in memory. Records are lost on
+// restart and are not shared between gateway instances.
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]*port.IdempotencyRecord
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records:   make(map[string]*port.IdempotencyRecord),
+		lastSweep: time.Now(),
+	}
+}
+
+// Reserve stores the record unless an unexpired one with its key exists.
+func (s *MemoryStore) Reserve(ctx context.Context, record *port.IdempotencyRecord) (*port.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	if existing, ok := s.records[record.Key]; ok && now.Before(existing.ExpiresAt) {
+		return clone(existing), false, nil
+	}
+	s.records[record.Key] = clone(record)
+	return nil, true, nil
+}
+
+// Save replaces the record with the same key.
+func (s *MemoryStore) Save(ctx context.Context, record *port.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.Key] = clone(record)
+	return nil
+}
+
+// Delete removes the record with the key, if any.
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// Close releases nothing; the store stays usable.
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// clone copies the record and its result, so callers cannot change what is stored.
+func clone(record *port.IdempotencyRecord) *port.IdempotencyRecord {
+	c := *record
+	if record.Result != nil {
+		result := *record.Result
+		result.Meta = maps.Clone(record.Result.Meta)
+		result.Attempts = slices.Clone(record.Result.Attempts)
+		c.Result = &result
+	}
+	return &c
+}
@@ -0,0 +1,326 @@
+// Package ledger provides implementations of port.Ledger.
+package ledger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/atomicfile"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+const (
+	logName = "ledger.log"
+
+	// DefaultRetention is how long records are kept when Options.Retention is unset.
+	DefaultRetention = 7 * 24 * time.Hour
+	// DefaultMaxRecords caps the ledger when Options.MaxRecords is unset.
+	DefaultMaxRecords = 100_000
+	// DefaultListLimit is the number of records List returns when Limit is unset.
+	DefaultListLimit = 50
+)
+
+var _ port.Ledger = (*Store)(nil)
+
+// Options configures a Store. Zero fields use the defaults.
+type Options struct {
+	// Dir is where the ledger is persisted. Empty keeps it in memory only.
+	Dir string
+	// Retention is how long a record is kept after it is created.
+	Retention time.Duration
+	// MaxRecords is the most records kept; the oldest are dropped first.
+	MaxRecords int
+}
+
+// Store is a port.Ledger held in memory and, when a directory is given,
+// persisted to an append-only JSON-lines log that is replayed on open.
+// Appends are not fsynced, so a crash may lose the most recent updates.
+type Store struct {
+	path       string
+	retention  time.Duration
+	maxRecords int
+
+	mu         sync.RWMutex
+	file       *os.File
+	records    map[string]*contracts.MessageRecord
+	order      []string // record IDs, oldest first
+	byProvider map[string]string
+	appended   int
+}
+
+// Open creates a Store, replaying the log in opts.Dir if there is one.
+func Open(opts Options) (*Store, error) {
+	s := &Store{
+		retention:  opts.Retention,
+		maxRecords: opts.MaxRecords,
+		records:    make(map[string]*contracts.MessageRecord),
+		byProvider: make(map[string]string),
+	}
+	if s.retention <= 0 {
+		s.retention = DefaultRetention
+	}
+	if s.maxRecords <= 0 {
+		s.maxRecords = DefaultMaxRecords
+	}
+
+	if opts.Dir == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("ledger: failed to create directory: %w", err)
+	}
+	s.path = filepath.Join(opts.Dir, logName)
+
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	s.prune(time.Now())
+	if err := s.rewrite(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// replay loads the log. A torn final line from a crash mid-write is ignored.
+func (s *Store) replay() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ledger: failed to open log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record contracts.MessageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		s.put(&record)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ledger: failed to read log: %w", err)
+	}
+	return nil
+}
+
+// Save inserts or replaces the record.
+func (s *Store) Save(ctx context.Context, record *contracts.MessageRecord) error {
+	stored := clone(record)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		line, err := json.Marshal(stored)
+		if err != nil {
+			return fmt.Errorf("ledger: failed to encode record: %w", err)
+		}
+		if _, err := s.file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("ledger: failed to write log: %w", err)
+		}
+		s.appended++
+	}
+
+	s.put(stored)
+	s.prune(time.Now())
+
+	// Rewrite once superseded and pruned lines outnumber the live records.
+	if s.file != nil && s.appended > len(s.records)+1000 {
+		return s.rewrite()
+	}
+	return nil
+}
+
+// Get returns a copy of the record with the ID.
+func (s *Store) Get(ctx context.Context, id string) (*contracts.MessageRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return nil, port.ErrMessageNotFound
+	}
+	return clone(record), nil
+}
+
+// FindByProviderID returns a copy of the record with the provider's message ID.
+func (s *Store) FindByProviderID(ctx context.Context, provider, providerMessageID string) (*contracts.MessageRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.records[s.byProvider[providerMessageID]]
+	if !ok || providerMessageID == "" || (provider != "" && record.Provider != provider) {
+		return nil, port.ErrMessageNotFound
+	}
+	return clone(record), nil
+}
+
+// List returns copies of the records matching the filter, newest first.
+func (s *Store) List(ctx context.Context, filter port.MessageFilter) ([]*contracts.MessageRecord, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*contracts.MessageRecord, 0, min(limit, len(s.order)))
+	for i := len(s.order) - 1; i >= 0 && len(records) < limit; i-- {
+		record := s.records[s.order[i]]
+		if matches(record, filter) {
+			records = append(records, clone(record))
+		}
+	}
+	return records, nil
+}
+
+// Close closes the log.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func matches(r *contracts.MessageRecord, f port.MessageFilter) bool {
+	switch {
+	case f.Channel != "" && r.Channel != f.Channel:
+		return false
+	case f.Provider != "" && r.Provider != f.Provider:
+		return false
+	case f.Status != "" && r.Status != f.Status:
+		return false
+	case !f.Since.IsZero() && r.CreatedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !r.CreatedAt.Before(f.Until):
+		return false
+	case f.Recipient != "" && !slices.Contains(r.Recipients, f.Recipient):
+		return false
+	case f.APIKey != "" && r.APIKey != f.APIKey:
+		return false
+	case f.Tenant != "" && r.Tenant != f.Tenant:
+		return false
+	default:
+		return true
+	}
+}
+
+// put stores the record and indexes it. The caller holds the lock.
+func (s *Store) put(record *contracts.MessageRecord) {
+	if prev, ok := s.records[record.ID]; ok {
+		s.unindex(prev)
+	} else {
+		s.order = append(s.order, record.ID)
+	}
+	s.records[record.ID] = record
+	for _, id := range providerIDs(record) {
+		s.byProvider[id] = record.ID
+	}
+}
+
+// unindex removes the record's provider IDs from the index. The caller holds the lock.
+func (s *Store) unindex(record *contracts.MessageRecord) {
+	for _, id := range providerIDs(record) {
+		if s.byProvider[id] == record.ID {
+			delete(s.byProvider, id)
+		}
+	}
+}
+
+// providerIDs returns every provider message ID a record is known by.
+func providerIDs(record *contracts.MessageRecord) []string {
+	ids := make([]string, 0, 1+len(record.RecipientMessageIDs))
+	if record.ProviderMessageID != "" {
+		ids = append(ids, record.ProviderMessageID)
+	}
+	for _, id := range record.RecipientMessageIDs {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// prune drops the oldest records past the retention period or the record
+// cap. The caller holds the lock.
+func (s *Store) prune(now time.Time) {
+	cutoff := now.Add(-s.retention)
+
+	n := 0
+	for n < len(s.order) {
+		record := s.records[s.order[n]]
+		if len(s.order)-n <= s.maxRecords && !record.CreatedAt.Before(cutoff) {
+			break
+		}
+		delete(s.records, record.ID)
+		s.unindex(record)
+		n++
+	}
+	if n > 0 {
+		s.order = slices.Delete(s.order, 0, n)
+	}
+}
+
+// rewrite replaces the log with one line per live record, via
+// atomicfile. The caller holds the lock.
+func (s *Store) rewrite() error {
+	err := atomicfile.Write(s.path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, id := range s.order {
+			if err := enc.Encode(s.records[id]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ledger: failed to rewrite log: %w", err)
+	}
+
+	if s.file != nil {
+		_ = s.file.Close()
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("ledger: failed to open log: %w", err)
+	}
+	s.file = f
+	s.appended = 0
+	return nil
+}
+
+// clone copies a record so callers cannot modify the stored one.
+func clone(r *contracts.MessageRecord) *contracts.MessageRecord {
+	c := *r
+	c.Recipients = slices.Clone(r.Recipients)
+	c.RecipientMessageIDs = maps.Clone(r.RecipientMessageIDs)
+	c.Attempts = slices.Clone(r.Attempts)
+	c.Events = slices.Clone(r.Events)
+	if r.ScheduledAt != nil {
+		t := *r.ScheduledAt
+		c.ScheduledAt = &t
+	}
+	return &c
+}
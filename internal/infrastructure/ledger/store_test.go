@@ -0,0 +1,296 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+func record(id string, created time.Time) *contracts.MessageRecord {
+	return &contracts.MessageRecord{
+		ID:         id,
+		Channel:    "sms",
+		Provider:   "twilio",
+		Recipients: []string{"+15550100"},
+		Status:     contracts.StatusQueued,
+		CreatedAt:  created,
+		UpdatedAt:  created,
+	}
+}
+
+func openStore(t *testing.T, opts Options) *Store {
+	t.Helper()
+	s, err := Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func save(t *testing.T, s *Store, records ...*contracts.MessageRecord) {
+	t.Helper()
+	for _, r := range records {
+		if err := s.Save(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func ids(records []*contracts.MessageRecord) string {
+	out := make([]string, len(records))
+	for i, r := range records {
+		out[i] = r.ID
+	}
+	return fmt.Sprint(out)
+}
+
+func TestStoreReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Now().UTC()
+
+	s := openStore(t, Options{Dir: dir})
+	save(t, s, record("a", now.Add(-3*time.Minute)), record("b", now.Add(-2*time.Minute)), record("c", now.Add(-time.Minute)))
+	sent := record("a", now.Add(-3*time.Minute))
+	sent.Status = contracts.StatusSent
+	sent.ProviderMessageID = "SM1"
+	save(t, s, sent)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openStore(t, Options{Dir: dir})
+	records, err := reopened.List(ctx, port.MessageFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(records); got != "[c b a]" {
+		t.Errorf("List = %s, want [c b a]", got)
+	}
+	got, err := reopened.Get(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != contracts.StatusSent {
+		t.Errorf("status = %s, want the last saved %s", got.Status, contracts.StatusSent)
+	}
+	if _, err := reopened.FindByProviderID(ctx, "twilio", "SM1"); err != nil {
+		t.Errorf("FindByProviderID after reopen: %v", err)
+	}
+}
+
+func TestStoreReplayIgnoresTornLine(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, Options{Dir: dir})
+	save(t, s, record("a", time.Now().UTC()))
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, logName), os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"id":"b","chan`); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	reopened := openStore(t, Options{Dir: dir})
+	records, _ := reopened.List(context.Background(), port.MessageFilter{})
+	if got := ids(records); got != "[a]" {
+		t.Errorf("List = %s, want [a]", got)
+	}
+}
+
+func TestStorePrune(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name    string
+		opts    Options
+		records []*contracts.MessageRecord
+		want    string
+	}{
+		{
+			name:    "records past the retention are dropped",
+			opts:    Options{Retention: time.Hour},
+			records: []*contracts.MessageRecord{record("old", now.Add(-2*time.Hour)), record("new", now)},
+			want:    "[new]",
+		},
+		{
+			name:    "oldest records past the cap are dropped",
+			opts:    Options{MaxRecords: 2},
+			records: []*contracts.MessageRecord{record("a", now.Add(-3*time.Minute)), record("b", now.Add(-2*time.Minute)), record("c", now.Add(-time.Minute))},
+			want:    "[c b]",
+		},
+		{
+			name:    "updating a record keeps its place",
+			opts:    Options{MaxRecords: 2},
+			records: []*contracts.MessageRecord{record("a", now.Add(-3*time.Minute)), record("b", now.Add(-2*time.Minute)), record("a", now.Add(-3*time.Minute))},
+			want:    "[b a]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := openStore(t, tt.opts)
+			save(t, s, tt.records...)
+
+			records, err := s.List(ctx, port.MessageFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(records); got != tt.want {
+				t.Errorf("List = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStorePrunesOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	s := openStore(t, Options{Dir: dir})
+	save(t, s, record("old", now.Add(-2*time.Hour)), record("new", now))
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openStore(t, Options{Dir: dir, Retention: time.Hour})
+	if _, err := reopened.Get(context.Background(), "old"); !errors.Is(err, port.ErrMessageNotFound) {
+		t.Errorf("Get(old) error = %v, want ErrMessageNotFound", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, logName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(`"old"`)) {
+		t.Error("pruned record is still in the log")
+	}
+}
+
+func TestStoreFindByProviderID(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name     string
+		provider string
+		pid      string
+		wantID   string
+	}{
+		{name: "current provider ID", provider: "twilio", pid: "SM2", wantID: "a"},
+		{name: "any provider", pid: "SM2", wantID: "a"},
+		{name: "replaced provider ID", provider: "twilio", pid: "SM1"},
+		{name: "recipient message ID", provider: "twilio", pid: "SM3", wantID: "a"},
+		{name: "other provider", provider: "vonage", pid: "SM2"},
+		{name: "empty ID", provider: "twilio"},
+		{name: "record pruned past the cap", provider: "twilio", pid: "SM9"},
+	}
+
+	s := openStore(t, Options{Dir: t.TempDir(), MaxRecords: 2})
+	pruned := record("pruned", now.Add(-time.Hour))
+	pruned.ProviderMessageID = "SM9"
+	first := record("a", now.Add(-time.Minute))
+	first.ProviderMessageID = "SM1"
+	save(t, s, pruned, first)
+	resent := record("a", now.Add(-time.Minute))
+	resent.ProviderMessageID = "SM2"
+	resent.RecipientMessageIDs = map[string]string{"+15550100": "SM3"}
+	save(t, s, resent, record("b", now))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.FindByProviderID(ctx, tt.provider, tt.pid)
+			if tt.wantID == "" {
+				if !errors.Is(err, port.ErrMessageNotFound) {
+					t.Errorf("error = %v, want ErrMessageNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != tt.wantID {
+				t.Errorf("ID = %s, want %s", got.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestStoreRewriteCompactsLog(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, Options{Dir: dir})
+
+	r := record("a", time.Now().UTC())
+	const saves = 1500
+	for i := range saves {
+		r.Error = fmt.Sprint(i)
+		save(t, s, r)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, logName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines >= saves-1000 {
+		t.Errorf("log has %d lines for one record saved %d times, want it compacted", lines, saves)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := openStore(t, Options{Dir: dir}).Get(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprint(saves - 1); got.Error != want {
+		t.Errorf("Error = %s, want the last saved %s", got.Error, want)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	created := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	r := record("a", created)
+	r.Tenant = "shop"
+	r.APIKey = "app"
+
+	tests := []struct {
+		name   string
+		filter port.MessageFilter
+		want   bool
+	}{
+		{"empty filter", port.MessageFilter{}, true},
+		{"channel", port.MessageFilter{Channel: "sms"}, true},
+		{"other channel", port.MessageFilter{Channel: "email"}, false},
+		{"other provider", port.MessageFilter{Provider: "vonage"}, false},
+		{"other status", port.MessageFilter{Status: contracts.StatusFailed}, false},
+		{"recipient", port.MessageFilter{Recipient: "+15550100"}, true},
+		{"other recipient", port.MessageFilter{Recipient: "+15550199"}, false},
+		{"since creation", port.MessageFilter{Since: created}, true},
+		{"since after creation", port.MessageFilter{Since: created.Add(time.Second)}, false},
+		{"until creation", port.MessageFilter{Until: created}, false},
+		{"tenant", port.MessageFilter{Tenant: "shop"}, true},
+		{"other tenant", port.MessageFilter{Tenant: "acme"}, false},
+		{"api key", port.MessageFilter{APIKey: "app"}, true},
+		{"other api key", port.MessageFilter{APIKey: "ops"}, false},
+		{"tenant and other api key", port.MessageFilter{Tenant: "shop", APIKey: "ops"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(r, tt.filter); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
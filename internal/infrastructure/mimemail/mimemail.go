@@ -0,0 +1,236 @@
+// Package mimemail renders contracts.Email as an RFC 5322 message with a MIME body.
+// It is shared by providers that deliver raw messages (SMTP, SES raw, Mailpit).
+package mimemail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// reservedHeaders are set from the email's fields, by the message builder or
+// by providers sending the fields through their APIs.
+var reservedHeaders = map[string]bool{
+	"From":                      true,
+	"To":                        true,
+	"Cc":                        true,
+	"Bcc":                       true,
+	"Reply-To":                  true,
+	"Subject":                   true,
+	"Date":                      true,
+	"Message-Id":                true,
+	"Mime-Version":              true,
+	"Content-Type":              true,
+	"Content-Transfer-Encoding": true,
+}
+
+// ReservedHeader reports whether key, in any case, is a header set from the
+// email's fields, which contracts.Email.Headers cannot override.
+func ReservedHeader(key string) bool {
+	return reservedHeaders[textproto.CanonicalMIMEHeaderKey(key)]
+}
+
+// validHeaderName reports whether key is an RFC 5322 field name: one or
+// more printable ASCII characters other than the colon.
+func validHeaderName(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c < 33 || c > 126 || c == ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// entity is a single MIME entity: its header block and already-encoded body.
+type entity struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// Build renders email as an RFC 5322 message with a MIME body.
+// Bcc recipients are never written to the headers. An empty messageID
+// leaves Message-ID to the relay.
+func Build(email *contracts.Email, fromAddr, fromName, messageID string, date time.Time) ([]byte, error) {
+	root, err := buildBody(email)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+
+	from := mail.Address{Name: fromName, Address: fromAddr}
+	writeHeader(&msg, "From", from.String())
+	writeHeader(&msg, "To", strings.Join(email.To, ", "))
+	if len(email.CC) > 0 {
+		writeHeader(&msg, "Cc", strings.Join(email.CC, ", "))
+	}
+	if email.ReplyTo != "" {
+		writeHeader(&msg, "Reply-To", email.ReplyTo)
+	}
+	writeHeader(&msg, "Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	writeHeader(&msg, "Date", date.Format(time.RFC1123Z))
+	if messageID != "" {
+		writeHeader(&msg, "Message-ID", messageID)
+	}
+
+	keys := make([]string, 0, len(email.Headers))
+	for key := range email.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !validHeaderName(key) {
+			return nil, fmt.Errorf("invalid header name %q", key)
+		}
+		if ReservedHeader(key) {
+			continue
+		}
+		writeHeader(&msg, textproto.CanonicalMIMEHeaderKey(key), email.Headers[key])
+	}
+
+	writeHeader(&msg, "MIME-Version", "1.0")
+	for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if v := root.header.Get(key); v != "" {
+			writeHeader(&msg, key, v)
+		}
+	}
+	msg.WriteString("\r\n")
+	msg.Write(root.body)
+
+	return msg.Bytes(), nil
+}
+
+// buildBody assembles the MIME tree:
+//
+//	multipart/mixed            (only with attachments)
+//	└── multipart/alternative  (only with both text and HTML)
+//	    ├── text/plain
+//	    └── text/html
+func buildBody(email *contracts.Email) (entity, error) {
+	var content entity
+	var err error
+
+	switch {
+	case email.HTML != "" && email.PlainText != "":
+		content, err = multipartEntity("alternative", []entity{
+			textEntity("text/plain", email.PlainText),
+			textEntity("text/html", email.HTML),
+		})
+		if err != nil {
+			return entity{}, err
+		}
+	case email.HTML != "":
+		content = textEntity("text/html", email.HTML)
+	default:
+		content = textEntity("text/plain", email.PlainText)
+	}
+
+	if len(email.Attachments) == 0 {
+		return content, nil
+	}
+
+	parts := []entity{content}
+	for _, att := range email.Attachments {
+		part, err := attachmentEntity(att)
+		if err != nil {
+			return entity{}, err
+		}
+		parts = append(parts, part)
+	}
+	return multipartEntity("mixed", parts)
+}
+
+func textEntity(mediaType, text string) entity {
+	var body bytes.Buffer
+	qp := quotedprintable.NewWriter(&body)
+	_, _ = qp.Write([]byte(text))
+	_ = qp.Close()
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mediaType+"; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return entity{header: header, body: body.Bytes()}
+}
+
+func attachmentEntity(att contracts.Attachment) (entity, error) {
+	if att.Filename == "" {
+		return entity{}, errors.New("attachment filename is required")
+	}
+	if len(att.Data) == 0 {
+		return entity{}, fmt.Errorf("attachment %q has no data", att.Filename)
+	}
+
+	contentType := att.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(contentType, map[string]string{"name": att.Filename}))
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": att.Filename}))
+	header.Set("Content-Transfer-Encoding", "base64")
+
+	return entity{header: header, body: wrapBase64(att.Data)}, nil
+}
+
+func multipartEntity(subtype string, parts []entity) (entity, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	for _, part := range parts {
+		pw, err := w.CreatePart(part.header)
+		if err != nil {
+			return entity{}, err
+		}
+		if _, err := pw.Write(part.body); err != nil {
+			return entity{}, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return entity{}, err
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", fmt.Sprintf("multipart/%s; boundary=%q", subtype, w.Boundary()))
+	return entity{header: header, body: body.Bytes()}, nil
+}
+
+// wrapBase64 encodes data as base64 folded at 76 characters per line (RFC 2045).
+func wrapBase64(data []byte) []byte {
+	const lineLen = 76
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var out bytes.Buffer
+	for len(encoded) > lineLen {
+		out.WriteString(encoded[:lineLen])
+		out.WriteString("\r\n")
+		encoded = encoded[lineLen:]
+	}
+	out.WriteString(encoded)
+	out.WriteString("\r\n")
+	return out.Bytes()
+}
+
+// writeHeader writes a single header line, stripping CR/LF from the value
+// to prevent header injection through user-supplied fields.
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	buf.WriteString(key)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,162 @@
+package mimemail
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+func TestBuild(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		email       contracts.Email
+		messageID   string
+		wantErr     bool
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:      "plain text",
+			email:     contracts.Email{To: []string{"a@example.com"}, Subject: "Hi", PlainText: "Hello"},
+			messageID: "<id@example.com>",
+			contains: []string{
+				"From: \"Sender\" <from@example.com>\r\n",
+				"To: a@example.com\r\n",
+				"Subject: Hi\r\n",
+				"Date: Fri, 01 Mar 2024 12:00:00 +0000\r\n",
+				"Message-ID: <id@example.com>\r\n",
+				"MIME-Version: 1.0\r\n",
+				"Content-Type: text/plain; charset=UTF-8\r\n",
+				"Content-Transfer-Encoding: quoted-printable\r\n",
+				"\r\n\r\nHello",
+			},
+		},
+		{
+			name:        "html only without message id",
+			email:       contracts.Email{To: []string{"a@example.com"}, HTML: "<p>Hi</p>"},
+			contains:    []string{"Content-Type: text/html; charset=UTF-8\r\n"},
+			notContains: []string{"Message-ID:", "multipart"},
+		},
+		{
+			name:     "html and text",
+			email:    contracts.Email{To: []string{"a@example.com"}, HTML: "<p>Hi</p>", PlainText: "Hi"},
+			contains: []string{"Content-Type: multipart/alternative; boundary=", "text/plain; charset=UTF-8", "text/html; charset=UTF-8"},
+		},
+		{
+			name: "attachment",
+			email: contracts.Email{To: []string{"a@example.com"}, PlainText: "Hi", Attachments: []contracts.Attachment{
+				{Filename: "a.bin", Data: []byte{0, 1, 2}},
+			}},
+			contains: []string{
+				"Content-Type: multipart/mixed; boundary=",
+				"Content-Type: application/octet-stream; name=a.bin",
+				"Content-Disposition: attachment; filename=a.bin",
+				"AAEC\r\n",
+			},
+		},
+		{
+			name:        "bcc is not written",
+			email:       contracts.Email{To: []string{"a@example.com"}, CC: []string{"c@example.com"}, BCC: []string{"b@example.com"}, PlainText: "Hi"},
+			contains:    []string{"Cc: c@example.com\r\n"},
+			notContains: []string{"b@example.com"},
+		},
+		{
+			name:        "non-ascii subject is encoded",
+			email:       contracts.Email{To: []string{"a@example.com"}, Subject: "Grüße", PlainText: "Hi"},
+			contains:    []string{"Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=\r\n"},
+			notContains: []string{"Grüße"},
+		},
+		{
+			name: "custom headers are canonical and cannot override reserved ones",
+			email: contracts.Email{To: []string{"a@example.com"}, Subject: "Hi", PlainText: "Hi", Headers: map[string]string{
+				"x-campaign":   "spring",
+				"subject":      "override",
+				"content-type": "text/html",
+			}},
+			contains:    []string{"X-Campaign: spring\r\n"},
+			notContains: []string{"override", "Content-Type: text/html"},
+		},
+		{
+			name:        "header injection is stripped",
+			email:       contracts.Email{To: []string{"a@example.com"}, Subject: "Hi\r\nBcc: evil@example.com", PlainText: "Hi"},
+			notContains: []string{"\r\nBcc:"},
+		},
+		{
+			name: "header name injection is rejected",
+			email: contracts.Email{To: []string{"a@example.com"}, Subject: "Hi", PlainText: "Hi", Headers: map[string]string{
+				"X-A: 1\r\nBcc: evil@example.com\r\nX-B": "x",
+			}},
+			wantErr: true,
+		},
+		{
+			name: "header name with a space is rejected",
+			email: contracts.Email{To: []string{"a@example.com"}, Subject: "Hi", PlainText: "Hi", Headers: map[string]string{
+				"X Campaign": "spring",
+			}},
+			wantErr: true,
+		},
+		{
+			name: "attachment without filename",
+			email: contracts.Email{To: []string{"a@example.com"}, Attachments: []contracts.Attachment{
+				{Data: []byte("x")},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "empty attachment",
+			email: contracts.Email{To: []string{"a@example.com"}, Attachments: []contracts.Attachment{
+				{Filename: "a.txt"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Build(&tt.email, "from@example.com", "Sender", tt.messageID, date)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(msg), want) {
+					t.Errorf("message does not contain %q:\n%s", want, msg)
+				}
+			}
+			for _, unwanted := range tt.notContains {
+				if strings.Contains(string(msg), unwanted) {
+					t.Errorf("message contains %q:\n%s", unwanted, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestWrapBase64(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		wantLines int
+	}{
+		{"short", 3, 1},
+		{"exactly one line", 57, 1},
+		{"folded", 58, 2},
+		{"several lines", 200, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := strings.TrimSuffix(string(wrapBase64(make([]byte, tt.size))), "\r\n")
+			lines := strings.Split(out, "\r\n")
+			if len(lines) != tt.wantLines {
+				t.Errorf("lines = %d, want %d", len(lines), tt.wantLines)
+			}
+			for _, line := range lines {
+				if len(line) > 76 {
+					t.Errorf("line of %d characters exceeds 76", len(line))
+				}
+			}
+		})
+	}
+}
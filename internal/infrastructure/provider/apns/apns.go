@@ -0,0 +1,379 @@
+package apns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName      = "apns"
+	productionBaseURL = "https://api.push.apple.com"
+	sandboxBaseURL    = "https://api.sandbox.push.apple.com"
+	defaultTimeout    = 30 * time.Second
+	// maxConcurrency bounds the number of concurrent streams per Send.
+	maxConcurrency = 10
+)
+
+// Push types accepted in the apns-push-type header.
+const (
+	PushTypeAlert      = "alert"
+	PushTypeBackground = "background"
+)
+
+// APNs reasons that mean the device token should be removed.
+const (
+	ReasonUnregistered           = "Unregistered"
+	ReasonBadDeviceToken         = "BadDeviceToken"
+	ReasonDeviceTokenNotForTopic = "DeviceTokenNotForTopic"
+	reasonExpiredProviderToken   = "ExpiredProviderToken"
+)
+
+var _ port.PushSender = (*Provider)(nil)
+
+// Config holds APNs token-based authentication configuration.
+type Config struct {
+	// KeyFile is the path to the .p8 signing key; Key holds its content directly.
+	KeyFile string
+	Key     string
+	KeyID   string
+	TeamID  string
+	// Topic is the app bundle ID (apns-topic).
+	Topic string
+	// Sandbox selects the development environment host.
+	Sandbox bool
+	// BaseURL overrides the APNs host. An http:// URL uses HTTP/2 without TLS (h2c).
+	BaseURL string
+	// PushType is the default apns-push-type for notifications with a title or body.
+	PushType string
+}
+
+// Provider implements port.PushSender for Apple Push Notification service.
+type Provider struct {
+	client *http.Client
+	config Config
+	signer *tokenSigner
+}
+
+type errorResponse struct {
+	Reason    string `json:"reason"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// deviceResult is the outcome of sending to a single device token.
+type deviceResult struct {
+	token  string
+	apnsID string
+	reason string
+	err    error
+}
+
+// New creates a new APNs provider.
+func New(cfg Config) (*Provider, error) {
+	keyPEM := []byte(cfg.Key)
+	if len(keyPEM) == 0 {
+		if cfg.KeyFile == "" {
+			return nil, errors.New("apns: key file is required")
+		}
+		var err error
+		keyPEM, err = os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("apns: failed to read key file: %w", err)
+		}
+	}
+	if cfg.KeyID == "" {
+		return nil, errors.New("apns: key ID is required")
+	}
+	if cfg.TeamID == "" {
+		return nil, errors.New("apns: team ID is required")
+	}
+	if cfg.Topic == "" {
+		return nil, errors.New("apns: topic is required")
+	}
+
+	signer, err := newTokenSigner(keyPEM, cfg.KeyID, cfg.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = productionBaseURL
+		if cfg.Sandbox {
+			cfg.BaseURL = sandboxBaseURL
+		}
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.PushType == "" {
+		cfg.PushType = PushTypeAlert
+	}
+
+	return &Provider{
+		client: &http.Client{Transport: newTransport(cfg.BaseURL)},
+		config: cfg,
+		signer: signer,
+	}, nil
+}
+
+// newTransport returns an HTTP/2-only transport. APNs requires HTTP/2;
+// plain http:// hosts (local stand-ins) are spoken to with h2c.
+func newTransport(baseURL string) *http.Transport {
+	protocols := new(http.Protocols)
+	if strings.HasPrefix(baseURL, "http://") {
+		protocols.SetUnencryptedHTTP2(true)
+	} else {
+		protocols.SetHTTP2(true)
+	}
+
+	return &http.Transport{
+		Protocols:         protocols,
+		ForceAttemptHTTP2: true,
+		IdleConnTimeout:   90 * time.Second,
+	}
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send delivers the notification to every device token.
+//
+// Per-device outcomes are reported in SendResult.Meta as "id:<token>" (apns-id)
+// and "error:<token>" (APNs reason). Tokens that should be pruned are also listed,
+// comma-separated, under "unregistered" and "invalid". An error is returned when
+// no device succeeded.
+func (p *Provider) Send(ctx context.Context, push *contracts.PushNotification) (*contracts.SendResult, error) {
+	if len(push.DeviceTokens) == 0 {
+		return nil, errors.New("no device tokens specified")
+	}
+
+	payload, err := buildPayload(push)
+	if err != nil {
+		return nil, err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	results := make([]deviceResult, len(push.DeviceTokens))
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, token := range push.DeviceTokens {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, token string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = p.sendOne(sendCtx, token, push, payload)
+		}(i, token)
+	}
+	wg.Wait()
+
+	return aggregate(results)
+}
+
+func aggregate(results []deviceResult) (*contracts.SendResult, error) {
+	meta := make(map[string]string, len(results)+4)
+	var unregistered, invalid []string
+	var firstID string
+	var firstErr error
+	sent := 0
+
+	for _, r := range results {
+		if r.err == nil {
+			sent++
+			if firstID == "" {
+				firstID = r.apnsID
+			}
+			meta["id:"+r.token] = r.apnsID
+			continue
+		}
+
+		if firstErr == nil {
+			firstErr = r.err
+		}
+		meta["error:"+r.token] = r.reason
+		switch r.reason {
+		case ReasonUnregistered:
+			unregistered = append(unregistered, r.token)
+		case ReasonBadDeviceToken, ReasonDeviceTokenNotForTopic:
+			invalid = append(invalid, r.token)
+		}
+	}
+
+	if sent == 0 {
+		if len(results) == 1 {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("apns: all %d devices failed: %w", len(results), firstErr)
+	}
+
+	meta["sent"] = strconv.Itoa(sent)
+	meta["failed"] = strconv.Itoa(len(results) - sent)
+	if len(unregistered) > 0 {
+		meta["unregistered"] = strings.Join(unregistered, ",")
+	}
+	if len(invalid) > 0 {
+		meta["invalid"] = strings.Join(invalid, ",")
+	}
+
+	result := &contracts.SendResult{
+		ID:         firstID,
+		StatusCode: http.StatusOK,
+		Message:    "Push notification sent successfully",
+		Meta:       meta,
+	}
+	if sent < len(results) {
+		result.StatusCode = http.StatusMultiStatus
+		result.Message = fmt.Sprintf("Push notification sent to %d of %d devices", sent, len(results))
+	}
+	return result, nil
+}
+
+func (p *Provider) sendOne(ctx context.Context, token string, push *contracts.PushNotification, payload []byte) deviceResult {
+	providerToken, err := p.signer.Token(time.Now())
+	if err != nil {
+		return deviceResult{token: token, err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.BaseURL+"/3/device/"+token, bytes.NewReader(payload))
+	if err != nil {
+		return deviceResult{token: token, err: fmt.Errorf("apns: failed to create request: %w", err)}
+	}
+	p.setHeaders(req, providerToken, push)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return deviceResult{token: token, err: fmt.Errorf("apns: failed to send push: %w", err)}
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send push", 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return deviceResult{token: token, err: perr}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	apnsID := resp.Header.Get("apns-id")
+	if resp.StatusCode == http.StatusOK {
+		return deviceResult{token: token, apnsID: apnsID}
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	var errResp errorResponse
+	_ = json.Unmarshal(body, &errResp)
+	if errResp.Reason == reasonExpiredProviderToken {
+		p.signer.Invalidate(providerToken)
+	}
+
+	return deviceResult{
+		token:  token,
+		apnsID: apnsID,
+		reason: errResp.Reason,
+		err:    newSendError(resp.StatusCode, errResp.Reason),
+	}
+}
+
+func (p *Provider) setHeaders(req *http.Request, providerToken string, push *contracts.PushNotification) {
+	pushType := p.config.PushType
+	if push.Title == "" && push.Body == "" {
+		pushType = PushTypeBackground
+	}
+
+	// Background pushes must use priority 5; alerts default to 10.
+	priority := "10"
+	if pushType == PushTypeBackground || push.Priority == contracts.PushPriorityNormal {
+		priority = "5"
+	}
+
+	req.Header.Set("authorization", "bearer "+providerToken)
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("apns-topic", p.config.Topic)
+	req.Header.Set("apns-push-type", pushType)
+	req.Header.Set("apns-priority", priority)
+	if push.CollapseID != "" {
+		req.Header.Set("apns-collapse-id", push.CollapseID)
+	}
+	if push.ExpiresAt != nil {
+		req.Header.Set("apns-expiration", strconv.FormatInt(push.ExpiresAt.Unix(), 10))
+	}
+}
+
+// buildPayload renders the JSON payload: the aps dictionary plus custom
+// Data keys at the top level, where iOS apps read them.
+func buildPayload(push *contracts.PushNotification) ([]byte, error) {
+	aps := map[string]any{}
+	if push.Title != "" || push.Body != "" {
+		alert := map[string]string{}
+		if push.Title != "" {
+			alert["title"] = push.Title
+		}
+		if push.Body != "" {
+			alert["body"] = push.Body
+		}
+		aps["alert"] = alert
+	} else {
+		aps["content-available"] = 1
+	}
+	if push.Badge != nil {
+		aps["badge"] = *push.Badge
+	}
+	if push.Sound != "" {
+		aps["sound"] = push.Sound
+	}
+
+	payload := make(map[string]any, len(push.Data)+1)
+	for k, v := range push.Data {
+		payload[k] = v
+	}
+	payload["aps"] = aps
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("apns: failed to encode payload: %w", err)
+	}
+	return data, nil
+}
+
+// errorKinds maps APNs reasons to gateway error kinds.
+// See https://developer.apple.com/documentation/usernotifications/handling-notification-responses-from-apns.
+var errorKinds = map[string]error{
+	ReasonUnregistered:            pkgerrors.ErrInvalidRecipient,
+	ReasonBadDeviceToken:          pkgerrors.ErrInvalidRecipient,
+	ReasonDeviceTokenNotForTopic:  pkgerrors.ErrInvalidRecipient,
+	reasonExpiredProviderToken:    pkgerrors.ErrUnauthorized,
+	"InvalidProviderToken":        pkgerrors.ErrUnauthorized,
+	"MissingProviderToken":        pkgerrors.ErrUnauthorized,
+	"TooManyProviderTokenUpdates": pkgerrors.ErrRateLimited,
+	"TooManyRequests":             pkgerrors.ErrRateLimited,
+	"InternalServerError":         pkgerrors.ErrUnavailable,
+	"ServiceUnavailable":          pkgerrors.ErrUnavailable,
+	"Shutdown":                    pkgerrors.ErrUnavailable,
+}
+
+func newSendError(statusCode int, reason string) *pkgerrors.ProviderError {
+	msg := reason
+	if msg == "" {
+		msg = http.StatusText(statusCode)
+	}
+
+	perr := pkgerrors.NewProviderError(ProviderName, "failed to send push", statusCode, errors.New(msg))
+	perr.Code = reason
+	if kind, ok := errorKinds[reason]; ok {
+		perr.Kind = kind
+	}
+	return perr
+}
@@ -0,0 +1,336 @@
+package apns
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// apnsServer is an h2c stand-in for the APNs API.
+type apnsServer struct {
+	*httptest.Server
+	key *ecdsa.PrivateKey
+	// respond returns the status and reason for a device token.
+	respond func(token string) (int, string)
+
+	mu       sync.Mutex
+	requests []*http.Request
+	payloads map[string]string
+	tokens   []string
+}
+
+func newAPNsServer(t *testing.T) *apnsServer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &apnsServer{
+		key:      key,
+		payloads: map[string]string{},
+		respond:  func(string) (int, string) { return http.StatusOK, "" },
+	}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.handle))
+	s.Config.Protocols = new(http.Protocols)
+	s.Config.Protocols.SetUnencryptedHTTP2(true)
+	s.Start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *apnsServer) handle(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.URL.Path, "/3/device/")
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	s.payloads[token] = string(body)
+	providerToken := strings.TrimPrefix(r.Header.Get("authorization"), "bearer ")
+	s.tokens = append(s.tokens, providerToken)
+	s.mu.Unlock()
+
+	if r.ProtoMajor != 2 {
+		http.Error(w, "HTTP/2 required", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	if !s.validToken(providerToken) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"reason":"InvalidProviderToken"}`))
+		return
+	}
+
+	w.Header().Set("apns-id", "id-"+token)
+	status, reason := s.respond(token)
+	w.WriteHeader(status)
+	if reason != "" {
+		_ = json.NewEncoder(w).Encode(errorResponse{Reason: reason})
+	}
+}
+
+// validToken verifies the ES256 signature and claims of a provider token.
+func (s *apnsServer) validToken(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		return false
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, sVal := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(&s.key.PublicKey, digest[:], r, sVal) {
+		return false
+	}
+
+	var header, claims map[string]any
+	headerJSON, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	_ = json.Unmarshal(headerJSON, &header)
+	_ = json.Unmarshal(claimsJSON, &claims)
+	return header["alg"] == "ES256" && header["kid"] == "KEY123" && claims["iss"] == "TEAM123"
+}
+
+func (s *apnsServer) keyPEM(t *testing.T) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(s.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func (s *apnsServer) provider(t *testing.T) *Provider {
+	t.Helper()
+
+	p, err := New(Config{Key: s.keyPEM(t), KeyID: "KEY123", TeamID: "TEAM123", Topic: "com.example.app", BaseURL: s.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNew(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantErr     bool
+		wantBaseURL string
+	}{
+		{"production", Config{Key: keyPEM, KeyID: "K", TeamID: "T", Topic: "app"}, false, productionBaseURL},
+		{"sandbox", Config{Key: keyPEM, KeyID: "K", TeamID: "T", Topic: "app", Sandbox: true}, false, sandboxBaseURL},
+		{"missing key", Config{KeyID: "K", TeamID: "T", Topic: "app"}, true, ""},
+		{"missing key file", Config{KeyFile: "/nonexistent/key.p8", KeyID: "K", TeamID: "T", Topic: "app"}, true, ""},
+		{"missing key ID", Config{Key: keyPEM, TeamID: "T", Topic: "app"}, true, ""},
+		{"missing team ID", Config{Key: keyPEM, KeyID: "K", Topic: "app"}, true, ""},
+		{"missing topic", Config{Key: keyPEM, KeyID: "K", TeamID: "T"}, true, ""},
+		{"not PEM", Config{Key: "nope", KeyID: "K", TeamID: "T", Topic: "app"}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && p.config.BaseURL != tt.wantBaseURL {
+				t.Errorf("base URL = %q, want %q", p.config.BaseURL, tt.wantBaseURL)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	badge := 2
+	expires := time.Unix(1900000000, 0)
+
+	tests := []struct {
+		name        string
+		push        contracts.PushNotification
+		wantHeaders map[string]string
+		wantPayload string
+	}{
+		{
+			name:        "alert",
+			push:        contracts.PushNotification{Title: "Hi", Body: "There", Data: map[string]string{"order": "42"}},
+			wantHeaders: map[string]string{"apns-push-type": "alert", "apns-priority": "10", "apns-topic": "com.example.app"},
+			wantPayload: `{"aps":{"alert":{"body":"There","title":"Hi"}},"order":"42"}`,
+		},
+		{
+			name:        "background",
+			push:        contracts.PushNotification{Data: map[string]string{"sync": "1"}},
+			wantHeaders: map[string]string{"apns-push-type": "background", "apns-priority": "5"},
+			wantPayload: `{"aps":{"content-available":1},"sync":"1"}`,
+		},
+		{
+			name:        "badge and sound",
+			push:        contracts.PushNotification{Title: "Hi", Badge: &badge, Sound: "default"},
+			wantHeaders: map[string]string{"apns-push-type": "alert"},
+			wantPayload: `{"aps":{"alert":{"title":"Hi"},"badge":2,"sound":"default"}}`,
+		},
+		{
+			name: "normal priority, collapse ID and expiry",
+			push: contracts.PushNotification{Title: "Hi", Priority: contracts.PushPriorityNormal, CollapseID: "score", ExpiresAt: &expires},
+			wantHeaders: map[string]string{
+				"apns-priority":    "5",
+				"apns-collapse-id": "score",
+				"apns-expiration":  "1900000000",
+			},
+			wantPayload: `{"aps":{"alert":{"title":"Hi"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newAPNsServer(t)
+			p := srv.provider(t)
+
+			tt.push.DeviceTokens = []string{"abc123"}
+			result, err := p.Send(context.Background(), &tt.push)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "id-abc123" || result.StatusCode != http.StatusOK {
+				t.Errorf("result = %+v", result)
+			}
+
+			r := srv.requests[0]
+			if r.Method != http.MethodPost || r.URL.Path != "/3/device/abc123" {
+				t.Errorf("request = %s %s", r.Method, r.URL.Path)
+			}
+			for name, want := range tt.wantHeaders {
+				if got := r.Header.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if got := srv.payloads["abc123"]; got != tt.wantPayload {
+				t.Errorf("payload = %s\nwant      %s", got, tt.wantPayload)
+			}
+		})
+	}
+}
+
+func TestSend_ReusesProviderToken(t *testing.T) {
+	srv := newAPNsServer(t)
+	p := srv.provider(t)
+
+	if _, err := p.Send(context.Background(), &contracts.PushNotification{DeviceTokens: []string{"a", "b", "c"}, Title: "Hi"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	for _, token := range srv.tokens[1:] {
+		if token != srv.tokens[0] {
+			t.Fatal("provider token was re-signed within its TTL")
+		}
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	reason := func(status int, reason string) func(string) (int, string) {
+		return func(string) (int, string) { return status, reason }
+	}
+
+	tests := []struct {
+		name             string
+		tokens           []string
+		respond          func(token string) (int, string)
+		wantErr          error
+		wantCode         string
+		wantStatus       int
+		wantUnregistered string
+		wantInvalid      string
+	}{
+		{"all unregistered", []string{"a", "b"}, reason(http.StatusGone, ReasonUnregistered), pkgerrors.ErrInvalidRecipient, ReasonUnregistered, 0, "", ""},
+		{"bad device token", []string{"a"}, reason(http.StatusBadRequest, ReasonBadDeviceToken), pkgerrors.ErrInvalidRecipient, ReasonBadDeviceToken, 0, "", ""},
+		{"bad payload", []string{"a"}, reason(http.StatusBadRequest, "PayloadTooLarge"), pkgerrors.ErrRejected, "PayloadTooLarge", 0, "", ""},
+		{"expired provider token", []string{"a"}, reason(http.StatusForbidden, reasonExpiredProviderToken), pkgerrors.ErrUnauthorized, reasonExpiredProviderToken, 0, "", ""},
+		{"too many requests", []string{"a"}, reason(http.StatusTooManyRequests, "TooManyRequests"), pkgerrors.ErrRateLimited, "TooManyRequests", 0, "", ""},
+		{"unavailable", []string{"a"}, reason(http.StatusServiceUnavailable, "ServiceUnavailable"), pkgerrors.ErrUnavailable, "ServiceUnavailable", 0, "", ""},
+		{
+			name:   "some pruned",
+			tokens: []string{"ok", "gone", "bad"},
+			respond: func(token string) (int, string) {
+				switch token {
+				case "gone":
+					return http.StatusGone, ReasonUnregistered
+				case "bad":
+					return http.StatusBadRequest, ReasonBadDeviceToken
+				}
+				return http.StatusOK, ""
+			},
+			wantStatus:       http.StatusMultiStatus,
+			wantUnregistered: "gone",
+			wantInvalid:      "bad",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newAPNsServer(t)
+			srv.respond = tt.respond
+			p := srv.provider(t)
+
+			result, err := p.Send(context.Background(), &contracts.PushNotification{DeviceTokens: tt.tokens, Title: "Hi"})
+			if tt.wantErr != nil {
+				var perr *pkgerrors.ProviderError
+				if !errors.As(err, &perr) || !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if perr.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", perr.Code, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.StatusCode != tt.wantStatus || result.Meta["unregistered"] != tt.wantUnregistered || result.Meta["invalid"] != tt.wantInvalid {
+				t.Errorf("status = %d meta = %v", result.StatusCode, result.Meta)
+			}
+		})
+	}
+}
+
+func TestSend_ExpiredProviderTokenIsRenewed(t *testing.T) {
+	srv := newAPNsServer(t)
+	expired := true
+	srv.respond = func(string) (int, string) {
+		if expired {
+			expired = false
+			return http.StatusForbidden, reasonExpiredProviderToken
+		}
+		return http.StatusOK, ""
+	}
+	p := srv.provider(t)
+	push := &contracts.PushNotification{DeviceTokens: []string{"a"}, Title: "Hi"}
+
+	if _, err := p.Send(context.Background(), push); !errors.Is(err, pkgerrors.ErrUnauthorized) {
+		t.Fatalf("first Send() error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := p.Send(context.Background(), push); err != nil {
+		t.Fatalf("second Send() error = %v", err)
+	}
+	if srv.tokens[1] == srv.tokens[0] {
+		t.Error("expired provider token was reused")
+	}
+}
@@ -0,0 +1,21 @@
+package apns
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterPushProvider(ProviderName, func(cfg registry.PushConfig) (port.PushSender, error) {
+		return New(Config{
+			KeyFile:  cfg.Extra["key_file"],
+			Key:      cfg.Extra["key"],
+			KeyID:    cfg.Extra["key_id"],
+			TeamID:   cfg.Extra["team_id"],
+			Topic:    cfg.Topic,
+			Sandbox:  cfg.Extra["environment"] == "sandbox" || cfg.Extra["environment"] == "development",
+			BaseURL:  cfg.BaseURL,
+			PushType: cfg.Extra["push_type"],
+		})
+	})
+}
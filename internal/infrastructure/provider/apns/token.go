@@ -0,0 +1,113 @@
+package apns
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// tokenTTL is how long a provider token is reused. Apple rejects tokens older
+// than one hour and throttles refreshes more frequent than every 20 minutes.
+const tokenTTL = 50 * time.Minute
+
+// tokenSigner issues and caches ES256 provider authentication tokens.
+type tokenSigner struct {
+	key    *ecdsa.PrivateKey
+	keyID  string
+	teamID string
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func newTokenSigner(keyPEM []byte, keyID, teamID string) (*tokenSigner, error) {
+	key, err := parseP8Key(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenSigner{key: key, keyID: keyID, teamID: teamID}, nil
+}
+
+// Token returns the cached provider token, signing a new one when it is
+// older than tokenTTL.
+func (s *tokenSigner) Token(now time.Time) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && now.Sub(s.issuedAt) < tokenTTL {
+		return s.token, nil
+	}
+
+	token, err := s.sign(now)
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	s.issuedAt = now
+	return token, nil
+}
+
+// Invalidate drops the cached token so the next call signs a fresh one.
+// Called when APNs reports ExpiredProviderToken.
+func (s *tokenSigner) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+func (s *tokenSigner) sign(now time.Time) (string, error) {
+	headerJSON, err := json.Marshal(map[string]string{"alg": "ES256", "kid": s.keyID})
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(map[string]any{"iss": s.teamID, "iat": now.Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(headerJSON) + "." + enc.EncodeToString(claimsJSON)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, sVal, err := ecdsa.Sign(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("apns: failed to sign provider token: %w", err)
+	}
+
+	// JWS ES256 signatures are the fixed-width concatenation R || S.
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	sVal.FillBytes(sig[32:])
+
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+// parseP8Key parses the PKCS#8 EC private key from an App Store Connect .p8 file.
+func parseP8Key(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("apns: key is not PEM encoded")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("apns: failed to parse key: %w", err)
+	}
+
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("apns: key is not an ECDSA key")
+	}
+	return ecKey, nil
+}
@@ -0,0 +1,222 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "discord"
+	defaultTimeout = 30 * time.Second
+
+	// Discord embed limits.
+	maxEmbedFields = 25
+	maxContentLen  = 2000
+)
+
+var _ port.ChatSender = (*Provider)(nil)
+
+// Config holds Discord webhook configuration.
+type Config struct {
+	WebhookURL string
+	// Username overrides the webhook's default display name.
+	Username string
+}
+
+// Provider implements port.ChatSender for Discord webhooks.
+type Provider struct {
+	client  *http.Client
+	config  Config
+	sendURL string
+}
+
+type payload struct {
+	Content  string  `json:"content,omitempty"`
+	Username string  `json:"username,omitempty"`
+	Embeds   []embed `json:"embeds,omitempty"`
+}
+
+type embed struct {
+	Description string       `json:"description,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Image       *embedImage  `json:"image,omitempty"`
+	Fields      []embedField `json:"fields,omitempty"`
+}
+
+type embedImage struct {
+	URL string `json:"url"`
+}
+
+type embedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type messageResponse struct {
+	ID string `json:"id"`
+}
+
+type errorResponse struct {
+	Message    string  `json:"message"`
+	Code       int     `json:"code"`
+	RetryAfter float64 `json:"retry_after"`
+}
+
+// New creates a new Discord provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.WebhookURL == "" {
+		return nil, errors.New("discord: webhook URL is required")
+	}
+
+	// wait=true makes Discord return the created message, including its ID.
+	u, err := url.Parse(cfg.WebhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("discord: invalid webhook URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("wait", "true")
+	u.RawQuery = q.Encode()
+
+	return &Provider{
+		client:  &http.Client{},
+		config:  cfg,
+		sendURL: u.String(),
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send posts the message to the configured webhook. Media, buttons and
+// metadata are rendered as a single embed.
+func (p *Provider) Send(ctx context.Context, message *contracts.ChatMessage) (*contracts.SendResult, error) {
+	if message.Message == "" && message.MediaURL == "" {
+		return nil, errors.New("discord: message or media URL is required")
+	}
+	if utf8.RuneCountInString(message.Message) > maxContentLen {
+		return nil, fmt.Errorf("discord: message exceeds %d characters", maxContentLen)
+	}
+
+	body, err := json.Marshal(p.buildPayload(message))
+	if err != nil {
+		return nil, fmt.Errorf("discord: failed to encode payload: %w", err)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(sendCtx, http.MethodPost, p.sendURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("discord: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if sendCtx.Err() != nil {
+			return nil, fmt.Errorf("discord: failed to send message: %w", err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send message", 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return nil, perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return nil, newSendError(resp.StatusCode, respBody)
+	}
+
+	var msg messageResponse
+	_ = json.Unmarshal(respBody, &msg)
+
+	return &contracts.SendResult{
+		ID:         msg.ID,
+		StatusCode: http.StatusOK,
+		Message:    "Message sent successfully",
+	}, nil
+}
+
+func (p *Provider) buildPayload(message *contracts.ChatMessage) payload {
+	out := payload{
+		Content:  message.Message,
+		Username: message.From,
+	}
+	if out.Username == "" {
+		out.Username = p.config.Username
+	}
+
+	var e embed
+	if message.MediaURL != "" {
+		if message.MediaType == "" || strings.HasPrefix(message.MediaType, "image") {
+			e.Image = &embedImage{URL: message.MediaURL}
+		} else {
+			e.Description = fmt.Sprintf("[Attachment](%s)", message.MediaURL)
+		}
+	}
+
+	// Webhooks that are not owned by an application cannot send interactive
+	// components, so buttons become markdown links.
+	var links []string
+	for _, b := range message.Buttons {
+		if b.URL != "" {
+			links = append(links, fmt.Sprintf("[%s](%s)", b.Text, b.URL))
+		}
+	}
+	if len(links) > 0 {
+		e.Fields = append(e.Fields, embedField{Name: "Links", Value: strings.Join(links, " · ")})
+	}
+
+	keys := make([]string, 0, len(message.Metadata))
+	for k := range message.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if len(e.Fields) == maxEmbedFields {
+			break
+		}
+		e.Fields = append(e.Fields, embedField{Name: k, Value: message.Metadata[k], Inline: true})
+	}
+
+	if e.Image != nil || e.Description != "" || len(e.Fields) > 0 {
+		out.Embeds = []embed{e}
+	}
+	return out
+}
+
+func newSendError(statusCode int, body []byte) *pkgerrors.ProviderError {
+	var resp errorResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Message == "" {
+		resp.Message = strings.TrimSpace(string(body))
+	}
+
+	msg := resp.Message
+	if statusCode == http.StatusTooManyRequests && resp.RetryAfter > 0 {
+		msg = fmt.Sprintf("%s (retry after %.1fs)", msg, resp.RetryAfter)
+	}
+
+	perr := pkgerrors.NewProviderError(ProviderName, "failed to send message", statusCode, errors.New(msg))
+	if resp.Code != 0 {
+		perr.Code = strconv.Itoa(resp.Code)
+	}
+	return perr
+}
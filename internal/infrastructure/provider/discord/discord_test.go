@@ -0,0 +1,181 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantErr     bool
+		wantSendURL string
+	}{
+		{"valid", Config{WebhookURL: "https://discord.com/api/webhooks/1/abc"}, false, "https://discord.com/api/webhooks/1/abc?wait=true"},
+		{"keeps query", Config{WebhookURL: "https://discord.com/api/webhooks/1/abc?thread_id=9"}, false, "https://discord.com/api/webhooks/1/abc?thread_id=9&wait=true"},
+		{"missing webhook URL", Config{}, true, ""},
+		{"invalid webhook URL", Config{WebhookURL: "://bad"}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && p.sendURL != tt.wantSendURL {
+				t.Errorf("send URL = %q, want %q", p.sendURL, tt.wantSendURL)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		message contracts.ChatMessage
+		want    string
+	}{
+		{
+			name:    "text with configured username",
+			cfg:     Config{Username: "Gateway"},
+			message: contracts.ChatMessage{Message: "Hello"},
+			want:    `{"content":"Hello","username":"Gateway"}`,
+		},
+		{
+			name:    "message from overrides username",
+			cfg:     Config{Username: "Gateway"},
+			message: contracts.ChatMessage{From: "Deploy Bot", Message: "Hello"},
+			want:    `{"content":"Hello","username":"Deploy Bot"}`,
+		},
+		{
+			name:    "image embed",
+			message: contracts.ChatMessage{MediaURL: "https://example.com/a.png", MediaType: "image/png"},
+			want:    `{"embeds":[{"image":{"url":"https://example.com/a.png"}}]}`,
+		},
+		{
+			name:    "non-image media is a link",
+			message: contracts.ChatMessage{Message: "Report", MediaURL: "https://example.com/r.pdf", MediaType: "document"},
+			want:    `{"content":"Report","embeds":[{"description":"[Attachment](https://example.com/r.pdf)"}]}`,
+		},
+		{
+			name: "url buttons become links and metadata fields",
+			message: contracts.ChatMessage{
+				Message:  "Released",
+				Buttons:  []contracts.ChatButton{{ID: "x", Text: "Callback only"}, {Text: "Notes", URL: "https://example.com/notes"}},
+				Metadata: map[string]string{"version": "1.2", "env": "prod"},
+			},
+			want: `{"content":"Released","embeds":[{"fields":[` +
+				`{"name":"Links","value":"[Notes](https://example.com/notes)"},` +
+				`{"name":"env","value":"prod","inline":true},` +
+				`{"name":"version","value":"1.2","inline":true}]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			var r *http.Request
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				r = req
+				data, _ := io.ReadAll(req.Body)
+				got = string(data)
+				_, _ = w.Write([]byte(`{"id":"112233"}`))
+			}))
+			defer srv.Close()
+
+			cfg := tt.cfg
+			cfg.WebhookURL = srv.URL + "/api/webhooks/1/abc"
+			p, _ := New(cfg)
+			result, err := p.Send(context.Background(), &tt.message)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "112233" {
+				t.Errorf("ID = %q", result.ID)
+			}
+			if r.Method != http.MethodPost || r.URL.Path != "/api/webhooks/1/abc" || r.URL.Query().Get("wait") != "true" {
+				t.Errorf("request = %s %s", r.Method, r.URL)
+			}
+			if got != tt.want {
+				t.Errorf("payload = %s\nwant      %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSend_ContentLength(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer srv.Close()
+	p, _ := New(Config{WebhookURL: srv.URL})
+
+	tests := []struct {
+		name    string
+		message string
+		wantErr bool
+	}{
+		{"ascii at limit", strings.Repeat("a", maxContentLen), false},
+		{"ascii over limit", strings.Repeat("a", maxContentLen+1), true},
+		{"multi-byte at limit", strings.Repeat("é", maxContentLen), false},
+		{"emoji at limit", strings.Repeat("🚀", maxContentLen), false},
+		{"multi-byte over limit", strings.Repeat("é", maxContentLen+1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Send(context.Background(), &contracts.ChatMessage{Message: tt.message})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantKind error
+		wantCode string
+		wantMsg  string
+	}{
+		{"invalid form body", http.StatusBadRequest, `{"message":"Invalid Form Body","code":50035}`, pkgerrors.ErrRejected, "50035", "Invalid Form Body"},
+		{"unknown webhook", http.StatusNotFound, `{"message":"Unknown Webhook","code":10015}`, pkgerrors.ErrRejected, "10015", "Unknown Webhook"},
+		{"invalid token", http.StatusUnauthorized, `{"message":"Invalid Webhook Token","code":50027}`, pkgerrors.ErrUnauthorized, "50027", "Invalid Webhook Token"},
+		{"rate limited", http.StatusTooManyRequests, `{"message":"You are being rate limited.","retry_after":1.5,"global":false}`, pkgerrors.ErrRateLimited, "", "retry after 1.5s"},
+		{"outage", http.StatusBadGateway, `<html>bad gateway</html>`, pkgerrors.ErrUnavailable, "", "bad gateway"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{WebhookURL: srv.URL})
+			_, err := p.Send(context.Background(), &contracts.ChatMessage{Message: "Hi"})
+
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.wantKind) || perr.Code != tt.wantCode {
+				t.Errorf("kind = %v code = %q, want %v %q", perr.Kind, perr.Code, tt.wantKind, tt.wantCode)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
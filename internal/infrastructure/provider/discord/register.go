@@ -0,0 +1,15 @@
+package discord
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterChatProvider(ProviderName, func(cfg registry.ChatConfig) (port.ChatSender, error) {
+		return New(Config{
+			WebhookURL: cfg.WebhookURL,
+			Username:   cfg.Extra["username"],
+		})
+	})
+}
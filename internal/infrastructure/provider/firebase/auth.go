@@ -0,0 +1,194 @@
+package firebase
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	messagingScope  = "https://www.googleapis.com/auth/firebase.messaging"
+	defaultTokenURL = "https://oauth2.googleapis.com/token"
+	jwtBearerGrant  = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	assertionTTL    = time.Hour
+	// refreshSkew renews the access token this long before it expires.
+	refreshSkew = time.Minute
+)
+
+// serviceAccount is the subset of a Google service-account key file we need.
+type serviceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+func parseServiceAccount(data []byte) (*serviceAccount, error) {
+	var sa serviceAccount
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return nil, fmt.Errorf("firebase: invalid service account JSON: %w", err)
+	}
+	if sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, errors.New("firebase: service account must contain client_email and private_key")
+	}
+	return &sa, nil
+}
+
+// tokenSource mints and caches OAuth2 access tokens using the
+// JWT bearer flow (RFC 7523) with the service-account key.
+type tokenSource struct {
+	client   *http.Client
+	email    string
+	keyID    string
+	key      *rsa.PrivateKey
+	tokenURL string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newTokenSource(client *http.Client, sa *serviceAccount, tokenURL string) (*tokenSource, error) {
+	key, err := parseRSAKey(sa.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if tokenURL == "" {
+		tokenURL = sa.TokenURI
+	}
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+
+	return &tokenSource{
+		client:   client,
+		email:    sa.ClientEmail,
+		keyID:    sa.PrivateKeyID,
+		key:      key,
+		tokenURL: tokenURL,
+	}, nil
+}
+
+// Token returns a valid access token, fetching a new one when the cached
+// token is missing or about to expire.
+func (ts *tokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token != "" && time.Now().Add(refreshSkew).Before(ts.expires) {
+		return ts.token, nil
+	}
+
+	assertion, err := ts.signAssertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", jwtBearerGrant)
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("firebase: failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("firebase: token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("firebase: failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("firebase: token request returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil || tok.AccessToken == "" {
+		return "", fmt.Errorf("firebase: invalid token response: %s", strings.TrimSpace(string(body)))
+	}
+
+	ts.token = tok.AccessToken
+	ts.expires = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return ts.token, nil
+}
+
+// signAssertion builds the RS256-signed JWT exchanged for an access token.
+func (ts *tokenSource) signAssertion(now time.Time) (string, error) {
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	if ts.keyID != "" {
+		header["kid"] = ts.keyID
+	}
+	claims := map[string]any{
+		"iss":   ts.email,
+		"scope": messagingScope,
+		"aud":   ts.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(assertionTTL).Unix(),
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(headerJSON) + "." + enc.EncodeToString(claimsJSON)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, ts.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("firebase: failed to sign assertion: %w", err)
+	}
+
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+func parseRSAKey(pemData string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, errors.New("firebase: private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("firebase: private key is not an RSA key")
+		}
+		return rsaKey, nil
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("firebase: failed to parse private key: %w", err)
+	}
+	return key, nil
+}
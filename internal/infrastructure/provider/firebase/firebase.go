@@ -0,0 +1,399 @@
+package firebase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "firebase"
+	defaultBaseURL = "https://fcm.googleapis.com"
+	defaultTimeout = 30 * time.Second
+	// maxConcurrency bounds the number of in-flight FCM requests per Send.
+	maxConcurrency = 10
+)
+
+// ErrorCodeUnregistered is the FCM error code meaning the device token is
+// no longer valid and should be removed. INVALID_ARGUMENT is not treated
+// the same way, as it is also returned for a malformed message.
+const ErrorCodeUnregistered = "UNREGISTERED"
+
+var _ port.PushSender = (*Provider)(nil)
+
+// Config holds Firebase Cloud Messaging configuration.
+type Config struct {
+	// ServiceAccountFile is the path to a service-account key file.
+	ServiceAccountFile string
+	// ServiceAccountJSON is the key file content; takes precedence over ServiceAccountFile.
+	ServiceAccountJSON string
+	// ProjectID defaults to the project_id of the service account.
+	ProjectID string
+	BaseURL   string
+	// TokenURL overrides the OAuth2 token endpoint from the service account.
+	TokenURL string
+}
+
+// Provider implements port.PushSender for FCM HTTP v1.
+type Provider struct {
+	client    *http.Client
+	config    Config
+	projectID string
+	tokens    *tokenSource
+}
+
+// sendRequest is the body of POST /v1/projects/{project}/messages:send.
+type sendRequest struct {
+	Message message `json:"message"`
+}
+
+type message struct {
+	Token        string            `json:"token"`
+	Notification *notification     `json:"notification,omitempty"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      *androidConfig    `json:"android,omitempty"`
+	APNS         *apnsConfig       `json:"apns,omitempty"`
+}
+
+type notification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+type androidConfig struct {
+	Priority     string               `json:"priority,omitempty"`
+	CollapseKey  string               `json:"collapse_key,omitempty"`
+	TTL          string               `json:"ttl,omitempty"`
+	Notification *androidNotification `json:"notification,omitempty"`
+}
+
+type androidNotification struct {
+	Sound string `json:"sound,omitempty"`
+}
+
+type apnsConfig struct {
+	Headers map[string]string `json:"headers,omitempty"`
+	Payload apnsPayload       `json:"payload"`
+}
+
+type apnsPayload struct {
+	APS aps `json:"aps"`
+}
+
+type aps struct {
+	Badge            *int   `json:"badge,omitempty"`
+	Sound            string `json:"sound,omitempty"`
+	ContentAvailable int    `json:"content-available,omitempty"`
+}
+
+type sendResponse struct {
+	Name string `json:"name"`
+}
+
+// errorResponse is the google.rpc.Status error envelope returned by FCM.
+type errorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type      string `json:"@type"`
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// tokenResult is the outcome of sending to a single device token.
+type tokenResult struct {
+	token string
+	name  string
+	err   error
+	code  string
+}
+
+// New creates a new FCM provider.
+func New(cfg Config) (*Provider, error) {
+	data := []byte(cfg.ServiceAccountJSON)
+	if len(data) == 0 {
+		if cfg.ServiceAccountFile == "" {
+			return nil, errors.New("firebase: service account file is required")
+		}
+		var err error
+		data, err = os.ReadFile(cfg.ServiceAccountFile)
+		if err != nil {
+			return nil, fmt.Errorf("firebase: failed to read service account file: %w", err)
+		}
+	}
+
+	sa, err := parseServiceAccount(data)
+	if err != nil {
+		return nil, err
+	}
+
+	projectID := cfg.ProjectID
+	if projectID == "" {
+		projectID = sa.ProjectID
+	}
+	if projectID == "" {
+		return nil, errors.New("firebase: project ID is required")
+	}
+
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	client := &http.Client{}
+	tokens, err := newTokenSource(client, sa, cfg.TokenURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		client:    client,
+		config:    cfg,
+		projectID: projectID,
+		tokens:    tokens,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send delivers the notification to every device token.
+//
+// Per-token outcomes are reported in SendResult.Meta as "id:<token>" (FCM message name)
+// and "error:<token>" (FCM error code). Tokens that should be pruned are also listed,
+// comma-separated, under "unregistered". An error is returned when no token succeeded.
+func (p *Provider) Send(ctx context.Context, push *contracts.PushNotification) (*contracts.SendResult, error) {
+	if len(push.DeviceTokens) == 0 {
+		return nil, errors.New("no device tokens specified")
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	accessToken, err := p.tokens.Token(sendCtx)
+	if err != nil {
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to obtain access token", 0, err)
+		perr.Kind = pkgerrors.ErrUnauthorized
+		return nil, perr
+	}
+
+	results := make([]tokenResult, len(push.DeviceTokens))
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, token := range push.DeviceTokens {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, token string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = p.sendOne(sendCtx, accessToken, token, push)
+		}(i, token)
+	}
+	wg.Wait()
+
+	return aggregate(results)
+}
+
+func aggregate(results []tokenResult) (*contracts.SendResult, error) {
+	meta := make(map[string]string, len(results)+4)
+	var unregistered []string
+	var firstID string
+	var firstErr error
+	sent := 0
+
+	for _, r := range results {
+		if r.err == nil {
+			sent++
+			if firstID == "" {
+				firstID = r.name
+			}
+			meta["id:"+r.token] = r.name
+			continue
+		}
+
+		if firstErr == nil {
+			firstErr = r.err
+		}
+		meta["error:"+r.token] = r.code
+		if r.code == ErrorCodeUnregistered {
+			unregistered = append(unregistered, r.token)
+		}
+	}
+
+	if sent == 0 {
+		if len(results) == 1 {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("firebase: all %d device tokens failed: %w", len(results), firstErr)
+	}
+
+	meta["sent"] = strconv.Itoa(sent)
+	meta["failed"] = strconv.Itoa(len(results) - sent)
+	if len(unregistered) > 0 {
+		meta["unregistered"] = strings.Join(unregistered, ",")
+	}
+
+	result := &contracts.SendResult{
+		ID:         firstID,
+		StatusCode: http.StatusOK,
+		Message:    "Push notification sent successfully",
+		Meta:       meta,
+	}
+	if sent < len(results) {
+		result.StatusCode = http.StatusMultiStatus
+		result.Message = fmt.Sprintf("Push notification sent to %d of %d devices", sent, len(results))
+	}
+	return result, nil
+}
+
+func (p *Provider) sendOne(ctx context.Context, accessToken, token string, push *contracts.PushNotification) tokenResult {
+	body, err := json.Marshal(sendRequest{Message: buildMessage(token, push)})
+	if err != nil {
+		return tokenResult{token: token, err: fmt.Errorf("firebase: failed to encode request: %w", err)}
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", p.config.BaseURL, p.projectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return tokenResult{token: token, err: fmt.Errorf("firebase: failed to create request: %w", err)}
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return tokenResult{token: token, err: fmt.Errorf("firebase: failed to send push: %w", err)}
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send push", 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return tokenResult{token: token, err: perr}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return tokenResult{token: token, err: fmt.Errorf("firebase: failed to read response: %w", err)}
+	}
+
+	if resp.StatusCode >= 300 {
+		code, perr := newSendError(resp.StatusCode, data)
+		return tokenResult{token: token, err: perr, code: code}
+	}
+
+	var out sendResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return tokenResult{token: token, err: fmt.Errorf("firebase: failed to decode response: %w", err)}
+	}
+	return tokenResult{token: token, name: out.Name}
+}
+
+func buildMessage(token string, push *contracts.PushNotification) message {
+	msg := message{
+		Token: token,
+		Data:  push.Data,
+	}
+
+	hasAlert := push.Title != "" || push.Body != ""
+	if hasAlert {
+		msg.Notification = &notification{Title: push.Title, Body: push.Body}
+	}
+
+	android := &androidConfig{CollapseKey: push.CollapseID}
+	apnsHeaders := map[string]string{}
+
+	switch push.Priority {
+	case contracts.PushPriorityHigh:
+		android.Priority = "HIGH"
+		apnsHeaders["apns-priority"] = "10"
+	case contracts.PushPriorityNormal:
+		android.Priority = "NORMAL"
+		apnsHeaders["apns-priority"] = "5"
+	}
+	if push.CollapseID != "" {
+		apnsHeaders["apns-collapse-id"] = push.CollapseID
+	}
+	if push.ExpiresAt != nil {
+		ttl := time.Until(*push.ExpiresAt).Round(time.Second)
+		if ttl < 0 {
+			ttl = 0
+		}
+		android.TTL = fmt.Sprintf("%ds", int64(ttl.Seconds()))
+		apnsHeaders["apns-expiration"] = strconv.FormatInt(push.ExpiresAt.Unix(), 10)
+	}
+	if push.Sound != "" {
+		android.Notification = &androidNotification{Sound: push.Sound}
+	}
+	if *android != (androidConfig{}) {
+		msg.Android = android
+	}
+
+	if push.Badge != nil || push.Sound != "" || !hasAlert || len(apnsHeaders) > 0 {
+		apsPayload := aps{Badge: push.Badge, Sound: push.Sound}
+		if !hasAlert {
+			// Data-only messages must be flagged to wake the app on iOS.
+			apsPayload.ContentAvailable = 1
+		}
+		if len(apnsHeaders) == 0 {
+			apnsHeaders = nil
+		}
+		msg.APNS = &apnsConfig{Headers: apnsHeaders, Payload: apnsPayload{APS: apsPayload}}
+	}
+
+	return msg
+}
+
+// errorKinds maps FCM error codes to gateway error kinds.
+// See https://firebase.google.com/docs/reference/fcm/rest/v1/ErrorCode.
+var errorKinds = map[string]error{
+	ErrorCodeUnregistered:    pkgerrors.ErrInvalidRecipient,
+	"SENDER_ID_MISMATCH":     pkgerrors.ErrInvalidRecipient,
+	"THIRD_PARTY_AUTH_ERROR": pkgerrors.ErrUnauthorized,
+	"UNAUTHENTICATED":        pkgerrors.ErrUnauthorized,
+	"PERMISSION_DENIED":      pkgerrors.ErrUnauthorized,
+	"QUOTA_EXCEEDED":         pkgerrors.ErrRateLimited,
+	"UNAVAILABLE":            pkgerrors.ErrUnavailable,
+	"INTERNAL":               pkgerrors.ErrUnavailable,
+}
+
+// newSendError decodes an FCM error body, returning the FCM error code
+// (preferring the FcmError detail over the generic RPC status) and a typed error.
+func newSendError(statusCode int, body []byte) (string, *pkgerrors.ProviderError) {
+	var resp errorResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error.Message == "" {
+		return "", pkgerrors.NewProviderError(ProviderName, "failed to send push", statusCode, errors.New(strings.TrimSpace(string(body))))
+	}
+
+	code := resp.Error.Status
+	for _, d := range resp.Error.Details {
+		if strings.HasSuffix(d.Type, "google.firebase.fcm.v1.FcmError") && d.ErrorCode != "" {
+			code = d.ErrorCode
+		}
+	}
+
+	perr := pkgerrors.NewProviderError(ProviderName, "failed to send push", statusCode, errors.New(resp.Error.Message))
+	perr.Code = code
+	if kind, ok := errorKinds[code]; ok {
+		perr.Kind = kind
+	}
+	return code, perr
+}
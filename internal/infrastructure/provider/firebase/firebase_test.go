@@ -0,0 +1,372 @@
+package firebase
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// fcmServer stands in for both the OAuth2 token endpoint and the FCM API.
+type fcmServer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	tokenRequests atomic.Int32
+	tokenStatus   int
+	// respond returns the status and body for a device token.
+	respond func(token string) (int, string)
+
+	mu       sync.Mutex
+	requests []*http.Request
+	messages map[string]message
+}
+
+func newFCMServer(t *testing.T) *fcmServer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fcmServer{
+		key:         key,
+		tokenStatus: http.StatusOK,
+		messages:    map[string]message{},
+		respond: func(string) (int, string) {
+			return http.StatusOK, `{"name":"projects/proj/messages/1"}`
+		},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fcmServer) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		s.tokenRequests.Add(1)
+		_ = r.ParseForm()
+		if r.PostForm.Get("grant_type") != jwtBearerGrant || !s.validAssertion(r.PostForm.Get("assertion")) {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		if s.tokenStatus != http.StatusOK {
+			http.Error(w, `{"error":"unavailable"}`, s.tokenStatus)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"at-1","expires_in":3600}`))
+		return
+	}
+
+	var req sendRequest
+	data, _ := io.ReadAll(r.Body)
+	_ = json.Unmarshal(data, &req)
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	s.messages[req.Message.Token] = req.Message
+	s.mu.Unlock()
+
+	status, body := s.respond(req.Message.Token)
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body))
+}
+
+// validAssertion verifies the RS256 signature and claims of a JWT assertion.
+func (s *fcmServer) validAssertion(assertion string) bool {
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(&s.key.PublicKey, crypto.SHA256, digest[:], sig) != nil {
+		return false
+	}
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]any
+	_ = json.Unmarshal(claimsJSON, &claims)
+	return claims["iss"] == "fcm@proj.iam.gserviceaccount.com" && claims["scope"] == messagingScope && claims["aud"] == s.URL+"/token"
+}
+
+func (s *fcmServer) serviceAccount(t *testing.T) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(s.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sa, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "proj",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email": "fcm@proj.iam.gserviceaccount.com",
+	})
+	return string(sa)
+}
+
+func (s *fcmServer) provider(t *testing.T) *Provider {
+	t.Helper()
+
+	p, err := New(Config{ServiceAccountJSON: s.serviceAccount(t), BaseURL: s.URL, TokenURL: s.URL + "/token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNew(t *testing.T) {
+	srv := newFCMServer(t)
+	sa := srv.serviceAccount(t)
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{ServiceAccountJSON: sa}, false},
+		{"project override", Config{ServiceAccountJSON: sa, ProjectID: "other"}, false},
+		{"missing service account", Config{}, true},
+		{"missing file", Config{ServiceAccountFile: "/nonexistent/sa.json"}, true},
+		{"invalid JSON", Config{ServiceAccountJSON: "{"}, true},
+		{"missing key", Config{ServiceAccountJSON: `{"client_email":"a@b.c","project_id":"p"}`}, true},
+		{"bad PEM", Config{ServiceAccountJSON: `{"client_email":"a@b.c","project_id":"p","private_key":"nope"}`}, true},
+		{"missing project", Config{ServiceAccountJSON: strings.Replace(sa, `"project_id":"proj"`, `"project_id":""`, 1)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	badge := 3
+
+	tests := []struct {
+		name string
+		push contracts.PushNotification
+		want string
+	}{
+		{
+			name: "alert",
+			push: contracts.PushNotification{Title: "Hi", Body: "There", Data: map[string]string{"k": "v"}},
+			want: `{"token":"tok-1","notification":{"title":"Hi","body":"There"},"data":{"k":"v"}}`,
+		},
+		{
+			name: "data only wakes the app on iOS",
+			push: contracts.PushNotification{Data: map[string]string{"k": "v"}},
+			want: `{"token":"tok-1","data":{"k":"v"},"apns":{"payload":{"aps":{"content-available":1}}}}`,
+		},
+		{
+			name: "badge and sound",
+			push: contracts.PushNotification{Title: "Hi", Badge: &badge, Sound: "ping.caf"},
+			want: `{"token":"tok-1","notification":{"title":"Hi"},"android":{"notification":{"sound":"ping.caf"}},"apns":{"payload":{"aps":{"badge":3,"sound":"ping.caf"}}}}`,
+		},
+		{
+			name: "high priority with collapse ID",
+			push: contracts.PushNotification{Title: "Hi", Priority: contracts.PushPriorityHigh, CollapseID: "score"},
+			want: `{"token":"tok-1","notification":{"title":"Hi"},"android":{"priority":"HIGH","collapse_key":"score"},"apns":{"headers":{"apns-collapse-id":"score","apns-priority":"10"},"payload":{"aps":{}}}}`,
+		},
+		{
+			name: "normal priority",
+			push: contracts.PushNotification{Title: "Hi", Priority: contracts.PushPriorityNormal},
+			want: `{"token":"tok-1","notification":{"title":"Hi"},"android":{"priority":"NORMAL"},"apns":{"headers":{"apns-priority":"5"},"payload":{"aps":{}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFCMServer(t)
+			p := srv.provider(t)
+
+			tt.push.DeviceTokens = []string{"tok-1"}
+			result, err := p.Send(context.Background(), &tt.push)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "projects/proj/messages/1" || result.StatusCode != http.StatusOK {
+				t.Errorf("result = %+v", result)
+			}
+
+			r := srv.requests[0]
+			if r.Method != http.MethodPost || r.URL.Path != "/v1/projects/proj/messages:send" {
+				t.Errorf("request = %s %s", r.Method, r.URL.Path)
+			}
+			if auth := r.Header.Get("Authorization"); auth != "Bearer at-1" {
+				t.Errorf("Authorization = %q", auth)
+			}
+			got, _ := json.Marshal(srv.messages["tok-1"])
+			if string(got) != tt.want {
+				t.Errorf("message = %s\nwant      %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSend_CachesAccessToken(t *testing.T) {
+	srv := newFCMServer(t)
+	p := srv.provider(t)
+
+	push := &contracts.PushNotification{DeviceTokens: []string{"a", "b", "c"}, Title: "Hi"}
+	for range 2 {
+		if _, err := p.Send(context.Background(), push); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	if n := srv.tokenRequests.Load(); n != 1 {
+		t.Errorf("token requests = %d, want 1", n)
+	}
+	if len(srv.requests) != 6 {
+		t.Errorf("send requests = %d, want 6", len(srv.requests))
+	}
+}
+
+func TestSend_TokenFailure(t *testing.T) {
+	srv := newFCMServer(t)
+	srv.tokenStatus = http.StatusServiceUnavailable
+	p := srv.provider(t)
+
+	_, err := p.Send(context.Background(), &contracts.PushNotification{DeviceTokens: []string{"a"}, Title: "Hi"})
+	if !errors.Is(err, pkgerrors.ErrUnauthorized) {
+		t.Errorf("error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func fcmError(status int, rpcStatus, fcmCode string) (int, string) {
+	body := map[string]any{"error": map[string]any{
+		"code":    status,
+		"message": "failed: " + fcmCode,
+		"status":  rpcStatus,
+		"details": []map[string]string{{"@type": "type.googleapis.com/google.firebase.fcm.v1.FcmError", "errorCode": fcmCode}},
+	}}
+	data, _ := json.Marshal(body)
+	return status, string(data)
+}
+
+func TestSend_Errors(t *testing.T) {
+	unregistered := func(string) (int, string) { return fcmError(http.StatusNotFound, "NOT_FOUND", ErrorCodeUnregistered) }
+	invalidArgument := func(string) (int, string) {
+		return fcmError(http.StatusBadRequest, "INVALID_ARGUMENT", "INVALID_ARGUMENT")
+	}
+
+	tests := []struct {
+		name             string
+		tokens           []string
+		respond          func(token string) (int, string)
+		wantErr          error
+		wantStatus       int
+		wantUnregistered string
+	}{
+		{
+			name:    "all unregistered",
+			tokens:  []string{"a", "b"},
+			respond: unregistered,
+			wantErr: pkgerrors.ErrInvalidRecipient,
+		},
+		{
+			name:    "single invalid argument",
+			tokens:  []string{"a"},
+			respond: invalidArgument,
+			wantErr: pkgerrors.ErrRejected,
+		},
+		{
+			name:    "all invalid argument",
+			tokens:  []string{"a", "b"},
+			respond: invalidArgument,
+			wantErr: pkgerrors.ErrRejected,
+		},
+		{
+			name:   "quota exceeded",
+			tokens: []string{"a"},
+			respond: func(string) (int, string) {
+				return fcmError(http.StatusTooManyRequests, "RESOURCE_EXHAUSTED", "QUOTA_EXCEEDED")
+			},
+			wantErr: pkgerrors.ErrRateLimited,
+		},
+		{
+			name:   "third party auth",
+			tokens: []string{"a"},
+			respond: func(string) (int, string) {
+				return fcmError(http.StatusUnauthorized, "UNAUTHENTICATED", "THIRD_PARTY_AUTH_ERROR")
+			},
+			wantErr: pkgerrors.ErrUnauthorized,
+		},
+		{
+			name:    "non-JSON outage",
+			tokens:  []string{"a"},
+			respond: func(string) (int, string) { return http.StatusBadGateway, "bad gateway" },
+			wantErr: pkgerrors.ErrUnavailable,
+		},
+		{
+			name:   "some unregistered",
+			tokens: []string{"ok", "gone"},
+			respond: func(token string) (int, string) {
+				if token == "gone" {
+					return unregistered(token)
+				}
+				return http.StatusOK, `{"name":"projects/proj/messages/1"}`
+			},
+			wantStatus:       http.StatusMultiStatus,
+			wantUnregistered: "gone",
+		},
+		{
+			name:   "some invalid argument are not pruned",
+			tokens: []string{"ok", "bad"},
+			respond: func(token string) (int, string) {
+				if token == "bad" {
+					return invalidArgument(token)
+				}
+				return http.StatusOK, `{"name":"projects/proj/messages/1"}`
+			},
+			wantStatus: http.StatusMultiStatus,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFCMServer(t)
+			srv.respond = tt.respond
+			p := srv.provider(t)
+
+			result, err := p.Send(context.Background(), &contracts.PushNotification{DeviceTokens: tt.tokens, Title: "Hi"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", result.StatusCode, tt.wantStatus)
+			}
+			if result.Meta["unregistered"] != tt.wantUnregistered {
+				t.Errorf("unregistered = %q, want %q", result.Meta["unregistered"], tt.wantUnregistered)
+			}
+			if _, ok := result.Meta["invalid"]; ok {
+				t.Errorf("meta lists invalid tokens: %v", result.Meta)
+			}
+		})
+	}
+}
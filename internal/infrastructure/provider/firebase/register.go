@@ -0,0 +1,23 @@
+package firebase
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterPushProvider(ProviderName, func(cfg registry.PushConfig) (port.PushSender, error) {
+		projectID := cfg.Extra["project_id"]
+		if projectID == "" {
+			projectID = cfg.AppID
+		}
+
+		return New(Config{
+			ServiceAccountFile: cfg.Extra["service_account_file"],
+			ServiceAccountJSON: cfg.Extra["service_account_json"],
+			ProjectID:          projectID,
+			BaseURL:            cfg.BaseURL,
+			TokenURL:           cfg.Extra["token_url"],
+		})
+	})
+}
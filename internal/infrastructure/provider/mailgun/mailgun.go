@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/mailgun/mailgun-go/v4"
 
 	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
 )
 
 const (
@@ -110,6 +112,16 @@ func (p *Provider) Send(ctx context.Context, email *contracts.Email) (*contracts
 
 	_, id, err := p.client.Send(sendCtx, msg)
 	if err != nil {
+		var respErr *mailgun.UnexpectedResponseError
+		if errors.As(err, &respErr) {
+			return nil, pkgerrors.NewProviderError(ProviderName, "failed to send email", respErr.Actual, err)
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && sendCtx.Err() == nil {
+			perr := pkgerrors.NewProviderError(ProviderName, "failed to send email", 0, err)
+			perr.Kind = pkgerrors.ErrUnavailable
+			return nil, perr
+		}
 		return nil, fmt.Errorf("mailgun: failed to send email: %w", err)
 	}
 
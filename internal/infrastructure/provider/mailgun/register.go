@@ -15,4 +15,8 @@ func init() {
 			FromName:  cfg.FromName,
 		})
 	})
+
+	registry.RegisterWebhookParser(ProviderName, func(cfg registry.CommonConfig) (port.WebhookParser, error) {
+		return NewWebhookParser(cfg.Extra["webhook_signing_key"])
+	})
 }
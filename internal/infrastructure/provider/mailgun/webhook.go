@@ -0,0 +1,179 @@
+package mailgun
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// maxWebhookAge is how far a webhook's signed timestamp may be from now.
+// Mailgun signs every request with a fresh token, so a token seen again
+// within this window is a replay.
+const maxWebhookAge = 5 * time.Minute
+
+var _ port.WebhookParser = (*WebhookParser)(nil)
+
+// WebhookParser parses Mailgun event webhooks, verified with the
+// account's HTTP webhook signing key.
+type WebhookParser struct {
+	signingKey []byte
+	now        func() time.Time
+
+	mu sync.Mutex
+	// tokens maps the tokens of verified requests to their timestamps,
+	// until they are older than maxWebhookAge.
+	tokens map[string]time.Time
+}
+
+// NewWebhookParser creates a Mailgun webhook parser.
+func NewWebhookParser(signingKey string) (*WebhookParser, error) {
+	if signingKey == "" {
+		return nil, errors.New("mailgun: webhook signing key is required")
+	}
+	return &WebhookParser{
+		signingKey: []byte(signingKey),
+		now:        time.Now,
+		tokens:     make(map[string]time.Time),
+	}, nil
+}
+
+type webhookPayload struct {
+	Signature struct {
+		Timestamp string `json:"timestamp"`
+		Token     string `json:"token"`
+		Signature string `json:"signature"`
+	} `json:"signature"`
+	EventData struct {
+		Event     string  `json:"event"`
+		Timestamp float64 `json:"timestamp"`
+		Recipient string  `json:"recipient"`
+		Severity  string  `json:"severity"`
+		Reason    string  `json:"reason"`
+		Message   struct {
+			Headers struct {
+				MessageID string `json:"message-id"`
+			} `json:"headers"`
+		} `json:"message"`
+		DeliveryStatus struct {
+			Description string `json:"description"`
+			Message     string `json:"message"`
+		} `json:"delivery-status"`
+	} `json:"event-data"`
+}
+
+// Verify checks the HMAC-SHA256 of the timestamp and token against the
+// signature, and rejects requests signed more than maxWebhookAge from now
+// or whose token has already been seen.
+func (p *WebhookParser) Verify(req *port.WebhookRequest) error {
+	var payload webhookPayload
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		return port.ErrInvalidSignature
+	}
+
+	sig := payload.Signature
+	mac := hmac.New(sha256.New, p.signingKey)
+	mac.Write([]byte(sig.Timestamp + sig.Token))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if sig.Signature == "" || !hmac.Equal([]byte(expected), []byte(sig.Signature)) {
+		return port.ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(sig.Timestamp, 10, 64)
+	if err != nil {
+		return port.ErrInvalidSignature
+	}
+	signedAt := time.Unix(unix, 0)
+	now := p.now()
+	if age := now.Sub(signedAt); age > maxWebhookAge || age < -maxWebhookAge {
+		return port.ErrInvalidSignature
+	}
+	if !p.firstUse(sig.Token, signedAt, now) {
+		return port.ErrInvalidSignature
+	}
+	return nil
+}
+
+// firstUse records a token and reports whether it was new, forgetting
+// tokens too old to pass the timestamp check.
+func (p *WebhookParser) firstUse(token string, signedAt, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for t, at := range p.tokens {
+		if now.Sub(at) > maxWebhookAge {
+			delete(p.tokens, t)
+		}
+	}
+	if _, seen := p.tokens[token]; seen {
+		return false
+	}
+	p.tokens[token] = signedAt
+	return true
+}
+
+// Parse returns the event in the request. Temporary failures, which
+// Mailgun retries, and unsubscribes are skipped.
+func (p *WebhookParser) Parse(req *port.WebhookRequest) ([]contracts.DeliveryEvent, error) {
+	var payload webhookPayload
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	data := payload.EventData
+	var status contracts.MessageStatus
+	switch data.Event {
+	case "delivered":
+		status = contracts.StatusDelivered
+	case "failed":
+		if data.Severity != "permanent" {
+			return nil, nil
+		}
+		status = contracts.StatusBounced
+	case "rejected":
+		status = contracts.StatusFailed
+	case "opened":
+		status = contracts.StatusOpened
+	case "clicked":
+		status = contracts.StatusClicked
+	case "complained":
+		status = contracts.StatusComplained
+	default:
+		return nil, nil
+	}
+
+	messageID := data.Message.Headers.MessageID
+	if messageID == "" {
+		return nil, errors.New("missing message-id")
+	}
+
+	reason := data.DeliveryStatus.Description
+	if reason == "" {
+		reason = data.DeliveryStatus.Message
+	}
+	if reason == "" {
+		reason = data.Reason
+	}
+
+	sec, frac := math.Modf(data.Timestamp)
+	return []contracts.DeliveryEvent{{
+		Provider: ProviderName,
+		// Sends return the ID in angle brackets; the webhook header does not.
+		ProviderMessageID: "<" + strings.Trim(messageID, "<>") + ">",
+		Status:            status,
+		Recipient:         data.Recipient,
+		Reason:            reason,
+		Time:              time.Unix(int64(sec), int64(frac*1e9)),
+	}}, nil
+}
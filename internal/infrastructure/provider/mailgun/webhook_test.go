@@ -0,0 +1,105 @@
+package mailgun
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+const testSigningKey = "key-secret"
+
+// signedRequest builds a webhook request signed with testSigningKey.
+func signedRequest(t *testing.T, signedAt time.Time, token string) *port.WebhookRequest {
+	t.Helper()
+
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(testSigningKey))
+	mac.Write([]byte(timestamp + token))
+
+	var payload webhookPayload
+	payload.Signature.Timestamp = timestamp
+	payload.Signature.Token = token
+	payload.Signature.Signature = hex.EncodeToString(mac.Sum(nil))
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &port.WebhookRequest{Body: body}
+}
+
+func TestWebhookParser_Verify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name    string
+		req     func(t *testing.T) *port.WebhookRequest
+		wantErr bool
+	}{
+		{"valid", func(t *testing.T) *port.WebhookRequest { return signedRequest(t, now, "tok-1") }, false},
+		{"within the window", func(t *testing.T) *port.WebhookRequest {
+			return signedRequest(t, now.Add(-maxWebhookAge+time.Second), "tok-1")
+		}, false},
+		{"too old", func(t *testing.T) *port.WebhookRequest {
+			return signedRequest(t, now.Add(-maxWebhookAge-time.Second), "tok-1")
+		}, true},
+		{"from the future", func(t *testing.T) *port.WebhookRequest {
+			return signedRequest(t, now.Add(maxWebhookAge+time.Second), "tok-1")
+		}, true},
+		{"bad signature", func(t *testing.T) *port.WebhookRequest {
+			req := signedRequest(t, now, "tok-1")
+			var payload webhookPayload
+			_ = json.Unmarshal(req.Body, &payload)
+			payload.Signature.Token = "tok-2"
+			req.Body, _ = json.Marshal(payload)
+			return req
+		}, true},
+		{"not JSON", func(*testing.T) *port.WebhookRequest { return &port.WebhookRequest{Body: []byte("nope")} }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewWebhookParser(testSigningKey)
+			p.now = func() time.Time { return now }
+
+			err := p.Verify(tt.req(t))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, port.ErrInvalidSignature) {
+				t.Errorf("error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestWebhookParser_VerifyRejectsReplays(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p, _ := NewWebhookParser(testSigningKey)
+	p.now = func() time.Time { return now }
+
+	req := signedRequest(t, now, "tok-1")
+	if err := p.Verify(req); err != nil {
+		t.Fatalf("first Verify() error = %v", err)
+	}
+	if err := p.Verify(req); !errors.Is(err, port.ErrInvalidSignature) {
+		t.Errorf("replayed Verify() error = %v, want ErrInvalidSignature", err)
+	}
+	if err := p.Verify(signedRequest(t, now, "tok-2")); err != nil {
+		t.Errorf("new token Verify() error = %v", err)
+	}
+
+	// Tokens are forgotten once their requests are too old to pass anyway.
+	now = now.Add(maxWebhookAge + time.Second)
+	if err := p.Verify(signedRequest(t, now, "tok-3")); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(p.tokens) != 1 {
+		t.Errorf("tokens = %v, want only tok-3", p.tokens)
+	}
+}
@@ -11,12 +11,14 @@ import (
 
 // ChatProvider implements port.ChatSender using an in-memory store.
 type ChatProvider struct {
-	store *Store
+	store  *Store
+	tenant string
 }
 
-// NewChatProvider creates a new memory chat provider.
-func NewChatProvider(store *Store) *ChatProvider {
-	return &ChatProvider{store: store}
+// NewChatProvider creates a new memory chat provider storing messages
+// for tenant, or without one for an empty tenant.
+func NewChatProvider(store *Store, tenant string) *ChatProvider {
+	return &ChatProvider{store: store, tenant: tenant}
 }
 
 // Store returns the underlying memory store.
@@ -36,6 +38,7 @@ func (c *ChatProvider) Send(ctx context.Context, chat *contracts.ChatMessage) (*
 	stored := &StoredChat{
 		ID:        id,
 		CreatedAt: time.Now(),
+		Tenant:    c.tenant,
 		Chat:      chat,
 	}
 	c.store.AddChat(stored)
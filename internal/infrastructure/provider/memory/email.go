@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"net/smtp"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/mimemail"
 	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
 )
 
@@ -51,11 +51,17 @@ func (f *smtpForwarder) forward(email *contracts.Email) {
 		return
 	}
 
-	msg := f.buildMessage(email, from)
+	// The full MIME message keeps the plain-text alternative and the
+	// attachments, so Mailpit shows the email as a client would.
+	msg, err := mimemail.Build(email, from, email.FromName, "", time.Now())
+	if err != nil {
+		log.Printf("SMTP forward to Mailpit failed: %v", err)
+		return
+	}
 	recipients := f.collectRecipients(email)
 
 	addr := fmt.Sprintf("%s:%s", f.host, f.port)
-	if err := smtp.SendMail(addr, nil, from, recipients, []byte(msg)); err != nil {
+	if err := smtp.SendMail(addr, nil, from, recipients, msg); err != nil {
 		log.Printf("SMTP forward to Mailpit failed: %v", err)
 		return
 	}
@@ -63,31 +69,6 @@ func (f *smtpForwarder) forward(email *contracts.Email) {
 	log.Printf("Email forwarded to Mailpit: %s -> %v", email.Subject, email.To)
 }
 
-func (f *smtpForwarder) buildMessage(email *contracts.Email, from string) string {
-	var msg strings.Builder
-
-	msg.WriteString(fmt.Sprintf("From: %s\r\n", from))
-	msg.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(email.To, ", ")))
-	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", email.Subject))
-
-	if len(email.CC) > 0 {
-		msg.WriteString(fmt.Sprintf("Cc: %s\r\n", strings.Join(email.CC, ", ")))
-	}
-
-	if email.HTML != "" {
-		msg.WriteString("MIME-Version: 1.0\r\n")
-		msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
-		msg.WriteString("\r\n")
-		msg.WriteString(email.HTML)
-	} else {
-		msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
-		msg.WriteString("\r\n")
-		msg.WriteString(email.PlainText)
-	}
-
-	return msg.String()
-}
-
 func (f *smtpForwarder) collectRecipients(email *contracts.Email) []string {
 	recipients := make([]string, 0, len(email.To)+len(email.CC)+len(email.BCC))
 	recipients = append(recipients, email.To...)
@@ -100,13 +81,16 @@ func (f *smtpForwarder) collectRecipients(email *contracts.Email) []string {
 type EmailProvider struct {
 	store         *Store
 	smtpForwarder *smtpForwarder
+	tenant        string
 }
 
-// NewEmailProvider creates a new memory email provider.
-func NewEmailProvider(store *Store, mailpitCfg MailpitConfig) *EmailProvider {
+// NewEmailProvider creates a new memory email provider storing messages
+// for tenant, or without one for an empty tenant.
+func NewEmailProvider(store *Store, mailpitCfg MailpitConfig, tenant string) *EmailProvider {
 	return &EmailProvider{
 		store:         store,
 		smtpForwarder: newSMTPForwarder(mailpitCfg),
+		tenant:        tenant,
 	}
 }
 
@@ -122,6 +106,7 @@ func (e *EmailProvider) Send(ctx context.Context, email *contracts.Email) (*cont
 	stored := &StoredEmail{
 		ID:        id,
 		CreatedAt: time.Now(),
+		Tenant:    e.tenant,
 		Email:     email,
 	}
 	e.store.AddEmail(stored)
@@ -11,12 +11,14 @@ import (
 
 // PushProvider implements port.PushSender using an in-memory store.
 type PushProvider struct {
-	store *Store
+	store  *Store
+	tenant string
 }
 
-// NewPushProvider creates a new memory push provider.
-func NewPushProvider(store *Store) *PushProvider {
-	return &PushProvider{store: store}
+// NewPushProvider creates a new memory push provider storing messages
+// for tenant, or without one for an empty tenant.
+func NewPushProvider(store *Store, tenant string) *PushProvider {
+	return &PushProvider{store: store, tenant: tenant}
 }
 
 // Store returns the underlying memory store.
@@ -36,6 +38,7 @@ func (p *PushProvider) Send(ctx context.Context, push *contracts.PushNotificatio
 	stored := &StoredPush{
 		ID:        id,
 		CreatedAt: time.Now(),
+		Tenant:    p.tenant,
 		Push:      push,
 	}
 	p.store.AddPush(stored)
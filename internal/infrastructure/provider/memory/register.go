@@ -5,21 +5,23 @@ import (
 	"github.com/weprodev/wpd-message-gateway/internal/core/port"
 )
 
+// Each provider tags what it stores with the tenant it was created for, so
+// the DevBox can show one tenant's messages.
 func init() {
 	registry.RegisterEmailProvider("memory", func(cfg registry.EmailConfig, mailpit registry.MailpitConfig) (port.EmailSender, error) {
 		mailpitCfg := MailpitConfig{Enabled: mailpit.Enabled}
-		return NewEmailProvider(GetStore(), mailpitCfg), nil
+		return NewEmailProvider(GetStore(), mailpitCfg, cfg.Tenant), nil
 	})
 
 	registry.RegisterSMSProvider("memory", func(cfg registry.SMSConfig) (port.SMSSender, error) {
-		return NewSMSProvider(GetStore()), nil
+		return NewSMSProvider(GetStore(), cfg.Tenant), nil
 	})
 
 	registry.RegisterPushProvider("memory", func(cfg registry.PushConfig) (port.PushSender, error) {
-		return NewPushProvider(GetStore()), nil
+		return NewPushProvider(GetStore(), cfg.Tenant), nil
 	})
 
 	registry.RegisterChatProvider("memory", func(cfg registry.ChatConfig) (port.ChatSender, error) {
-		return NewChatProvider(GetStore()), nil
+		return NewChatProvider(GetStore(), cfg.Tenant), nil
 	})
 }
@@ -11,12 +11,14 @@ import (
 
 // SMSProvider implements port.SMSSender using an in-memory store.
 type SMSProvider struct {
-	store *Store
+	store  *Store
+	tenant string
 }
 
-// NewSMSProvider creates a new memory SMS provider.
-func NewSMSProvider(store *Store) *SMSProvider {
-	return &SMSProvider{store: store}
+// NewSMSProvider creates a new memory SMS provider storing messages
+// for tenant, or without one for an empty tenant.
+func NewSMSProvider(store *Store, tenant string) *SMSProvider {
+	return &SMSProvider{store: store, tenant: tenant}
 }
 
 // Store returns the underlying memory store.
@@ -36,6 +38,7 @@ func (s *SMSProvider) Send(ctx context.Context, sms *contracts.SMS) (*contracts.
 	stored := &StoredSMS{
 		ID:        id,
 		CreatedAt: time.Now(),
+		Tenant:    s.tenant,
 		SMS:       sms,
 	}
 	s.store.AddSMS(stored)
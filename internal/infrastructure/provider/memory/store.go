@@ -28,6 +28,7 @@ type StoredEmail struct {
 	ID        string           `json:"id"`
 	CreatedAt time.Time        `json:"created_at"`
 	Email     *contracts.Email `json:"email"`
+	Tenant    string           `json:"tenant,omitempty"`
 }
 
 // StoredSMS wraps an SMS with metadata for storage.
@@ -35,6 +36,7 @@ type StoredSMS struct {
 	ID        string         `json:"id"`
 	CreatedAt time.Time      `json:"created_at"`
 	SMS       *contracts.SMS `json:"sms"`
+	Tenant    string         `json:"tenant,omitempty"`
 }
 
 // StoredPush wraps a push notification with metadata for storage.
@@ -42,6 +44,7 @@ type StoredPush struct {
 	ID        string                      `json:"id"`
 	CreatedAt time.Time                   `json:"created_at"`
 	Push      *contracts.PushNotification `json:"push"`
+	Tenant    string                      `json:"tenant,omitempty"`
 }
 
 // StoredChat wraps a chat message with metadata for storage.
@@ -49,6 +52,7 @@ type StoredChat struct {
 	ID        string                 `json:"id"`
 	CreatedAt time.Time              `json:"created_at"`
 	Chat      *contracts.ChatMessage `json:"chat"`
+	Tenant    string                 `json:"tenant,omitempty"`
 }
 
 // Store implements an in-memory message store for all message types.
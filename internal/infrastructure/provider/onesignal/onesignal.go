@@ -0,0 +1,278 @@
+package onesignal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "onesignal"
+	defaultBaseURL = "https://api.onesignal.com"
+	defaultTimeout = 30 * time.Second
+	// defaultLanguage is the key OneSignal requires in headings and contents.
+	defaultLanguage = "en"
+)
+
+var _ port.PushSender = (*Provider)(nil)
+
+// Config holds OneSignal configuration.
+type Config struct {
+	AppID string
+	// APIKey is the app's REST API key.
+	APIKey  string
+	BaseURL string
+}
+
+// Provider implements port.PushSender for OneSignal.
+type Provider struct {
+	client *http.Client
+	config Config
+}
+
+type notificationRequest struct {
+	AppID                  string              `json:"app_id"`
+	TargetChannel          string              `json:"target_channel,omitempty"`
+	IncludeSubscriptionIDs []string            `json:"include_subscription_ids,omitempty"`
+	IncludeAliases         map[string][]string `json:"include_aliases,omitempty"`
+	IncludedSegments       []string            `json:"included_segments,omitempty"`
+	Headings               map[string]string   `json:"headings,omitempty"`
+	Contents               map[string]string   `json:"contents,omitempty"`
+	ContentAvailable       bool                `json:"content_available,omitempty"`
+	Data                   map[string]string   `json:"data,omitempty"`
+	IOSBadgeType           string              `json:"ios_badgeType,omitempty"`
+	IOSBadgeCount          *int                `json:"ios_badgeCount,omitempty"`
+	IOSSound               string              `json:"ios_sound,omitempty"`
+	Priority               int                 `json:"priority,omitempty"`
+	CollapseID             string              `json:"collapse_id,omitempty"`
+	TTL                    *int64              `json:"ttl,omitempty"`
+}
+
+// notificationResponse is the body returned by POST /notifications. Errors
+// is either a list of messages or an object of invalid recipient IDs.
+type notificationResponse struct {
+	ID     string          `json:"id"`
+	Errors json.RawMessage `json:"errors"`
+}
+
+type invalidRecipients struct {
+	InvalidPlayerIDs       []string            `json:"invalid_player_ids"`
+	InvalidExternalUserIDs []string            `json:"invalid_external_user_ids"`
+	InvalidAliases         map[string][]string `json:"invalid_aliases"`
+}
+
+// New creates a new OneSignal provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.AppID == "" {
+		return nil, errors.New("onesignal: app ID is required")
+	}
+	if cfg.APIKey == "" {
+		return nil, errors.New("onesignal: API key is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &Provider{
+		client: &http.Client{},
+		config: cfg,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send creates a OneSignal notification targeting exactly one of DeviceTokens
+// (subscription/player IDs), ExternalIDs or Segments.
+//
+// SendResult.ID is the OneSignal notification ID. Recipients OneSignal could
+// not resolve are reported in SendResult.Meta as "error:<id>" and listed,
+// comma-separated, under "invalid".
+func (p *Provider) Send(ctx context.Context, push *contracts.PushNotification) (*contracts.SendResult, error) {
+	targets := 0
+	for _, t := range [][]string{push.DeviceTokens, push.ExternalIDs, push.Segments} {
+		if len(t) > 0 {
+			targets++
+		}
+	}
+	if targets == 0 {
+		return nil, errors.New("no device tokens, external IDs or segments specified")
+	}
+	if targets > 1 {
+		return nil, errors.New("onesignal: device tokens, external IDs and segments cannot be combined")
+	}
+
+	body, err := json.Marshal(p.buildRequest(push))
+	if err != nil {
+		return nil, fmt.Errorf("onesignal: failed to encode request: %w", err)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(sendCtx, http.MethodPost, p.config.BaseURL+"/notifications", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("onesignal: failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Key "+p.config.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if sendCtx.Err() != nil {
+			return nil, fmt.Errorf("onesignal: failed to send push: %w", err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send push", 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return nil, perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("onesignal: failed to read response: %w", err)
+	}
+
+	var nResp notificationResponse
+	if err := json.Unmarshal(data, &nResp); err != nil {
+		if resp.StatusCode >= 300 {
+			return nil, pkgerrors.NewProviderError(ProviderName, "failed to send push", resp.StatusCode, errors.New(strings.TrimSpace(string(data))))
+		}
+		return nil, fmt.Errorf("onesignal: failed to decode response: %w", err)
+	}
+
+	messages, invalid := parseErrors(nResp.Errors)
+	if resp.StatusCode >= 300 {
+		return nil, pkgerrors.NewProviderError(ProviderName, "failed to send push", resp.StatusCode, errors.New(strings.Join(messages, "; ")))
+	}
+
+	// An empty ID means nothing was sent, typically because no recipient
+	// is subscribed.
+	if nResp.ID == "" {
+		msg := strings.Join(messages, "; ")
+		if msg == "" {
+			msg = "no recipients"
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send push", resp.StatusCode, errors.New(msg))
+		perr.Kind = pkgerrors.ErrInvalidRecipient
+		return nil, perr
+	}
+
+	result := &contracts.SendResult{
+		ID:         nResp.ID,
+		StatusCode: http.StatusOK,
+		Message:    "Push notification sent successfully",
+	}
+	if len(invalid) > 0 || len(messages) > 0 {
+		result.Meta = make(map[string]string, len(invalid)+2)
+		ids := make([]string, 0, len(invalid))
+		for id, reason := range invalid {
+			result.Meta["error:"+id] = reason
+			ids = append(ids, id)
+		}
+		if len(ids) > 0 {
+			sort.Strings(ids)
+			result.Meta["invalid"] = strings.Join(ids, ",")
+		}
+		if len(messages) > 0 {
+			result.Meta["errors"] = strings.Join(messages, "; ")
+		}
+		result.StatusCode = http.StatusMultiStatus
+		result.Message = "Push notification sent with errors"
+	}
+	return result, nil
+}
+
+func (p *Provider) buildRequest(push *contracts.PushNotification) notificationRequest {
+	req := notificationRequest{
+		AppID:         p.config.AppID,
+		TargetChannel: "push",
+		Data:          push.Data,
+		IOSSound:      push.Sound,
+		CollapseID:    push.CollapseID,
+	}
+
+	req.IncludeSubscriptionIDs = push.DeviceTokens
+	if len(push.ExternalIDs) > 0 {
+		req.IncludeAliases = map[string][]string{"external_id": push.ExternalIDs}
+	}
+	req.IncludedSegments = push.Segments
+
+	if push.Title != "" {
+		req.Headings = map[string]string{defaultLanguage: push.Title}
+	}
+	if push.Body != "" {
+		req.Contents = map[string]string{defaultLanguage: push.Body}
+	} else if push.Title == "" {
+		req.ContentAvailable = true
+	}
+
+	if push.Badge != nil {
+		req.IOSBadgeType = "SetTo"
+		req.IOSBadgeCount = push.Badge
+	}
+
+	switch push.Priority {
+	case contracts.PushPriorityHigh:
+		req.Priority = 10
+	case contracts.PushPriorityNormal:
+		req.Priority = 5
+	}
+
+	if push.ExpiresAt != nil {
+		ttl := int64(time.Until(*push.ExpiresAt).Seconds())
+		if ttl < 0 {
+			ttl = 0
+		}
+		req.TTL = &ttl
+	}
+
+	return req
+}
+
+// parseErrors splits OneSignal's errors field into free-form messages and
+// invalid recipient IDs mapped to a reason.
+func parseErrors(raw json.RawMessage) ([]string, map[string]string) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var messages []string
+	if err := json.Unmarshal(raw, &messages); err == nil {
+		return messages, nil
+	}
+
+	var inv invalidRecipients
+	if err := json.Unmarshal(raw, &inv); err != nil {
+		return []string{string(raw)}, nil
+	}
+
+	invalid := make(map[string]string)
+	for _, id := range inv.InvalidPlayerIDs {
+		invalid[id] = "invalid_player_id"
+	}
+	for _, id := range inv.InvalidExternalUserIDs {
+		invalid[id] = "invalid_external_id"
+	}
+	for alias, ids := range inv.InvalidAliases {
+		for _, id := range ids {
+			invalid[id] = "invalid_" + alias
+		}
+	}
+	return nil, invalid
+}
@@ -0,0 +1,245 @@
+package onesignal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{AppID: "app", APIKey: "key"}, false},
+		{"missing app ID", Config{APIKey: "key"}, true},
+		{"missing API key", Config{AppID: "app"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	badge := 3
+
+	tests := []struct {
+		name string
+		push contracts.PushNotification
+		want string
+	}{
+		{
+			name: "subscription IDs",
+			push: contracts.PushNotification{
+				DeviceTokens: []string{"sub-1", "sub-2"},
+				Title:        "Hello",
+				Body:         "World",
+				Data:         map[string]string{"order": "42"},
+				Badge:        &badge,
+				Sound:        "ping.caf",
+				Priority:     contracts.PushPriorityHigh,
+				CollapseID:   "orders",
+			},
+			want: `{"app_id":"app","target_channel":"push","include_subscription_ids":["sub-1","sub-2"],` +
+				`"headings":{"en":"Hello"},"contents":{"en":"World"},"data":{"order":"42"},` +
+				`"ios_badgeType":"SetTo","ios_badgeCount":3,"ios_sound":"ping.caf","priority":10,"collapse_id":"orders"}`,
+		},
+		{
+			name: "external IDs",
+			push: contracts.PushNotification{ExternalIDs: []string{"user-1"}, Body: "Hi", Priority: contracts.PushPriorityNormal},
+			want: `{"app_id":"app","target_channel":"push","include_aliases":{"external_id":["user-1"]},"contents":{"en":"Hi"},"priority":5}`,
+		},
+		{
+			name: "segments",
+			push: contracts.PushNotification{Segments: []string{"Active Users"}, Title: "News"},
+			want: `{"app_id":"app","target_channel":"push","included_segments":["Active Users"],"headings":{"en":"News"}}`,
+		},
+		{
+			name: "silent",
+			push: contracts.PushNotification{DeviceTokens: []string{"sub-1"}, Data: map[string]string{"sync": "1"}},
+			want: `{"app_id":"app","target_channel":"push","include_subscription_ids":["sub-1"],"content_available":true,"data":{"sync":"1"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var body string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				data, _ := io.ReadAll(r.Body)
+				body = string(data)
+				_, _ = w.Write([]byte(`{"id":"notif-1"}`))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{AppID: "app", APIKey: "key", BaseURL: srv.URL + "/"})
+			result, err := p.Send(context.Background(), &tt.push)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "notif-1" || result.StatusCode != http.StatusOK || result.Meta != nil {
+				t.Errorf("result = %+v", result)
+			}
+			if got.Method != http.MethodPost || got.URL.Path != "/notifications" || got.Header.Get("Authorization") != "Key key" {
+				t.Errorf("request = %s %s %s", got.Method, got.URL.Path, got.Header.Get("Authorization"))
+			}
+			if body != tt.want {
+				t.Errorf("body = %s\nwant   %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildRequest_TTL(t *testing.T) {
+	p, _ := New(Config{AppID: "app", APIKey: "key"})
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		min, max  int64
+	}{
+		{"future", time.Now().Add(time.Hour), 3590, 3600},
+		{"past clamps to zero", time.Now().Add(-time.Hour), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := p.buildRequest(&contracts.PushNotification{DeviceTokens: []string{"sub-1"}, ExpiresAt: &tt.expiresAt})
+			if req.TTL == nil || *req.TTL < tt.min || *req.TTL > tt.max {
+				t.Errorf("TTL = %v, want between %d and %d", req.TTL, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestSend_Response(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantKind   error
+		wantStatus int
+		wantMeta   map[string]string
+	}{
+		{
+			name:     "no subscribed recipients",
+			status:   http.StatusOK,
+			body:     `{"id":"","errors":["All included players are not subscribed"]}`,
+			wantKind: pkgerrors.ErrInvalidRecipient,
+		},
+		{
+			name:       "invalid recipients",
+			status:     http.StatusOK,
+			body:       `{"id":"notif-1","errors":{"invalid_player_ids":["sub-2"],"invalid_aliases":{"external_id":["user-9"]}}}`,
+			wantStatus: http.StatusMultiStatus,
+			wantMeta: map[string]string{
+				"error:sub-2":  "invalid_player_id",
+				"error:user-9": "invalid_external_id",
+				"invalid":      "sub-2,user-9",
+			},
+		},
+		{
+			name:       "warnings",
+			status:     http.StatusOK,
+			body:       `{"id":"notif-1","errors":["Some warning"]}`,
+			wantStatus: http.StatusMultiStatus,
+			wantMeta:   map[string]string{"errors": "Some warning"},
+		},
+		{
+			name:     "bad request",
+			status:   http.StatusBadRequest,
+			body:     `{"errors":["app_id not found"]}`,
+			wantKind: pkgerrors.ErrRejected,
+		},
+		{
+			name:     "bad API key",
+			status:   http.StatusForbidden,
+			body:     `{"errors":["Access denied.  Please include an 'Authorization: ...' header with a valid API key"]}`,
+			wantKind: pkgerrors.ErrUnauthorized,
+		},
+		{
+			name:     "rate limited",
+			status:   http.StatusTooManyRequests,
+			body:     `{"errors":["API rate limit exceeded"]}`,
+			wantKind: pkgerrors.ErrRateLimited,
+		},
+		{
+			name:     "non-JSON outage",
+			status:   http.StatusBadGateway,
+			body:     `bad gateway`,
+			wantKind: pkgerrors.ErrUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{AppID: "app", APIKey: "key", BaseURL: srv.URL})
+			result, err := p.Send(context.Background(), &contracts.PushNotification{DeviceTokens: []string{"sub-1", "sub-2"}, Body: "Hi"})
+
+			if tt.wantKind != nil {
+				var perr *pkgerrors.ProviderError
+				if !errors.As(err, &perr) || !errors.Is(err, tt.wantKind) {
+					t.Fatalf("error = %v, want %v", err, tt.wantKind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.StatusCode != tt.wantStatus || len(result.Meta) != len(tt.wantMeta) {
+				t.Fatalf("result = %+v", result)
+			}
+			for k, v := range tt.wantMeta {
+				if result.Meta[k] != v {
+					t.Errorf("meta[%s] = %q, want %q", k, result.Meta[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestSend_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	p, _ := New(Config{AppID: "app", APIKey: "key", BaseURL: srv.URL})
+	_, err := p.Send(context.Background(), &contracts.PushNotification{DeviceTokens: []string{"sub-1"}, Body: "Hi"})
+	if !errors.Is(err, pkgerrors.ErrUnavailable) {
+		t.Errorf("error = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestSend_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		push contracts.PushNotification
+	}{
+		{"no targets", contracts.PushNotification{Body: "Hi"}},
+		{"combined targets", contracts.PushNotification{DeviceTokens: []string{"sub-1"}, Segments: []string{"All"}, Body: "Hi"}},
+	}
+	p, _ := New(Config{AppID: "app", APIKey: "key", BaseURL: "http://127.0.0.1:1"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := p.Send(context.Background(), &tt.push); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package onesignal
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterPushProvider(ProviderName, func(cfg registry.PushConfig) (port.PushSender, error) {
+		return New(Config{
+			AppID:   cfg.AppID,
+			APIKey:  cfg.APIKey,
+			BaseURL: cfg.BaseURL,
+		})
+	})
+}
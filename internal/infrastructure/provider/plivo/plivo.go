@@ -0,0 +1,216 @@
+package plivo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/gsm"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "plivo"
+	defaultBaseURL = "https://api.plivo.com"
+	defaultTimeout = 30 * time.Second
+)
+
+var _ port.SMSSender = (*Provider)(nil)
+
+// Config holds Plivo configuration.
+type Config struct {
+	AuthID    string
+	AuthToken string
+	FromPhone string
+	// StatusCallback receives message status updates when set.
+	StatusCallback string
+	BaseURL        string
+}
+
+// Provider implements port.SMSSender for Plivo.
+type Provider struct {
+	client *http.Client
+	config Config
+}
+
+type messageRequest struct {
+	Src  string `json:"src"`
+	Dst  string `json:"dst"`
+	Text string `json:"text"`
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+}
+
+type messageResponse struct {
+	APIID       string   `json:"api_id"`
+	Message     string   `json:"message"`
+	MessageUUID []string `json:"message_uuid"`
+	Error       string   `json:"error"`
+}
+
+// New creates a new Plivo provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.AuthID == "" {
+		return nil, errors.New("plivo: auth ID is required")
+	}
+	if cfg.AuthToken == "" {
+		return nil, errors.New("plivo: auth token is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &Provider{
+		client: &http.Client{},
+		config: cfg,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send sends the SMS to each recipient as a separate Plivo message. Plivo
+// picks GSM-7 or UCS-2 from the text itself; the detected encoding and
+// segment count are reported so callers can anticipate billing.
+//
+// Per-recipient outcomes are reported in SendResult.Meta as "id:<to>" (message UUID)
+// and "error:<to>", alongside "encoding" and "segments". An error is only
+// returned when every recipient failed.
+func (p *Provider) Send(ctx context.Context, sms *contracts.SMS) (*contracts.SendResult, error) {
+	if len(sms.To) == 0 {
+		return nil, errors.New("no recipients specified")
+	}
+
+	from := sms.From
+	if from == "" {
+		from = p.config.FromPhone
+	}
+	if from == "" {
+		return nil, errors.New("no from phone number specified")
+	}
+
+	encoding, segments := gsm.Encode(sms.Message)
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	meta := make(map[string]string, len(sms.To)*2+4)
+	var firstID string
+	var firstErr error
+	sent := 0
+
+	for _, to := range sms.To {
+		id, err := p.sendOne(sendCtx, from, to, sms.Message)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			meta["error:"+to] = err.Error()
+			continue
+		}
+
+		sent++
+		if firstID == "" {
+			firstID = id
+		}
+		meta["id:"+to] = id
+	}
+
+	meta["sent"] = strconv.Itoa(sent)
+	meta["failed"] = strconv.Itoa(len(sms.To) - sent)
+	meta["encoding"] = encoding
+	meta["segments"] = strconv.Itoa(segments)
+
+	if sent == 0 {
+		if len(sms.To) == 1 {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("plivo: all %d recipients failed: %w", len(sms.To), firstErr)
+	}
+
+	result := &contracts.SendResult{
+		ID:         firstID,
+		StatusCode: http.StatusOK,
+		Message:    "SMS sent successfully",
+		Meta:       meta,
+	}
+	if sent < len(sms.To) {
+		result.StatusCode = http.StatusMultiStatus
+		result.Message = fmt.Sprintf("SMS sent to %d of %d recipients", sent, len(sms.To))
+	}
+	return result, nil
+}
+
+func (p *Provider) sendOne(ctx context.Context, from, to, text string) (string, error) {
+	body, err := json.Marshal(messageRequest{
+		Src:  from,
+		Dst:  to,
+		Text: text,
+		Type: "sms",
+		URL:  p.config.StatusCallback,
+	})
+	if err != nil {
+		return "", fmt.Errorf("plivo: failed to encode request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/Account/%s/Message/", p.config.BaseURL, url.PathEscape(p.config.AuthID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("plivo: failed to create request: %w", err)
+	}
+	req.SetBasicAuth(p.config.AuthID, p.config.AuthToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("plivo: failed to send SMS to %s: %w", to, err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send SMS to "+to, 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return "", perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("plivo: failed to read response: %w", err)
+	}
+
+	var msgResp messageResponse
+	decodeErr := json.Unmarshal(data, &msgResp)
+
+	if resp.StatusCode >= 300 {
+		msg := msgResp.Error
+		if decodeErr != nil || msg == "" {
+			msg = strings.TrimSpace(string(data))
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send SMS to "+to, resp.StatusCode, errors.New(msg))
+		// Plivo reports unusable destinations as a plain 400.
+		if resp.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(msg), "dst") {
+			perr.Kind = pkgerrors.ErrInvalidRecipient
+		}
+		return "", perr
+	}
+
+	if decodeErr != nil {
+		return "", fmt.Errorf("plivo: failed to decode response: %w", decodeErr)
+	}
+	if len(msgResp.MessageUUID) == 0 {
+		return "", errors.New("plivo: response contained no message UUID")
+	}
+	return msgResp.MessageUUID[0], nil
+}
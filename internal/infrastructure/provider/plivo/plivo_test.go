@@ -0,0 +1,205 @@
+package plivo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{AuthID: "MA123", AuthToken: "token"}, false},
+		{"missing auth ID", Config{AuthToken: "token"}, true},
+		{"missing auth token", Config{AuthID: "MA123"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		sms          contracts.SMS
+		want         string
+		wantEncoding string
+		wantSegments string
+	}{
+		{
+			name:         "gsm text",
+			cfg:          Config{FromPhone: "+15550001111"},
+			sms:          contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"},
+			want:         `{"src":"+15550001111","dst":"+15552223333","text":"Hello","type":"sms"}`,
+			wantEncoding: "GSM-7",
+			wantSegments: "1",
+		},
+		{
+			name:         "unicode text and status callback",
+			cfg:          Config{FromPhone: "+15550001111", StatusCallback: "https://example.com/status"},
+			sms:          contracts.SMS{To: []string{"+15552223333"}, Message: "Привет"},
+			want:         `{"src":"+15550001111","dst":"+15552223333","text":"Привет","type":"sms","url":"https://example.com/status"}`,
+			wantEncoding: "UCS-2",
+			wantSegments: "1",
+		},
+		{
+			name:         "message from overrides config",
+			cfg:          Config{FromPhone: "+15550001111"},
+			sms:          contracts.SMS{From: "+15554445555", To: []string{"+15552223333"}, Message: "Hello"},
+			want:         `{"src":"+15554445555","dst":"+15552223333","text":"Hello","type":"sms"}`,
+			wantEncoding: "GSM-7",
+			wantSegments: "1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var body string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				data, _ := io.ReadAll(r.Body)
+				body = string(data)
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte(`{"api_id":"a1","message":"message(s) queued","message_uuid":["uuid-1"]}`))
+			}))
+			defer srv.Close()
+
+			cfg := tt.cfg
+			cfg.AuthID, cfg.AuthToken, cfg.BaseURL = "MA123", "token", srv.URL+"/"
+			p, _ := New(cfg)
+
+			result, err := p.Send(context.Background(), &tt.sms)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "uuid-1" || result.StatusCode != http.StatusOK {
+				t.Errorf("result = %+v", result)
+			}
+			if result.Meta["encoding"] != tt.wantEncoding || result.Meta["segments"] != tt.wantSegments {
+				t.Errorf("encoding = %s segments = %s, want %s %s", result.Meta["encoding"], result.Meta["segments"], tt.wantEncoding, tt.wantSegments)
+			}
+			if got.Method != http.MethodPost || got.URL.Path != "/v1/Account/MA123/Message/" {
+				t.Errorf("request = %s %s", got.Method, got.URL.Path)
+			}
+			if user, pass, ok := got.BasicAuth(); !ok || user != "MA123" || pass != "token" {
+				t.Errorf("basic auth = %q:%q", user, pass)
+			}
+			if body != tt.want {
+				t.Errorf("body = %s\nwant   %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	apiError := func(message string) string {
+		data, _ := json.Marshal(map[string]string{"api_id": "a1", "error": message})
+		return string(data)
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantKind error
+		wantMsg  string
+	}{
+		{"invalid destination", http.StatusBadRequest, apiError("dst parameter is invalid"), pkgerrors.ErrInvalidRecipient, "dst parameter is invalid"},
+		{"invalid source", http.StatusBadRequest, apiError("src parameter not present"), pkgerrors.ErrRejected, "src parameter not present"},
+		{"authentication", http.StatusUnauthorized, apiError("authentication failed"), pkgerrors.ErrUnauthorized, "authentication failed"},
+		{"rate limited", http.StatusTooManyRequests, apiError("too many requests"), pkgerrors.ErrRateLimited, "too many requests"},
+		{"non-JSON server error", http.StatusBadGateway, "upstream failed", pkgerrors.ErrUnavailable, "upstream failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{AuthID: "MA123", AuthToken: "token", FromPhone: "+15550001111", BaseURL: srv.URL})
+			_, err := p.Send(context.Background(), &contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"})
+
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.wantKind) || perr.StatusCode != tt.status || perr.Err.Error() != tt.wantMsg {
+				t.Errorf("error = %v, want %v %d %q", err, tt.wantKind, tt.status, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestSend_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	p, _ := New(Config{AuthID: "MA123", AuthToken: "token", FromPhone: "+15550001111", BaseURL: srv.URL})
+	_, err := p.Send(context.Background(), &contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"})
+	if !errors.Is(err, pkgerrors.ErrUnavailable) {
+		t.Errorf("error = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestSend_PartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req messageRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Dst == "+15550000000" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"api_id":"a1","error":"dst parameter is invalid"}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"api_id":"a1","message_uuid":["uuid-1"]}`))
+	}))
+	defer srv.Close()
+
+	p, _ := New(Config{AuthID: "MA123", AuthToken: "token", FromPhone: "+15550001111", BaseURL: srv.URL})
+	result, err := p.Send(context.Background(), &contracts.SMS{To: []string{"+15551111111", "+15550000000"}, Message: "Hello"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if result.StatusCode != http.StatusMultiStatus || result.Meta["id:+15551111111"] != "uuid-1" || result.Meta["error:+15550000000"] == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestSend_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		sms  contracts.SMS
+	}{
+		{"no recipients", Config{FromPhone: "+15550001111"}, contracts.SMS{Message: "Hello"}},
+		{"no from", Config{}, contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.AuthID, cfg.AuthToken, cfg.BaseURL = "MA123", "token", "http://127.0.0.1:1"
+			p, _ := New(cfg)
+			if _, err := p.Send(context.Background(), &tt.sms); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package plivo
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterSMSProvider(ProviderName, func(cfg registry.SMSConfig) (port.SMSSender, error) {
+		authID := cfg.Extra["auth_id"]
+		if authID == "" {
+			authID = cfg.APIKey
+		}
+		authToken := cfg.Extra["auth_token"]
+		if authToken == "" {
+			authToken = cfg.APISecret
+		}
+
+		return New(Config{
+			AuthID:         authID,
+			AuthToken:      authToken,
+			FromPhone:      cfg.FromPhone,
+			StatusCallback: cfg.Extra["status_callback"],
+			BaseURL:        cfg.BaseURL,
+		})
+	})
+}
@@ -0,0 +1,21 @@
+package sendgrid
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterEmailProvider(ProviderName, func(cfg registry.EmailConfig, _ registry.MailpitConfig) (port.EmailSender, error) {
+		return New(Config{
+			APIKey:    cfg.APIKey,
+			BaseURL:   cfg.BaseURL,
+			FromEmail: cfg.FromEmail,
+			FromName:  cfg.FromName,
+		})
+	})
+
+	registry.RegisterWebhookParser(ProviderName, func(cfg registry.CommonConfig) (port.WebhookParser, error) {
+		return NewWebhookParser(cfg.Extra["webhook_public_key"])
+	})
+}
@@ -0,0 +1,261 @@
+package sendgrid
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/mimemail"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "sendgrid"
+	defaultBaseURL = "https://api.sendgrid.com"
+	defaultTimeout = 30 * time.Second
+)
+
+var _ port.EmailSender = (*Provider)(nil)
+
+// Config holds SendGrid-specific configuration.
+type Config struct {
+	APIKey    string
+	BaseURL   string
+	FromEmail string
+	FromName  string
+}
+
+// Provider implements port.EmailSender for SendGrid.
+type Provider struct {
+	client *http.Client
+	config Config
+}
+
+// sendgridHeaders are set by SendGrid itself and, like the headers set from
+// the request fields, are rejected by the API in "headers". Both are dropped
+// from contracts.Email.Headers.
+// See https://www.twilio.com/docs/sendgrid/api-reference/mail-send/mail-send.
+var sendgridHeaders = map[string]bool{
+	"X-Sg-Id":        true,
+	"X-Sg-Eid":       true,
+	"Received":       true,
+	"Dkim-Signature": true,
+}
+
+// mailSendRequest is the body of POST /v3/mail/send.
+type mailSendRequest struct {
+	Personalizations []personalization `json:"personalizations"`
+	From             address           `json:"from"`
+	ReplyTo          *address          `json:"reply_to,omitempty"`
+	Subject          string            `json:"subject"`
+	Content          []content         `json:"content,omitempty"`
+	Attachments      []attachment      `json:"attachments,omitempty"`
+	Headers          map[string]string `json:"headers,omitempty"`
+}
+
+type personalization struct {
+	To  []address `json:"to"`
+	CC  []address `json:"cc,omitempty"`
+	BCC []address `json:"bcc,omitempty"`
+}
+
+type address struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type content struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type attachment struct {
+	Content     string `json:"content"`
+	Filename    string `json:"filename"`
+	Type        string `json:"type,omitempty"`
+	Disposition string `json:"disposition"`
+}
+
+// errorResponse is the error body returned by the v3 API.
+type errorResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+		Field   string `json:"field"`
+	} `json:"errors"`
+}
+
+// New creates a new SendGrid provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("sendgrid: API key is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &Provider{
+		client: &http.Client{},
+		config: cfg,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send sends an email via the SendGrid v3 mail/send API.
+func (p *Provider) Send(ctx context.Context, email *contracts.Email) (*contracts.SendResult, error) {
+	if len(email.To) == 0 {
+		return nil, errors.New("no recipients specified")
+	}
+
+	payload, err := p.buildRequest(email)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("sendgrid: failed to encode request: %w", err)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(sendCtx, http.MethodPost, p.config.BaseURL+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("sendgrid: failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if sendCtx.Err() != nil {
+			return nil, fmt.Errorf("sendgrid: failed to send email: %w", err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send email", 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return nil, perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return nil, pkgerrors.NewProviderError(ProviderName, "failed to send email", resp.StatusCode, decodeError(data))
+	}
+
+	return &contracts.SendResult{
+		ID:         resp.Header.Get("X-Message-Id"),
+		StatusCode: resp.StatusCode,
+		Message:    "Email sent successfully",
+	}, nil
+}
+
+func (p *Provider) buildRequest(email *contracts.Email) (*mailSendRequest, error) {
+	from := address{Email: email.From, Name: email.FromName}
+	if from.Email == "" {
+		from.Email = p.config.FromEmail
+	}
+	if from.Name == "" {
+		from.Name = p.config.FromName
+	}
+	if from.Email == "" {
+		return nil, errors.New("no from address specified")
+	}
+
+	req := &mailSendRequest{
+		Personalizations: []personalization{{
+			To:  toAddresses(email.To),
+			CC:  toAddresses(email.CC),
+			BCC: toAddresses(email.BCC),
+		}},
+		From:    from,
+		Subject: email.Subject,
+		Headers: customHeaders(email.Headers),
+	}
+
+	if email.ReplyTo != "" {
+		req.ReplyTo = &address{Email: email.ReplyTo}
+	}
+
+	// SendGrid requires text/plain to precede text/html.
+	if email.PlainText != "" {
+		req.Content = append(req.Content, content{Type: "text/plain", Value: email.PlainText})
+	}
+	if email.HTML != "" {
+		req.Content = append(req.Content, content{Type: "text/html", Value: email.HTML})
+	}
+	if len(req.Content) == 0 {
+		return nil, errors.New("sendgrid: email body is required")
+	}
+
+	for _, att := range email.Attachments {
+		if len(att.Data) == 0 {
+			return nil, fmt.Errorf("sendgrid: attachment %q has no data", att.Filename)
+		}
+		req.Attachments = append(req.Attachments, attachment{
+			Content:     base64.StdEncoding.EncodeToString(att.Data),
+			Filename:    att.Filename,
+			Type:        att.ContentType,
+			Disposition: "attachment",
+		})
+	}
+
+	return req, nil
+}
+
+// customHeaders returns headers without the reserved ones.
+func customHeaders(headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if mimemail.ReservedHeader(key) || sendgridHeaders[textproto.CanonicalMIMEHeaderKey(key)] {
+			continue
+		}
+		out[key] = value
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func toAddresses(emails []string) []address {
+	if len(emails) == 0 {
+		return nil
+	}
+	out := make([]address, len(emails))
+	for i, e := range emails {
+		out[i] = address{Email: e}
+	}
+	return out
+}
+
+func decodeError(data []byte) error {
+	var resp errorResponse
+	if err := json.Unmarshal(data, &resp); err != nil || len(resp.Errors) == 0 {
+		return errors.New(strings.TrimSpace(string(data)))
+	}
+
+	msgs := make([]string, 0, len(resp.Errors))
+	for _, e := range resp.Errors {
+		if e.Field != "" {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", e.Field, e.Message))
+		} else {
+			msgs = append(msgs, e.Message)
+		}
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
@@ -0,0 +1,202 @@
+package sendgrid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{APIKey: "key"}, false},
+		{"missing key", Config{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	tests := []struct {
+		name  string
+		email contracts.Email
+		want  mailSendRequest
+	}{
+		{
+			name:  "defaults from config",
+			email: contracts.Email{To: []string{"to@example.com"}, Subject: "Hi", PlainText: "Hello"},
+			want: mailSendRequest{
+				Personalizations: []personalization{{To: []address{{Email: "to@example.com"}}}},
+				From:             address{Email: "noreply@example.com", Name: "Example"},
+				Subject:          "Hi",
+				Content:          []content{{Type: "text/plain", Value: "Hello"}},
+			},
+		},
+		{
+			name: "all fields",
+			email: contracts.Email{
+				From:      "me@example.com",
+				FromName:  "Me",
+				To:        []string{"to@example.com"},
+				CC:        []string{"cc@example.com"},
+				BCC:       []string{"bcc@example.com"},
+				ReplyTo:   "reply@example.com",
+				Subject:   "Hi",
+				HTML:      "<p>Hello</p>",
+				PlainText: "Hello",
+				Attachments: []contracts.Attachment{
+					{Filename: "a.txt", ContentType: "text/plain", Data: []byte("abc")},
+				},
+			},
+			want: mailSendRequest{
+				Personalizations: []personalization{{
+					To:  []address{{Email: "to@example.com"}},
+					CC:  []address{{Email: "cc@example.com"}},
+					BCC: []address{{Email: "bcc@example.com"}},
+				}},
+				From:    address{Email: "me@example.com", Name: "Me"},
+				ReplyTo: &address{Email: "reply@example.com"},
+				Subject: "Hi",
+				Content: []content{
+					{Type: "text/plain", Value: "Hello"},
+					{Type: "text/html", Value: "<p>Hello</p>"},
+				},
+				Attachments: []attachment{
+					{Content: "YWJj", Filename: "a.txt", Type: "text/plain", Disposition: "attachment"},
+				},
+			},
+		},
+		{
+			name: "reserved headers are dropped",
+			email: contracts.Email{
+				To:        []string{"to@example.com"},
+				PlainText: "Hello",
+				Headers: map[string]string{
+					"X-Campaign":   "spring",
+					"subject":      "override",
+					"From":         "evil@example.com",
+					"content-type": "text/html",
+					"X-SG-EID":     "forged",
+				},
+			},
+			want: mailSendRequest{
+				Personalizations: []personalization{{To: []address{{Email: "to@example.com"}}}},
+				From:             address{Email: "noreply@example.com", Name: "Example"},
+				Content:          []content{{Type: "text/plain", Value: "Hello"}},
+				Headers:          map[string]string{"X-Campaign": "spring"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got mailSendRequest
+			var r *http.Request
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				r = req
+				data, _ := io.ReadAll(req.Body)
+				_ = json.Unmarshal(data, &got)
+				w.Header().Set("X-Message-Id", "msg-1")
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{APIKey: "key", BaseURL: srv.URL, FromEmail: "noreply@example.com", FromName: "Example"})
+			result, err := p.Send(context.Background(), &tt.email)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "msg-1" || result.StatusCode != http.StatusAccepted {
+				t.Errorf("result = %+v", result)
+			}
+			if r.Method != http.MethodPost || r.URL.Path != "/v3/mail/send" {
+				t.Errorf("request = %s %s", r.Method, r.URL.Path)
+			}
+			if auth := r.Header.Get("Authorization"); auth != "Bearer key" {
+				t.Errorf("Authorization = %q", auth)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantKind error
+		wantMsg  string
+	}{
+		{"bad request", http.StatusBadRequest, `{"errors":[{"message":"Does not contain a valid address.","field":"personalizations.0.to.0.email"}]}`, pkgerrors.ErrRejected, "personalizations.0.to.0.email: Does not contain a valid address."},
+		{"unauthorized", http.StatusUnauthorized, `{"errors":[{"message":"The provided authorization grant is invalid"}]}`, pkgerrors.ErrUnauthorized, "The provided authorization grant is invalid"},
+		{"forbidden", http.StatusForbidden, `{"errors":[{"message":"access forbidden"}]}`, pkgerrors.ErrUnauthorized, "access forbidden"},
+		{"rate limited", http.StatusTooManyRequests, `{"errors":[{"message":"too many requests"}]}`, pkgerrors.ErrRateLimited, "too many requests"},
+		{"server error", http.StatusServiceUnavailable, `unavailable`, pkgerrors.ErrUnavailable, "unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{APIKey: "key", BaseURL: srv.URL, FromEmail: "noreply@example.com"})
+			_, err := p.Send(context.Background(), &contracts.Email{To: []string{"to@example.com"}, PlainText: "Hello"})
+
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.wantKind) || perr.StatusCode != tt.status {
+				t.Errorf("kind = %v status = %d, want %v %d", perr.Kind, perr.StatusCode, tt.wantKind, tt.status)
+			}
+			if perr.Err == nil || perr.Err.Error() != tt.wantMsg {
+				t.Errorf("message = %v, want %q", perr.Err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestSend_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		email contracts.Email
+	}{
+		{"no recipients", Config{FromEmail: "a@example.com"}, contracts.Email{PlainText: "Hello"}},
+		{"no from", Config{}, contracts.Email{To: []string{"b@example.com"}, PlainText: "Hello"}},
+		{"no body", Config{FromEmail: "a@example.com"}, contracts.Email{To: []string{"b@example.com"}}},
+		{"empty attachment", Config{FromEmail: "a@example.com"}, contracts.Email{To: []string{"b@example.com"}, PlainText: "Hello", Attachments: []contracts.Attachment{{Filename: "a.txt"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.APIKey, cfg.BaseURL = "key", "http://127.0.0.1:1"
+			p, _ := New(cfg)
+			_, err := p.Send(context.Background(), &tt.email)
+			if err == nil || errors.Is(err, pkgerrors.ErrUnavailable) {
+				t.Errorf("error = %v, want a validation error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package sendgrid
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// Signed Event Webhook headers.
+const (
+	HeaderSignature = "X-Twilio-Email-Event-Webhook-Signature"
+	HeaderTimestamp = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// maxWebhookAge is how far a webhook's signed timestamp may be from now.
+const maxWebhookAge = 5 * time.Minute
+
+var _ port.WebhookParser = (*WebhookParser)(nil)
+
+// WebhookParser parses SendGrid Event Webhook batches, verified with the
+// verification key shown when signed event webhooks are enabled.
+type WebhookParser struct {
+	publicKey *ecdsa.PublicKey
+	now       func() time.Time
+}
+
+// NewWebhookParser creates a SendGrid webhook parser from the base64
+// encoded ECDSA verification key.
+func NewWebhookParser(publicKey string) (*WebhookParser, error) {
+	if publicKey == "" {
+		return nil, errors.New("sendgrid: webhook public key is required")
+	}
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("sendgrid: invalid webhook public key: %w", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("sendgrid: invalid webhook public key: %w", err)
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("sendgrid: webhook public key is not an ECDSA key")
+	}
+	return &WebhookParser{publicKey: ecKey, now: time.Now}, nil
+}
+
+type webhookEvent struct {
+	Event       string `json:"event"`
+	Email       string `json:"email"`
+	Timestamp   int64  `json:"timestamp"`
+	SGMessageID string `json:"sg_message_id"`
+	Reason      string `json:"reason"`
+	Response    string `json:"response"`
+}
+
+// Verify checks the ECDSA signature of the timestamp followed by the body,
+// and rejects requests signed more than maxWebhookAge from now.
+func (p *WebhookParser) Verify(req *port.WebhookRequest) error {
+	sig, err := base64.StdEncoding.DecodeString(req.Header.Get(HeaderSignature))
+	if err != nil || len(sig) == 0 {
+		return port.ErrInvalidSignature
+	}
+
+	timestamp := req.Header.Get(HeaderTimestamp)
+	hash := sha256.Sum256(append([]byte(timestamp), req.Body...))
+	if !ecdsa.VerifyASN1(p.publicKey, hash[:], sig) {
+		return port.ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return port.ErrInvalidSignature
+	}
+	if age := p.now().Sub(time.Unix(unix, 0)); age > maxWebhookAge || age < -maxWebhookAge {
+		return port.ErrInvalidSignature
+	}
+	return nil
+}
+
+// Parse returns the events in the batch. Processed and deferred events,
+// which precede a final outcome, are skipped.
+func (p *WebhookParser) Parse(req *port.WebhookRequest) ([]contracts.DeliveryEvent, error) {
+	var batch []webhookEvent
+	if err := json.Unmarshal(req.Body, &batch); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	events := make([]contracts.DeliveryEvent, 0, len(batch))
+	for _, e := range batch {
+		var status contracts.MessageStatus
+		switch e.Event {
+		case "delivered":
+			status = contracts.StatusDelivered
+		case "bounce":
+			status = contracts.StatusBounced
+		case "dropped":
+			status = contracts.StatusFailed
+		case "open":
+			status = contracts.StatusOpened
+		case "click":
+			status = contracts.StatusClicked
+		case "spamreport":
+			status = contracts.StatusComplained
+		default:
+			continue
+		}
+		if e.SGMessageID == "" {
+			continue
+		}
+
+		reason := e.Reason
+		if reason == "" {
+			reason = e.Response
+		}
+
+		// sg_message_id is the X-Message-Id returned on send, followed by
+		// ".filter..." routing details.
+		id, _, _ := strings.Cut(e.SGMessageID, ".")
+		events = append(events, contracts.DeliveryEvent{
+			Provider:          ProviderName,
+			ProviderMessageID: id,
+			Status:            status,
+			Recipient:         e.Email,
+			Reason:            reason,
+			Time:              time.Unix(e.Timestamp, 0),
+		})
+	}
+	return events, nil
+}
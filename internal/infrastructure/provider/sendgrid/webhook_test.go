@@ -0,0 +1,75 @@
+package sendgrid
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func TestWebhookParser_Verify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	body := []byte(`[{"event":"delivered","sg_message_id":"abc.filter"}]`)
+
+	sign := func(timestamp string) string {
+		hash := sha256.Sum256(append([]byte(timestamp), body...))
+		sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+	unix := func(at time.Time) string { return strconv.FormatInt(at.Unix(), 10) }
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		wantErr   bool
+	}{
+		{"valid", unix(now), sign(unix(now)), false},
+		{"within the window", unix(now.Add(-maxWebhookAge + time.Second)), sign(unix(now.Add(-maxWebhookAge + time.Second))), false},
+		{"too old", unix(now.Add(-maxWebhookAge - time.Second)), sign(unix(now.Add(-maxWebhookAge - time.Second))), true},
+		{"from the future", unix(now.Add(maxWebhookAge + time.Second)), sign(unix(now.Add(maxWebhookAge + time.Second))), true},
+		{"timestamp not signed", unix(now), sign(unix(now.Add(-time.Second))), true},
+		{"timestamp not a number", "soon", sign("soon"), true},
+		{"no signature", unix(now), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewWebhookParser(base64.StdEncoding.EncodeToString(der))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.now = func() time.Time { return now }
+
+			header := http.Header{}
+			header.Set(HeaderTimestamp, tt.timestamp)
+			header.Set(HeaderSignature, tt.signature)
+			err = p.Verify(&port.WebhookRequest{Header: header, Body: body})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, port.ErrInvalidSignature) {
+				t.Errorf("error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package ses
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// errorKinds maps SES v2 exception names to gateway error kinds.
+var errorKinds = map[string]error{
+	"MessageRejected":                    pkgerrors.ErrRejected,
+	"MailFromDomainNotVerifiedException": pkgerrors.ErrRejected,
+	"NotFoundException":                  pkgerrors.ErrRejected,
+	"BadRequestException":                pkgerrors.ErrRejected,
+	"AccountSuspendedException":          pkgerrors.ErrUnauthorized,
+	"SendingPausedException":             pkgerrors.ErrUnavailable,
+	"TooManyRequestsException":           pkgerrors.ErrRateLimited,
+	"LimitExceededException":             pkgerrors.ErrRateLimited,
+}
+
+// newSendError converts an SES error response into a typed provider error.
+// The exception name comes from the X-Amzn-ErrorType header, which has the
+// form "Name:http://internal.amazon.com/...".
+func newSendError(resp *http.Response, body []byte) *pkgerrors.ProviderError {
+	var payload struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Message == "" {
+		payload.Message = strings.TrimSpace(string(body))
+	}
+
+	perr := pkgerrors.NewProviderError(ProviderName, "failed to send email", resp.StatusCode, errors.New(payload.Message))
+
+	errorType, _, _ := strings.Cut(resp.Header.Get("X-Amzn-ErrorType"), ":")
+	if errorType != "" {
+		perr.Code = errorType
+		if kind, ok := errorKinds[errorType]; ok {
+			perr.Kind = kind
+		}
+	}
+	return perr
+}
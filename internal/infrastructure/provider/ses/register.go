@@ -0,0 +1,21 @@
+package ses
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterEmailProvider(ProviderName, func(cfg registry.EmailConfig, _ registry.MailpitConfig) (port.EmailSender, error) {
+		return New(Config{
+			AccessKeyID:      cfg.APIKey,
+			SecretAccessKey:  cfg.APISecret,
+			SessionToken:     cfg.Extra["session_token"],
+			Region:           cfg.Region,
+			BaseURL:          cfg.BaseURL,
+			FromEmail:        cfg.FromEmail,
+			FromName:         cfg.FromName,
+			ConfigurationSet: cfg.Extra["configuration_set"],
+		})
+	})
+}
@@ -0,0 +1,300 @@
+package ses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/mimemail"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "ses"
+	defaultRegion  = "us-east-1"
+	defaultTimeout = 30 * time.Second
+
+	// Headers recognised by SES for per-message configuration sets and tags.
+	// They are lifted into the API request and never sent to recipients.
+	headerConfigurationSet = "X-Ses-Configuration-Set"
+	headerMessageTags      = "X-Ses-Message-Tags"
+)
+
+var _ port.EmailSender = (*Provider)(nil)
+
+// Config holds SES-specific configuration.
+type Config struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	// BaseURL overrides the regional endpoint https://email.{region}.amazonaws.com.
+	BaseURL   string
+	FromEmail string
+	FromName  string
+	// ConfigurationSet is applied to every message unless overridden
+	// by the X-SES-CONFIGURATION-SET header.
+	ConfigurationSet string
+}
+
+// Provider implements port.EmailSender for Amazon SES (API v2).
+type Provider struct {
+	client *http.Client
+	config Config
+	creds  credentials
+}
+
+// sendEmailRequest is the body of POST /v2/email/outbound-emails.
+type sendEmailRequest struct {
+	FromEmailAddress     string       `json:"FromEmailAddress"`
+	Destination          destination  `json:"Destination"`
+	ReplyToAddresses     []string     `json:"ReplyToAddresses,omitempty"`
+	Content              emailContent `json:"Content"`
+	ConfigurationSetName string       `json:"ConfigurationSetName,omitempty"`
+	EmailTags            []messageTag `json:"EmailTags,omitempty"`
+}
+
+type destination struct {
+	ToAddresses  []string `json:"ToAddresses,omitempty"`
+	CcAddresses  []string `json:"CcAddresses,omitempty"`
+	BccAddresses []string `json:"BccAddresses,omitempty"`
+}
+
+type emailContent struct {
+	Simple *simpleContent `json:"Simple,omitempty"`
+	Raw    *rawContent    `json:"Raw,omitempty"`
+}
+
+type simpleContent struct {
+	Subject contentData   `json:"Subject"`
+	Body    body          `json:"Body"`
+	Headers []headerField `json:"Headers,omitempty"`
+}
+
+type body struct {
+	Text *contentData `json:"Text,omitempty"`
+	HTML *contentData `json:"Html,omitempty"`
+}
+
+type contentData struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset,omitempty"`
+}
+
+type rawContent struct {
+	// Data is base64-encoded by encoding/json.
+	Data []byte `json:"Data"`
+}
+
+type headerField struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+type messageTag struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+type sendEmailResponse struct {
+	MessageID string `json:"MessageId"`
+}
+
+// New creates a new SES provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.AccessKeyID == "" {
+		return nil, errors.New("ses: access key ID is required")
+	}
+	if cfg.SecretAccessKey == "" {
+		return nil, errors.New("ses: secret access key is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = fmt.Sprintf("https://email.%s.amazonaws.com", cfg.Region)
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &Provider{
+		client: &http.Client{},
+		config: cfg,
+		creds: credentials{
+			accessKeyID:     cfg.AccessKeyID,
+			secretAccessKey: cfg.SecretAccessKey,
+			sessionToken:    cfg.SessionToken,
+		},
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send sends an email via SES. Messages with attachments are sent as raw MIME;
+// everything else uses Simple content.
+func (p *Provider) Send(ctx context.Context, email *contracts.Email) (*contracts.SendResult, error) {
+	if len(email.To) == 0 {
+		return nil, errors.New("no recipients specified")
+	}
+
+	payload, err := p.buildRequest(email)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("ses: failed to encode request: %w", err)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(sendCtx, http.MethodPost, p.config.BaseURL+"/v2/email/outbound-emails", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("ses: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signRequest(req, data, p.creds, p.config.Region, time.Now())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if sendCtx.Err() != nil {
+			return nil, fmt.Errorf("ses: failed to send email: %w", err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send email", 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return nil, perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ses: failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return nil, newSendError(resp, respBody)
+	}
+
+	var out sendEmailResponse
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return nil, fmt.Errorf("ses: failed to decode response: %w", err)
+	}
+
+	return &contracts.SendResult{
+		ID:         out.MessageID,
+		StatusCode: http.StatusOK,
+		Message:    "Email sent successfully",
+	}, nil
+}
+
+func (p *Provider) buildRequest(email *contracts.Email) (*sendEmailRequest, error) {
+	fromAddr := email.From
+	if fromAddr == "" {
+		fromAddr = p.config.FromEmail
+	}
+	if fromAddr == "" {
+		return nil, errors.New("no from address specified")
+	}
+	fromName := email.FromName
+	if fromName == "" {
+		fromName = p.config.FromName
+	}
+
+	headers, configSet, tags := splitSESHeaders(email.Headers)
+	if configSet == "" {
+		configSet = p.config.ConfigurationSet
+	}
+
+	req := &sendEmailRequest{
+		FromEmailAddress: (&mail.Address{Name: fromName, Address: fromAddr}).String(),
+		Destination: destination{
+			ToAddresses:  email.To,
+			CcAddresses:  email.CC,
+			BccAddresses: email.BCC,
+		},
+		ConfigurationSetName: configSet,
+		EmailTags:            tags,
+	}
+	if email.ReplyTo != "" {
+		req.ReplyToAddresses = []string{email.ReplyTo}
+	}
+
+	if len(email.Attachments) > 0 {
+		stripped := *email
+		stripped.Headers = headers
+		raw, err := mimemail.Build(&stripped, fromAddr, fromName, "", time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("ses: failed to build raw message: %w", err)
+		}
+		req.Content.Raw = &rawContent{Data: raw}
+		return req, nil
+	}
+
+	simple := &simpleContent{
+		Subject: contentData{Data: email.Subject, Charset: "UTF-8"},
+	}
+	if email.PlainText != "" {
+		simple.Body.Text = &contentData{Data: email.PlainText, Charset: "UTF-8"}
+	}
+	if email.HTML != "" {
+		simple.Body.HTML = &contentData{Data: email.HTML, Charset: "UTF-8"}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		simple.Headers = append(simple.Headers, headerField{Name: name, Value: headers[name]})
+	}
+	req.Content.Simple = simple
+
+	return req, nil
+}
+
+// splitSESHeaders separates the SES control headers from the headers that
+// should be delivered with the message, dropping those set from the email's
+// fields.
+//
+// X-SES-MESSAGE-TAGS uses the SES SMTP syntax: "name1=value1, name2=value2".
+func splitSESHeaders(in map[string]string) (map[string]string, string, []messageTag) {
+	var configSet string
+	var tags []messageTag
+	out := make(map[string]string, len(in))
+
+	for key, value := range in {
+		switch textproto.CanonicalMIMEHeaderKey(key) {
+		case headerConfigurationSet:
+			configSet = strings.TrimSpace(value)
+		case headerMessageTags:
+			for _, pair := range strings.Split(value, ",") {
+				name, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || name == "" {
+					continue
+				}
+				tags = append(tags, messageTag{Name: strings.TrimSpace(name), Value: strings.TrimSpace(val)})
+			}
+		default:
+			if !mimemail.ReservedHeader(key) {
+				out[key] = value
+			}
+		}
+	}
+
+	return out, configSet, tags
+}
@@ -0,0 +1,357 @@
+package ses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantErr     bool
+		wantBaseURL string
+	}{
+		{"valid", Config{AccessKeyID: "AKID", SecretAccessKey: "secret"}, false, "https://email.us-east-1.amazonaws.com"},
+		{"region endpoint", Config{AccessKeyID: "AKID", SecretAccessKey: "secret", Region: "eu-west-1"}, false, "https://email.eu-west-1.amazonaws.com"},
+		{"base URL override", Config{AccessKeyID: "AKID", SecretAccessKey: "secret", BaseURL: "http://localhost:4566/"}, false, "http://localhost:4566"},
+		{"missing access key", Config{SecretAccessKey: "secret"}, true, ""},
+		{"missing secret", Config{AccessKeyID: "AKID"}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && p.config.BaseURL != tt.wantBaseURL {
+				t.Errorf("base URL = %q, want %q", p.config.BaseURL, tt.wantBaseURL)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		email contracts.Email
+		want  sendEmailRequest
+	}{
+		{
+			name:  "simple content",
+			email: contracts.Email{To: []string{"to@example.com"}, Subject: "Hi", PlainText: "Hello", HTML: "<p>Hello</p>"},
+			want: sendEmailRequest{
+				FromEmailAddress: `"Example" <noreply@example.com>`,
+				Destination:      destination{ToAddresses: []string{"to@example.com"}},
+				Content: emailContent{Simple: &simpleContent{
+					Subject: contentData{Data: "Hi", Charset: "UTF-8"},
+					Body: body{
+						Text: &contentData{Data: "Hello", Charset: "UTF-8"},
+						HTML: &contentData{Data: "<p>Hello</p>", Charset: "UTF-8"},
+					},
+				}},
+			},
+		},
+		{
+			name: "recipients, reply-to and headers",
+			email: contracts.Email{
+				From:      "me@example.com",
+				To:        []string{"to@example.com"},
+				CC:        []string{"cc@example.com"},
+				BCC:       []string{"bcc@example.com"},
+				ReplyTo:   "reply@example.com",
+				Subject:   "Hi",
+				PlainText: "Hello",
+				Headers:   map[string]string{"X-B": "2", "X-A": "1"},
+			},
+			want: sendEmailRequest{
+				FromEmailAddress: `"Example" <me@example.com>`,
+				Destination: destination{
+					ToAddresses:  []string{"to@example.com"},
+					CcAddresses:  []string{"cc@example.com"},
+					BccAddresses: []string{"bcc@example.com"},
+				},
+				ReplyToAddresses: []string{"reply@example.com"},
+				Content: emailContent{Simple: &simpleContent{
+					Subject: contentData{Data: "Hi", Charset: "UTF-8"},
+					Body:    body{Text: &contentData{Data: "Hello", Charset: "UTF-8"}},
+					Headers: []headerField{{Name: "X-A", Value: "1"}, {Name: "X-B", Value: "2"}},
+				}},
+			},
+		},
+		{
+			name: "reserved headers are dropped",
+			email: contracts.Email{
+				To:        []string{"to@example.com"},
+				Subject:   "Hi",
+				PlainText: "Hello",
+				Headers: map[string]string{
+					"X-Campaign":   "spring",
+					"subject":      "override",
+					"From":         "evil@example.com",
+					"BCC":          "hidden@example.com",
+					"content-type": "text/html",
+				},
+			},
+			want: sendEmailRequest{
+				FromEmailAddress: `"Example" <noreply@example.com>`,
+				Destination:      destination{ToAddresses: []string{"to@example.com"}},
+				Content: emailContent{Simple: &simpleContent{
+					Subject: contentData{Data: "Hi", Charset: "UTF-8"},
+					Body:    body{Text: &contentData{Data: "Hello", Charset: "UTF-8"}},
+					Headers: []headerField{{Name: "X-Campaign", Value: "spring"}},
+				}},
+			},
+		},
+		{
+			name: "configuration set and tags from headers",
+			cfg:  Config{ConfigurationSet: "default-set"},
+			email: contracts.Email{
+				To:        []string{"to@example.com"},
+				PlainText: "Hello",
+				Headers: map[string]string{
+					"x-ses-configuration-set": "marketing",
+					"X-SES-MESSAGE-TAGS":      "campaign=spring, tier = gold, invalid",
+				},
+			},
+			want: sendEmailRequest{
+				FromEmailAddress:     `"Example" <noreply@example.com>`,
+				Destination:          destination{ToAddresses: []string{"to@example.com"}},
+				ConfigurationSetName: "marketing",
+				EmailTags:            []messageTag{{Name: "campaign", Value: "spring"}, {Name: "tier", Value: "gold"}},
+				Content: emailContent{Simple: &simpleContent{
+					Subject: contentData{Charset: "UTF-8"},
+					Body:    body{Text: &contentData{Data: "Hello", Charset: "UTF-8"}},
+				}},
+			},
+		},
+		{
+			name:  "default configuration set",
+			cfg:   Config{ConfigurationSet: "default-set"},
+			email: contracts.Email{To: []string{"to@example.com"}, PlainText: "Hello"},
+			want: sendEmailRequest{
+				FromEmailAddress:     `"Example" <noreply@example.com>`,
+				Destination:          destination{ToAddresses: []string{"to@example.com"}},
+				ConfigurationSetName: "default-set",
+				Content: emailContent{Simple: &simpleContent{
+					Subject: contentData{Charset: "UTF-8"},
+					Body:    body{Text: &contentData{Data: "Hello", Charset: "UTF-8"}},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got sendEmailRequest
+			var r *http.Request
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				r = req
+				data, _ := io.ReadAll(req.Body)
+				_ = json.Unmarshal(data, &got)
+				_, _ = w.Write([]byte(`{"MessageId":"0100-abc"}`))
+			}))
+			defer srv.Close()
+
+			cfg := tt.cfg
+			cfg.AccessKeyID, cfg.SecretAccessKey, cfg.BaseURL = "AKID", "secret", srv.URL
+			cfg.FromEmail, cfg.FromName = "noreply@example.com", "Example"
+			p, _ := New(cfg)
+
+			result, err := p.Send(context.Background(), &tt.email)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "0100-abc" {
+				t.Errorf("ID = %q", result.ID)
+			}
+			if r.Method != http.MethodPost || r.URL.Path != "/v2/email/outbound-emails" {
+				t.Errorf("request = %s %s", r.Method, r.URL.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("body = %s\nwant   %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestSend_RawWithAttachments(t *testing.T) {
+	var got sendEmailRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, _ := io.ReadAll(req.Body)
+		_ = json.Unmarshal(data, &got)
+		_, _ = w.Write([]byte(`{"MessageId":"0100-raw"}`))
+	}))
+	defer srv.Close()
+
+	p, _ := New(Config{AccessKeyID: "AKID", SecretAccessKey: "secret", BaseURL: srv.URL, FromEmail: "noreply@example.com"})
+	_, err := p.Send(context.Background(), &contracts.Email{
+		To:          []string{"to@example.com"},
+		BCC:         []string{"bcc@example.com"},
+		Subject:     "Report",
+		PlainText:   "See attached",
+		Headers:     map[string]string{"X-SES-CONFIGURATION-SET": "reports", "X-Campaign": "q1"},
+		Attachments: []contracts.Attachment{{Filename: "r.csv", ContentType: "text/csv", Data: []byte("a,b")}},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if got.Content.Simple != nil || got.Content.Raw == nil {
+		t.Fatalf("content = %+v, want raw", got.Content)
+	}
+	if got.ConfigurationSetName != "reports" {
+		t.Errorf("configuration set = %q", got.ConfigurationSetName)
+	}
+	if !reflect.DeepEqual(got.Destination.BccAddresses, []string{"bcc@example.com"}) {
+		t.Errorf("bcc = %v", got.Destination.BccAddresses)
+	}
+
+	raw := string(got.Content.Raw.Data)
+	for _, want := range []string{"Subject: Report\r\n", "X-Campaign: q1\r\n", "multipart/mixed", "filename=r.csv"} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("raw message does not contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{"X-Ses-Configuration-Set", "bcc@example.com"} {
+		if strings.Contains(raw, unwanted) {
+			t.Errorf("raw message contains %q", unwanted)
+		}
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		errorType string
+		body      string
+		wantKind  error
+		wantCode  string
+	}{
+		{"message rejected", http.StatusBadRequest, "MessageRejected:http://internal.amazon.com/coral/com.amazonaws.sesv2/", `{"message":"Email address is not verified."}`, pkgerrors.ErrRejected, "MessageRejected"},
+		{"throttled", http.StatusTooManyRequests, "TooManyRequestsException", `{"message":"Maximum sending rate exceeded."}`, pkgerrors.ErrRateLimited, "TooManyRequestsException"},
+		{"limit exceeded on 400", http.StatusBadRequest, "LimitExceededException", `{"message":"Daily quota exceeded"}`, pkgerrors.ErrRateLimited, "LimitExceededException"},
+		{"account suspended", http.StatusBadRequest, "AccountSuspendedException", `{"message":"suspended"}`, pkgerrors.ErrUnauthorized, "AccountSuspendedException"},
+		{"sending paused", http.StatusBadRequest, "SendingPausedException", `{"message":"paused"}`, pkgerrors.ErrUnavailable, "SendingPausedException"},
+		{"bad signature", http.StatusForbidden, "", `{"message":"The request signature we calculated does not match"}`, pkgerrors.ErrUnauthorized, ""},
+		{"server error", http.StatusInternalServerError, "", `internal failure`, pkgerrors.ErrUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.errorType != "" {
+					w.Header().Set("X-Amzn-ErrorType", tt.errorType)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{AccessKeyID: "AKID", SecretAccessKey: "secret", BaseURL: srv.URL, FromEmail: "noreply@example.com"})
+			_, err := p.Send(context.Background(), &contracts.Email{To: []string{"to@example.com"}, PlainText: "Hello"})
+
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.wantKind) || perr.Code != tt.wantCode {
+				t.Errorf("kind = %v code = %q, want %v %q", perr.Kind, perr.Code, tt.wantKind, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	payload := []byte(`{"a":1}`)
+
+	sign := func(creds credentials, body []byte) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "https://email.eu-west-1.amazonaws.com/v2/email/outbound-emails", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		signRequest(req, body, creds, "eu-west-1", now)
+		return req
+	}
+	creds := credentials{accessKeyID: "AKID", secretAccessKey: "secret"}
+	pattern := regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=AKID/20240301/eu-west-1/ses/aws4_request, SignedHeaders=([a-z0-9;-]+), Signature=([0-9a-f]{64})$`)
+
+	tests := []struct {
+		name              string
+		req               *http.Request
+		wantSignedHeaders string
+		wantToken         string
+	}{
+		{"static credentials", sign(creds, payload), "content-type;host;x-amz-content-sha256;x-amz-date", ""},
+		{
+			"session token is signed",
+			sign(credentials{accessKeyID: "AKID", secretAccessKey: "secret", sessionToken: "tok"}, payload),
+			"content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token",
+			"tok",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.Header.Get("X-Amz-Date"); got != "20240301T123000Z" {
+				t.Errorf("X-Amz-Date = %q", got)
+			}
+			if got := tt.req.Header.Get("X-Amz-Content-Sha256"); got != hashHex(payload) {
+				t.Errorf("X-Amz-Content-Sha256 = %q", got)
+			}
+			if got := tt.req.Header.Get("X-Amz-Security-Token"); got != tt.wantToken {
+				t.Errorf("X-Amz-Security-Token = %q, want %q", got, tt.wantToken)
+			}
+			m := pattern.FindStringSubmatch(tt.req.Header.Get("Authorization"))
+			if m == nil {
+				t.Fatalf("Authorization = %q", tt.req.Header.Get("Authorization"))
+			}
+			if m[1] != tt.wantSignedHeaders {
+				t.Errorf("SignedHeaders = %q, want %q", m[1], tt.wantSignedHeaders)
+			}
+		})
+	}
+
+	a := sign(creds, payload).Header.Get("Authorization")
+	if b := sign(creds, payload).Header.Get("Authorization"); a != b {
+		t.Error("signature is not deterministic")
+	}
+	if b := sign(creds, []byte(`{"a":2}`)).Header.Get("Authorization"); a == b {
+		t.Error("signature does not cover the payload")
+	}
+	if b := sign(credentials{accessKeyID: "AKID", secretAccessKey: "other"}, payload).Header.Get("Authorization"); a == b {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestAWSEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"abc-_.~XYZ09", "abc-_.~XYZ09"},
+		{"a b", "a%20b"},
+		{"a/b+c=d", "a%2Fb%2Bc%3Dd"},
+		{"é", "%C3%A9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := awsEscape(tt.in); got != tt.want {
+				t.Errorf("awsEscape(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package slack
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterChatProvider(ProviderName, func(cfg registry.ChatConfig) (port.ChatSender, error) {
+		return New(Config{
+			WebhookURL: cfg.WebhookURL,
+		})
+	})
+}
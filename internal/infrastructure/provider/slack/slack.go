@@ -0,0 +1,207 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "slack"
+	defaultTimeout = 30 * time.Second
+
+	// Block Kit limits.
+	maxActionElements  = 25
+	maxContextElements = 10
+)
+
+var _ port.ChatSender = (*Provider)(nil)
+
+// Config holds Slack incoming-webhook configuration.
+type Config struct {
+	WebhookURL string
+}
+
+// Provider implements port.ChatSender for Slack incoming webhooks.
+type Provider struct {
+	client *http.Client
+	config Config
+}
+
+type payload struct {
+	Text   string  `json:"text"`
+	Blocks []block `json:"blocks,omitempty"`
+}
+
+type block struct {
+	Type     string    `json:"type"`
+	Text     *text     `json:"text,omitempty"`
+	ImageURL string    `json:"image_url,omitempty"`
+	AltText  string    `json:"alt_text,omitempty"`
+	Elements []element `json:"elements,omitempty"`
+}
+
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type element struct {
+	Type     string `json:"type"`
+	Text     any    `json:"text"`
+	URL      string `json:"url,omitempty"`
+	ActionID string `json:"action_id,omitempty"`
+	Value    string `json:"value,omitempty"`
+}
+
+// New creates a new Slack provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.WebhookURL == "" {
+		return nil, errors.New("slack: webhook URL is required")
+	}
+
+	return &Provider{
+		client: &http.Client{},
+		config: cfg,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send posts the message to the configured webhook as Block Kit blocks.
+func (p *Provider) Send(ctx context.Context, message *contracts.ChatMessage) (*contracts.SendResult, error) {
+	if message.Message == "" && message.MediaURL == "" {
+		return nil, errors.New("slack: message or media URL is required")
+	}
+
+	body, err := json.Marshal(buildPayload(message))
+	if err != nil {
+		return nil, fmt.Errorf("slack: failed to encode payload: %w", err)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(sendCtx, http.MethodPost, p.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("slack: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if sendCtx.Err() != nil {
+			return nil, fmt.Errorf("slack: failed to send message: %w", err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send message", 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return nil, perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		// Slack returns a short error code such as "invalid_payload" or "channel_not_found".
+		code := strings.TrimSpace(string(respBody))
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send message", resp.StatusCode, errors.New(code))
+		perr.Code = code
+		return nil, perr
+	}
+
+	return &contracts.SendResult{
+		ID:         uuid.New().String(),
+		StatusCode: http.StatusOK,
+		Message:    "Message sent successfully",
+	}, nil
+}
+
+func buildPayload(message *contracts.ChatMessage) payload {
+	p := payload{Text: message.Message}
+
+	if message.Message != "" {
+		p.Blocks = append(p.Blocks, block{
+			Type: "section",
+			Text: &text{Type: "mrkdwn", Text: message.Message},
+		})
+	}
+
+	if message.MediaURL != "" {
+		if message.MediaType == "" || strings.HasPrefix(message.MediaType, "image") {
+			p.Blocks = append(p.Blocks, block{
+				Type:     "image",
+				ImageURL: message.MediaURL,
+				AltText:  altText(message),
+			})
+		} else {
+			p.Blocks = append(p.Blocks, block{
+				Type: "section",
+				Text: &text{Type: "mrkdwn", Text: fmt.Sprintf("<%s|Attachment>", message.MediaURL)},
+			})
+		}
+		if p.Text == "" {
+			p.Text = message.MediaURL
+		}
+	}
+
+	var buttons []element
+	for _, b := range message.Buttons {
+		if len(buttons) == maxActionElements {
+			break
+		}
+		buttons = append(buttons, element{
+			Type:     "button",
+			Text:     text{Type: "plain_text", Text: b.Text},
+			URL:      b.URL,
+			ActionID: b.ID,
+			Value:    b.ID,
+		})
+	}
+	if len(buttons) > 0 {
+		p.Blocks = append(p.Blocks, block{Type: "actions", Elements: buttons})
+	}
+
+	if len(message.Metadata) > 0 {
+		keys := make([]string, 0, len(message.Metadata))
+		for k := range message.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var fields []element
+		for _, k := range keys {
+			if len(fields) == maxContextElements {
+				break
+			}
+			fields = append(fields, element{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("*%s:* %s", k, message.Metadata[k]),
+			})
+		}
+		p.Blocks = append(p.Blocks, block{Type: "context", Elements: fields})
+	}
+
+	return p
+}
+
+func altText(message *contracts.ChatMessage) string {
+	if message.Message != "" {
+		return message.Message
+	}
+	return "image"
+}
@@ -0,0 +1,139 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{WebhookURL: "https://hooks.slack.com/services/T/B/X"}, false},
+		{"missing webhook URL", Config{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	tests := []struct {
+		name    string
+		message contracts.ChatMessage
+		want    string
+	}{
+		{
+			name:    "text",
+			message: contracts.ChatMessage{Message: "*Deploy* done"},
+			want:    `{"text":"*Deploy* done","blocks":[{"type":"section","text":{"type":"mrkdwn","text":"*Deploy* done"}}]}`,
+		},
+		{
+			name:    "image without text",
+			message: contracts.ChatMessage{MediaURL: "https://example.com/a.png"},
+			want:    `{"text":"https://example.com/a.png","blocks":[{"type":"image","image_url":"https://example.com/a.png","alt_text":"image"}]}`,
+		},
+		{
+			name:    "non-image media is a link",
+			message: contracts.ChatMessage{Message: "Report", MediaURL: "https://example.com/r.pdf", MediaType: "document"},
+			want:    `{"text":"Report","blocks":[{"type":"section","text":{"type":"mrkdwn","text":"Report"}},{"type":"section","text":{"type":"mrkdwn","text":"\u003chttps://example.com/r.pdf|Attachment\u003e"}}]}`,
+		},
+		{
+			name: "buttons and metadata",
+			message: contracts.ChatMessage{
+				Message:  "Approve?",
+				Buttons:  []contracts.ChatButton{{ID: "approve", Text: "Approve"}, {ID: "docs", Text: "Docs", URL: "https://example.com"}},
+				Metadata: map[string]string{"env": "prod", "by": "ci"},
+			},
+			want: `{"text":"Approve?","blocks":[` +
+				`{"type":"section","text":{"type":"mrkdwn","text":"Approve?"}},` +
+				`{"type":"actions","elements":[{"type":"button","text":{"type":"plain_text","text":"Approve"},"action_id":"approve","value":"approve"},{"type":"button","text":{"type":"plain_text","text":"Docs"},"url":"https://example.com","action_id":"docs","value":"docs"}]},` +
+				`{"type":"context","elements":[{"type":"mrkdwn","text":"*by:* ci"},{"type":"mrkdwn","text":"*env:* prod"}]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			var r *http.Request
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				r = req
+				data, _ := io.ReadAll(req.Body)
+				got = string(data)
+				_, _ = w.Write([]byte("ok"))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{WebhookURL: srv.URL + "/services/T/B/X"})
+			result, err := p.Send(context.Background(), &tt.message)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID == "" {
+				t.Error("result has no ID")
+			}
+			if r.Method != http.MethodPost || r.URL.Path != "/services/T/B/X" || r.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("request = %s %s %s", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+			}
+			if got != tt.want {
+				t.Errorf("payload = %s\nwant      %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantKind error
+		wantCode string
+	}{
+		{"invalid payload", http.StatusBadRequest, "invalid_payload", pkgerrors.ErrRejected, "invalid_payload"},
+		{"revoked token", http.StatusForbidden, "invalid_token", pkgerrors.ErrUnauthorized, "invalid_token"},
+		{"channel gone", http.StatusNotFound, "channel_not_found", pkgerrors.ErrRejected, "channel_not_found"},
+		{"rate limited", http.StatusTooManyRequests, "rate_limited", pkgerrors.ErrRateLimited, "rate_limited"},
+		{"outage", http.StatusInternalServerError, "internal_error", pkgerrors.ErrUnavailable, "internal_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{WebhookURL: srv.URL})
+			_, err := p.Send(context.Background(), &contracts.ChatMessage{Message: "Hi"})
+
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.wantKind) || perr.Code != tt.wantCode {
+				t.Errorf("kind = %v code = %q, want %v %q", perr.Kind, perr.Code, tt.wantKind, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSend_Validation(t *testing.T) {
+	p, _ := New(Config{WebhookURL: "http://127.0.0.1:1"})
+	if _, err := p.Send(context.Background(), &contracts.ChatMessage{}); err == nil || errors.Is(err, pkgerrors.ErrUnavailable) {
+		t.Errorf("error = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,66 @@
+package smtp
+
+import (
+	"errors"
+	"fmt"
+	netsmtp "net/smtp"
+	"strings"
+)
+
+// newAuth returns the SASL mechanism selected by the config,
+// or nil when no credentials are configured.
+func newAuth(cfg Config) (netsmtp.Auth, error) {
+	if cfg.Username == "" && cfg.Password == "" {
+		return nil, nil
+	}
+
+	switch strings.ToLower(cfg.Auth) {
+	case "", AuthPlain:
+		return netsmtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host), nil
+	case AuthLogin:
+		return &loginAuth{username: cfg.Username, password: cfg.Password, host: cfg.Host}, nil
+	case AuthCRAMMD5:
+		return netsmtp.CRAMMD5Auth(cfg.Username, cfg.Password), nil
+	default:
+		return nil, fmt.Errorf("smtp: unsupported auth mechanism %q", cfg.Auth)
+	}
+}
+
+// loginAuth implements the non-standard but widely deployed LOGIN mechanism,
+// which net/smtp does not provide.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *netsmtp.ServerInfo) (string, []byte, error) {
+	// Same rule as net/smtp.PlainAuth: never send credentials in the clear,
+	// except to a local server.
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:", "user name", "username":
+		return []byte(a.username), nil
+	case "password:", "password":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
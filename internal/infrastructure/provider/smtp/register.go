@@ -0,0 +1,27 @@
+package smtp
+
+import (
+	"strconv"
+
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterEmailProvider(ProviderName, func(cfg registry.EmailConfig, _ registry.MailpitConfig) (port.EmailSender, error) {
+		insecure, _ := strconv.ParseBool(cfg.Extra["insecure_skip_verify"])
+
+		return New(Config{
+			Host:               cfg.Extra["host"],
+			Port:               cfg.Extra["port"],
+			Username:           cfg.Extra["username"],
+			Password:           cfg.Extra["password"],
+			FromEmail:          cfg.FromEmail,
+			FromName:           cfg.FromName,
+			Auth:               cfg.Extra["auth"],
+			Encryption:         cfg.Extra["encryption"],
+			HeloName:           cfg.Extra["helo_name"],
+			InsecureSkipVerify: insecure,
+		})
+	})
+}
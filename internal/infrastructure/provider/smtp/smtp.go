@@ -0,0 +1,294 @@
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	netsmtp "net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/mimemail"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "smtp"
+	defaultPort    = "587"
+	defaultTimeout = 30 * time.Second
+)
+
+// Encryption modes supported by the provider.
+const (
+	EncryptionNone     = "none"
+	EncryptionSTARTTLS = "starttls"
+	EncryptionTLS      = "tls"
+)
+
+// Authentication mechanisms supported by the provider.
+const (
+	AuthPlain   = "plain"
+	AuthLogin   = "login"
+	AuthCRAMMD5 = "cram-md5"
+)
+
+var _ port.EmailSender = (*Provider)(nil)
+
+// Config holds SMTP-specific configuration.
+type Config struct {
+	Host      string
+	Port      string
+	Username  string
+	Password  string
+	FromEmail string
+	FromName  string
+
+	// Auth selects the SASL mechanism: plain, login or cram-md5.
+	// Defaults to plain when credentials are set.
+	Auth string
+
+	// Encryption selects none, starttls or tls (implicit TLS).
+	// Defaults to tls on port 465 and opportunistic STARTTLS otherwise.
+	Encryption string
+
+	// HeloName is the name sent in EHLO. Defaults to "localhost".
+	HeloName string
+
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+}
+
+// Provider implements port.EmailSender over SMTP.
+type Provider struct {
+	config Config
+	auth   netsmtp.Auth
+}
+
+// New creates a new SMTP provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp: host is required")
+	}
+	if cfg.Port == "" {
+		cfg.Port = defaultPort
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	cfg.Encryption = strings.ToLower(cfg.Encryption)
+	if cfg.Encryption == "ssl" {
+		cfg.Encryption = EncryptionTLS
+	}
+	if cfg.Encryption == "" && cfg.Port == "465" {
+		cfg.Encryption = EncryptionTLS
+	}
+	switch cfg.Encryption {
+	case "", EncryptionNone, EncryptionSTARTTLS, EncryptionTLS:
+	default:
+		return nil, fmt.Errorf("smtp: unsupported encryption %q", cfg.Encryption)
+	}
+
+	auth, err := newAuth(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		config: cfg,
+		auth:   auth,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send sends an email via SMTP.
+func (p *Provider) Send(ctx context.Context, email *contracts.Email) (*contracts.SendResult, error) {
+	if len(email.To) == 0 {
+		return nil, errors.New("no recipients specified")
+	}
+
+	fromAddr := email.From
+	if fromAddr == "" {
+		fromAddr = p.config.FromEmail
+	}
+	if fromAddr == "" {
+		return nil, errors.New("no from address specified")
+	}
+	fromName := email.FromName
+	if fromName == "" {
+		fromName = p.config.FromName
+	}
+
+	messageID := fmt.Sprintf("<%s@%s>", uuid.New().String(), domainOf(fromAddr))
+
+	msg, err := mimemail.Build(email, fromAddr, fromName, messageID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("smtp: failed to build message: %w", err)
+	}
+
+	recipients := make([]string, 0, len(email.To)+len(email.CC)+len(email.BCC))
+	recipients = append(recipients, email.To...)
+	recipients = append(recipients, email.CC...)
+	recipients = append(recipients, email.BCC...)
+
+	sendCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	if err := p.deliver(sendCtx, fromAddr, recipients, msg); err != nil {
+		return nil, classify(err)
+	}
+
+	return &contracts.SendResult{
+		ID:         messageID,
+		StatusCode: 200,
+		Message:    "Email sent successfully",
+	}, nil
+}
+
+// deliver runs a single SMTP transaction against the configured server.
+func (p *Provider) deliver(ctx context.Context, from string, recipients []string, msg []byte) error {
+	addr := net.JoinHostPort(p.config.Host, p.config.Port)
+	tlsConfig := &tls.Config{
+		ServerName:         p.config.Host,
+		InsecureSkipVerify: p.config.InsecureSkipVerify, //nolint:gosec // opt-in for self-signed relays
+	}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if p.config.Encryption == EncryptionTLS {
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("tls handshake: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	client, err := netsmtp.NewClient(conn, p.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("greeting: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	// Abort the transaction if the caller goes away mid-conversation.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	heloName := p.config.HeloName
+	if heloName == "" {
+		heloName = "localhost"
+	}
+	if err := client.Hello(heloName); err != nil {
+		return fmt.Errorf("ehlo: %w", err)
+	}
+
+	if p.config.Encryption != EncryptionTLS && p.config.Encryption != EncryptionNone {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("starttls: %w", err)
+			}
+		} else if p.config.Encryption == EncryptionSTARTTLS {
+			return errors.New("server does not support STARTTLS")
+		}
+	}
+
+	if p.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("server does not support AUTH")
+		}
+		if err := client.Auth(p.auth); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("mail from: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("rcpt to %s: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return &bodyError{fmt.Errorf("write body: %w", err)}
+	}
+	if err := w.Close(); err != nil {
+		return &bodyError{fmt.Errorf("end data: %w", err)}
+	}
+
+	// The message is accepted; a failed QUIT must not make it look unsent.
+	_ = client.Quit()
+	return nil
+}
+
+// bodyError is a failure after the message body started going out, when
+// the server may already have the message.
+type bodyError struct {
+	err error
+}
+
+func (e *bodyError) Error() string { return e.err.Error() }
+
+func (e *bodyError) Unwrap() error { return e.err }
+
+// classify wraps a failed SMTP transaction in a *pkgerrors.ProviderError.
+// A 535 reply means bad credentials, other 5xx replies reject the message,
+// and 4xx replies as well as connection and TLS failures are transient.
+// These leave the message unaccepted, so they carry a 503 status and are
+// retried like an HTTP provider's outage. A failure without a reply once the
+// body is going out has status 0: the server may have accepted the message,
+// so it is only retried or failed over when the policy retries timeouts.
+func classify(err error) error {
+	var reply *textproto.Error
+	var body *bodyError
+	status := http.StatusServiceUnavailable
+	if !errors.As(err, &reply) && errors.As(err, &body) {
+		status = 0
+	}
+
+	perr := pkgerrors.NewProviderError(ProviderName, "failed to send email", status, err)
+	perr.Kind = pkgerrors.ErrUnavailable
+	if reply != nil {
+		perr.Code = strconv.Itoa(reply.Code)
+		switch {
+		case reply.Code == 535:
+			perr.Kind = pkgerrors.ErrUnauthorized
+		case reply.Code >= 500:
+			perr.Kind = pkgerrors.ErrRejected
+		}
+	}
+	return perr
+}
+
+func domainOf(addr string) string {
+	if i := strings.LastIndex(addr, "@"); i >= 0 && i < len(addr)-1 {
+		return strings.Trim(addr[i+1:], ">")
+	}
+	return "localhost"
+}
@@ -0,0 +1,564 @@
+package smtp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            Config
+		wantErr        bool
+		wantEncryption string
+	}{
+		{"valid", Config{Host: "mail.example.com"}, false, ""},
+		{"missing host", Config{}, true, ""},
+		{"implicit tls on 465", Config{Host: "mail.example.com", Port: "465"}, false, EncryptionTLS},
+		{"ssl alias", Config{Host: "mail.example.com", Encryption: "SSL"}, false, EncryptionTLS},
+		{"starttls", Config{Host: "mail.example.com", Encryption: "starttls"}, false, EncryptionSTARTTLS},
+		{"unsupported encryption", Config{Host: "mail.example.com", Encryption: "tls1.3"}, true, ""},
+		{"login auth", Config{Host: "mail.example.com", Username: "u", Password: "p", Auth: "LOGIN"}, false, ""},
+		{"unsupported auth", Config{Host: "mail.example.com", Username: "u", Password: "p", Auth: "xoauth2"}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && p.config.Encryption != tt.wantEncryption {
+				t.Errorf("encryption = %q, want %q", p.config.Encryption, tt.wantEncryption)
+			}
+		})
+	}
+}
+
+func TestSend_Transport(t *testing.T) {
+	tests := []struct {
+		name       string
+		server     fakeServer
+		encryption string
+		wantTLS    bool
+		wantErr    string
+	}{
+		{"opportunistic starttls upgrades", fakeServer{startTLS: true}, "", true, ""},
+		{"opportunistic starttls falls back to plain", fakeServer{}, "", false, ""},
+		{"required starttls", fakeServer{startTLS: true}, EncryptionSTARTTLS, true, ""},
+		{"required starttls not advertised", fakeServer{}, EncryptionSTARTTLS, false, "server does not support STARTTLS"},
+		{"implicit tls", fakeServer{implicitTLS: true}, EncryptionTLS, true, ""},
+		{"no encryption ignores starttls", fakeServer{startTLS: true}, EncryptionNone, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := tt.server
+			srv.start(t)
+
+			p := srv.provider(t, Config{Encryption: tt.encryption})
+			_, err := p.Send(context.Background(), testEmail())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if got := srv.delivery(t); got.tls != tt.wantTLS {
+				t.Errorf("delivered over TLS = %v, want %v", got.tls, tt.wantTLS)
+			}
+		})
+	}
+}
+
+func TestSend_Auth(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   fakeServer
+		auth     string
+		password string
+		wantMech string
+		wantErr  string
+	}{
+		{"plain", fakeServer{auth: true}, AuthPlain, "secret", "PLAIN", ""},
+		{"default is plain", fakeServer{auth: true}, "", "secret", "PLAIN", ""},
+		{"login", fakeServer{auth: true}, AuthLogin, "secret", "LOGIN", ""},
+		{"cram-md5", fakeServer{auth: true}, AuthCRAMMD5, "secret", "CRAM-MD5", ""},
+		{"login over starttls", fakeServer{auth: true, startTLS: true}, AuthLogin, "secret", "LOGIN", ""},
+		{"wrong password", fakeServer{auth: true}, AuthPlain, "wrong", "", "auth:"},
+		{"cram-md5 wrong password", fakeServer{auth: true}, AuthCRAMMD5, "wrong", "", "auth:"},
+		{"auth not advertised", fakeServer{}, AuthPlain, "secret", "", "server does not support AUTH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := tt.server
+			srv.username, srv.password = "user", "secret"
+			srv.start(t)
+
+			p := srv.provider(t, Config{Username: "user", Password: tt.password, Auth: tt.auth})
+			_, err := p.Send(context.Background(), testEmail())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if got := srv.delivery(t); got.auth != tt.wantMech {
+				t.Errorf("auth mechanism = %q, want %q", got.auth, tt.wantMech)
+			}
+		})
+	}
+}
+
+func TestSend_Message(t *testing.T) {
+	srv := fakeServer{}
+	srv.start(t)
+	p := srv.provider(t, Config{FromEmail: "noreply@example.com", FromName: "Example"})
+
+	email := &contracts.Email{
+		To:        []string{"to@example.com"},
+		CC:        []string{"cc@example.com"},
+		BCC:       []string{"bcc@example.com"},
+		Subject:   "Grüße",
+		HTML:      "<p>Hello</p>",
+		PlainText: "Hello",
+		Headers:   map[string]string{"X-Campaign": "spring", "Subject": "override"},
+		Attachments: []contracts.Attachment{
+			{Filename: "report.txt", ContentType: "text/plain", Data: []byte("report body")},
+		},
+	}
+	result, err := p.Send(context.Background(), email)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	got := srv.delivery(t)
+
+	if got.from != "noreply@example.com" {
+		t.Errorf("MAIL FROM = %q", got.from)
+	}
+	if want := []string{"to@example.com", "cc@example.com", "bcc@example.com"}; strings.Join(got.rcpt, ",") != strings.Join(want, ",") {
+		t.Errorf("RCPT TO = %v, want %v", got.rcpt, want)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	headers := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"From", msg.Header.Get("From"), `"Example" <noreply@example.com>`},
+		{"To", msg.Header.Get("To"), "to@example.com"},
+		{"Cc", msg.Header.Get("Cc"), "cc@example.com"},
+		{"Bcc", msg.Header.Get("Bcc"), ""},
+		{"Subject", subject, "Grüße"},
+		{"Message-ID", msg.Header.Get("Message-Id"), result.ID},
+		{"X-Campaign", msg.Header.Get("X-Campaign"), "spring"},
+	}
+	for _, h := range headers {
+		if h.got != h.want {
+			t.Errorf("%s = %q, want %q", h.name, h.got, h.want)
+		}
+	}
+	if !strings.HasSuffix(result.ID, "@example.com>") {
+		t.Errorf("message ID = %q, want the sender's domain", result.ID)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, want multipart/mixed", msg.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	alternative, err := parts.NextPart()
+	if err != nil {
+		t.Fatalf("first part: %v", err)
+	}
+	if ct := alternative.Header.Get("Content-Type"); !strings.HasPrefix(ct, "multipart/alternative") {
+		t.Errorf("first part Content-Type = %q, want multipart/alternative", ct)
+	}
+	attachment, err := parts.NextPart()
+	if err != nil {
+		t.Fatalf("attachment part: %v", err)
+	}
+	if attachment.FileName() != "report.txt" {
+		t.Errorf("attachment filename = %q", attachment.FileName())
+	}
+	encoded, _ := io.ReadAll(attachment)
+	if data, _ := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", "")); string(data) != "report body" {
+		t.Errorf("attachment data = %q", data)
+	}
+}
+
+func TestSend_ErrorKinds(t *testing.T) {
+	tests := []struct {
+		name       string
+		server     fakeServer
+		password   string
+		encryption string
+		wantKind   error
+		wantStatus int
+	}{
+		{"bad credentials", fakeServer{auth: true}, "wrong", "", pkgerrors.ErrUnauthorized, http.StatusServiceUnavailable},
+		{"temporary rejection", fakeServer{rcptReply: "451 try again later"}, "", "", pkgerrors.ErrUnavailable, http.StatusServiceUnavailable},
+		{"permanent rejection", fakeServer{rcptReply: "550 no such user"}, "", "", pkgerrors.ErrRejected, http.StatusServiceUnavailable},
+		{"required starttls not advertised", fakeServer{}, "", EncryptionSTARTTLS, pkgerrors.ErrUnavailable, http.StatusServiceUnavailable},
+		{"temporary rejection after data", fakeServer{dataReply: "451 try again later"}, "", "", pkgerrors.ErrUnavailable, http.StatusServiceUnavailable},
+		{"connection lost after data", fakeServer{dataReply: "-"}, "", "", pkgerrors.ErrUnavailable, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := tt.server
+			srv.username, srv.password = "user", "secret"
+			srv.start(t)
+
+			cfg := Config{Encryption: tt.encryption}
+			if tt.password != "" {
+				cfg.Username, cfg.Password = "user", tt.password
+			}
+			_, err := srv.provider(t, cfg).Send(context.Background(), testEmail())
+			if !errors.Is(err, tt.wantKind) {
+				t.Fatalf("error = %v, want kind %v", err, tt.wantKind)
+			}
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) || perr.StatusCode != tt.wantStatus {
+				t.Errorf("error = %#v, want status %d", err, tt.wantStatus)
+			}
+		})
+	}
+
+	t.Run("connection refused", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		host, port, _ := net.SplitHostPort(ln.Addr().String())
+		_ = ln.Close()
+
+		p, err := New(Config{Host: host, Port: port, Timeout: time.Second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.Send(context.Background(), testEmail())
+		if !errors.Is(err, pkgerrors.ErrUnavailable) {
+			t.Errorf("error = %v, want kind ErrUnavailable", err)
+		}
+	})
+}
+
+func TestSend_QuitFailure(t *testing.T) {
+	srv := fakeServer{dropQuit: true}
+	srv.start(t)
+
+	if _, err := srv.provider(t, Config{}).Send(context.Background(), testEmail()); err != nil {
+		t.Fatalf("Send() error = %v, want the accepted message reported as sent", err)
+	}
+	srv.delivery(t)
+}
+
+func TestSend_Validation(t *testing.T) {
+	p, err := New(Config{Host: "127.0.0.1", Port: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		email *contracts.Email
+	}{
+		{"no recipients", &contracts.Email{From: "a@example.com"}},
+		{"no from address", &contracts.Email{To: []string{"b@example.com"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := p.Send(context.Background(), tt.email); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func testEmail() *contracts.Email {
+	return &contracts.Email{
+		From:      "sender@example.com",
+		To:        []string{"rcpt@example.com"},
+		Subject:   "Hello",
+		PlainText: "Hello",
+	}
+}
+
+// delivery is a message accepted by fakeServer.
+type delivery struct {
+	from string
+	rcpt []string
+	data string
+	tls  bool
+	auth string
+}
+
+// fakeServer is a minimal in-process SMTP server speaking enough of the
+// protocol for net/smtp: EHLO, STARTTLS, AUTH PLAIN/LOGIN/CRAM-MD5, MAIL,
+// RCPT, DATA and QUIT.
+type fakeServer struct {
+	implicitTLS bool
+	startTLS    bool
+	auth        bool
+	username    string
+	password    string
+	// rcptReply, when set, is the reply to every RCPT command.
+	rcptReply string
+	// dataReply, when set, is the reply to the message body; "-" closes
+	// the connection instead.
+	dataReply string
+	// dropQuit closes the connection instead of replying to QUIT.
+	dropQuit bool
+
+	addr       net.Addr
+	tlsConfig  *tls.Config
+	deliveries chan delivery
+}
+
+func (s *fakeServer) start(t *testing.T) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	s.addr = ln.Addr()
+	s.tlsConfig = selfSignedTLS(t)
+	s.deliveries = make(chan delivery, 1)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+}
+
+// provider returns a provider for the server, with cfg's auth and
+// encryption settings.
+func (s *fakeServer) provider(t *testing.T, cfg Config) *Provider {
+	t.Helper()
+
+	host, port, _ := net.SplitHostPort(s.addr.String())
+	cfg.Host = host
+	cfg.Port = port
+	cfg.InsecureSkipVerify = true
+	cfg.Timeout = 5 * time.Second
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func (s *fakeServer) delivery(t *testing.T) delivery {
+	t.Helper()
+
+	select {
+	case d := <-s.deliveries:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message delivered")
+		return delivery{}
+	}
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	secure := s.implicitTLS
+	if secure {
+		conn = tls.Server(conn, s.tlsConfig)
+	}
+	tp := textproto.NewConn(conn)
+	var d delivery
+
+	_ = tp.PrintfLine("220 fake.test ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			ext := []string{"fake.test"}
+			if s.startTLS && !secure {
+				ext = append(ext, "STARTTLS")
+			}
+			if s.auth {
+				ext = append(ext, "AUTH PLAIN LOGIN CRAM-MD5")
+			}
+			for i, e := range ext {
+				sep := "-"
+				if i == len(ext)-1 {
+					sep = " "
+				}
+				_ = tp.PrintfLine("250%s%s", sep, e)
+			}
+		case "STARTTLS":
+			_ = tp.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			secure = true
+		case "AUTH":
+			mech, ok := s.authenticate(tp, arg)
+			if !ok {
+				_ = tp.PrintfLine("535 authentication failed")
+				continue
+			}
+			d.auth = mech
+			_ = tp.PrintfLine("235 authenticated")
+		case "MAIL":
+			d.from = angleAddr(arg)
+			_ = tp.PrintfLine("250 ok")
+		case "RCPT":
+			if s.rcptReply != "" {
+				_ = tp.PrintfLine("%s", s.rcptReply)
+				continue
+			}
+			d.rcpt = append(d.rcpt, angleAddr(arg))
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			body, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			if s.dataReply == "-" {
+				return
+			}
+			if s.dataReply != "" {
+				_ = tp.PrintfLine("%s", s.dataReply)
+				continue
+			}
+			d.data = string(body)
+			d.tls = secure
+			s.deliveries <- d
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			if s.dropQuit {
+				return
+			}
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// authenticate runs the SASL exchange for an AUTH command and reports the
+// mechanism and whether the credentials matched.
+func (s *fakeServer) authenticate(tp *textproto.Conn, arg string) (string, bool) {
+	mech, initial, _ := strings.Cut(arg, " ")
+	mech = strings.ToUpper(mech)
+
+	switch mech {
+	case "PLAIN":
+		resp, _ := base64.StdEncoding.DecodeString(initial)
+		parts := strings.Split(string(resp), "\x00")
+		return mech, len(parts) == 3 && parts[1] == s.username && parts[2] == s.password
+	case "LOGIN":
+		username := challenge(tp, "Username:")
+		password := challenge(tp, "Password:")
+		return mech, username == s.username && password == s.password
+	case "CRAM-MD5":
+		const nonce = "<1896.697170952@fake.test>"
+		resp := challenge(tp, nonce)
+		mac := hmac.New(md5.New, []byte(s.password))
+		mac.Write([]byte(nonce))
+		return mech, resp == s.username+" "+hex.EncodeToString(mac.Sum(nil))
+	default:
+		return mech, false
+	}
+}
+
+// challenge sends a 334 challenge and returns the decoded response.
+func challenge(tp *textproto.Conn, prompt string) string {
+	_ = tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+	line, err := tp.ReadLine()
+	if err != nil {
+		return ""
+	}
+	resp, _ := base64.StdEncoding.DecodeString(line)
+	return string(resp)
+}
+
+// angleAddr returns the address in "FROM:<a@b> BODY=8BITMIME".
+func angleAddr(arg string) string {
+	start := strings.Index(arg, "<")
+	end := strings.Index(arg, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+func selfSignedTLS(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake.test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+}
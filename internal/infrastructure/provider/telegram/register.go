@@ -0,0 +1,21 @@
+package telegram
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterChatProvider(ProviderName, func(cfg registry.ChatConfig) (port.ChatSender, error) {
+		botToken := cfg.Extra["bot_token"]
+		if botToken == "" {
+			botToken = cfg.APIKey
+		}
+
+		return New(Config{
+			BotToken:  botToken,
+			BaseURL:   cfg.BaseURL,
+			ParseMode: cfg.Extra["parse_mode"],
+		})
+	})
+}
@@ -0,0 +1,366 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "telegram"
+	defaultBaseURL = "https://api.telegram.org"
+	defaultTimeout = 30 * time.Second
+
+	// maxAttempts bounds how often a rate-limited request is retried.
+	maxAttempts = 3
+	// defaultMaxRetryAfter is the longest retry_after we are willing to wait.
+	defaultMaxRetryAfter = 30 * time.Second
+
+	// Bot API limits.
+	maxTextLen         = 4096
+	maxCaptionLen      = 1024
+	maxCallbackDataLen = 64
+)
+
+var _ port.ChatSender = (*Provider)(nil)
+
+// Config holds Telegram Bot API configuration.
+type Config struct {
+	BotToken string
+	BaseURL  string
+	// ParseMode is passed through as parse_mode (HTML, MarkdownV2 or Markdown).
+	ParseMode string
+	// MaxRetryAfter caps how long Send waits on a 429 before giving up.
+	MaxRetryAfter time.Duration
+}
+
+// Provider implements port.ChatSender for the Telegram Bot API.
+type Provider struct {
+	client *http.Client
+	config Config
+}
+
+type inlineButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+type replyMarkup struct {
+	InlineKeyboard [][]inlineButton `json:"inline_keyboard"`
+}
+
+type replyParameters struct {
+	MessageID                int  `json:"message_id"`
+	AllowSendingWithoutReply bool `json:"allow_sending_without_reply"`
+}
+
+// apiResponse is the envelope every Bot API method returns.
+type apiResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+	ErrorCode   int    `json:"error_code"`
+	Result      struct {
+		MessageID int `json:"message_id"`
+	} `json:"result"`
+	Parameters struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// New creates a new Telegram provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.BotToken == "" {
+		return nil, errors.New("telegram: bot token is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.MaxRetryAfter == 0 {
+		cfg.MaxRetryAfter = defaultMaxRetryAfter
+	}
+
+	return &Provider{
+		client: &http.Client{},
+		config: cfg,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send sends the message to each chat ID in message.To.
+//
+// Per-chat outcomes are reported in SendResult.Meta as "id:<chat>" (message_id)
+// and "error:<chat>". An error is only returned when every chat failed.
+func (p *Provider) Send(ctx context.Context, message *contracts.ChatMessage) (*contracts.SendResult, error) {
+	if len(message.To) == 0 {
+		return nil, errors.New("no recipients specified")
+	}
+
+	method, params, err := p.buildRequest(message)
+	if err != nil {
+		return nil, err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	meta := make(map[string]string, len(message.To)*2+2)
+	var firstID string
+	var firstErr error
+	sent := 0
+
+	for _, chatID := range message.To {
+		params["chat_id"] = chatID
+		id, err := p.call(sendCtx, method, params)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			meta["error:"+chatID] = err.Error()
+			continue
+		}
+
+		sent++
+		if firstID == "" {
+			firstID = id
+		}
+		meta["id:"+chatID] = id
+	}
+
+	meta["sent"] = strconv.Itoa(sent)
+	meta["failed"] = strconv.Itoa(len(message.To) - sent)
+
+	if sent == 0 {
+		if len(message.To) == 1 {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("telegram: all %d chats failed: %w", len(message.To), firstErr)
+	}
+
+	result := &contracts.SendResult{
+		ID:         firstID,
+		StatusCode: http.StatusOK,
+		Message:    "Message sent successfully",
+		Meta:       meta,
+	}
+	if sent < len(message.To) {
+		result.StatusCode = http.StatusMultiStatus
+		result.Message = fmt.Sprintf("Message sent to %d of %d chats", sent, len(message.To))
+	}
+	return result, nil
+}
+
+// buildRequest picks the Bot API method for the message and builds its
+// parameters, minus chat_id.
+func (p *Provider) buildRequest(message *contracts.ChatMessage) (string, map[string]any, error) {
+	params := map[string]any{}
+	method := "sendMessage"
+
+	if message.MediaURL != "" {
+		var field string
+		method, field = mediaMethod(message.MediaType)
+		params[field] = message.MediaURL
+		if message.Message != "" {
+			if utf16Len(message.Message) > maxCaptionLen {
+				return "", nil, fmt.Errorf("telegram: caption exceeds %d characters", maxCaptionLen)
+			}
+			params["caption"] = message.Message
+		}
+	} else {
+		if message.Message == "" {
+			return "", nil, errors.New("telegram: message or media URL is required")
+		}
+		if utf16Len(message.Message) > maxTextLen {
+			return "", nil, fmt.Errorf("telegram: message exceeds %d characters", maxTextLen)
+		}
+		params["text"] = message.Message
+	}
+
+	if p.config.ParseMode != "" {
+		params["parse_mode"] = p.config.ParseMode
+	}
+
+	if message.ReplyToID != "" {
+		replyTo, err := strconv.Atoi(message.ReplyToID)
+		if err != nil {
+			return "", nil, fmt.Errorf("telegram: reply_to_id must be a message ID: %w", err)
+		}
+		params["reply_parameters"] = replyParameters{MessageID: replyTo, AllowSendingWithoutReply: true}
+	}
+
+	if len(message.Buttons) > 0 {
+		markup, err := buildKeyboard(message.Buttons)
+		if err != nil {
+			return "", nil, err
+		}
+		params["reply_markup"] = markup
+	}
+
+	return method, params, nil
+}
+
+// utf16Len returns the length of s in UTF-16 code units, which the Bot API
+// counts text and caption limits in.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// mediaMethod maps a MIME type to the Bot API method and its file field.
+func mediaMethod(mediaType string) (method, field string) {
+	switch {
+	case mediaType == "image/gif":
+		return "sendAnimation", "animation"
+	case mediaType == "" || strings.HasPrefix(mediaType, "image"):
+		return "sendPhoto", "photo"
+	case strings.HasPrefix(mediaType, "video"):
+		return "sendVideo", "video"
+	case strings.HasPrefix(mediaType, "audio"):
+		return "sendAudio", "audio"
+	default:
+		return "sendDocument", "document"
+	}
+}
+
+// buildKeyboard renders one button per row. Buttons with a URL open it;
+// the rest send their ID back as callback_data.
+func buildKeyboard(buttons []contracts.ChatButton) (*replyMarkup, error) {
+	markup := &replyMarkup{}
+	for _, b := range buttons {
+		btn := inlineButton{Text: b.Text}
+		if b.URL != "" {
+			btn.URL = b.URL
+		} else {
+			if b.ID == "" {
+				return nil, fmt.Errorf("telegram: button %q needs a URL or an ID", b.Text)
+			}
+			if len(b.ID) > maxCallbackDataLen {
+				return nil, fmt.Errorf("telegram: button ID exceeds %d bytes", maxCallbackDataLen)
+			}
+			btn.CallbackData = b.ID
+		}
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []inlineButton{btn})
+	}
+	return markup, nil
+}
+
+// call invokes a Bot API method and returns the sent message ID. On 429 it
+// waits for retry_after and tries again, up to maxAttempts.
+func (p *Provider) call(ctx context.Context, method string, params map[string]any) (string, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("telegram: failed to encode request: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, statusCode, err := p.do(ctx, method, body)
+		if err != nil {
+			return "", err
+		}
+		if resp.OK {
+			return strconv.Itoa(resp.Result.MessageID), nil
+		}
+
+		retryAfter := time.Duration(resp.Parameters.RetryAfter) * time.Second
+		if statusCode != http.StatusTooManyRequests || attempt == maxAttempts || retryAfter > p.config.MaxRetryAfter {
+			return "", newSendError(statusCode, resp)
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", newSendError(statusCode, resp)
+		case <-timer.C:
+		}
+	}
+}
+
+func (p *Provider) do(ctx context.Context, method string, body []byte) (*apiResponse, int, error) {
+	endpoint := fmt.Sprintf("%s/bot%s/%s", p.config.BaseURL, p.config.BotToken, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		// The URL embeds the bot token; never surface it.
+		return nil, 0, errors.New("telegram: failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		err = redactToken(err, p.config.BotToken)
+		if ctx.Err() != nil {
+			return nil, 0, fmt.Errorf("telegram: failed to send message: %w", err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send message", 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return nil, 0, perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("telegram: failed to read response: %w", err)
+	}
+
+	var apiResp apiResponse
+	if err := json.Unmarshal(data, &apiResp); err != nil {
+		if resp.StatusCode >= 300 {
+			return &apiResponse{ErrorCode: resp.StatusCode, Description: strings.TrimSpace(string(data))}, resp.StatusCode, nil
+		}
+		return nil, 0, fmt.Errorf("telegram: failed to decode response: %w", err)
+	}
+	return &apiResp, resp.StatusCode, nil
+}
+
+// redactToken removes the bot token from transport errors, which quote the URL.
+func redactToken(err error, token string) error {
+	msg := err.Error()
+	if !strings.Contains(msg, token) {
+		return err
+	}
+	return errors.New(strings.ReplaceAll(msg, token, "<token>"))
+}
+
+func newSendError(statusCode int, resp *apiResponse) *pkgerrors.ProviderError {
+	msg := resp.Description
+	if msg == "" {
+		msg = http.StatusText(statusCode)
+	}
+	if resp.Parameters.RetryAfter > 0 {
+		msg = fmt.Sprintf("%s (retry after %ds)", msg, resp.Parameters.RetryAfter)
+	}
+
+	perr := pkgerrors.NewProviderError(ProviderName, "failed to send message", statusCode, errors.New(msg))
+	if resp.ErrorCode != 0 {
+		perr.Code = strconv.Itoa(resp.ErrorCode)
+	}
+
+	// 403 means the bot was blocked by, or removed from, that chat; it says
+	// nothing about our credentials, which fail with 401.
+	switch {
+	case statusCode == http.StatusForbidden,
+		statusCode == http.StatusBadRequest && strings.Contains(resp.Description, "chat not found"):
+		perr.Kind = pkgerrors.ErrInvalidRecipient
+	}
+	return perr
+}
@@ -0,0 +1,281 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// botServer is a stand-in for the Bot API.
+type botServer struct {
+	*httptest.Server
+	// respond returns the status and body for a chat ID.
+	respond func(chatID string) (int, string)
+
+	mu       sync.Mutex
+	paths    []string
+	requests []map[string]any
+}
+
+func newBotServer(t *testing.T) *botServer {
+	t.Helper()
+
+	s := &botServer{
+		respond: func(string) (int, string) { return http.StatusOK, `{"ok":true,"result":{"message_id":42}}` },
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var params map[string]any
+		_ = json.Unmarshal(data, &params)
+
+		s.mu.Lock()
+		s.paths = append(s.paths, r.URL.Path)
+		s.requests = append(s.requests, params)
+		s.mu.Unlock()
+
+		chatID, _ := params["chat_id"].(string)
+		status, body := s.respond(chatID)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *botServer) provider(t *testing.T, cfg Config) *Provider {
+	t.Helper()
+
+	cfg.BotToken = "123:secret"
+	cfg.BaseURL = s.URL
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{BotToken: "123:abc"}, false},
+		{"missing token", Config{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		message    contracts.ChatMessage
+		wantMethod string
+		want       string
+	}{
+		{
+			name:       "text",
+			cfg:        Config{ParseMode: "MarkdownV2"},
+			message:    contracts.ChatMessage{Message: "*Hi*"},
+			wantMethod: "sendMessage",
+			want:       `{"chat_id":"1001","parse_mode":"MarkdownV2","text":"*Hi*"}`,
+		},
+		{
+			name:       "photo with caption",
+			message:    contracts.ChatMessage{Message: "Look", MediaURL: "https://example.com/a.jpg", MediaType: "image/jpeg"},
+			wantMethod: "sendPhoto",
+			want:       `{"caption":"Look","chat_id":"1001","photo":"https://example.com/a.jpg"}`,
+		},
+		{
+			name:       "gif",
+			message:    contracts.ChatMessage{MediaURL: "https://example.com/a.gif", MediaType: "image/gif"},
+			wantMethod: "sendAnimation",
+			want:       `{"animation":"https://example.com/a.gif","chat_id":"1001"}`,
+		},
+		{
+			name:       "video",
+			message:    contracts.ChatMessage{MediaURL: "https://example.com/a.mp4", MediaType: "video/mp4"},
+			wantMethod: "sendVideo",
+			want:       `{"chat_id":"1001","video":"https://example.com/a.mp4"}`,
+		},
+		{
+			name:       "document",
+			message:    contracts.ChatMessage{MediaURL: "https://example.com/a.pdf", MediaType: "application/pdf"},
+			wantMethod: "sendDocument",
+			want:       `{"chat_id":"1001","document":"https://example.com/a.pdf"}`,
+		},
+		{
+			name: "reply and inline keyboard",
+			message: contracts.ChatMessage{
+				Message:   "Pick",
+				ReplyToID: "7",
+				Buttons:   []contracts.ChatButton{{ID: "yes", Text: "Yes"}, {Text: "Docs", URL: "https://example.com"}},
+			},
+			wantMethod: "sendMessage",
+			want: `{"chat_id":"1001",` +
+				`"reply_markup":{"inline_keyboard":[[{"callback_data":"yes","text":"Yes"}],[{"text":"Docs","url":"https://example.com"}]]},` +
+				`"reply_parameters":{"allow_sending_without_reply":true,"message_id":7},"text":"Pick"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newBotServer(t)
+			p := srv.provider(t, tt.cfg)
+
+			tt.message.To = []string{"1001"}
+			result, err := p.Send(context.Background(), &tt.message)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "42" {
+				t.Errorf("ID = %q", result.ID)
+			}
+			if want := "/bot123:secret/" + tt.wantMethod; srv.paths[0] != want {
+				t.Errorf("path = %q, want %q", srv.paths[0], want)
+			}
+			got, _ := json.Marshal(srv.requests[0])
+			if string(got) != tt.want {
+				t.Errorf("params = %s\nwant     %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSend_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		message contracts.ChatMessage
+		wantErr bool
+	}{
+		{"no recipients", contracts.ChatMessage{Message: "Hi"}, true},
+		{"no content", contracts.ChatMessage{To: []string{"1"}}, true},
+		{"text at limit", contracts.ChatMessage{To: []string{"1"}, Message: strings.Repeat("a", maxTextLen)}, false},
+		{"text over limit", contracts.ChatMessage{To: []string{"1"}, Message: strings.Repeat("a", maxTextLen+1)}, true},
+		{"multi-byte text at limit", contracts.ChatMessage{To: []string{"1"}, Message: strings.Repeat("é", maxTextLen)}, false},
+		{"astral text counts two units", contracts.ChatMessage{To: []string{"1"}, Message: strings.Repeat("🚀", maxTextLen/2+1)}, true},
+		{"astral text at limit", contracts.ChatMessage{To: []string{"1"}, Message: strings.Repeat("🚀", maxTextLen/2)}, false},
+		{"caption over limit", contracts.ChatMessage{To: []string{"1"}, MediaURL: "https://example.com/a.jpg", Message: strings.Repeat("a", maxCaptionLen+1)}, true},
+		{"multi-byte caption at limit", contracts.ChatMessage{To: []string{"1"}, MediaURL: "https://example.com/a.jpg", Message: strings.Repeat("ж", maxCaptionLen)}, false},
+		{"bad reply ID", contracts.ChatMessage{To: []string{"1"}, Message: "Hi", ReplyToID: "abc"}, true},
+		{"button without URL or ID", contracts.ChatMessage{To: []string{"1"}, Message: "Hi", Buttons: []contracts.ChatButton{{Text: "?"}}}, true},
+		{"callback data too long", contracts.ChatMessage{To: []string{"1"}, Message: "Hi", Buttons: []contracts.ChatButton{{ID: strings.Repeat("x", 65), Text: "?"}}}, true},
+	}
+	srv := newBotServer(t)
+	p := srv.provider(t, Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Send(context.Background(), &tt.message)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantKind error
+		wantCode string
+	}{
+		{"bot blocked", http.StatusForbidden, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`, pkgerrors.ErrInvalidRecipient, "403"},
+		{"chat not found", http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`, pkgerrors.ErrInvalidRecipient, "400"},
+		{"bad entities", http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities"}`, pkgerrors.ErrRejected, "400"},
+		{"bad token", http.StatusUnauthorized, `{"ok":false,"error_code":401,"description":"Unauthorized"}`, pkgerrors.ErrUnauthorized, "401"},
+		{"rate limited beyond cap", http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":3600}}`, pkgerrors.ErrRateLimited, "429"},
+		{"non-JSON outage", http.StatusBadGateway, `bad gateway`, pkgerrors.ErrUnavailable, "502"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newBotServer(t)
+			srv.respond = func(string) (int, string) { return tt.status, tt.body }
+			p := srv.provider(t, Config{})
+
+			_, err := p.Send(context.Background(), &contracts.ChatMessage{To: []string{"1"}, Message: "Hi"})
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.wantKind) || perr.Code != tt.wantCode {
+				t.Errorf("kind = %v code = %q, want %v %q", perr.Kind, perr.Code, tt.wantKind, tt.wantCode)
+			}
+			if strings.Contains(err.Error(), "secret") {
+				t.Errorf("error leaks the bot token: %v", err)
+			}
+		})
+	}
+}
+
+func TestSend_RetriesAfter429(t *testing.T) {
+	srv := newBotServer(t)
+	calls := 0
+	srv.respond = func(string) (int, string) {
+		calls++
+		if calls == 1 {
+			return http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":1}}`
+		}
+		return http.StatusOK, `{"ok":true,"result":{"message_id":43}}`
+	}
+	p := srv.provider(t, Config{MaxRetryAfter: 2 * time.Second})
+
+	result, err := p.Send(context.Background(), &contracts.ChatMessage{To: []string{"1"}, Message: "Hi"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if result.ID != "43" || calls != 2 {
+		t.Errorf("ID = %q after %d calls", result.ID, calls)
+	}
+}
+
+func TestSend_PartialFailure(t *testing.T) {
+	srv := newBotServer(t)
+	srv.respond = func(chatID string) (int, string) {
+		if chatID == "blocked" {
+			return http.StatusForbidden, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`
+		}
+		return http.StatusOK, `{"ok":true,"result":{"message_id":42}}`
+	}
+	p := srv.provider(t, Config{})
+
+	result, err := p.Send(context.Background(), &contracts.ChatMessage{To: []string{"1", "blocked"}, Message: "Hi"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if result.StatusCode != http.StatusMultiStatus || result.Meta["id:1"] != "42" || result.Meta["error:blocked"] == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestSend_RedactsTokenFromTransportErrors(t *testing.T) {
+	srv := newBotServer(t)
+	p := srv.provider(t, Config{})
+	srv.Close()
+
+	_, err := p.Send(context.Background(), &contracts.ChatMessage{To: []string{"1"}, Message: "Hi"})
+	if !errors.Is(err, pkgerrors.ErrUnavailable) {
+		t.Fatalf("error = %v, want ErrUnavailable", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("error leaks the bot token: %v", err)
+	}
+}
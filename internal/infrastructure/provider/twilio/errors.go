@@ -0,0 +1,51 @@
+package twilio
+
+import (
+	"fmt"
+	"strconv"
+
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// APIError is the error body returned by the Twilio REST API.
+type APIError struct {
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	MoreInfo string `json:"more_info"`
+	Status   int    `json:"status"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("twilio error %d: %s", e.Code, e.Message)
+}
+
+// errorKinds maps well-known Twilio error codes to gateway error kinds.
+// See https://www.twilio.com/docs/api/errors.
+var errorKinds = map[int]error{
+	20003: pkgerrors.ErrUnauthorized,     // Authentication failed
+	20429: pkgerrors.ErrRateLimited,      // Too many requests
+	14107: pkgerrors.ErrRateLimited,      // SMS send rate limit exceeded
+	30022: pkgerrors.ErrRateLimited,      // US A2P 10DLC throughput exceeded
+	20500: pkgerrors.ErrUnavailable,      // Internal server error
+	20503: pkgerrors.ErrUnavailable,      // Service unavailable
+	21211: pkgerrors.ErrInvalidRecipient, // Invalid 'To' phone number
+	21408: pkgerrors.ErrInvalidRecipient, // Region not enabled for recipient
+	21610: pkgerrors.ErrInvalidRecipient, // Recipient unsubscribed (STOP)
+	21612: pkgerrors.ErrInvalidRecipient, // 'To' number cannot be reached
+	21614: pkgerrors.ErrInvalidRecipient, // 'To' number is not a mobile number
+	21212: pkgerrors.ErrRejected,         // Invalid 'From' phone number
+	21606: pkgerrors.ErrRejected,         // 'From' number cannot send SMS
+	21617: pkgerrors.ErrRejected,         // Body exceeds maximum length
+}
+
+// newSendError converts a Twilio API error into a typed provider error.
+func newSendError(to string, statusCode int, apiErr *APIError) *pkgerrors.ProviderError {
+	perr := pkgerrors.NewProviderError(ProviderName, "failed to send SMS to "+to, statusCode, apiErr)
+	if apiErr.Code != 0 {
+		perr.Code = strconv.Itoa(apiErr.Code)
+		if kind, ok := errorKinds[apiErr.Code]; ok {
+			perr.Kind = kind
+		}
+	}
+	return perr
+}
@@ -0,0 +1,36 @@
+package twilio
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterSMSProvider(ProviderName, func(cfg registry.SMSConfig) (port.SMSSender, error) {
+		accountSID := cfg.Extra["account_sid"]
+		if accountSID == "" {
+			accountSID = cfg.APIKey
+		}
+		authToken := cfg.Extra["auth_token"]
+		if authToken == "" {
+			authToken = cfg.APISecret
+		}
+
+		return New(Config{
+			AccountSID:          accountSID,
+			AuthToken:           authToken,
+			FromPhone:           cfg.FromPhone,
+			MessagingServiceSID: cfg.Extra["messaging_service_sid"],
+			StatusCallback:      cfg.Extra["status_callback"],
+			BaseURL:             cfg.BaseURL,
+		})
+	})
+
+	registry.RegisterWebhookParser(ProviderName, func(cfg registry.CommonConfig) (port.WebhookParser, error) {
+		authToken := cfg.Extra["auth_token"]
+		if authToken == "" {
+			authToken = cfg.APISecret
+		}
+		return NewWebhookParser(authToken, cfg.Extra["status_callback"])
+	})
+}
@@ -0,0 +1,191 @@
+package twilio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "twilio"
+	defaultBaseURL = "https://api.twilio.com"
+	defaultTimeout = 30 * time.Second
+)
+
+var _ port.SMSSender = (*Provider)(nil)
+
+// Config holds Twilio-specific configuration.
+type Config struct {
+	AccountSID string
+	AuthToken  string
+	FromPhone  string
+	// MessagingServiceSID is used instead of FromPhone when set.
+	MessagingServiceSID string
+	StatusCallback      string
+	BaseURL             string
+}
+
+// Provider implements port.SMSSender for Twilio.
+type Provider struct {
+	client *http.Client
+	config Config
+}
+
+// messageResponse is the subset of the Twilio Message resource we read.
+type messageResponse struct {
+	SID    string `json:"sid"`
+	Status string `json:"status"`
+}
+
+// New creates a new Twilio provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.AccountSID == "" {
+		return nil, errors.New("twilio: account SID is required")
+	}
+	if cfg.AuthToken == "" {
+		return nil, errors.New("twilio: auth token is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &Provider{
+		client: &http.Client{},
+		config: cfg,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send sends the SMS to each recipient as a separate Twilio message.
+//
+// Per-recipient outcomes are reported in SendResult.Meta as "id:<to>" (message SID)
+// and "error:<to>". An error is only returned when every recipient failed.
+func (p *Provider) Send(ctx context.Context, sms *contracts.SMS) (*contracts.SendResult, error) {
+	if len(sms.To) == 0 {
+		return nil, errors.New("no recipients specified")
+	}
+
+	from := sms.From
+	if from == "" {
+		from = p.config.FromPhone
+	}
+	if from == "" && p.config.MessagingServiceSID == "" {
+		return nil, errors.New("no from phone number specified")
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	meta := make(map[string]string, len(sms.To)*2+2)
+	var firstID string
+	var firstErr error
+	sent := 0
+
+	for _, to := range sms.To {
+		msg, err := p.sendOne(sendCtx, from, to, sms.Message)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			meta["error:"+to] = err.Error()
+			continue
+		}
+
+		sent++
+		if firstID == "" {
+			firstID = msg.SID
+		}
+		meta["id:"+to] = msg.SID
+	}
+
+	meta["sent"] = strconv.Itoa(sent)
+	meta["failed"] = strconv.Itoa(len(sms.To) - sent)
+
+	if sent == 0 {
+		if len(sms.To) == 1 {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("twilio: all %d recipients failed: %w", len(sms.To), firstErr)
+	}
+
+	result := &contracts.SendResult{
+		ID:         firstID,
+		StatusCode: http.StatusOK,
+		Message:    "SMS sent successfully",
+		Meta:       meta,
+	}
+	if sent < len(sms.To) {
+		result.StatusCode = http.StatusMultiStatus
+		result.Message = fmt.Sprintf("SMS sent to %d of %d recipients", sent, len(sms.To))
+	}
+	return result, nil
+}
+
+func (p *Provider) sendOne(ctx context.Context, from, to, body string) (*messageResponse, error) {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("Body", body)
+	if p.config.MessagingServiceSID != "" {
+		form.Set("MessagingServiceSid", p.config.MessagingServiceSID)
+	} else {
+		form.Set("From", from)
+	}
+	if p.config.StatusCallback != "" {
+		form.Set("StatusCallback", p.config.StatusCallback)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", p.config.BaseURL, url.PathEscape(p.config.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("twilio: failed to create request: %w", err)
+	}
+	req.SetBasicAuth(p.config.AccountSID, p.config.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("twilio: failed to send SMS to %s: %w", to, err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send SMS to "+to, 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return nil, perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("twilio: failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		apiErr := &APIError{Status: resp.StatusCode}
+		if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return nil, newSendError(to, resp.StatusCode, apiErr)
+	}
+
+	var msg messageResponse
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("twilio: failed to decode response: %w", err)
+	}
+	return &msg, nil
+}
@@ -0,0 +1,247 @@
+package twilio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{AccountSID: "AC123", AuthToken: "token"}, false},
+		{"missing account SID", Config{AuthToken: "token"}, true},
+		{"missing auth token", Config{AccountSID: "AC123"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		sms      contracts.SMS
+		wantForm url.Values
+	}{
+		{
+			name: "from phone",
+			cfg:  Config{FromPhone: "+15550001111"},
+			sms:  contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"},
+			wantForm: url.Values{
+				"To":   {"+15552223333"},
+				"From": {"+15550001111"},
+				"Body": {"Hello"},
+			},
+		},
+		{
+			name: "message from overrides config",
+			cfg:  Config{FromPhone: "+15550001111"},
+			sms:  contracts.SMS{From: "+15554445555", To: []string{"+15552223333"}, Message: "Hello"},
+			wantForm: url.Values{
+				"To":   {"+15552223333"},
+				"From": {"+15554445555"},
+				"Body": {"Hello"},
+			},
+		},
+		{
+			name: "messaging service and status callback",
+			cfg:  Config{MessagingServiceSID: "MG123", StatusCallback: "https://example.com/status"},
+			sms:  contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"},
+			wantForm: url.Values{
+				"To":                  {"+15552223333"},
+				"MessagingServiceSid": {"MG123"},
+				"StatusCallback":      {"https://example.com/status"},
+				"Body":                {"Hello"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var form url.Values
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				_ = r.ParseForm()
+				form = r.PostForm
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"sid":"SM1","status":"queued"}`))
+			}))
+			defer srv.Close()
+
+			cfg := tt.cfg
+			cfg.AccountSID, cfg.AuthToken, cfg.BaseURL = "AC123", "token", srv.URL+"/"
+			p, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := p.Send(context.Background(), &tt.sms)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "SM1" || result.StatusCode != http.StatusOK {
+				t.Errorf("result = %+v", result)
+			}
+
+			if got.Method != http.MethodPost || got.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+				t.Errorf("request = %s %s", got.Method, got.URL.Path)
+			}
+			if user, pass, ok := got.BasicAuth(); !ok || user != "AC123" || pass != "token" {
+				t.Errorf("basic auth = %q:%q", user, pass)
+			}
+			if ct := got.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if form.Encode() != tt.wantForm.Encode() {
+				t.Errorf("form = %v, want %v", form, tt.wantForm)
+			}
+		})
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantKind error
+		wantCode string
+	}{
+		{"invalid number", http.StatusBadRequest, `{"code":21211,"message":"Invalid 'To' Phone Number","status":400}`, pkgerrors.ErrInvalidRecipient, "21211"},
+		{"unsubscribed", http.StatusBadRequest, `{"code":21610,"message":"Attempt to send to unsubscribed recipient","status":400}`, pkgerrors.ErrInvalidRecipient, "21610"},
+		{"bad from number", http.StatusBadRequest, `{"code":21212,"message":"Invalid From Number","status":400}`, pkgerrors.ErrRejected, "21212"},
+		{"authentication", http.StatusUnauthorized, `{"code":20003,"message":"Authenticate","status":401}`, pkgerrors.ErrUnauthorized, "20003"},
+		{"rate limited", http.StatusTooManyRequests, `{"code":20429,"message":"Too Many Requests","status":429}`, pkgerrors.ErrRateLimited, "20429"},
+		{"unknown code falls back to status", http.StatusBadRequest, `{"code":99999,"message":"Something","status":400}`, pkgerrors.ErrRejected, "99999"},
+		{"non-JSON server error", http.StatusBadGateway, `upstream failed`, pkgerrors.ErrUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{AccountSID: "AC123", AuthToken: "token", FromPhone: "+15550001111", BaseURL: srv.URL})
+			_, err := p.Send(context.Background(), &contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"})
+
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("kind = %v, want %v", perr.Kind, tt.wantKind)
+			}
+			if perr.Code != tt.wantCode || perr.StatusCode != tt.status {
+				t.Errorf("code = %q status = %d, want %q %d", perr.Code, perr.StatusCode, tt.wantCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestSend_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	p, _ := New(Config{AccountSID: "AC123", AuthToken: "token", FromPhone: "+15550001111", BaseURL: srv.URL})
+	_, err := p.Send(context.Background(), &contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"})
+	if !errors.Is(err, pkgerrors.ErrUnavailable) {
+		t.Errorf("error = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestSend_PartialFailure(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if strings.HasSuffix(r.PostForm.Get("To"), "0000") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":21211,"message":"Invalid 'To' Phone Number","status":400}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"sid":"SM%d","status":"queued"}`, n)
+	}))
+	defer srv.Close()
+
+	p, _ := New(Config{AccountSID: "AC123", AuthToken: "token", FromPhone: "+15550001111", BaseURL: srv.URL})
+
+	tests := []struct {
+		name       string
+		to         []string
+		wantErr    bool
+		wantStatus int
+		wantSent   string
+	}{
+		{"all sent", []string{"+15551111111", "+15552222222"}, false, http.StatusOK, "2"},
+		{"some failed", []string{"+15551111111", "+15550000000"}, false, http.StatusMultiStatus, "1"},
+		{"all failed", []string{"+15550000000", "+15560000000"}, true, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := p.Send(context.Background(), &contracts.SMS{To: tt.to, Message: "Hello"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, pkgerrors.ErrInvalidRecipient) {
+					t.Errorf("error = %v, want ErrInvalidRecipient", err)
+				}
+				return
+			}
+			if result.StatusCode != tt.wantStatus || result.Meta["sent"] != tt.wantSent {
+				t.Errorf("status = %d sent = %s, want %d %s", result.StatusCode, result.Meta["sent"], tt.wantStatus, tt.wantSent)
+			}
+			for _, to := range tt.to {
+				if result.Meta["id:"+to] == "" && result.Meta["error:"+to] == "" {
+					t.Errorf("no outcome for %s in %v", to, result.Meta)
+				}
+			}
+		})
+	}
+}
+
+func TestSend_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		sms  contracts.SMS
+	}{
+		{"no recipients", Config{FromPhone: "+15550001111"}, contracts.SMS{Message: "Hello"}},
+		{"no from", Config{}, contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.AccountSID, cfg.AuthToken, cfg.BaseURL = "AC123", "token", "http://127.0.0.1:1"
+			p, _ := New(cfg)
+			if _, err := p.Send(context.Background(), &tt.sms); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package twilio
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// HeaderSignature is the header carrying Twilio's request signature.
+const HeaderSignature = "X-Twilio-Signature"
+
+var _ port.WebhookParser = (*WebhookParser)(nil)
+
+// WebhookParser parses Twilio message status callbacks, verified with the
+// account's auth token.
+type WebhookParser struct {
+	authToken string
+	// callbackURL is the URL Twilio signs. When empty, the URL of the
+	// request is used, which is wrong behind a proxy that rewrites it.
+	callbackURL string
+}
+
+// NewWebhookParser creates a Twilio webhook parser.
+func NewWebhookParser(authToken, callbackURL string) (*WebhookParser, error) {
+	if authToken == "" {
+		return nil, errors.New("twilio: auth token is required")
+	}
+	return &WebhookParser{authToken: authToken, callbackURL: callbackURL}, nil
+}
+
+// Verify checks X-Twilio-Signature: the base64 HMAC-SHA1 of the URL followed
+// by each POST parameter's name and value, sorted by name.
+func (p *WebhookParser) Verify(req *port.WebhookRequest) error {
+	params, err := url.ParseQuery(string(req.Body))
+	if err != nil {
+		return port.ErrInvalidSignature
+	}
+
+	callbackURL := p.callbackURL
+	if callbackURL == "" {
+		callbackURL = req.URL
+	}
+
+	expected := Signature(p.authToken, callbackURL, params)
+	got := req.Header.Get(HeaderSignature)
+	if got == "" || !hmac.Equal([]byte(expected), []byte(got)) {
+		return port.ErrInvalidSignature
+	}
+	return nil
+}
+
+// Signature computes Twilio's request signature.
+func Signature(authToken, callbackURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(callbackURL)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			b.WriteString(key)
+			b.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Parse returns the status change in the callback. Intermediate statuses
+// (queued, sending, accepted) are skipped.
+func (p *WebhookParser) Parse(req *port.WebhookRequest) ([]contracts.DeliveryEvent, error) {
+	params, err := url.ParseQuery(string(req.Body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid form body: %w", err)
+	}
+
+	var status contracts.MessageStatus
+	switch params.Get("MessageStatus") {
+	case "sent":
+		status = contracts.StatusSent
+	case "delivered":
+		status = contracts.StatusDelivered
+	case "undelivered":
+		status = contracts.StatusBounced
+	case "failed":
+		status = contracts.StatusFailed
+	case "read":
+		status = contracts.StatusOpened
+	default:
+		return nil, nil
+	}
+
+	sid := params.Get("MessageSid")
+	if sid == "" {
+		return nil, errors.New("missing MessageSid")
+	}
+
+	var reason string
+	if code := params.Get("ErrorCode"); code != "" {
+		reason = "error " + code
+	}
+
+	return []contracts.DeliveryEvent{{
+		Provider:          ProviderName,
+		ProviderMessageID: sid,
+		Status:            status,
+		Recipient:         params.Get("To"),
+		Reason:            reason,
+		Time:              time.Now(),
+	}}, nil
+}
@@ -0,0 +1,112 @@
+package twilio
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// The example request from Twilio's webhook security documentation.
+const (
+	docAuthToken = "12345"
+	docURL       = "https://mycompany.com/myapp.php?foo=1&bar=2"
+	docSignature = "0/KCTR6DLpKmkAf8muzZqo1nDgQ="
+)
+
+var docParams = url.Values{
+	"CallSid": {"CA1234567890ABCDE"},
+	"Caller":  {"+12349013030"},
+	"Digits":  {"1234"},
+	"From":    {"+12349013030"},
+	"To":      {"+18005551212"},
+}
+
+func TestSignature(t *testing.T) {
+	if got := Signature(docAuthToken, docURL, docParams); got != docSignature {
+		t.Errorf("Signature() = %s, want %s", got, docSignature)
+	}
+}
+
+func TestWebhookParser_Verify(t *testing.T) {
+	body := []byte(docParams.Encode())
+
+	tests := []struct {
+		name        string
+		callbackURL string
+		url         string
+		body        []byte
+		signature   string
+		wantErr     bool
+	}{
+		{name: "valid", callbackURL: docURL, body: body, signature: docSignature},
+		{name: "request URL without a callback URL", url: docURL, body: body, signature: docSignature},
+		{name: "callback URL wins over the request URL", callbackURL: docURL, url: "http://internal:10101/v1/webhooks/twilio", body: body, signature: docSignature},
+		{name: "other URL", callbackURL: "https://mycompany.com/myapp.php", body: body, signature: docSignature, wantErr: true},
+		{name: "changed parameter", callbackURL: docURL, body: []byte(docParams.Encode() + "&Extra=1"), signature: docSignature, wantErr: true},
+		{name: "missing signature", callbackURL: docURL, body: body, wantErr: true},
+		{name: "malformed body", callbackURL: docURL, body: []byte("%zz"), signature: docSignature, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewWebhookParser(docAuthToken, tt.callbackURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &port.WebhookRequest{URL: tt.url, Header: http.Header{}, Body: tt.body}
+			if tt.signature != "" {
+				req.Header.Set(HeaderSignature, tt.signature)
+			}
+
+			err = p.Verify(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, port.ErrInvalidSignature) {
+				t.Errorf("error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestWebhookParser_Parse(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     url.Values
+		wantStatus contracts.MessageStatus
+		wantReason string
+		wantErr    bool
+	}{
+		{name: "delivered", params: url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"delivered"}, "To": {"+15550100"}}, wantStatus: contracts.StatusDelivered},
+		{name: "undelivered", params: url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"undelivered"}, "ErrorCode": {"30003"}}, wantStatus: contracts.StatusBounced, wantReason: "error 30003"},
+		{name: "failed", params: url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"failed"}}, wantStatus: contracts.StatusFailed},
+		{name: "intermediate status", params: url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"sending"}}},
+		{name: "missing sid", params: url.Values{"MessageStatus": {"delivered"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewWebhookParser(docAuthToken, "")
+
+			events, err := p.Parse(&port.WebhookRequest{Body: []byte(tt.params.Encode())})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantStatus == "" {
+				if len(events) != 0 {
+					t.Errorf("events = %+v, want none", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("events = %+v, want one", events)
+			}
+			e := events[0]
+			if e.ProviderMessageID != "SM1" || e.Status != tt.wantStatus || e.Reason != tt.wantReason {
+				t.Errorf("event = %+v, want SM1 %s %q", e, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
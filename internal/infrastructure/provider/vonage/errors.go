@@ -0,0 +1,41 @@
+package vonage
+
+import (
+	"errors"
+	"net/http"
+
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// statusKinds maps Vonage SMS API status codes to gateway error kinds.
+// See https://developer.vonage.com/en/messaging/sms/guides/troubleshooting-sms.
+var statusKinds = map[string]error{
+	"1":  pkgerrors.ErrRateLimited,      // Throttled
+	"2":  pkgerrors.ErrRejected,         // Missing parameters
+	"3":  pkgerrors.ErrRejected,         // Invalid parameters
+	"4":  pkgerrors.ErrUnauthorized,     // Invalid credentials
+	"5":  pkgerrors.ErrUnavailable,      // Internal error
+	"6":  pkgerrors.ErrRejected,         // Invalid message
+	"7":  pkgerrors.ErrInvalidRecipient, // Number barred
+	"8":  pkgerrors.ErrUnauthorized,     // Partner account barred
+	"9":  pkgerrors.ErrRejected,         // Partner quota violation
+	"15": pkgerrors.ErrRejected,         // Illegal sender address
+	"29": pkgerrors.ErrInvalidRecipient, // Non-whitelisted destination
+}
+
+// newSendError converts a failed message status into a typed provider error.
+func newSendError(to, status, errorText string) *pkgerrors.ProviderError {
+	if errorText == "" {
+		errorText = "status " + status
+	}
+
+	// The SMS API answers 200 even for rejected messages; the status code
+	// carries the real outcome.
+	perr := pkgerrors.NewProviderError(ProviderName, "failed to send SMS to "+to, http.StatusOK, errors.New(errorText))
+	perr.Code = status
+	perr.Kind = pkgerrors.ErrRejected
+	if kind, ok := statusKinds[status]; ok {
+		perr.Kind = kind
+	}
+	return perr
+}
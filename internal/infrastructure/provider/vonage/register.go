@@ -0,0 +1,18 @@
+package vonage
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterSMSProvider(ProviderName, func(cfg registry.SMSConfig) (port.SMSSender, error) {
+		return New(Config{
+			APIKey:         cfg.APIKey,
+			APISecret:      cfg.APISecret,
+			FromPhone:      cfg.FromPhone,
+			StatusCallback: cfg.Extra["status_callback"],
+			BaseURL:        cfg.BaseURL,
+		})
+	})
+}
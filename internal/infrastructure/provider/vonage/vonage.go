@@ -0,0 +1,209 @@
+package vonage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/gsm"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "vonage"
+	defaultBaseURL = "https://rest.nexmo.com"
+	defaultTimeout = 30 * time.Second
+)
+
+var _ port.SMSSender = (*Provider)(nil)
+
+// Config holds Vonage SMS API configuration.
+type Config struct {
+	APIKey    string
+	APISecret string
+	FromPhone string
+	// StatusCallback receives delivery receipts when set.
+	StatusCallback string
+	BaseURL        string
+}
+
+// Provider implements port.SMSSender for the Vonage (Nexmo) SMS API.
+type Provider struct {
+	client *http.Client
+	config Config
+}
+
+// smsResponse is the body returned by POST /sms/json.
+type smsResponse struct {
+	MessageCount string `json:"message-count"`
+	Messages     []struct {
+		To        string `json:"to"`
+		MessageID string `json:"message-id"`
+		Status    string `json:"status"`
+		ErrorText string `json:"error-text"`
+	} `json:"messages"`
+}
+
+// New creates a new Vonage provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("vonage: API key is required")
+	}
+	if cfg.APISecret == "" {
+		return nil, errors.New("vonage: API secret is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &Provider{
+		client: &http.Client{},
+		config: cfg,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send sends the SMS to each recipient as a separate Vonage request. Text
+// outside the GSM-7 alphabet is sent as type=unicode (UCS-2).
+//
+// Per-recipient outcomes are reported in SendResult.Meta as "id:<to>" (message ID)
+// and "error:<to>", alongside "encoding" and "segments". An error is only
+// returned when every recipient failed.
+func (p *Provider) Send(ctx context.Context, sms *contracts.SMS) (*contracts.SendResult, error) {
+	if len(sms.To) == 0 {
+		return nil, errors.New("no recipients specified")
+	}
+
+	from := sms.From
+	if from == "" {
+		from = p.config.FromPhone
+	}
+	if from == "" {
+		return nil, errors.New("no from phone number specified")
+	}
+
+	encoding, segments := gsm.Encode(sms.Message)
+	msgType := "text"
+	if encoding == gsm.EncodingUCS2 {
+		msgType = "unicode"
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	meta := make(map[string]string, len(sms.To)*2+4)
+	var firstID string
+	var firstErr error
+	sent := 0
+
+	for _, to := range sms.To {
+		id, err := p.sendOne(sendCtx, from, to, sms.Message, msgType)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			meta["error:"+to] = err.Error()
+			continue
+		}
+
+		sent++
+		if firstID == "" {
+			firstID = id
+		}
+		meta["id:"+to] = id
+	}
+
+	meta["sent"] = strconv.Itoa(sent)
+	meta["failed"] = strconv.Itoa(len(sms.To) - sent)
+	meta["encoding"] = encoding
+	meta["segments"] = strconv.Itoa(segments)
+
+	if sent == 0 {
+		if len(sms.To) == 1 {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("vonage: all %d recipients failed: %w", len(sms.To), firstErr)
+	}
+
+	result := &contracts.SendResult{
+		ID:         firstID,
+		StatusCode: http.StatusOK,
+		Message:    "SMS sent successfully",
+		Meta:       meta,
+	}
+	if sent < len(sms.To) {
+		result.StatusCode = http.StatusMultiStatus
+		result.Message = fmt.Sprintf("SMS sent to %d of %d recipients", sent, len(sms.To))
+	}
+	return result, nil
+}
+
+func (p *Provider) sendOne(ctx context.Context, from, to, text, msgType string) (string, error) {
+	form := url.Values{}
+	form.Set("api_key", p.config.APIKey)
+	form.Set("api_secret", p.config.APISecret)
+	form.Set("from", strings.TrimPrefix(from, "+"))
+	form.Set("to", strings.TrimPrefix(to, "+"))
+	form.Set("text", text)
+	form.Set("type", msgType)
+	if p.config.StatusCallback != "" {
+		form.Set("callback", p.config.StatusCallback)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.BaseURL+"/sms/json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("vonage: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("vonage: failed to send SMS to %s: %w", to, err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send SMS to "+to, 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return "", perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("vonage: failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return "", pkgerrors.NewProviderError(ProviderName, "failed to send SMS to "+to, resp.StatusCode, errors.New(strings.TrimSpace(string(data))))
+	}
+
+	var smsResp smsResponse
+	if err := json.Unmarshal(data, &smsResp); err != nil {
+		return "", fmt.Errorf("vonage: failed to decode response: %w", err)
+	}
+	if len(smsResp.Messages) == 0 {
+		return "", errors.New("vonage: response contained no messages")
+	}
+
+	// Long messages are split into parts; any failed part fails the recipient.
+	for _, m := range smsResp.Messages {
+		if m.Status != "0" {
+			return "", newSendError(to, m.Status, m.ErrorText)
+		}
+	}
+	return smsResp.Messages[0].MessageID, nil
+}
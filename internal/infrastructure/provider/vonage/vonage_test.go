@@ -0,0 +1,231 @@
+package vonage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{APIKey: "key", APISecret: "secret"}, false},
+		{"missing API key", Config{APISecret: "secret"}, true},
+		{"missing API secret", Config{APIKey: "key"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		sms          contracts.SMS
+		wantForm     url.Values
+		wantEncoding string
+		wantSegments string
+	}{
+		{
+			name: "gsm text",
+			cfg:  Config{FromPhone: "+15550001111"},
+			sms:  contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"},
+			wantForm: url.Values{
+				"api_key": {"key"}, "api_secret": {"secret"},
+				"from": {"15550001111"}, "to": {"15552223333"},
+				"text": {"Hello"}, "type": {"text"},
+			},
+			wantEncoding: "GSM-7",
+			wantSegments: "1",
+		},
+		{
+			name: "unicode text and status callback",
+			cfg:  Config{FromPhone: "Gateway", StatusCallback: "https://example.com/dlr"},
+			sms:  contracts.SMS{To: []string{"15552223333"}, Message: "Привет"},
+			wantForm: url.Values{
+				"api_key": {"key"}, "api_secret": {"secret"},
+				"from": {"Gateway"}, "to": {"15552223333"},
+				"text": {"Привет"}, "type": {"unicode"},
+				"callback": {"https://example.com/dlr"},
+			},
+			wantEncoding: "UCS-2",
+			wantSegments: "1",
+		},
+		{
+			name: "message from overrides config and long text",
+			cfg:  Config{FromPhone: "+15550001111"},
+			sms:  contracts.SMS{From: "+15554445555", To: []string{"+15552223333"}, Message: strings.Repeat("a", 161)},
+			wantForm: url.Values{
+				"api_key": {"key"}, "api_secret": {"secret"},
+				"from": {"15554445555"}, "to": {"15552223333"},
+				"text": {strings.Repeat("a", 161)}, "type": {"text"},
+			},
+			wantEncoding: "GSM-7",
+			wantSegments: "2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var form url.Values
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				_ = r.ParseForm()
+				form = r.PostForm
+				_, _ = w.Write([]byte(`{"message-count":"1","messages":[{"to":"15552223333","message-id":"msg-1","status":"0"}]}`))
+			}))
+			defer srv.Close()
+
+			cfg := tt.cfg
+			cfg.APIKey, cfg.APISecret, cfg.BaseURL = "key", "secret", srv.URL+"/"
+			p, _ := New(cfg)
+
+			result, err := p.Send(context.Background(), &tt.sms)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "msg-1" || result.StatusCode != http.StatusOK {
+				t.Errorf("result = %+v", result)
+			}
+			if result.Meta["encoding"] != tt.wantEncoding || result.Meta["segments"] != tt.wantSegments {
+				t.Errorf("encoding = %s segments = %s, want %s %s", result.Meta["encoding"], result.Meta["segments"], tt.wantEncoding, tt.wantSegments)
+			}
+			if got.Method != http.MethodPost || got.URL.Path != "/sms/json" {
+				t.Errorf("request = %s %s", got.Method, got.URL.Path)
+			}
+			if form.Encode() != tt.wantForm.Encode() {
+				t.Errorf("form = %v, want %v", form, tt.wantForm)
+			}
+		})
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	status := func(code, text string) string {
+		return fmt.Sprintf(`{"message-count":"1","messages":[{"status":%q,"error-text":%q}]}`, code, text)
+	}
+
+	tests := []struct {
+		name       string
+		httpStatus int
+		body       string
+		wantKind   error
+		wantCode   string
+		wantStatus int
+	}{
+		{"throttled", http.StatusOK, status("1", "Throttled"), pkgerrors.ErrRateLimited, "1", http.StatusOK},
+		{"invalid credentials", http.StatusOK, status("4", "Bad Credentials"), pkgerrors.ErrUnauthorized, "4", http.StatusOK},
+		{"internal error", http.StatusOK, status("5", "Internal Error"), pkgerrors.ErrUnavailable, "5", http.StatusOK},
+		{"number barred", http.StatusOK, status("7", "Number barred"), pkgerrors.ErrInvalidRecipient, "7", http.StatusOK},
+		{"non-whitelisted destination", http.StatusOK, status("29", "Non White-listed Destination"), pkgerrors.ErrInvalidRecipient, "29", http.StatusOK},
+		{"unknown status", http.StatusOK, status("99", ""), pkgerrors.ErrRejected, "99", http.StatusOK},
+		{"http outage", http.StatusServiceUnavailable, "unavailable", pkgerrors.ErrUnavailable, "", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.httpStatus)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{APIKey: "key", APISecret: "secret", FromPhone: "+15550001111", BaseURL: srv.URL})
+			_, err := p.Send(context.Background(), &contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"})
+
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("kind = %v, want %v", perr.Kind, tt.wantKind)
+			}
+			if perr.Code != tt.wantCode || perr.StatusCode != tt.wantStatus {
+				t.Errorf("code = %q status = %d, want %q %d", perr.Code, perr.StatusCode, tt.wantCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSend_FailedPart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"message-count":"2","messages":[` +
+			`{"message-id":"msg-1","status":"0"},{"status":"6","error-text":"Invalid Message"}]}`))
+	}))
+	defer srv.Close()
+
+	p, _ := New(Config{APIKey: "key", APISecret: "secret", FromPhone: "+15550001111", BaseURL: srv.URL})
+	_, err := p.Send(context.Background(), &contracts.SMS{To: []string{"+15552223333"}, Message: strings.Repeat("a", 200)})
+	if !errors.Is(err, pkgerrors.ErrRejected) {
+		t.Errorf("error = %v, want ErrRejected", err)
+	}
+}
+
+func TestSend_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	p, _ := New(Config{APIKey: "key", APISecret: "secret", FromPhone: "+15550001111", BaseURL: srv.URL})
+	_, err := p.Send(context.Background(), &contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"})
+	if !errors.Is(err, pkgerrors.ErrUnavailable) {
+		t.Errorf("error = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestSend_PartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.PostForm.Get("to") == "15550000000" {
+			_, _ = w.Write([]byte(`{"message-count":"1","messages":[{"status":"7","error-text":"Number barred"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"message-count":"1","messages":[{"message-id":"msg-1","status":"0"}]}`))
+	}))
+	defer srv.Close()
+
+	p, _ := New(Config{APIKey: "key", APISecret: "secret", FromPhone: "+15550001111", BaseURL: srv.URL})
+	result, err := p.Send(context.Background(), &contracts.SMS{To: []string{"+15551111111", "+15550000000"}, Message: "Hello"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if result.StatusCode != http.StatusMultiStatus || result.Meta["id:+15551111111"] != "msg-1" || result.Meta["error:+15550000000"] == "" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestSend_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		sms  contracts.SMS
+	}{
+		{"no recipients", Config{FromPhone: "+15550001111"}, contracts.SMS{Message: "Hello"}},
+		{"no from", Config{}, contracts.SMS{To: []string{"+15552223333"}, Message: "Hello"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.APIKey, cfg.APISecret, cfg.BaseURL = "key", "secret", "http://127.0.0.1:1"
+			p, _ := New(cfg)
+			if _, err := p.Send(context.Background(), &tt.sms); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package webhook
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterChatProvider(ProviderName, func(cfg registry.ChatConfig) (port.ChatSender, error) {
+		secret := cfg.Extra["secret"]
+		if secret == "" {
+			secret = cfg.APISecret
+		}
+
+		return New(Config{
+			URL:    cfg.WebhookURL,
+			Secret: secret,
+		})
+	})
+}
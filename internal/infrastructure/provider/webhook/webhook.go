@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName   = "webhook"
+	defaultTimeout = 30 * time.Second
+)
+
+// Headers set on every delivery.
+const (
+	HeaderDelivery  = "X-Gateway-Delivery"
+	HeaderTimestamp = "X-Gateway-Timestamp"
+	HeaderSignature = "X-Gateway-Signature"
+)
+
+var _ port.ChatSender = (*Provider)(nil)
+
+// Config holds generic webhook configuration.
+type Config struct {
+	URL string
+	// Secret signs each request body. Signing is skipped when empty.
+	Secret string
+}
+
+// Provider implements port.ChatSender by posting the raw ChatMessage JSON.
+type Provider struct {
+	client *http.Client
+	config Config
+}
+
+type deliveryResponse struct {
+	ID string `json:"id"`
+}
+
+// New creates a new webhook provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webhook: webhook URL is required")
+	}
+
+	return &Provider{
+		client: &http.Client{},
+		config: cfg,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send posts the message as JSON. If the receiver responds with {"id": "..."}
+// that ID is returned; otherwise the delivery ID is used.
+func (p *Provider) Send(ctx context.Context, message *contracts.ChatMessage) (*contracts.SendResult, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: failed to encode message: %w", err)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(sendCtx, http.MethodPost, p.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("webhook: failed to create request: %w", err)
+	}
+
+	deliveryID := uuid.New().String()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if p.config.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(p.config.Secret, timestamp, body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if sendCtx.Err() != nil {
+			return nil, fmt.Errorf("webhook: failed to send message: %w", err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send message", 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return nil, perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		msg := strings.TrimSpace(string(respBody))
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		return nil, pkgerrors.NewProviderError(ProviderName, "failed to send message", resp.StatusCode, errors.New(msg))
+	}
+
+	id := deliveryID
+	var delivery deliveryResponse
+	if err := json.Unmarshal(respBody, &delivery); err == nil && delivery.ID != "" {
+		id = delivery.ID
+	}
+
+	return &contracts.SendResult{
+		ID:         id,
+		StatusCode: http.StatusOK,
+		Message:    "Message sent successfully",
+		Meta:       map[string]string{"delivery_id": deliveryID},
+	}, nil
+}
+
+// Sign returns the X-Gateway-Signature value for a request: "sha256=" followed
+// by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret. Receivers
+// should recompute it and compare with hmac.Equal.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
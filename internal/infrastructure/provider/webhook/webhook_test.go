@@ -0,0 +1,173 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{URL: "https://example.com/hook"}, false},
+		{"missing URL", Config{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	tests := []struct {
+		name         string
+		secret       string
+		responseBody string
+		wantSigned   bool
+		wantID       string
+	}{
+		{"unsigned", "", "", false, ""},
+		{"signed", "s3cret", "", true, ""},
+		{"receiver ID", "", `{"id":"rcv-1"}`, false, "rcv-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r *http.Request
+			var body []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				r = req
+				body, _ = io.ReadAll(req.Body)
+				_, _ = w.Write([]byte(tt.responseBody))
+			}))
+			defer srv.Close()
+
+			message := &contracts.ChatMessage{
+				To:       []string{"ops"},
+				Message:  "Hello",
+				Buttons:  []contracts.ChatButton{{ID: "ack", Text: "Ack"}},
+				Metadata: map[string]string{"env": "prod"},
+			}
+			p, _ := New(Config{URL: srv.URL + "/hook", Secret: tt.secret})
+			result, err := p.Send(context.Background(), message)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			if r.Method != http.MethodPost || r.URL.Path != "/hook" || r.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("request = %s %s %s", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+			}
+			var got contracts.ChatMessage
+			if err := json.Unmarshal(body, &got); err != nil || !reflect.DeepEqual(&got, message) {
+				t.Errorf("body = %s", body)
+			}
+
+			delivery := r.Header.Get(HeaderDelivery)
+			if delivery == "" || result.Meta["delivery_id"] != delivery {
+				t.Errorf("delivery ID = %q, meta %v", delivery, result.Meta)
+			}
+			wantID := tt.wantID
+			if wantID == "" {
+				wantID = delivery
+			}
+			if result.ID != wantID {
+				t.Errorf("ID = %q, want %q", result.ID, wantID)
+			}
+
+			timestamp := r.Header.Get(HeaderTimestamp)
+			if ts, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+				t.Errorf("timestamp = %q", timestamp)
+			}
+			signature := r.Header.Get(HeaderSignature)
+			if tt.wantSigned {
+				if signature != Sign(tt.secret, timestamp, body) {
+					t.Errorf("signature = %q does not match the body", signature)
+				}
+			} else if signature != "" {
+				t.Errorf("unexpected signature %q", signature)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{"known value", "secret", "1700000000", `{"message":"hi"}`, "sha256=ddbf7e9b21839be4fddc6442c675ef09ad9f59656a5130e224281c3bc8e232e5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sign(tt.secret, tt.timestamp, []byte(tt.body))
+			if got != tt.want {
+				t.Errorf("Sign() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantKind error
+		wantMsg  string
+	}{
+		{"rejected", http.StatusUnprocessableEntity, "unknown channel", pkgerrors.ErrRejected, "unknown channel"},
+		{"unauthorized", http.StatusUnauthorized, "", pkgerrors.ErrUnauthorized, "Unauthorized"},
+		{"rate limited", http.StatusTooManyRequests, "slow down", pkgerrors.ErrRateLimited, "slow down"},
+		{"outage", http.StatusServiceUnavailable, "", pkgerrors.ErrUnavailable, "Service Unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{URL: srv.URL})
+			_, err := p.Send(context.Background(), &contracts.ChatMessage{Message: "Hi"})
+
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.wantKind) || perr.StatusCode != tt.status || perr.Err.Error() != tt.wantMsg {
+				t.Errorf("error = %v, want %v %d %q", err, tt.wantKind, tt.status, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestSend_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	p, _ := New(Config{URL: srv.URL})
+	_, err := p.Send(context.Background(), &contracts.ChatMessage{Message: "Hi"})
+	if !errors.Is(err, pkgerrors.ErrUnavailable) {
+		t.Errorf("error = %v, want ErrUnavailable", err)
+	}
+}
@@ -0,0 +1,57 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strconv"
+
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// APIError is the error object returned by the Graph API.
+type APIError struct {
+	Message      string `json:"message"`
+	Type         string `json:"type"`
+	Code         int    `json:"code"`
+	ErrorSubcode int    `json:"error_subcode"`
+	FBTraceID    string `json:"fbtrace_id"`
+	ErrorData    struct {
+		Details string `json:"details"`
+	} `json:"error_data"`
+}
+
+func (e *APIError) Error() string {
+	if e.ErrorData.Details != "" {
+		return fmt.Sprintf("whatsapp error %d: %s: %s", e.Code, e.Message, e.ErrorData.Details)
+	}
+	return fmt.Sprintf("whatsapp error %d: %s", e.Code, e.Message)
+}
+
+// errorKinds maps well-known Cloud API error codes to gateway error kinds.
+// See https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes.
+var errorKinds = map[int]error{
+	190:    pkgerrors.ErrUnauthorized,     // Access token expired
+	4:      pkgerrors.ErrRateLimited,      // API too many calls
+	80007:  pkgerrors.ErrRateLimited,      // Rate limit issues
+	130429: pkgerrors.ErrRateLimited,      // Cloud API throughput reached
+	131048: pkgerrors.ErrRateLimited,      // Spam rate limit hit
+	131056: pkgerrors.ErrRateLimited,      // Pair rate limit hit
+	131000: pkgerrors.ErrUnavailable,      // Something went wrong
+	131016: pkgerrors.ErrUnavailable,      // Service unavailable
+	131026: pkgerrors.ErrInvalidRecipient, // Message undeliverable
+	131030: pkgerrors.ErrInvalidRecipient, // Recipient not in allowed list
+	131047: pkgerrors.ErrRejected,         // Re-engagement message (24h window closed)
+	132000: pkgerrors.ErrRejected,         // Template param count mismatch
+	132001: pkgerrors.ErrRejected,         // Template does not exist
+}
+
+// newSendError converts a Graph API error into a typed provider error.
+func newSendError(to string, statusCode int, apiErr *APIError) *pkgerrors.ProviderError {
+	perr := pkgerrors.NewProviderError(ProviderName, "failed to send message to "+to, statusCode, apiErr)
+	if apiErr.Code != 0 {
+		perr.Code = strconv.Itoa(apiErr.Code)
+		if kind, ok := errorKinds[apiErr.Code]; ok {
+			perr.Kind = kind
+		}
+	}
+	return perr
+}
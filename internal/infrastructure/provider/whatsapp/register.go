@@ -0,0 +1,23 @@
+package whatsapp
+
+import (
+	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func init() {
+	registry.RegisterChatProvider(ProviderName, func(cfg registry.ChatConfig) (port.ChatSender, error) {
+		accessToken := cfg.Extra["access_token"]
+		if accessToken == "" {
+			accessToken = cfg.APIKey
+		}
+
+		return New(Config{
+			AccessToken:   accessToken,
+			PhoneNumberID: cfg.Extra["phone_number_id"],
+			APIVersion:    cfg.Extra["api_version"],
+			BaseURL:       cfg.BaseURL,
+			Language:      cfg.Extra["language"],
+		})
+	})
+}
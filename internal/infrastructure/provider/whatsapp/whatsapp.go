@@ -0,0 +1,467 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+const (
+	ProviderName      = "whatsapp"
+	defaultBaseURL    = "https://graph.facebook.com"
+	defaultAPIVersion = "v21.0"
+	defaultLanguage   = "en_US"
+	defaultTimeout    = 30 * time.Second
+
+	// maxReplyButtons is the Cloud API limit for interactive reply buttons.
+	maxReplyButtons = 3
+)
+
+// MetaLanguage is the ChatMessage.Metadata key that selects the template
+// language (e.g. "de" or "pt_BR").
+const MetaLanguage = "language"
+
+var _ port.ChatSender = (*Provider)(nil)
+
+// Config holds WhatsApp Business Cloud API configuration.
+type Config struct {
+	AccessToken   string
+	PhoneNumberID string
+	APIVersion    string
+	BaseURL       string
+	// Language is the default template language code.
+	Language string
+}
+
+// Provider implements port.ChatSender for the WhatsApp Business Cloud API.
+type Provider struct {
+	client   *http.Client
+	config   Config
+	endpoint string
+}
+
+// messageRequest is the body of POST /{phone-number-id}/messages.
+type messageRequest struct {
+	MessagingProduct string        `json:"messaging_product"`
+	RecipientType    string        `json:"recipient_type"`
+	To               string        `json:"to"`
+	Type             string        `json:"type"`
+	Context          *replyContext `json:"context,omitempty"`
+	Text             *textObject   `json:"text,omitempty"`
+	Image            *mediaObject  `json:"image,omitempty"`
+	Video            *mediaObject  `json:"video,omitempty"`
+	Audio            *mediaObject  `json:"audio,omitempty"`
+	Document         *mediaObject  `json:"document,omitempty"`
+	Template         *template     `json:"template,omitempty"`
+	Interactive      *interactive  `json:"interactive,omitempty"`
+}
+
+type replyContext struct {
+	MessageID string `json:"message_id"`
+}
+
+type textObject struct {
+	Body       string `json:"body"`
+	PreviewURL bool   `json:"preview_url,omitempty"`
+}
+
+type mediaObject struct {
+	Link    string `json:"link"`
+	Caption string `json:"caption,omitempty"`
+}
+
+type template struct {
+	Name       string      `json:"name"`
+	Language   language    `json:"language"`
+	Components []component `json:"components,omitempty"`
+}
+
+type language struct {
+	Code string `json:"code"`
+}
+
+type component struct {
+	Type       string      `json:"type"`
+	SubType    string      `json:"sub_type,omitempty"`
+	Index      string      `json:"index,omitempty"`
+	Parameters []parameter `json:"parameters"`
+}
+
+type parameter struct {
+	Type     string       `json:"type"`
+	Text     string       `json:"text,omitempty"`
+	Payload  string       `json:"payload,omitempty"`
+	Image    *mediaObject `json:"image,omitempty"`
+	Video    *mediaObject `json:"video,omitempty"`
+	Document *mediaObject `json:"document,omitempty"`
+}
+
+type interactive struct {
+	Type   string            `json:"type"`
+	Header *interactiveMedia `json:"header,omitempty"`
+	Body   textObject        `json:"body"`
+	Action action            `json:"action"`
+}
+
+type interactiveMedia struct {
+	Type     string       `json:"type"`
+	Image    *mediaObject `json:"image,omitempty"`
+	Video    *mediaObject `json:"video,omitempty"`
+	Document *mediaObject `json:"document,omitempty"`
+}
+
+type action struct {
+	Buttons    []replyButton `json:"buttons,omitempty"`
+	Name       string        `json:"name,omitempty"`
+	Parameters *ctaURL       `json:"parameters,omitempty"`
+}
+
+type replyButton struct {
+	Type  string `json:"type"`
+	Reply struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	} `json:"reply"`
+}
+
+type ctaURL struct {
+	DisplayText string `json:"display_text"`
+	URL         string `json:"url"`
+}
+
+type messageResponse struct {
+	Messages []struct {
+		ID string `json:"id"`
+	} `json:"messages"`
+}
+
+type errorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+// New creates a new WhatsApp Cloud API provider.
+func New(cfg Config) (*Provider, error) {
+	if cfg.AccessToken == "" {
+		return nil, errors.New("whatsapp: access token is required")
+	}
+	if cfg.PhoneNumberID == "" {
+		return nil, errors.New("whatsapp: phone number ID is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = defaultAPIVersion
+	}
+	if cfg.Language == "" {
+		cfg.Language = defaultLanguage
+	}
+
+	return &Provider{
+		client:   &http.Client{},
+		config:   cfg,
+		endpoint: fmt.Sprintf("%s/%s/%s/messages", cfg.BaseURL, cfg.APIVersion, url.PathEscape(cfg.PhoneNumberID)),
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+// Send sends the message to each recipient. Messages with a TemplateID are
+// sent as approved templates; otherwise as session text, media or
+// interactive messages, which WhatsApp only delivers within 24 hours of the
+// user's last message.
+//
+// Per-recipient outcomes are reported in SendResult.Meta as "id:<to>" (wamid)
+// and "error:<to>". An error is only returned when every recipient failed.
+func (p *Provider) Send(ctx context.Context, message *contracts.ChatMessage) (*contracts.SendResult, error) {
+	if len(message.To) == 0 {
+		return nil, errors.New("no recipients specified")
+	}
+
+	req, err := p.buildRequest(message)
+	if err != nil {
+		return nil, err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	meta := make(map[string]string, len(message.To)*2+2)
+	var firstID string
+	var firstErr error
+	sent := 0
+
+	for _, to := range message.To {
+		req.To = strings.TrimPrefix(to, "+")
+		id, err := p.sendOne(sendCtx, to, req)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			meta["error:"+to] = err.Error()
+			continue
+		}
+
+		sent++
+		if firstID == "" {
+			firstID = id
+		}
+		meta["id:"+to] = id
+	}
+
+	meta["sent"] = strconv.Itoa(sent)
+	meta["failed"] = strconv.Itoa(len(message.To) - sent)
+
+	if sent == 0 {
+		if len(message.To) == 1 {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("whatsapp: all %d recipients failed: %w", len(message.To), firstErr)
+	}
+
+	result := &contracts.SendResult{
+		ID:         firstID,
+		StatusCode: http.StatusOK,
+		Message:    "Message sent successfully",
+		Meta:       meta,
+	}
+	if sent < len(message.To) {
+		result.StatusCode = http.StatusMultiStatus
+		result.Message = fmt.Sprintf("Message sent to %d of %d recipients", sent, len(message.To))
+	}
+	return result, nil
+}
+
+// buildRequest builds the request body shared by all recipients, minus To.
+func (p *Provider) buildRequest(message *contracts.ChatMessage) (*messageRequest, error) {
+	req := &messageRequest{
+		MessagingProduct: "whatsapp",
+		RecipientType:    "individual",
+	}
+	if message.ReplyToID != "" {
+		req.Context = &replyContext{MessageID: message.ReplyToID}
+	}
+
+	switch {
+	case message.TemplateID != "":
+		req.Type = "template"
+		req.Template = p.buildTemplate(message)
+	case len(message.Buttons) > 0:
+		i, err := buildInteractive(message)
+		if err != nil {
+			return nil, err
+		}
+		req.Type = "interactive"
+		req.Interactive = i
+	case message.MediaURL != "":
+		req.Type = mediaKind(message.MediaType)
+		media := &mediaObject{Link: message.MediaURL}
+		// Audio messages cannot carry a caption.
+		if req.Type != "audio" {
+			media.Caption = message.Message
+		}
+		switch req.Type {
+		case "image":
+			req.Image = media
+		case "video":
+			req.Video = media
+		case "audio":
+			req.Audio = media
+		default:
+			req.Document = media
+		}
+	case message.Message != "":
+		req.Type = "text"
+		req.Text = &textObject{
+			Body:       message.Message,
+			PreviewURL: strings.Contains(message.Message, "https://") || strings.Contains(message.Message, "http://"),
+		}
+	default:
+		return nil, errors.New("whatsapp: message, media URL or template ID is required")
+	}
+
+	return req, nil
+}
+
+// buildTemplate maps TemplateParams to body parameters, MediaURL to a media
+// header and Buttons, in template order, to button parameters: a button's
+// URL is the dynamic suffix of a URL button, and its ID the payload of a
+// quick-reply button.
+func (p *Provider) buildTemplate(message *contracts.ChatMessage) *template {
+	lang := message.Metadata[MetaLanguage]
+	if lang == "" {
+		lang = p.config.Language
+	}
+
+	t := &template{
+		Name:     message.TemplateID,
+		Language: language{Code: lang},
+	}
+
+	if message.MediaURL != "" {
+		kind := mediaKind(message.MediaType)
+		param := parameter{Type: kind}
+		media := &mediaObject{Link: message.MediaURL}
+		switch kind {
+		case "image":
+			param.Image = media
+		case "video":
+			param.Video = media
+		default:
+			param.Type = "document"
+			param.Document = media
+		}
+		t.Components = append(t.Components, component{Type: "header", Parameters: []parameter{param}})
+	}
+
+	if len(message.TemplateParams) > 0 {
+		params := make([]parameter, len(message.TemplateParams))
+		for i, v := range message.TemplateParams {
+			params[i] = parameter{Type: "text", Text: v}
+		}
+		t.Components = append(t.Components, component{Type: "body", Parameters: params})
+	}
+
+	for i, b := range message.Buttons {
+		button := component{
+			Type:       "button",
+			SubType:    "quick_reply",
+			Index:      strconv.Itoa(i),
+			Parameters: []parameter{{Type: "payload", Payload: b.ID}},
+		}
+		if b.URL != "" {
+			button.SubType = "url"
+			button.Parameters = []parameter{{Type: "text", Text: b.URL}}
+		}
+		t.Components = append(t.Components, button)
+	}
+
+	return t
+}
+
+// buildInteractive renders session buttons: a single URL button becomes a
+// call-to-action URL message, otherwise up to three reply buttons.
+func buildInteractive(message *contracts.ChatMessage) (*interactive, error) {
+	if message.Message == "" {
+		return nil, errors.New("whatsapp: interactive messages require a message body")
+	}
+
+	i := &interactive{Body: textObject{Body: message.Message}}
+	if message.MediaURL != "" {
+		kind := mediaKind(message.MediaType)
+		header := &interactiveMedia{Type: kind}
+		media := &mediaObject{Link: message.MediaURL}
+		switch kind {
+		case "image":
+			header.Image = media
+		case "video":
+			header.Video = media
+		default:
+			header.Type = "document"
+			header.Document = media
+		}
+		i.Header = header
+	}
+
+	if len(message.Buttons) == 1 && message.Buttons[0].URL != "" {
+		i.Type = "cta_url"
+		i.Action = action{
+			Name:       "cta_url",
+			Parameters: &ctaURL{DisplayText: message.Buttons[0].Text, URL: message.Buttons[0].URL},
+		}
+		return i, nil
+	}
+
+	if len(message.Buttons) > maxReplyButtons {
+		return nil, fmt.Errorf("whatsapp: at most %d reply buttons are allowed", maxReplyButtons)
+	}
+	i.Type = "button"
+	for _, b := range message.Buttons {
+		if b.URL != "" {
+			return nil, errors.New("whatsapp: URL buttons cannot be combined with other buttons")
+		}
+		var rb replyButton
+		rb.Type = "reply"
+		rb.Reply.ID = b.ID
+		rb.Reply.Title = b.Text
+		i.Action.Buttons = append(i.Action.Buttons, rb)
+	}
+	return i, nil
+}
+
+// mediaKind maps a MIME type to a WhatsApp media type.
+func mediaKind(mediaType string) string {
+	switch {
+	case mediaType == "" || strings.HasPrefix(mediaType, "image"):
+		return "image"
+	case strings.HasPrefix(mediaType, "video"):
+		return "video"
+	case strings.HasPrefix(mediaType, "audio"):
+		return "audio"
+	default:
+		return "document"
+	}
+}
+
+func (p *Provider) sendOne(ctx context.Context, to string, msg *messageRequest) (string, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("whatsapp: failed to encode message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("whatsapp: failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.config.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("whatsapp: failed to send message to %s: %w", to, err)
+		}
+		perr := pkgerrors.NewProviderError(ProviderName, "failed to send message to "+to, 0, err)
+		perr.Kind = pkgerrors.ErrUnavailable
+		return "", perr
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("whatsapp: failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var errResp errorResponse
+		if err := json.Unmarshal(data, &errResp); err != nil || errResp.Error == nil {
+			errResp.Error = &APIError{Message: strings.TrimSpace(string(data))}
+		}
+		return "", newSendError(to, resp.StatusCode, errResp.Error)
+	}
+
+	var msgResp messageResponse
+	if err := json.Unmarshal(data, &msgResp); err != nil {
+		return "", fmt.Errorf("whatsapp: failed to decode response: %w", err)
+	}
+	if len(msgResp.Messages) == 0 {
+		return "", errors.New("whatsapp: response contained no message ID")
+	}
+	return msgResp.Messages[0].ID, nil
+}
@@ -0,0 +1,209 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		wantErr      bool
+		wantEndpoint string
+	}{
+		{"valid", Config{AccessToken: "tok", PhoneNumberID: "1055"}, false, "https://graph.facebook.com/v21.0/1055/messages"},
+		{"api version", Config{AccessToken: "tok", PhoneNumberID: "1055", APIVersion: "v19.0", BaseURL: "http://localhost/"}, false, "http://localhost/v19.0/1055/messages"},
+		{"missing token", Config{PhoneNumberID: "1055"}, true, ""},
+		{"missing phone number ID", Config{AccessToken: "tok"}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && p.endpoint != tt.wantEndpoint {
+				t.Errorf("endpoint = %q, want %q", p.endpoint, tt.wantEndpoint)
+			}
+		})
+	}
+}
+
+func TestSend_Request(t *testing.T) {
+	const envelope = `"messaging_product":"whatsapp","recipient_type":"individual","to":"15551234567"`
+
+	tests := []struct {
+		name    string
+		message contracts.ChatMessage
+		want    string
+	}{
+		{
+			name:    "text",
+			message: contracts.ChatMessage{Message: "Hello"},
+			want:    `{` + envelope + `,"type":"text","text":{"body":"Hello"}}`,
+		},
+		{
+			name:    "text with link preview and reply",
+			message: contracts.ChatMessage{Message: "See https://example.com", ReplyToID: "wamid.1"},
+			want:    `{` + envelope + `,"type":"text","context":{"message_id":"wamid.1"},"text":{"body":"See https://example.com","preview_url":true}}`,
+		},
+		{
+			name:    "image with caption",
+			message: contracts.ChatMessage{Message: "Look", MediaURL: "https://example.com/a.jpg", MediaType: "image/jpeg"},
+			want:    `{` + envelope + `,"type":"image","image":{"link":"https://example.com/a.jpg","caption":"Look"}}`,
+		},
+		{
+			name:    "audio drops caption",
+			message: contracts.ChatMessage{Message: "Listen", MediaURL: "https://example.com/a.ogg", MediaType: "audio/ogg"},
+			want:    `{` + envelope + `,"type":"audio","audio":{"link":"https://example.com/a.ogg"}}`,
+		},
+		{
+			name:    "document",
+			message: contracts.ChatMessage{MediaURL: "https://example.com/a.pdf", MediaType: "application/pdf"},
+			want:    `{` + envelope + `,"type":"document","document":{"link":"https://example.com/a.pdf"}}`,
+		},
+		{
+			name: "reply buttons",
+			message: contracts.ChatMessage{
+				Message: "Confirm?",
+				Buttons: []contracts.ChatButton{{ID: "yes", Text: "Yes"}, {ID: "no", Text: "No"}},
+			},
+			want: `{` + envelope + `,"type":"interactive","interactive":{"type":"button","body":{"body":"Confirm?"},"action":{"buttons":[` +
+				`{"type":"reply","reply":{"id":"yes","title":"Yes"}},{"type":"reply","reply":{"id":"no","title":"No"}}]}}}`,
+		},
+		{
+			name: "call-to-action URL",
+			message: contracts.ChatMessage{
+				Message: "Track it",
+				Buttons: []contracts.ChatButton{{Text: "Track", URL: "https://example.com/t/1"}},
+			},
+			want: `{` + envelope + `,"type":"interactive","interactive":{"type":"cta_url","body":{"body":"Track it"},"action":{"name":"cta_url","parameters":{"display_text":"Track","url":"https://example.com/t/1"}}}}`,
+		},
+		{
+			name: "template with header, body and buttons",
+			message: contracts.ChatMessage{
+				TemplateID:     "order_update",
+				TemplateParams: []string{"Jane", "#1042"},
+				MediaURL:       "https://example.com/order.png",
+				Buttons: []contracts.ChatButton{
+					{ID: "stop", Text: "Stop updates"},
+					{Text: "Track order", URL: "1042"},
+				},
+				Metadata: map[string]string{MetaLanguage: "de"},
+			},
+			want: `{` + envelope + `,"type":"template","template":{"name":"order_update","language":{"code":"de"},"components":[` +
+				`{"type":"header","parameters":[{"type":"image","image":{"link":"https://example.com/order.png"}}]},` +
+				`{"type":"body","parameters":[{"type":"text","text":"Jane"},{"type":"text","text":"#1042"}]},` +
+				`{"type":"button","sub_type":"quick_reply","index":"0","parameters":[{"type":"payload","payload":"stop"}]},` +
+				`{"type":"button","sub_type":"url","index":"1","parameters":[{"type":"text","text":"1042"}]}]}}`,
+		},
+		{
+			name:    "template default language",
+			message: contracts.ChatMessage{TemplateID: "hello_world"},
+			want:    `{` + envelope + `,"type":"template","template":{"name":"hello_world","language":{"code":"en_US"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			var r *http.Request
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				r = req
+				data, _ := io.ReadAll(req.Body)
+				got = string(data)
+				_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.ABC"}]}`))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{AccessToken: "tok", PhoneNumberID: "1055", BaseURL: srv.URL})
+			tt.message.To = []string{"+15551234567"}
+			result, err := p.Send(context.Background(), &tt.message)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if result.ID != "wamid.ABC" {
+				t.Errorf("ID = %q", result.ID)
+			}
+			if r.Method != http.MethodPost || r.URL.Path != "/v21.0/1055/messages" || r.Header.Get("Authorization") != "Bearer tok" {
+				t.Errorf("request = %s %s %s", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+			}
+			if got != tt.want {
+				t.Errorf("body = %s\nwant   %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSend_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		message contracts.ChatMessage
+	}{
+		{"no recipients", contracts.ChatMessage{Message: "Hi"}},
+		{"no content", contracts.ChatMessage{To: []string{"1"}}},
+		{"buttons without body", contracts.ChatMessage{To: []string{"1"}, Buttons: []contracts.ChatButton{{ID: "a", Text: "A"}}}},
+		{"too many reply buttons", contracts.ChatMessage{To: []string{"1"}, Message: "Hi", Buttons: []contracts.ChatButton{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}}},
+		{"URL mixed with reply buttons", contracts.ChatMessage{To: []string{"1"}, Message: "Hi", Buttons: []contracts.ChatButton{{ID: "a"}, {URL: "https://example.com"}}}},
+	}
+	p, _ := New(Config{AccessToken: "tok", PhoneNumberID: "1055", BaseURL: "http://127.0.0.1:1"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Send(context.Background(), &tt.message)
+			if err == nil || errors.Is(err, pkgerrors.ErrUnavailable) {
+				t.Errorf("error = %v, want a validation error", err)
+			}
+		})
+	}
+}
+
+func TestSend_Errors(t *testing.T) {
+	graphError := func(code int, message string) string {
+		data, _ := json.Marshal(map[string]any{"error": map[string]any{"message": message, "type": "OAuthException", "code": code, "fbtrace_id": "A1"}})
+		return string(data)
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantKind error
+		wantCode string
+	}{
+		{"expired token", http.StatusUnauthorized, graphError(190, "Error validating access token"), pkgerrors.ErrUnauthorized, "190"},
+		{"throughput", http.StatusBadRequest, graphError(130429, "Rate limit hit"), pkgerrors.ErrRateLimited, "130429"},
+		{"undeliverable", http.StatusBadRequest, graphError(131026, "Message undeliverable"), pkgerrors.ErrInvalidRecipient, "131026"},
+		{"session window closed", http.StatusBadRequest, graphError(131047, "Re-engagement message"), pkgerrors.ErrRejected, "131047"},
+		{"unknown template", http.StatusNotFound, graphError(132001, "Template name does not exist"), pkgerrors.ErrRejected, "132001"},
+		{"unknown code falls back to status", http.StatusServiceUnavailable, graphError(1, "Unknown"), pkgerrors.ErrUnavailable, "1"},
+		{"non-JSON outage", http.StatusBadGateway, "bad gateway", pkgerrors.ErrUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p, _ := New(Config{AccessToken: "tok", PhoneNumberID: "1055", BaseURL: srv.URL})
+			_, err := p.Send(context.Background(), &contracts.ChatMessage{To: []string{"+15551234567"}, Message: "Hi"})
+
+			var perr *pkgerrors.ProviderError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a *ProviderError", err)
+			}
+			if !errors.Is(err, tt.wantKind) || perr.Code != tt.wantCode {
+				t.Errorf("kind = %v code = %q, want %v %q", perr.Kind, perr.Code, tt.wantKind, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/atomicfile"
+)
+
+const failedName = "failed.json"
+
+var _ port.FailedJobStore = (*FailedStore)(nil)
+
+// FailedStore is a port.FailedJobStore that keeps failed jobs in memory and
+// writes the full set to a JSON file on every change. Failed jobs are
+// expected to be few and short-lived.
+type FailedStore struct {
+	path string
+
+	mu     sync.Mutex
+	jobs   map[string]*port.FailedJob
+	closed bool
+}
+
+// OpenFailed opens or creates the failed job store in dir.
+func OpenFailed(dir string) (*FailedStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("queue: failed to create directory: %w", err)
+	}
+
+	s := &FailedStore{
+		path: filepath.Join(dir, failedName),
+		jobs: make(map[string]*port.FailedJob),
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("queue: failed to read %s: %w", s.path, err)
+	}
+	if len(data) > 0 {
+		var jobs []*port.FailedJob
+		if err := json.Unmarshal(data, &jobs); err != nil {
+			return nil, fmt.Errorf("queue: failed to parse %s: %w", s.path, err)
+		}
+		for _, job := range jobs {
+			s.jobs[job.ID] = job
+		}
+	}
+	return s, nil
+}
+
+// Save inserts or replaces the job.
+func (s *FailedStore) Save(ctx context.Context, job *port.FailedJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("queue: failed job store closed")
+	}
+
+	prev, existed := s.jobs[job.ID]
+	stored := *job
+	s.jobs[job.ID] = &stored
+
+	if err := s.write(); err != nil {
+		if existed {
+			s.jobs[job.ID] = prev
+		} else {
+			delete(s.jobs, job.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns a copy of the job with the ID.
+func (s *FailedStore) Get(ctx context.Context, id string) (*port.FailedJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, port.ErrJobNotFound
+	}
+	stored := *job
+	return &stored, nil
+}
+
+// List returns copies of all jobs, oldest failure first.
+func (s *FailedStore) List(ctx context.Context) ([]*port.FailedJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sorted(), nil
+}
+
+// Delete removes the job with the ID.
+func (s *FailedStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return port.ErrJobNotFound
+	}
+	delete(s.jobs, id)
+
+	if err := s.write(); err != nil {
+		s.jobs[id] = job
+		return err
+	}
+	return nil
+}
+
+// Close stops accepting changes.
+func (s *FailedStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	return nil
+}
+
+func (s *FailedStore) sorted() []*port.FailedJob {
+	jobs := make([]*port.FailedJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		stored := *job
+		jobs = append(jobs, &stored)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].FailedAt.Equal(jobs[j].FailedAt) {
+			return jobs[i].ID < jobs[j].ID
+		}
+		return jobs[i].FailedAt.Before(jobs[j].FailedAt)
+	})
+	return jobs
+}
+
+// write replaces the file with the current jobs via atomicfile.
+func (s *FailedStore) write() error {
+	data, err := json.Marshal(s.sorted())
+	if err != nil {
+		return fmt.Errorf("queue: failed to encode failed jobs: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("queue: %w", err)
+	}
+	return nil
+}
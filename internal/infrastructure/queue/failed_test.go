@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func TestFailedStoreReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := OpenFailed(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	for i, id := range []string{"b", "a", "c"} {
+		failed := &port.FailedJob{Job: *job(id), Error: "boom", FailedAt: now.Add(time.Duration(i) * time.Second)}
+		if err := s.Save(ctx, failed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a"); !errors.Is(err, port.ErrJobNotFound) {
+		t.Errorf("second Delete error = %v, want ErrJobNotFound", err)
+	}
+	_ = s.Close()
+
+	s, err = OpenFailed(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, j := range jobs {
+		ids = append(ids, j.ID)
+	}
+	if len(ids) != 2 || ids[0] != "b" || ids[1] != "c" {
+		t.Errorf("failed jobs after reopen = %v, want [b c]", ids)
+	}
+	if _, err := s.Get(ctx, "a"); !errors.Is(err, port.ErrJobNotFound) {
+		t.Errorf("Get of deleted job error = %v, want ErrJobNotFound", err)
+	}
+}
@@ -0,0 +1,303 @@
+// Package queue provides durable implementations of port.Queue.
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/atomicfile"
+)
+
+const (
+	logName = "queue.log"
+	// compactAfter is the number of acknowledged records that triggers a
+	// rewrite of the log, provided they outnumber the live jobs.
+	compactAfter = 1000
+)
+
+var _ port.Queue = (*FileQueue)(nil)
+
+// record is one line of the append-only log.
+type record struct {
+	Op  string    `json:"op"`
+	Job *port.Job `json:"job,omitempty"`
+	ID  string    `json:"id,omitempty"`
+}
+
+const (
+	opPut = "put"
+	opAck = "ack"
+)
+
+// FileQueue is a port.Queue backed by an append-only JSON-lines log.
+//
+// Every enqueue and ack is appended and fsynced before returning. On open the
+// log is replayed: jobs without an ack are queued again in their original order.
+type FileQueue struct {
+	path string
+
+	mu      sync.Mutex
+	file    *os.File
+	pending []*port.Job
+	// inflight holds dequeued jobs in dequeue order, so a rewrite keeps
+	// them ahead of pending jobs in their original order.
+	inflight []*port.Job
+	acked    int
+	closed   bool
+	notify   chan struct{}
+	done     chan struct{}
+}
+
+// Open opens or creates the queue stored in dir.
+func Open(dir string) (*FileQueue, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("queue: failed to create directory: %w", err)
+	}
+
+	q := &FileQueue{
+		path:   filepath.Join(dir, logName),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	pending, err := replay(q.path)
+	if err != nil {
+		return nil, err
+	}
+	q.pending = pending
+
+	// Start from a compact log holding only the live jobs.
+	if err := q.rewrite(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// replay reads the log and returns unacknowledged jobs in enqueue order.
+// A torn final line from a crash mid-write is ignored; any other line that
+// cannot be decoded is an error, as jobs after it might be lost or resent.
+func replay(path string) ([]*port.Job, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("queue: failed to open log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var order []string
+	jobs := make(map[string]*port.Job)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 32*1024*1024)
+	var torn error
+	for line := 1; scanner.Scan(); line++ {
+		if torn != nil {
+			return nil, fmt.Errorf("queue: corrupt log at line %d: %w", line-1, torn)
+		}
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			torn = err
+			continue
+		}
+		switch rec.Op {
+		case opPut:
+			if rec.Job != nil {
+				if _, ok := jobs[rec.Job.ID]; !ok {
+					order = append(order, rec.Job.ID)
+				}
+				jobs[rec.Job.ID] = rec.Job
+			}
+		case opAck:
+			delete(jobs, rec.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("queue: failed to read log: %w", err)
+	}
+
+	pending := make([]*port.Job, 0, len(jobs))
+	for _, id := range order {
+		if job, ok := jobs[id]; ok {
+			pending = append(pending, job)
+			delete(jobs, id)
+		}
+	}
+	return pending, nil
+}
+
+// Enqueue durably appends the job to the queue.
+func (q *FileQueue) Enqueue(ctx context.Context, job *port.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return port.ErrQueueClosed
+	}
+	if err := q.append(record{Op: opPut, Job: job}); err != nil {
+		return err
+	}
+	q.pending = append(q.pending, job)
+	q.signal()
+	return nil
+}
+
+// Dequeue returns the oldest pending job, waiting for one if necessary.
+func (q *FileQueue) Dequeue(ctx context.Context) (*port.Job, error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil, port.ErrQueueClosed
+		}
+		if len(q.pending) > 0 {
+			job := q.pending[0]
+			q.pending[0] = nil
+			q.pending = q.pending[1:]
+			q.inflight = append(q.inflight, job)
+			if len(q.pending) > 0 {
+				q.signal()
+			}
+			q.mu.Unlock()
+			return job, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-q.done:
+			return nil, port.ErrQueueClosed
+		case <-q.notify:
+		}
+	}
+}
+
+// Ack marks a dequeued job as done so it is not delivered again.
+func (q *FileQueue) Ack(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return port.ErrQueueClosed
+	}
+	i := slices.IndexFunc(q.inflight, func(job *port.Job) bool { return job.ID == id })
+	if i < 0 {
+		return fmt.Errorf("queue: job %s is not in flight", id)
+	}
+	if err := q.append(record{Op: opAck, ID: id}); err != nil {
+		return err
+	}
+	q.inflight = slices.Delete(q.inflight, i, i+1)
+
+	q.acked++
+	if q.acked >= compactAfter && q.acked > len(q.pending)+len(q.inflight) {
+		return q.rewrite()
+	}
+	return nil
+}
+
+// Nack returns a dequeued job to the back of the pending jobs. The log is
+// not written: the job's put record is still live until it is acknowledged.
+func (q *FileQueue) Nack(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return port.ErrQueueClosed
+	}
+	i := slices.IndexFunc(q.inflight, func(job *port.Job) bool { return job.ID == id })
+	if i < 0 {
+		return fmt.Errorf("queue: job %s is not in flight", id)
+	}
+	job := q.inflight[i]
+	q.inflight = slices.Delete(q.inflight, i, i+1)
+	q.pending = append(q.pending, job)
+	q.signal()
+	return nil
+}
+
+// Len returns the number of jobs not yet acknowledged.
+func (q *FileQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending) + len(q.inflight)
+}
+
+// Close closes the log. In-flight jobs that were not acknowledged are
+// delivered again when the queue is reopened.
+func (q *FileQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.done)
+	return q.file.Close()
+}
+
+func (q *FileQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *FileQueue) append(rec record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("queue: failed to encode record: %w", err)
+	}
+	line = append(line, '\n')
+
+	if _, err := q.file.Write(line); err != nil {
+		return fmt.Errorf("queue: failed to write log: %w", err)
+	}
+	if err := q.file.Sync(); err != nil {
+		return fmt.Errorf("queue: failed to sync log: %w", err)
+	}
+	return nil
+}
+
+// rewrite replaces the log with one holding only live jobs, in order,
+// via atomicfile.
+func (q *FileQueue) rewrite() error {
+	live := make([]*port.Job, 0, len(q.inflight)+len(q.pending))
+	live = append(live, q.inflight...)
+	live = append(live, q.pending...)
+	err := atomicfile.Write(q.path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, job := range live {
+			if err := enc.Encode(record{Op: opPut, Job: job}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("queue: failed to rewrite log: %w", err)
+	}
+
+	if q.file != nil {
+		_ = q.file.Close()
+	}
+	f, err := os.OpenFile(q.path, os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("queue: failed to open log: %w", err)
+	}
+	q.file = f
+	q.acked = 0
+	return nil
+}
@@ -0,0 +1,273 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func job(id string) *port.Job {
+	return &port.Job{ID: id, Channel: "sms", Payload: []byte(`{}`)}
+}
+
+func openQueue(t *testing.T, dir string) *FileQueue {
+	t.Helper()
+	q, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = q.Close() })
+	return q
+}
+
+func dequeue(t *testing.T, q *FileQueue) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	j, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue: %v", err)
+	}
+	return j.ID
+}
+
+// drain dequeues every pending job and returns their IDs in order.
+func drain(t *testing.T, q *FileQueue) []string {
+	t.Helper()
+	var ids []string
+	for q.Len() > len(q.inflight) {
+		ids = append(ids, dequeue(t, q))
+	}
+	return ids
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		n++
+	}
+	return n
+}
+
+func TestFileQueueReopen(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// before runs against the first queue, which is closed before reopening.
+		before func(t *testing.T, q *FileQueue)
+		want   []string
+	}{
+		{
+			name: "pending jobs keep their order",
+			before: func(t *testing.T, q *FileQueue) {
+				for _, id := range []string{"a", "b", "c"} {
+					if err := q.Enqueue(ctx, job(id)); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "acknowledged jobs are not replayed",
+			before: func(t *testing.T, q *FileQueue) {
+				for _, id := range []string{"a", "b", "c"} {
+					_ = q.Enqueue(ctx, job(id))
+				}
+				dequeue(t, q)
+				if err := q.Ack(ctx, dequeue(t, q)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"a", "c"},
+		},
+		{
+			name: "in-flight jobs are delivered again ahead of pending ones",
+			before: func(t *testing.T, q *FileQueue) {
+				for _, id := range []string{"a", "b", "c"} {
+					_ = q.Enqueue(ctx, job(id))
+				}
+				dequeue(t, q)
+				dequeue(t, q)
+			},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "nacked jobs keep their place after a restart",
+			before: func(t *testing.T, q *FileQueue) {
+				for _, id := range []string{"a", "b"} {
+					_ = q.Enqueue(ctx, job(id))
+				}
+				if err := q.Nack(ctx, dequeue(t, q)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name:   "empty queue",
+			before: func(t *testing.T, q *FileQueue) {},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			q, err := Open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.before(t, q)
+			if err := q.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			got := drain(t, openQueue(t, dir))
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("jobs after reopen = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileQueueReplayDamagedLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		log     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "torn final line is dropped",
+			log: `{"op":"put","job":{"id":"a","channel":"sms","payload":{}}}
+{"op":"put","job":{"id":"b","chan`,
+			want: []string{"a"},
+		},
+		{
+			name: "corrupt line before the end is an error",
+			log: `{"op":"put","job":{"id":"a","channel":"sms","payload":{}}}
+not json
+{"op":"put","job":{"id":"b","channel":"sms","payload":{}}}
+`,
+			wantErr: true,
+		},
+		{
+			name: "ack for an unknown job is ignored",
+			log: `{"op":"ack","id":"x"}
+{"op":"put","job":{"id":"a","channel":"sms","payload":{}}}
+`,
+			want: []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, logName), []byte(tt.log), 0o640); err != nil {
+				t.Fatal(err)
+			}
+
+			q, err := Open(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Open error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = q.Close() })
+			if got := drain(t, q); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("jobs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileQueueCompactsLog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	q := openQueue(t, dir)
+
+	for i := 0; i <= compactAfter; i++ {
+		if err := q.Enqueue(ctx, job(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < compactAfter; i++ {
+		if err := q.Ack(ctx, dequeue(t, q)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := countLines(t, filepath.Join(dir, logName)); n != 1 {
+		t.Errorf("log has %d lines after compaction, want 1", n)
+	}
+	if err := q.Enqueue(ctx, job("next")); err != nil {
+		t.Fatalf("Enqueue after compaction: %v", err)
+	}
+	_ = q.Close()
+
+	want := []string{fmt.Sprint(compactAfter), "next"}
+	if got := drain(t, openQueue(t, dir)); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("jobs after reopen = %v, want %v", got, want)
+	}
+}
+
+func TestFileQueueNack(t *testing.T) {
+	ctx := context.Background()
+	q := openQueue(t, t.TempDir())
+	for _, id := range []string{"a", "b"} {
+		_ = q.Enqueue(ctx, job(id))
+	}
+
+	if err := q.Nack(ctx, dequeue(t, q)); err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(t, q); fmt.Sprint(got) != "[b a]" {
+		t.Errorf("jobs after nack = %v, want [b a]", got)
+	}
+	if err := q.Nack(ctx, "missing"); err == nil {
+		t.Error("Nack of a job not in flight succeeded")
+	}
+}
+
+func TestFileQueueClosed(t *testing.T) {
+	ctx := context.Background()
+	q, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocked := make(chan error, 1)
+	go func() {
+		_, err := q.Dequeue(ctx)
+		blocked <- err
+	}()
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-blocked:
+		if !errors.Is(err, port.ErrQueueClosed) {
+			t.Errorf("blocked Dequeue error = %v, want ErrQueueClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dequeue did not return after Close")
+	}
+	if err := q.Enqueue(ctx, job("a")); !errors.Is(err, port.ErrQueueClosed) {
+		t.Errorf("Enqueue error = %v, want ErrQueueClosed", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package schedule provides durable implementations of port.ScheduleStore.
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/atomicfile"
+)
+
+const fileName = "scheduled.json"
+
+var _ port.ScheduleStore = (*FileStore)(nil)
+
+// FileStore is a port.ScheduleStore that keeps jobs in memory and writes
+// the full set to a JSON file on every change. It suits the hundreds to
+// low thousands of pending jobs a gateway typically holds.
+type FileStore struct {
+	path string
+
+	mu     sync.Mutex
+	jobs   map[string]*port.ScheduledJob
+	closed bool
+}
+
+// Open opens or creates the store in dir.
+func Open(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("schedule: failed to create directory: %w", err)
+	}
+
+	s := &FileStore{
+		path: filepath.Join(dir, fileName),
+		jobs: make(map[string]*port.ScheduledJob),
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("schedule: failed to read %s: %w", s.path, err)
+	}
+	if len(data) > 0 {
+		var jobs []*port.ScheduledJob
+		if err := json.Unmarshal(data, &jobs); err != nil {
+			return nil, fmt.Errorf("schedule: failed to parse %s: %w", s.path, err)
+		}
+		for _, job := range jobs {
+			s.jobs[job.ID] = job
+		}
+	}
+	return s, nil
+}
+
+// Save inserts or replaces the job.
+func (s *FileStore) Save(ctx context.Context, job *port.ScheduledJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("schedule: store closed")
+	}
+
+	prev, existed := s.jobs[job.ID]
+	stored := *job
+	s.jobs[job.ID] = &stored
+
+	if err := s.write(); err != nil {
+		if existed {
+			s.jobs[job.ID] = prev
+		} else {
+			delete(s.jobs, job.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns a copy of the job with the ID.
+func (s *FileStore) Get(ctx context.Context, id string) (*port.ScheduledJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, port.ErrJobNotFound
+	}
+	stored := *job
+	return &stored, nil
+}
+
+// List returns copies of all jobs, earliest send time first.
+func (s *FileStore) List(ctx context.Context) ([]*port.ScheduledJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sorted(), nil
+}
+
+// Delete removes the job with the ID.
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return port.ErrJobNotFound
+	}
+	delete(s.jobs, id)
+
+	if err := s.write(); err != nil {
+		s.jobs[id] = job
+		return err
+	}
+	return nil
+}
+
+// Close stops accepting changes.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	return nil
+}
+
+func (s *FileStore) sorted() []*port.ScheduledJob {
+	jobs := make([]*port.ScheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		stored := *job
+		jobs = append(jobs, &stored)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].SendAt.Equal(jobs[j].SendAt) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return jobs[i].SendAt.Before(jobs[j].SendAt)
+	})
+	return jobs
+}
+
+// write replaces the file with the current jobs via atomicfile.
+func (s *FileStore) write() error {
+	data, err := json.Marshal(s.sorted())
+	if err != nil {
+		return fmt.Errorf("schedule: failed to encode jobs: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("schedule: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+)
+
+func scheduled(id string, sendAt time.Time) *port.ScheduledJob {
+	return &port.ScheduledJob{Job: port.Job{ID: id, Channel: "sms", Payload: []byte(`{}`)}, SendAt: sendAt}
+}
+
+func ids(jobs []*port.ScheduledJob) string {
+	out := make([]string, len(jobs))
+	for i, job := range jobs {
+		out[i] = job.ID
+	}
+	return fmt.Sprint(out)
+}
+
+func TestFileStoreReopen(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		change func(t *testing.T, s *FileStore)
+		want   string
+	}{
+		{
+			name:   "jobs are listed earliest first",
+			change: func(t *testing.T, s *FileStore) {},
+			want:   "[a b c]",
+		},
+		{
+			name: "deleted job is gone",
+			change: func(t *testing.T, s *FileStore) {
+				if err := s.Delete(ctx, "b"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "[a c]",
+		},
+		{
+			name: "saved job replaces the one with the same ID",
+			change: func(t *testing.T, s *FileStore) {
+				if err := s.Save(ctx, scheduled("a", base.Add(time.Hour))); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "[b c a]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s, err := Open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, id := range []string{"c", "a", "b"} {
+				sendAt := base.Add(time.Duration([]int{2, 0, 1}[i]) * time.Minute)
+				if err := s.Save(ctx, scheduled(id, sendAt)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tt.change(t, s)
+			_ = s.Close()
+
+			s, err = Open(dir)
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			jobs, err := s.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(jobs); got != tt.want {
+				t.Errorf("jobs after reopen = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileStoreNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, port.ErrJobNotFound) {
+		t.Errorf("Get error = %v, want ErrJobNotFound", err)
+	}
+	if err := s.Delete(ctx, "missing"); !errors.Is(err, port.ErrJobNotFound) {
+		t.Errorf("Delete error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestFileStoreRejectsCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, fileName), []byte("[{"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir); err == nil {
+		t.Error("Open of a corrupt file succeeded")
+	}
+}
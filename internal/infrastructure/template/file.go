@@ -0,0 +1,217 @@
+// Package template provides implementations of port.TemplateStore.
+package template
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/atomicfile"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+const fileName = "templates.json"
+
+var _ port.TemplateStore = (*FileStore)(nil)
+
+// key identifies a template in one locale.
+type key struct {
+	name   string
+	locale string
+}
+
+// FileStore is a port.TemplateStore that keeps templates in memory and,
+// when opened with a directory, writes them all to a JSON file on every
+// change.
+type FileStore struct {
+	path string
+
+	mu sync.RWMutex
+	// versions holds each template's versions in ascending order.
+	versions map[key][]*contracts.Template
+	closed   bool
+}
+
+// Open opens or creates the store in dir. An empty dir keeps templates in
+// memory only.
+func Open(dir string) (*FileStore, error) {
+	s := &FileStore{
+		versions: make(map[key][]*contracts.Template),
+	}
+	if dir == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("template: failed to create directory: %w", err)
+	}
+	s.path = filepath.Join(dir, fileName)
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("template: failed to read %s: %w", s.path, err)
+	}
+	if len(data) > 0 {
+		var templates []*contracts.Template
+		if err := json.Unmarshal(data, &templates); err != nil {
+			return nil, fmt.Errorf("template: failed to parse %s: %w", s.path, err)
+		}
+		for _, t := range templates {
+			k := key{t.Name, t.Locale}
+			s.versions[k] = append(s.versions[k], t)
+		}
+		for _, versions := range s.versions {
+			sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+		}
+	}
+	return s, nil
+}
+
+// Save stores a new version of the template.
+func (s *FileStore) Save(ctx context.Context, template *contracts.Template) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("template: store closed")
+	}
+
+	k := key{template.Name, template.Locale}
+	prev := s.versions[k]
+	for _, t := range prev {
+		if t.Version == template.Version {
+			return fmt.Errorf("template: %s version %d already exists", template.Name, template.Version)
+		}
+	}
+
+	stored := *template
+	versions := append(prev[:len(prev):len(prev)], &stored)
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	s.versions[k] = versions
+
+	if err := s.write(); err != nil {
+		s.restore(k, prev)
+		return err
+	}
+	return nil
+}
+
+// Get returns a copy of the version, or of the latest version when version is zero.
+func (s *FileStore) Get(ctx context.Context, name, locale string, version int) (*contracts.Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions := s.versions[key{name, locale}]
+	if len(versions) == 0 {
+		return nil, port.ErrTemplateNotFound
+	}
+	if version == 0 {
+		latest := *versions[len(versions)-1]
+		return &latest, nil
+	}
+	for _, t := range versions {
+		if t.Version == version {
+			stored := *t
+			return &stored, nil
+		}
+	}
+	return nil, port.ErrTemplateNotFound
+}
+
+// List returns copies of every version, ordered by name, locale and version.
+func (s *FileStore) List(ctx context.Context) ([]*contracts.Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sorted(), nil
+}
+
+// Delete removes every version of the template in every locale.
+func (s *FileStore) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := make(map[key][]*contracts.Template)
+	for k, versions := range s.versions {
+		if k.name == name {
+			removed[k] = versions
+			delete(s.versions, k)
+		}
+	}
+	if len(removed) == 0 {
+		return port.ErrTemplateNotFound
+	}
+
+	if err := s.write(); err != nil {
+		for k, versions := range removed {
+			s.versions[k] = versions
+		}
+		return err
+	}
+	return nil
+}
+
+// Close stops accepting changes.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	return nil
+}
+
+func (s *FileStore) restore(k key, versions []*contracts.Template) {
+	if len(versions) == 0 {
+		delete(s.versions, k)
+		return
+	}
+	s.versions[k] = versions
+}
+
+func (s *FileStore) sorted() []*contracts.Template {
+	var templates []*contracts.Template
+	for _, versions := range s.versions {
+		for _, t := range versions {
+			stored := *t
+			templates = append(templates, &stored)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		a, b := templates[i], templates[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Locale != b.Locale {
+			return a.Locale < b.Locale
+		}
+		return a.Version < b.Version
+	})
+	if templates == nil {
+		templates = []*contracts.Template{}
+	}
+	return templates
+}
+
+// write replaces the file with the current templates via atomicfile.
+// It does nothing for an in-memory store.
+func (s *FileStore) write() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.sorted())
+	if err != nil {
+		return fmt.Errorf("template: failed to encode templates: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+)
+
+// Auth authenticates API requests with `Authorization: Bearer <key>`.
+type Auth struct {
+	keys *service.APIKeys
+}
+
+// NewAuth creates the API key middleware. Without configured keys, or with
+// a nil Auth, requests are not authenticated.
+func NewAuth(keys *service.APIKeys) *Auth {
+	return &Auth{keys: keys}
+}
+
+// Authenticate rejects requests without a valid API key with 401, and adds
+// the key to the request context for the handlers and services.
+func (a *Auth) Authenticate(next http.Handler) http.Handler {
+	if a == nil || !a.keys.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="message-gateway"`)
+			respondError(w, http.StatusUnauthorized, "missing API key")
+			return
+		}
+		key, err := a.keys.Authenticate(token)
+		if err != nil {
+			log.Printf("Rejected API key for %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="message-gateway", error="invalid_token"`)
+			respondError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(service.WithAPIKey(r.Context(), key)))
+	})
+}
+
+// RequireAdmin rejects requests whose API key lacks the admin scope with 403.
+func (a *Auth) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !service.APIKeyFrom(r.Context()).IsAdmin() {
+			respondError(w, http.StatusForbidden, service.ErrForbidden.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerToken returns the credentials of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+)
+
+func testKeys(t *testing.T) *service.APIKeys {
+	t.Helper()
+	keys, err := service.NewAPIKeys([]service.APIKey{
+		{ID: "app", Hash: service.HashAPIKey("app-key"), Scopes: []string{"sms"}},
+		{ID: "ops", Hash: service.HashAPIKey("ops-key"), Scopes: []string{"admin"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// keyID responds with the ID of the request's API key.
+var keyID = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if key := service.APIKeyFrom(r.Context()); key != nil {
+		_, _ = w.Write([]byte(key.ID))
+	}
+})
+
+func TestAuthAuthenticate(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          *service.APIKeys
+		authorization string
+		wantStatus    int
+		wantKey       string
+	}{
+		{"valid key", testKeys(t), "Bearer app-key", http.StatusOK, "app"},
+		{"scheme is case-insensitive", testKeys(t), "bearer ops-key", http.StatusOK, "ops"},
+		{"missing key", testKeys(t), "", http.StatusUnauthorized, ""},
+		{"unknown key", testKeys(t), "Bearer nope", http.StatusUnauthorized, ""},
+		{"basic auth", testKeys(t), "Basic YXBwOmFwcC1rZXk=", http.StatusUnauthorized, ""},
+		{"no keys configured", nil, "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/messages", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			NewAuth(tt.keys).Authenticate(keyID).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+			if tt.wantStatus == http.StatusOK && rec.Body.String() != tt.wantKey {
+				t.Errorf("key in context = %q, want %q", rec.Body.String(), tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestAuthRequireAdmin(t *testing.T) {
+	auth := NewAuth(testKeys(t))
+	handler := auth.Authenticate(auth.RequireAdmin(keyID))
+
+	tests := []struct {
+		name       string
+		key        string
+		wantStatus int
+	}{
+		{"admin key", "ops-key", http.StatusOK},
+		{"channel key", "app-key", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/templates", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
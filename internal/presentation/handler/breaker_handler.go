@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+)
+
+// BreakerHandler exposes provider circuit breaker state.
+type BreakerHandler struct {
+	breakers *resilience.Breakers
+}
+
+// NewBreakerHandler creates a new breaker handler. breakers may be nil when
+// circuit breaking is disabled.
+func NewBreakerHandler(breakers *resilience.Breakers) *BreakerHandler {
+	return &BreakerHandler{
+		breakers: breakers,
+	}
+}
+
+// HandleListBreakers handles GET /v1/breakers
+func (h *BreakerHandler) HandleListBreakers(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.statuses(r))
+}
+
+// HandleMetrics handles GET /metrics in the Prometheus text format.
+func (h *BreakerHandler) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	statuses := h.statuses(r)
+
+	var b strings.Builder
+	writeMetric := func(name, help, kind string, value func(resilience.BreakerStatus) uint64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, s := range statuses {
+			fmt.Fprintf(&b, "%s{channel=%q,provider=%q} %d\n", name, s.Channel, s.Provider, value(s))
+		}
+	}
+
+	writeMetric("gateway_circuit_breaker_state", "Circuit breaker state (0=closed, 1=open, 2=half-open).", "gauge",
+		func(s resilience.BreakerStatus) uint64 { return uint64(s.StateCode) })
+	writeMetric("gateway_circuit_breaker_successes_total", "Provider calls that completed without a provider failure.", "counter",
+		func(s resilience.BreakerStatus) uint64 { return s.Successes })
+	writeMetric("gateway_circuit_breaker_failures_total", "Provider calls that failed with an outage, rate limit or timeout.", "counter",
+		func(s resilience.BreakerStatus) uint64 { return s.Failures })
+	writeMetric("gateway_circuit_breaker_rejected_total", "Calls rejected without contacting the provider.", "counter",
+		func(s resilience.BreakerStatus) uint64 { return s.Rejected })
+	writeMetric("gateway_circuit_breaker_opens_total", "Times the breaker opened.", "counter",
+		func(s resilience.BreakerStatus) uint64 { return s.Opens })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// statuses returns the breakers visible to the request's tenant. A tenant's
+// breakers are named "tenant/provider".
+func (h *BreakerHandler) statuses(r *http.Request) []resilience.BreakerStatus {
+	statuses := []resilience.BreakerStatus{}
+	if h.breakers == nil {
+		return statuses
+	}
+	for _, s := range h.breakers.Statuses() {
+		tenant, _, ok := strings.Cut(s.Provider, "/")
+		if !ok {
+			tenant = ""
+		}
+		if service.VisibleTo(r.Context(), tenant) {
+			statuses = append(statuses, s)
+		}
+	}
+	return statuses
+}
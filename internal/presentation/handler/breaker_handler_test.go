@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+)
+
+func TestBreakerHandlerTenants(t *testing.T) {
+	breakers := resilience.NewBreakers(resilience.BreakerSettings{})
+	breakers.Get("sms", "twilio")
+	breakers.Get("sms", "shop/twilio")
+	breakers.Get("email", "acme/ses")
+	h := NewBreakerHandler(breakers)
+
+	tests := []struct {
+		name   string
+		tenant string
+		want   []string
+	}{
+		{name: "without a tenant", want: []string{"email/acme/ses", "sms/shop/twilio", "sms/twilio"}},
+		{name: "tenant", tenant: "shop", want: []string{"sms/shop/twilio"}},
+		{name: "tenant without breakers", tenant: "other", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := service.WithTenant(context.Background(), tt.tenant)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/breakers", nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+			h.HandleListBreakers(rec, req)
+
+			var statuses []resilience.BreakerStatus
+			if err := json.NewDecoder(rec.Body).Decode(&statuses); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(statuses))
+			for i, s := range statuses {
+				got[i] = s.Channel + "/" + s.Provider
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("breakers = %v, want %v", got, tt.want)
+			}
+
+			req = httptest.NewRequest(http.MethodGet, "/metrics", nil).WithContext(ctx)
+			rec = httptest.NewRecorder()
+			h.HandleMetrics(rec, req)
+
+			states := strings.Count(rec.Body.String(), "gateway_circuit_breaker_state{")
+			if states != len(tt.want) {
+				t.Errorf("metrics list %d breakers, want %d", states, len(tt.want))
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
 	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/memory"
 	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
 )
@@ -17,7 +19,7 @@ type DevBoxHandler struct {
 	store       *memory.Store
 	mailpitCfg  memory.MailpitConfig
 	mu          sync.RWMutex // Protects subscribers map
-	subscribers map[chan []byte]bool
+	subscribers map[chan []byte]context.Context
 }
 
 // NewDevBoxHandler creates a new devbox handler.
@@ -25,19 +27,34 @@ func NewDevBoxHandler(store *memory.Store, mailpitCfg memory.MailpitConfig) *Dev
 	return &DevBoxHandler{
 		store:       store,
 		mailpitCfg:  mailpitCfg,
-		subscribers: make(map[chan []byte]bool),
+		subscribers: make(map[chan []byte]context.Context),
 	}
 }
 
-// HandleStats returns message counts by type.
+// HandleStats returns message counts by type, of the request's tenant if it
+// has one.
 func (h *DevBoxHandler) HandleStats(w http.ResponseWriter, r *http.Request) {
-	stats := h.store.Stats()
-	respondJSON(w, http.StatusOK, stats)
+	if service.TenantFrom(r.Context()) == "" {
+		respondJSON(w, http.StatusOK, h.store.Stats())
+		return
+	}
+
+	emails := len(ofTenant(r, h.store.Emails(), func(m *memory.StoredEmail) string { return m.Tenant }))
+	sms := len(ofTenant(r, h.store.AllSMS(), func(m *memory.StoredSMS) string { return m.Tenant }))
+	pushes := len(ofTenant(r, h.store.Pushes(), func(m *memory.StoredPush) string { return m.Tenant }))
+	chats := len(ofTenant(r, h.store.Chats(), func(m *memory.StoredChat) string { return m.Tenant }))
+	respondJSON(w, http.StatusOK, map[string]int{
+		"emails": emails,
+		"sms":    sms,
+		"push":   pushes,
+		"chat":   chats,
+		"total":  emails + sms + pushes + chats,
+	})
 }
 
-// HandleGetEmails returns all stored emails.
+// HandleGetEmails returns all stored emails, or the request's tenant's.
 func (h *DevBoxHandler) HandleGetEmails(w http.ResponseWriter, r *http.Request) {
-	emails := h.store.Emails()
+	emails := ofTenant(r, h.store.Emails(), func(m *memory.StoredEmail) string { return m.Tenant })
 	respondJSON(w, http.StatusOK, emails)
 }
 
@@ -45,7 +62,7 @@ func (h *DevBoxHandler) HandleGetEmails(w http.ResponseWriter, r *http.Request)
 func (h *DevBoxHandler) HandleGetEmailByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	email := h.store.EmailByID(id)
-	if email == nil {
+	if email == nil || !service.VisibleTo(r.Context(), email.Tenant) {
 		respondError(w, http.StatusNotFound, "email not found")
 		return
 	}
@@ -55,17 +72,18 @@ func (h *DevBoxHandler) HandleGetEmailByID(w http.ResponseWriter, r *http.Reques
 // HandleDeleteEmailByID deletes a single email by ID.
 func (h *DevBoxHandler) HandleDeleteEmailByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if !h.store.DeleteEmailByID(id) {
+	m := h.store.EmailByID(id)
+	if m == nil || !service.VisibleTo(r.Context(), m.Tenant) || !h.store.DeleteEmailByID(id) {
 		respondError(w, http.StatusNotFound, "email not found")
 		return
 	}
-	h.broadcast("email_deleted", id)
+	h.broadcast(m.Tenant, "email_deleted", id)
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// HandleGetSMS returns all stored SMS messages.
+// HandleGetSMS returns all stored SMS messages, or the request's tenant's.
 func (h *DevBoxHandler) HandleGetSMS(w http.ResponseWriter, r *http.Request) {
-	sms := h.store.AllSMS()
+	sms := ofTenant(r, h.store.AllSMS(), func(m *memory.StoredSMS) string { return m.Tenant })
 	respondJSON(w, http.StatusOK, sms)
 }
 
@@ -73,7 +91,7 @@ func (h *DevBoxHandler) HandleGetSMS(w http.ResponseWriter, r *http.Request) {
 func (h *DevBoxHandler) HandleGetSMSByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	sms := h.store.SMSByID(id)
-	if sms == nil {
+	if sms == nil || !service.VisibleTo(r.Context(), sms.Tenant) {
 		respondError(w, http.StatusNotFound, "sms not found")
 		return
 	}
@@ -83,17 +101,19 @@ func (h *DevBoxHandler) HandleGetSMSByID(w http.ResponseWriter, r *http.Request)
 // HandleDeleteSMSByID deletes a single SMS by ID.
 func (h *DevBoxHandler) HandleDeleteSMSByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if !h.store.DeleteSMSByID(id) {
+	m := h.store.SMSByID(id)
+	if m == nil || !service.VisibleTo(r.Context(), m.Tenant) || !h.store.DeleteSMSByID(id) {
 		respondError(w, http.StatusNotFound, "sms not found")
 		return
 	}
-	h.broadcast("sms_deleted", id)
+	h.broadcast(m.Tenant, "sms_deleted", id)
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// HandleGetPush returns all stored push notifications.
+// HandleGetPush returns all stored push notifications, or the request's
+// tenant's.
 func (h *DevBoxHandler) HandleGetPush(w http.ResponseWriter, r *http.Request) {
-	pushes := h.store.Pushes()
+	pushes := ofTenant(r, h.store.Pushes(), func(m *memory.StoredPush) string { return m.Tenant })
 	respondJSON(w, http.StatusOK, pushes)
 }
 
@@ -101,7 +121,7 @@ func (h *DevBoxHandler) HandleGetPush(w http.ResponseWriter, r *http.Request) {
 func (h *DevBoxHandler) HandleGetPushByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	push := h.store.PushByID(id)
-	if push == nil {
+	if push == nil || !service.VisibleTo(r.Context(), push.Tenant) {
 		respondError(w, http.StatusNotFound, "push notification not found")
 		return
 	}
@@ -111,17 +131,19 @@ func (h *DevBoxHandler) HandleGetPushByID(w http.ResponseWriter, r *http.Request
 // HandleDeletePushByID deletes a single push notification by ID.
 func (h *DevBoxHandler) HandleDeletePushByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if !h.store.DeletePushByID(id) {
+	m := h.store.PushByID(id)
+	if m == nil || !service.VisibleTo(r.Context(), m.Tenant) || !h.store.DeletePushByID(id) {
 		respondError(w, http.StatusNotFound, "push notification not found")
 		return
 	}
-	h.broadcast("push_deleted", id)
+	h.broadcast(m.Tenant, "push_deleted", id)
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// HandleGetChat returns all stored chat messages.
+// HandleGetChat returns all stored chat messages, or the request's
+// tenant's.
 func (h *DevBoxHandler) HandleGetChat(w http.ResponseWriter, r *http.Request) {
-	chats := h.store.Chats()
+	chats := ofTenant(r, h.store.Chats(), func(m *memory.StoredChat) string { return m.Tenant })
 	respondJSON(w, http.StatusOK, chats)
 }
 
@@ -129,7 +151,7 @@ func (h *DevBoxHandler) HandleGetChat(w http.ResponseWriter, r *http.Request) {
 func (h *DevBoxHandler) HandleGetChatByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	chat := h.store.ChatByID(id)
-	if chat == nil {
+	if chat == nil || !service.VisibleTo(r.Context(), chat.Tenant) {
 		respondError(w, http.StatusNotFound, "chat message not found")
 		return
 	}
@@ -139,18 +161,37 @@ func (h *DevBoxHandler) HandleGetChatByID(w http.ResponseWriter, r *http.Request
 // HandleDeleteChatByID deletes a single chat message by ID.
 func (h *DevBoxHandler) HandleDeleteChatByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if !h.store.DeleteChatByID(id) {
+	m := h.store.ChatByID(id)
+	if m == nil || !service.VisibleTo(r.Context(), m.Tenant) || !h.store.DeleteChatByID(id) {
 		respondError(w, http.StatusNotFound, "chat message not found")
 		return
 	}
-	h.broadcast("chat_deleted", id)
+	h.broadcast(m.Tenant, "chat_deleted", id)
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// HandleClearAll removes all stored messages.
+// HandleClearAll removes all stored messages, or only the request's tenant's
+// if it has one.
 func (h *DevBoxHandler) HandleClearAll(w http.ResponseWriter, r *http.Request) {
-	h.store.Clear()
-	h.broadcast("messages_cleared", nil)
+	tenant := service.TenantFrom(r.Context())
+	if tenant == "" {
+		h.store.Clear()
+		h.publish("messages_cleared", nil, func(context.Context) bool { return true })
+	} else {
+		for _, m := range ofTenant(r, h.store.Emails(), func(m *memory.StoredEmail) string { return m.Tenant }) {
+			h.store.DeleteEmailByID(m.ID)
+		}
+		for _, m := range ofTenant(r, h.store.AllSMS(), func(m *memory.StoredSMS) string { return m.Tenant }) {
+			h.store.DeleteSMSByID(m.ID)
+		}
+		for _, m := range ofTenant(r, h.store.Pushes(), func(m *memory.StoredPush) string { return m.Tenant }) {
+			h.store.DeletePushByID(m.ID)
+		}
+		for _, m := range ofTenant(r, h.store.Chats(), func(m *memory.StoredChat) string { return m.Tenant }) {
+			h.store.DeleteChatByID(m.ID)
+		}
+		h.broadcast(tenant, "messages_cleared", nil)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -162,14 +203,15 @@ func (h *DevBoxHandler) HandleIngestEmail(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	emailProvider := memory.NewEmailProvider(h.store, h.mailpitCfg)
+	tenant := service.TenantFrom(r.Context())
+	emailProvider := memory.NewEmailProvider(h.store, h.mailpitCfg, tenant)
 	result, err := emailProvider.Send(r.Context(), &email)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to store email: "+err.Error())
 		return
 	}
 
-	h.broadcast("email_received", map[string]string{"id": result.ID})
+	h.broadcast(tenant, "email_received", map[string]string{"id": result.ID})
 	respondJSON(w, http.StatusCreated, map[string]string{"id": result.ID})
 }
 
@@ -181,14 +223,15 @@ func (h *DevBoxHandler) HandleIngestSMS(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	smsProvider := memory.NewSMSProvider(h.store)
+	tenant := service.TenantFrom(r.Context())
+	smsProvider := memory.NewSMSProvider(h.store, tenant)
 	result, err := smsProvider.Send(r.Context(), &sms)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to store sms: "+err.Error())
 		return
 	}
 
-	h.broadcast("sms_received", map[string]string{"id": result.ID})
+	h.broadcast(tenant, "sms_received", map[string]string{"id": result.ID})
 	respondJSON(w, http.StatusCreated, map[string]string{"id": result.ID})
 }
 
@@ -200,14 +243,15 @@ func (h *DevBoxHandler) HandleIngestPush(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	pushProvider := memory.NewPushProvider(h.store)
+	tenant := service.TenantFrom(r.Context())
+	pushProvider := memory.NewPushProvider(h.store, tenant)
 	result, err := pushProvider.Send(r.Context(), &push)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to store push: "+err.Error())
 		return
 	}
 
-	h.broadcast("push_received", map[string]string{"id": result.ID})
+	h.broadcast(tenant, "push_received", map[string]string{"id": result.ID})
 	respondJSON(w, http.StatusCreated, map[string]string{"id": result.ID})
 }
 
@@ -219,14 +263,15 @@ func (h *DevBoxHandler) HandleIngestChat(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	chatProvider := memory.NewChatProvider(h.store)
+	tenant := service.TenantFrom(r.Context())
+	chatProvider := memory.NewChatProvider(h.store, tenant)
 	result, err := chatProvider.Send(r.Context(), &chat)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to store chat: "+err.Error())
 		return
 	}
 
-	h.broadcast("chat_received", map[string]string{"id": result.ID})
+	h.broadcast(tenant, "chat_received", map[string]string{"id": result.ID})
 	respondJSON(w, http.StatusCreated, map[string]string{"id": result.ID})
 }
 
@@ -235,10 +280,9 @@ func (h *DevBoxHandler) HandleSSE(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	events := make(chan []byte, 10)
-	h.addSubscriber(events)
+	h.addSubscriber(events, r.Context())
 	defer h.removeSubscriber(events)
 
 	flusher, ok := w.(http.Flusher)
@@ -264,10 +308,25 @@ func (h *DevBoxHandler) HandleSSE(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *DevBoxHandler) addSubscriber(ch chan []byte) {
+// ofTenant keeps the messages visible to the request's tenant.
+func ofTenant[T any](r *http.Request, messages []T, tenant func(T) string) []T {
+	if service.TenantFrom(r.Context()) == "" {
+		return messages
+	}
+	kept := make([]T, 0, len(messages))
+	for _, m := range messages {
+		if service.VisibleTo(r.Context(), tenant(m)) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// addSubscriber adds an SSE stream, with the context of its request.
+func (h *DevBoxHandler) addSubscriber(ch chan []byte, ctx context.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.subscribers[ch] = true
+	h.subscribers[ch] = ctx
 }
 
 func (h *DevBoxHandler) removeSubscriber(ch chan []byte) {
@@ -277,7 +336,15 @@ func (h *DevBoxHandler) removeSubscriber(ch chan []byte) {
 	close(ch)
 }
 
-func (h *DevBoxHandler) broadcast(eventType string, data interface{}) {
+// broadcast sends an event about tenant owner's messages to the
+// subscribers that may see them.
+func (h *DevBoxHandler) broadcast(owner, eventType string, data interface{}) {
+	h.publish(eventType, data, func(ctx context.Context) bool { return service.VisibleTo(ctx, owner) })
+}
+
+// publish sends an event to the subscribers whose request context passes
+// visible.
+func (h *DevBoxHandler) publish(eventType string, data interface{}, visible func(ctx context.Context) bool) {
 	event := map[string]interface{}{
 		"type": eventType,
 		"data": data,
@@ -291,7 +358,10 @@ func (h *DevBoxHandler) broadcast(eventType string, data interface{}) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	for ch := range h.subscribers {
+	for ch, ctx := range h.subscribers {
+		if !visible(ctx) {
+			continue
+		}
 		select {
 		case ch <- eventJSON:
 		default:
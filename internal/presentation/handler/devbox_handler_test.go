@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/memory"
+)
+
+func TestDevBoxHandlerIngestTenant(t *testing.T) {
+	store := memory.NewStore()
+	h := NewDevBoxHandler(store, memory.MailpitConfig{})
+
+	ingest := []struct {
+		name   string
+		handle http.HandlerFunc
+		body   string
+		tenant func(id string) string
+	}{
+		{"email", h.HandleIngestEmail, `{"to":["a@example.com"],"subject":"Hi"}`, func(id string) string { return store.EmailByID(id).Tenant }},
+		{"sms", h.HandleIngestSMS, `{"to":["+15550100"],"message":"Hi"}`, func(id string) string { return store.SMSByID(id).Tenant }},
+		{"push", h.HandleIngestPush, `{"device_tokens":["t"],"title":"Hi"}`, func(id string) string { return store.PushByID(id).Tenant }},
+		{"chat", h.HandleIngestChat, `{"message":"Hi"}`, func(id string) string { return store.ChatByID(id).Tenant }},
+	}
+	for _, in := range ingest {
+		for _, tenant := range []string{"", "shop"} {
+			t.Run(in.name+"/"+tenant, func(t *testing.T) {
+				ctx := service.WithTenant(context.Background(), tenant)
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(in.body)).WithContext(ctx)
+				rec := httptest.NewRecorder()
+				in.handle(rec, req)
+
+				if rec.Code != http.StatusCreated {
+					t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+				}
+				var created map[string]string
+				if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+					t.Fatal(err)
+				}
+				if got := in.tenant(created["id"]); got != tenant {
+					t.Errorf("stored tenant = %q, want %q", got, tenant)
+				}
+			})
+		}
+	}
+}
+
+func TestDevBoxHandlerEventTenants(t *testing.T) {
+	h := NewDevBoxHandler(memory.NewStore(), memory.MailpitConfig{})
+	streams := map[string]chan []byte{"": make(chan []byte, 10), "shop": make(chan []byte, 10), "acme": make(chan []byte, 10)}
+	for tenant, ch := range streams {
+		h.addSubscriber(ch, service.WithTenant(context.Background(), tenant))
+	}
+
+	steps := []struct {
+		name   string
+		tenant string
+		method string
+		handle http.HandlerFunc
+		body   string
+		// want are the streams receiving the event.
+		want []string
+	}{
+		{"shop ingests", "shop", http.MethodPost, h.HandleIngestEmail, `{"to":["a@example.com"],"subject":"Hi"}`, []string{"", "shop"}},
+		{"global ingests", "", http.MethodPost, h.HandleIngestEmail, `{"to":["a@example.com"],"subject":"Hi"}`, []string{""}},
+		{"acme clears its own", "acme", http.MethodDelete, h.HandleClearAll, "", []string{"", "acme"}},
+		{"admin clears every tenant's", "", http.MethodDelete, h.HandleClearAll, "", []string{"", "shop", "acme"}},
+	}
+	for _, step := range steps {
+		ctx := service.WithTenant(context.Background(), step.tenant)
+		req := httptest.NewRequest(step.method, "/", strings.NewReader(step.body)).WithContext(ctx)
+		step.handle(httptest.NewRecorder(), req)
+
+		for tenant, ch := range streams {
+			want := false
+			for _, w := range step.want {
+				want = want || w == tenant
+			}
+			select {
+			case <-ch:
+				if !want {
+					t.Errorf("%s: stream of tenant %q got the event", step.name, tenant)
+				}
+			default:
+				if want {
+					t.Errorf("%s: stream of tenant %q did not get the event", step.name, tenant)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+)
+
+// EventHandler handles the undelivered message event endpoints.
+type EventHandler struct {
+	events *service.EventDispatcher
+}
+
+// NewEventHandler creates a new event handler.
+func NewEventHandler(events *service.EventDispatcher) *EventHandler {
+	return &EventHandler{
+		events: events,
+	}
+}
+
+// replayResponse reports the outcome of POST /v1/events/dead-letters/replay.
+type replayResponse struct {
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
+// HandleListDeadLetters handles GET /v1/events/dead-letters with an optional
+// subscriber filter.
+func (h *EventHandler) HandleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	letters, err := h.events.DeadLetters(r.Context(), r.URL.Query().Get("subscriber"))
+	if err != nil {
+		log.Printf("List dead letters error: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to list: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, letters)
+}
+
+// HandleReplay handles POST /v1/events/dead-letters/{id}/replay
+func (h *EventHandler) HandleReplay(w http.ResponseWriter, r *http.Request) {
+	if err := h.events.Replay(r.Context(), chi.URLParam(r, "id")); err != nil {
+		respondEventError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, replayResponse{Replayed: 1})
+}
+
+// HandleReplayAll handles POST /v1/events/dead-letters/replay with an
+// optional subscriber filter.
+func (h *EventHandler) HandleReplayAll(w http.ResponseWriter, r *http.Request) {
+	replayed, failed, err := h.events.ReplayAll(r.Context(), r.URL.Query().Get("subscriber"))
+	if err != nil {
+		respondEventError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, replayResponse{Replayed: replayed, Failed: failed})
+}
+
+// HandleDiscard handles DELETE /v1/events/dead-letters/{id}
+func (h *EventHandler) HandleDiscard(w http.ResponseWriter, r *http.Request) {
+	if err := h.events.Discard(r.Context(), chi.URLParam(r, "id")); err != nil {
+		respondEventError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondEventError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, port.ErrDeadLetterNotFound):
+		respondError(w, http.StatusNotFound, "dead letter not found")
+	case errors.Is(err, service.ErrSubscriberNotFound):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrEventDeliveryFailed):
+		respondError(w, http.StatusBadGateway, err.Error())
+	default:
+		log.Printf("Dead letter error: %v", err)
+		http.Error(w, fmt.Sprintf("Failed: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -1,24 +1,54 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
 	"github.com/weprodev/wpd-message-gateway/internal/core/service"
 	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
 )
 
+// HeaderIdempotencyKey makes a send safe to retry: requests repeating a key
+// get the first request's response instead of sending again.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// HeaderIdempotentReplayed is set to "true" on responses replayed for a repeated key.
+const HeaderIdempotentReplayed = "Idempotent-Replayed"
+
 // GatewayHandler handles message sending API endpoints.
 type GatewayHandler struct {
-	service *service.GatewayService
+	service     *service.GatewayService
+	async       *service.AsyncSender
+	scheduler   *service.Scheduler
+	idempotency *service.Idempotency
+	templates   *service.TemplateService
 }
 
 // NewGatewayHandler creates a new gateway handler.
-func NewGatewayHandler(svc *service.GatewayService) *GatewayHandler {
+// async and scheduler may be nil, in which case ?async=true and scheduled
+// requests are rejected. idempotency may be nil to ignore Idempotency-Key.
+func NewGatewayHandler(
+	svc *service.GatewayService,
+	async *service.AsyncSender,
+	scheduler *service.Scheduler,
+	idempotency *service.Idempotency,
+	templates *service.TemplateService,
+) *GatewayHandler {
 	return &GatewayHandler{
-		service: svc,
+		service:     svc,
+		async:       async,
+		scheduler:   scheduler,
+		idempotency: idempotency,
+		templates:   templates,
 	}
 }
 
@@ -30,14 +60,9 @@ func (h *GatewayHandler) HandleSendEmail(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	result, err := h.service.SendEmail(r.Context(), &req)
-	if err != nil {
-		log.Printf("Send email error: %v", err)
-		http.Error(w, fmt.Sprintf("Failed to send: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	respondJSON(w, http.StatusOK, result)
+	h.send(w, r, "email", &req, &req.Schedule, func(ctx context.Context) (*contracts.SendResult, error) {
+		return h.service.SendEmail(ctx, &req)
+	})
 }
 
 // HandleSendSMS handles POST /v1/sms
@@ -48,14 +73,9 @@ func (h *GatewayHandler) HandleSendSMS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.service.SendSMS(r.Context(), &req)
-	if err != nil {
-		log.Printf("Send SMS error: %v", err)
-		http.Error(w, fmt.Sprintf("Failed to send: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	respondJSON(w, http.StatusOK, result)
+	h.send(w, r, "sms", &req, &req.Schedule, func(ctx context.Context) (*contracts.SendResult, error) {
+		return h.service.SendSMS(ctx, &req)
+	})
 }
 
 // HandleSendPush handles POST /v1/push
@@ -66,14 +86,9 @@ func (h *GatewayHandler) HandleSendPush(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	result, err := h.service.SendPush(r.Context(), &req)
-	if err != nil {
-		log.Printf("Send push error: %v", err)
-		http.Error(w, fmt.Sprintf("Failed to send: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	respondJSON(w, http.StatusOK, result)
+	h.send(w, r, "push", &req, &req.Schedule, func(ctx context.Context) (*contracts.SendResult, error) {
+		return h.service.SendPush(ctx, &req)
+	})
 }
 
 // HandleSendChat handles POST /v1/chat
@@ -84,14 +99,151 @@ func (h *GatewayHandler) HandleSendChat(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	result, err := h.service.SendChat(r.Context(), &req)
+	h.send(w, r, "chat", &req, &req.Schedule, func(ctx context.Context) (*contracts.SendResult, error) {
+		return h.service.SendChat(ctx, &req)
+	})
+}
+
+// idempotentRequest is what an Idempotency-Key is bound to.
+type idempotentRequest struct {
+	APIKey  string `json:"api_key,omitempty"`
+	Tenant  string `json:"tenant,omitempty"`
+	Channel string `json:"channel"`
+	Async   bool   `json:"async"`
+	Message any    `json:"message"`
+}
+
+// send sends the message now, or queues it when the request has ?async=true
+// or the message is scheduled, and responds with the result: 200 for a send,
+// 202 with the job ID for a queued or scheduled message.
+func (h *GatewayHandler) send(
+	w http.ResponseWriter,
+	r *http.Request,
+	channel string,
+	message any,
+	schedule *contracts.Schedule,
+	sendNow func(context.Context) (*contracts.SendResult, error),
+) {
+	sendAt, err := schedule.SendTime(time.Now())
 	if err != nil {
-		log.Printf("Send chat error: %v", err)
-		http.Error(w, fmt.Sprintf("Failed to send: %v", err), http.StatusInternalServerError)
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	async, _ := strconv.ParseBool(r.URL.Query().Get("async"))
+	key := service.APIKeyFrom(r.Context())
+	if !key.CanSend(channel) {
+		respondError(w, http.StatusForbidden, service.ErrForbidden.Error()+": "+channel)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, result)
+	status := http.StatusOK
+	switch {
+	case !sendAt.IsZero():
+		if h.scheduler == nil {
+			respondError(w, http.StatusBadRequest, service.ErrSchedulingDisabled.Error())
+			return
+		}
+		status = http.StatusAccepted
+	case async:
+		if h.async == nil {
+			respondError(w, http.StatusBadRequest, service.ErrAsyncDisabled.Error())
+			return
+		}
+		status = http.StatusAccepted
+	}
+
+	// The request is hashed as sent, with its schedule and before its template
+	// is rendered; the schedule is spent once the job is stored.
+	request := idempotentRequest{Tenant: service.TenantFrom(r.Context()), Channel: channel, Async: async, Message: message}
+	if key != nil {
+		request.APIKey = key.ID
+	}
+	result, replayed, err := h.idempotency.Do(r.Context(), r.Header.Get(HeaderIdempotencyKey), request,
+		func(ctx context.Context) (*contracts.SendResult, error) {
+			// Queued and scheduled messages are rendered now, so they are
+			// sent with the template version current when they were accepted.
+			if err := h.templates.Render(ctx, message); err != nil {
+				return nil, err
+			}
+			*schedule = contracts.Schedule{}
+			switch {
+			case !sendAt.IsZero():
+				return h.schedule(ctx, channel, message, sendAt)
+			case async:
+				return h.enqueue(ctx, channel, message)
+			default:
+				return sendNow(ctx)
+			}
+		})
+
+	switch {
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, service.ErrIdempotencyKeyInFlight):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrIdempotencyKeyTooLong):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrForbidden):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrUnknownTenant), errors.Is(err, service.ErrInvalidMessage):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, port.ErrTemplateNotFound), errors.Is(err, service.ErrTemplateRender):
+		respondTemplateError(w, err)
+	case errors.Is(err, pkgerrors.ErrRateLimited):
+		respondRetryLater(w, http.StatusTooManyRequests, err)
+	case errors.Is(err, pkgerrors.ErrUnavailable):
+		log.Printf("Send %s error: %v", channel, err)
+		respondRetryLater(w, http.StatusServiceUnavailable, err)
+	case err != nil:
+		log.Printf("Send %s error: %v", channel, err)
+		http.Error(w, fmt.Sprintf("Failed to send: %v", err), http.StatusInternalServerError)
+	default:
+		if replayed {
+			w.Header().Set(HeaderIdempotentReplayed, "true")
+		}
+		respondJSON(w, status, result)
+	}
+}
+
+func (h *GatewayHandler) enqueue(ctx context.Context, channel string, message any) (*contracts.SendResult, error) {
+	id, err := h.async.Enqueue(ctx, channel, message)
+	if err != nil {
+		return nil, err
+	}
+	return &contracts.SendResult{
+		ID:         id,
+		StatusCode: http.StatusAccepted,
+		Message:    "Message queued",
+	}, nil
+}
+
+func (h *GatewayHandler) schedule(ctx context.Context, channel string, message any, sendAt time.Time) (*contracts.SendResult, error) {
+	job, err := h.scheduler.Schedule(ctx, channel, message, sendAt)
+	if err != nil {
+		return nil, err
+	}
+	return &contracts.SendResult{
+		ID:         job.ID,
+		StatusCode: http.StatusAccepted,
+		Message:    "Message scheduled",
+		Meta:       map[string]string{"send_at": job.SendAt.Format(time.RFC3339)},
+	}, nil
+}
+
+// defaultRetryAfter is the Retry-After of a send that failed for now when
+// the provider gave no delay.
+const defaultRetryAfter = 5 * time.Second
+
+// respondRetryLater answers a send that may succeed later with status and
+// a Retry-After header in whole seconds.
+func respondRetryLater(w http.ResponseWriter, status int, err error) {
+	retryAfter := defaultRetryAfter
+	var perr *pkgerrors.ProviderError
+	if errors.As(err, &perr) && perr.RetryAfter > 0 {
+		retryAfter = perr.RetryAfter
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	respondError(w, status, err.Error())
 }
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/idempotency"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+	pkgerrors "github.com/weprodev/wpd-message-gateway/pkg/errors"
+)
+
+// smsConfig is a GatewayConfig sending SMS with the "test" provider.
+type smsConfig struct{}
+
+func (smsConfig) DefaultEmailProvider() string { return "" }
+func (smsConfig) DefaultSMSProvider() string   { return "test" }
+func (smsConfig) DefaultPushProvider() string  { return "" }
+func (smsConfig) DefaultChatProvider() string  { return "" }
+func (smsConfig) EmailFailover() []string      { return nil }
+func (smsConfig) SMSFailover() []string        { return nil }
+func (smsConfig) PushFailover() []string       { return nil }
+func (smsConfig) ChatFailover() []string       { return nil }
+func (smsConfig) RetryPolicies() resilience.RetryPolicies {
+	return resilience.RetryPolicies{}
+}
+
+// testSMSSender fails with errs in turn, then succeeds. When release is
+// set, a send closes started and waits for release.
+type testSMSSender struct {
+	mu      sync.Mutex
+	errs    []error
+	sends   int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *testSMSSender) Name() string { return "test" }
+
+func (s *testSMSSender) Send(ctx context.Context, sms *contracts.SMS) (*contracts.SendResult, error) {
+	if s.release != nil {
+		close(s.started)
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sends++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return nil, err
+	}
+	return &contracts.SendResult{ID: "sms-1", StatusCode: http.StatusOK}, nil
+}
+
+func (s *testSMSSender) Sends() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sends
+}
+
+func newIdempotentHandler(sender *testSMSSender) *GatewayHandler {
+	registry := service.NewRegistry()
+	registry.RegisterSMSProvider("test", sender)
+	svc := service.NewGatewayService(smsConfig{}, registry, nil)
+	return NewGatewayHandler(svc, nil, nil, service.NewIdempotency(idempotency.NewMemoryStore(), time.Hour), nil)
+}
+
+func postSMS(h *GatewayHandler, body, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/sms", strings.NewReader(body))
+	req.Header.Set(HeaderIdempotencyKey, key)
+	rec := httptest.NewRecorder()
+	h.HandleSendSMS(rec, req)
+	return rec
+}
+
+func TestGatewayHandlerIdempotency(t *testing.T) {
+	const (
+		hello = `{"to": ["+15550100"], "message": "hello"}`
+		bye   = `{"to": ["+15550100"], "message": "bye"}`
+	)
+
+	tests := []struct {
+		name         string
+		errs         []error
+		first        string
+		second       string
+		wantStatus   int
+		wantReplayed bool
+		wantSends    int
+	}{
+		{name: "same body is replayed", first: hello, second: hello, wantStatus: http.StatusOK, wantReplayed: true, wantSends: 1},
+		{name: "different body", first: hello, second: bye, wantStatus: http.StatusUnprocessableEntity, wantSends: 1},
+		{name: "failed send is retried", errs: []error{errors.New("provider down")}, first: hello, second: hello, wantStatus: http.StatusOK, wantSends: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &testSMSSender{errs: tt.errs}
+			h := newIdempotentHandler(sender)
+
+			postSMS(h, tt.first, "order-1")
+			rec := postSMS(h, tt.second, "order-1")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get(HeaderIdempotentReplayed) == "true"; got != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", got, tt.wantReplayed)
+			}
+			if got := sender.Sends(); got != tt.wantSends {
+				t.Errorf("sends = %d, want %d", got, tt.wantSends)
+			}
+		})
+	}
+}
+
+func TestGatewayHandlerProviderErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{
+			name:           "rate limited",
+			err:            &pkgerrors.ProviderError{Provider: "test", Message: "failed", Kind: pkgerrors.ErrRateLimited},
+			wantStatus:     http.StatusTooManyRequests,
+			wantRetryAfter: "5",
+		},
+		{
+			name:           "unavailable",
+			err:            &pkgerrors.ProviderError{Provider: "test", Message: "failed", Kind: pkgerrors.ErrUnavailable},
+			wantStatus:     http.StatusServiceUnavailable,
+			wantRetryAfter: "5",
+		},
+		{
+			name:           "circuit open",
+			err:            &pkgerrors.ProviderError{Provider: "test", Message: "skipped", Kind: pkgerrors.ErrUnavailable, RetryAfter: 12300 * time.Millisecond, Err: resilience.ErrCircuitOpen},
+			wantStatus:     http.StatusServiceUnavailable,
+			wantRetryAfter: "13",
+		},
+		{
+			name:       "unclassified",
+			err:        errors.New("provider down"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newIdempotentHandler(&testSMSSender{errs: []error{tt.err}})
+
+			rec := postSMS(h, `{"to": ["+15550100"], "message": "hello"}`, "")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestGatewayHandlerIdempotencyInFlight(t *testing.T) {
+	sender := &testSMSSender{started: make(chan struct{}), release: make(chan struct{})}
+	h := newIdempotentHandler(sender)
+	body := `{"to": ["+15550100"], "message": "hello"}`
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- postSMS(h, body, "order-1") }()
+	<-sender.started
+
+	rec := postSMS(h, body, "order-1")
+	close(sender.release)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if rec := <-first; rec.Code != http.StatusOK {
+		t.Errorf("first request status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := sender.Sends(); got != 1 {
+		t.Errorf("sends = %d, want 1", got)
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// maxListLimit caps the limit query parameter of GET /v1/messages.
+const maxListLimit = 500
+
+// MessageHandler handles message ledger lookups.
+type MessageHandler struct {
+	service *service.GatewayService
+}
+
+// NewMessageHandler creates a new message handler.
+func NewMessageHandler(svc *service.GatewayService) *MessageHandler {
+	return &MessageHandler{
+		service: svc,
+	}
+}
+
+// HandleGetMessage handles GET /v1/messages/{id}. The ID may be the
+// gateway's message ID or the provider's.
+func (h *MessageHandler) HandleGetMessage(w http.ResponseWriter, r *http.Request) {
+	record, err := h.service.Message(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, port.ErrMessageNotFound) {
+		respondError(w, http.StatusNotFound, "message not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Get message error: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to get message: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, record)
+}
+
+// HandleListMessages handles GET /v1/messages with the optional filters
+// channel, provider, status, recipient, api_key, tenant, since, until
+// (RFC 3339) and limit.
+func (h *MessageHandler) HandleListMessages(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseMessageFilter(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	records, err := h.service.Messages(r.Context(), filter)
+	if err != nil {
+		log.Printf("List messages error: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to list messages: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, records)
+}
+
+func parseMessageFilter(r *http.Request) (port.MessageFilter, error) {
+	q := r.URL.Query()
+	filter := port.MessageFilter{
+		Channel:   q.Get("channel"),
+		Provider:  q.Get("provider"),
+		Status:    contracts.MessageStatus(q.Get("status")),
+		Recipient: q.Get("recipient"),
+		APIKey:    q.Get("api_key"),
+		Tenant:    q.Get("tenant"),
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s: expected RFC 3339 time", p.name)
+			}
+			*p.dst = t
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = min(limit, maxListLimit)
+	}
+	return filter, nil
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+)
+
+// QueueHandler handles the failed queued message endpoints.
+type QueueHandler struct {
+	sender *service.AsyncSender
+}
+
+// NewQueueHandler creates a new queue handler.
+func NewQueueHandler(sender *service.AsyncSender) *QueueHandler {
+	return &QueueHandler{
+		sender: sender,
+	}
+}
+
+// requeueResponse reports the outcome of POST /v1/queue/failed/requeue.
+type requeueResponse struct {
+	Requeued int `json:"requeued"`
+}
+
+// HandleListFailed handles GET /v1/queue/failed
+func (h *QueueHandler) HandleListFailed(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.sender.FailedJobs(r.Context())
+	if err != nil {
+		log.Printf("List failed jobs error: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to list: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, jobs)
+}
+
+// HandleRequeue handles POST /v1/queue/failed/{id}/requeue
+func (h *QueueHandler) HandleRequeue(w http.ResponseWriter, r *http.Request) {
+	if err := h.sender.Requeue(r.Context(), chi.URLParam(r, "id")); err != nil {
+		respondQueueError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, requeueResponse{Requeued: 1})
+}
+
+// HandleRequeueAll handles POST /v1/queue/failed/requeue
+func (h *QueueHandler) HandleRequeueAll(w http.ResponseWriter, r *http.Request) {
+	requeued, err := h.sender.RequeueAll(r.Context())
+	if err != nil {
+		respondQueueError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, requeueResponse{Requeued: requeued})
+}
+
+// HandleDiscard handles DELETE /v1/queue/failed/{id}
+func (h *QueueHandler) HandleDiscard(w http.ResponseWriter, r *http.Request) {
+	if err := h.sender.Discard(r.Context(), chi.URLParam(r, "id")); err != nil {
+		respondQueueError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondQueueError(w http.ResponseWriter, err error) {
+	if errors.Is(err, port.ErrJobNotFound) {
+		respondError(w, http.StatusNotFound, "failed job not found")
+		return
+	}
+	log.Printf("Failed job error: %v", err)
+	http.Error(w, fmt.Sprintf("Failed: %v", err), http.StatusInternalServerError)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// ScheduledHandler handles the pending scheduled message endpoints.
+type ScheduledHandler struct {
+	scheduler *service.Scheduler
+}
+
+// NewScheduledHandler creates a new scheduled message handler.
+func NewScheduledHandler(scheduler *service.Scheduler) *ScheduledHandler {
+	return &ScheduledHandler{
+		scheduler: scheduler,
+	}
+}
+
+// HandleList handles GET /v1/scheduled
+func (h *ScheduledHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.scheduler.List(r.Context())
+	if err != nil {
+		log.Printf("List scheduled error: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to list: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, jobs)
+}
+
+// HandleGet handles GET /v1/scheduled/{id}
+func (h *ScheduledHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	job, err := h.scheduler.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondScheduledError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+// HandleCancel handles DELETE /v1/scheduled/{id}
+func (h *ScheduledHandler) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	if err := h.scheduler.Cancel(r.Context(), chi.URLParam(r, "id")); err != nil {
+		respondScheduledError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleReschedule handles PATCH /v1/scheduled/{id} with a send_at or delay body.
+func (h *ScheduledHandler) HandleReschedule(w http.ResponseWriter, r *http.Request) {
+	var req contracts.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	sendAt, err := req.SendTime(time.Now())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if sendAt.IsZero() {
+		respondError(w, http.StatusBadRequest, "send_at or delay is required")
+		return
+	}
+
+	job, err := h.scheduler.Reschedule(r.Context(), chi.URLParam(r, "id"), sendAt)
+	if err != nil {
+		respondScheduledError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+func respondScheduledError(w http.ResponseWriter, err error) {
+	if errors.Is(err, port.ErrJobNotFound) {
+		respondError(w, http.StatusNotFound, "scheduled message not found")
+		return
+	}
+	if errors.Is(err, service.ErrInvalidMessage) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.Printf("Scheduled message error: %v", err)
+	http.Error(w, fmt.Sprintf("Failed: %v", err), http.StatusInternalServerError)
+}
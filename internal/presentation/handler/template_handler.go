@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
+)
+
+// TemplateHandler handles the message template endpoints.
+type TemplateHandler struct {
+	templates *service.TemplateService
+}
+
+// NewTemplateHandler creates a new template handler.
+func NewTemplateHandler(templates *service.TemplateService) *TemplateHandler {
+	return &TemplateHandler{
+		templates: templates,
+	}
+}
+
+// HandleList handles GET /v1/templates, listing the latest version of each
+// template in each locale.
+func (h *TemplateHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.templates.List(r.Context())
+	if err != nil {
+		log.Printf("List templates error: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to list: %v", err), http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, http.StatusOK, templates)
+}
+
+// HandleCreate handles POST /v1/templates, storing the body as the next
+// version of the template.
+func (h *TemplateHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var req contracts.Template
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	template, err := h.templates.Create(r.Context(), &req)
+	if err != nil {
+		respondTemplateError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, template)
+}
+
+// HandleGet handles GET /v1/templates/{name} with optional locale and
+// version query parameters. The latest version is returned by default.
+func (h *TemplateHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, "version must be a positive integer")
+			return
+		}
+		version = n
+	}
+
+	template, err := h.templates.Get(r.Context(), chi.URLParam(r, "name"), r.URL.Query().Get("locale"), version)
+	if err != nil {
+		respondTemplateError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, template)
+}
+
+// HandleVersions handles GET /v1/templates/{name}/versions
+func (h *TemplateHandler) HandleVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.templates.Versions(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		respondTemplateError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, versions)
+}
+
+// HandleDelete handles DELETE /v1/templates/{name}, removing every version.
+func (h *TemplateHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if err := h.templates.Delete(r.Context(), chi.URLParam(r, "name")); err != nil {
+		respondTemplateError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlePreview handles POST /v1/{channel}/preview. It takes the same body
+// as a send and responds with the message as it would be sent.
+func (h *TemplateHandler) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	channel := chi.URLParam(r, "channel")
+	var message any
+	switch channel {
+	case "email":
+		message = &contracts.Email{}
+	case "sms":
+		message = &contracts.SMS{}
+	case "push":
+		message = &contracts.PushNotification{}
+	case "chat":
+		message = &contracts.ChatMessage{}
+	default:
+		respondError(w, http.StatusNotFound, "unknown channel: "+channel)
+		return
+	}
+	if !service.APIKeyFrom(r.Context()).CanSend(channel) {
+		respondError(w, http.StatusForbidden, service.ErrForbidden.Error()+": "+channel)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(message); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.templates.Render(r.Context(), message); err != nil {
+		respondTemplateError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, message)
+}
+
+// respondTemplateError maps template errors to a response.
+func respondTemplateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, port.ErrTemplateNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidTemplate), errors.Is(err, service.ErrTemplateRender):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("Template error: %v", err)
+		http.Error(w, fmt.Sprintf("Failed: %v", err), http.StatusInternalServerError)
+	}
+}
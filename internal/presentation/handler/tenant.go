@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+)
+
+// HeaderTenant selects the tenant of a request whose API key is not bound to
+// one.
+const HeaderTenant = "X-Tenant-ID"
+
+// Tenancy selects the tenant each API request is made for.
+type Tenancy struct {
+	known func(id string) bool
+}
+
+// NewTenancy creates the tenant middleware. known reports whether a tenant
+// is configured.
+func NewTenancy(known func(id string) bool) *Tenancy {
+	return &Tenancy{known: known}
+}
+
+// Select adds the request's tenant to its context: the API key's tenant, or
+// else the X-Tenant-ID header, which only keys with the admin scope may
+// send. Without either, the global providers are used. A header naming
+// another tenant than the key's, or sent with a non-admin key, is answered
+// 403, and an unknown tenant 400. It must run after Auth.Authenticate.
+func (t *Tenancy) Select(next http.Handler) http.Handler {
+	if t == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderTenant)
+		key := service.APIKeyFrom(r.Context())
+		switch {
+		case key != nil && key.Tenant != "":
+			if id != "" && id != key.Tenant {
+				respondError(w, http.StatusForbidden, service.ErrForbidden.Error()+": tenant "+id)
+				return
+			}
+			id = key.Tenant
+		case id != "" && !key.IsAdmin():
+			respondError(w, http.StatusForbidden, service.ErrForbidden.Error()+": tenant "+id)
+			return
+		}
+		if id == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !t.known(id) {
+			respondError(w, http.StatusBadRequest, service.ErrUnknownTenant.Error()+": "+id)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(service.WithTenant(r.Context(), id)))
+	})
+}
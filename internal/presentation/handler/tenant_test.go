@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+)
+
+// tenantID responds with the tenant of the request.
+var tenantID = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(service.TenantFrom(r.Context())))
+})
+
+func TestTenancySelect(t *testing.T) {
+	keys, err := service.NewAPIKeys([]service.APIKey{
+		{ID: "app", Hash: service.HashAPIKey("app-key"), Scopes: []string{"sms"}},
+		{ID: "ops", Hash: service.HashAPIKey("ops-key"), Scopes: []string{"admin"}},
+		{ID: "shop", Hash: service.HashAPIKey("shop-key"), Scopes: []string{"sms"}, Tenant: "shop"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	known := func(id string) bool { return id == "shop" || id == "acme" }
+
+	tests := []struct {
+		name       string
+		keys       *service.APIKeys
+		key        string
+		header     string
+		wantStatus int
+		wantTenant string
+	}{
+		{name: "key without a tenant", keys: keys, key: "app-key", wantStatus: http.StatusOK},
+		{name: "key's tenant", keys: keys, key: "shop-key", wantStatus: http.StatusOK, wantTenant: "shop"},
+		{name: "header naming the key's tenant", keys: keys, key: "shop-key", header: "shop", wantStatus: http.StatusOK, wantTenant: "shop"},
+		{name: "header naming another tenant than the key's", keys: keys, key: "shop-key", header: "acme", wantStatus: http.StatusForbidden},
+		{name: "header from a channel key", keys: keys, key: "app-key", header: "shop", wantStatus: http.StatusForbidden},
+		{name: "header from an admin key", keys: keys, key: "ops-key", header: "acme", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "unknown tenant", keys: keys, key: "ops-key", header: "nope", wantStatus: http.StatusBadRequest},
+		{name: "header without keys configured", header: "shop", wantStatus: http.StatusOK, wantTenant: "shop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/sms", nil)
+			if tt.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			if tt.header != "" {
+				req.Header.Set(HeaderTenant, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			NewAuth(tt.keys).Authenticate(NewTenancy(known).Select(tenantID)).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && rec.Body.String() != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", rec.Body.String(), tt.wantTenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+)
+
+// maxWebhookBody caps the size of an inbound webhook request.
+const maxWebhookBody = 5 << 20
+
+// WebhookHandler handles delivery-status webhooks posted by providers.
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler.
+func NewWebhookHandler(svc *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		service: svc,
+	}
+}
+
+// HandleWebhook handles POST /v1/webhooks/{provider}, with ?tenant= for a
+// tenant's provider.
+func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+	ctx := service.WithTenant(r.Context(), r.URL.Query().Get("tenant"))
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+
+	events, err := h.service.Handle(ctx, provider, &port.WebhookRequest{
+		URL:    requestURL(r),
+		Header: r.Header,
+		Body:   body,
+	})
+	switch {
+	case errors.Is(err, service.ErrWebhookNotConfigured):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, port.ErrInvalidSignature):
+		respondError(w, http.StatusUnauthorized, err.Error())
+	case err != nil:
+		log.Printf("Webhook %s error: %v", provider, err)
+		http.Error(w, fmt.Sprintf("Failed to process webhook: %v", err), http.StatusBadRequest)
+	default:
+		respondJSON(w, http.StatusOK, map[string]int{"events": len(events)})
+	}
+}
+
+// requestURL reconstructs the URL the provider called, honouring the
+// X-Forwarded-Proto header set by TLS-terminating proxies.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
@@ -12,15 +12,52 @@ import (
 
 // Router holds all HTTP handlers and provides route configuration.
 type Router struct {
-	gatewayHandler *handler.GatewayHandler
-	devboxHandler  *handler.DevBoxHandler
+	gatewayHandler   *handler.GatewayHandler
+	devboxHandler    *handler.DevBoxHandler
+	breakerHandler   *handler.BreakerHandler
+	scheduledHandler *handler.ScheduledHandler
+	queueHandler     *handler.QueueHandler
+	messageHandler   *handler.MessageHandler
+	webhookHandler   *handler.WebhookHandler
+	eventHandler     *handler.EventHandler
+	templateHandler  *handler.TemplateHandler
+	auth             *handler.Auth
+	tenancy          *handler.Tenancy
+	corsOrigins      []string
 }
 
 // NewRouter creates a new router with the given handlers.
-func NewRouter(gateway *handler.GatewayHandler, devbox *handler.DevBoxHandler) *Router {
+// devbox, scheduled, queue and event may be nil to leave their routes out, auth
+// may be nil to leave the API open, and tenancy nil to send every request
+// with the global providers. Without corsOrigins, browsers may not call the
+// API from other origins.
+func NewRouter(
+	gateway *handler.GatewayHandler,
+	devbox *handler.DevBoxHandler,
+	breaker *handler.BreakerHandler,
+	scheduled *handler.ScheduledHandler,
+	queue *handler.QueueHandler,
+	message *handler.MessageHandler,
+	webhook *handler.WebhookHandler,
+	event *handler.EventHandler,
+	template *handler.TemplateHandler,
+	auth *handler.Auth,
+	tenancy *handler.Tenancy,
+	corsOrigins []string,
+) *Router {
 	return &Router{
-		gatewayHandler: gateway,
-		devboxHandler:  devbox,
+		gatewayHandler:   gateway,
+		devboxHandler:    devbox,
+		breakerHandler:   breaker,
+		scheduledHandler: scheduled,
+		queueHandler:     queue,
+		messageHandler:   message,
+		webhookHandler:   webhook,
+		eventHandler:     event,
+		templateHandler:  template,
+		auth:             auth,
+		tenancy:          tenancy,
+		corsOrigins:      corsOrigins,
 	}
 }
 
@@ -32,30 +69,108 @@ func (rt *Router) Setup() chi.Router {
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Content-Type"},
-		AllowCredentials: false,
-		MaxAge:           300,
-	}))
+	// cors.Handler allows any origin when none are listed, so it is only
+	// installed for configured origins.
+	if len(rt.corsOrigins) > 0 {
+		r.Use(cors.Handler(cors.Options{
+			AllowedOrigins:   rt.corsOrigins,
+			AllowedMethods:   []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", handler.HeaderIdempotencyKey, handler.HeaderTenant},
+			ExposedHeaders:   []string{handler.HeaderIdempotentReplayed},
+			AllowCredentials: false,
+			MaxAge:           300,
+		}))
+	}
 
 	// Gateway API - for sending messages
 	r.Route("/v1", func(r chi.Router) {
-		r.Post("/email", rt.gatewayHandler.HandleSendEmail)
-		r.Post("/sms", rt.gatewayHandler.HandleSendSMS)
-		r.Post("/push", rt.gatewayHandler.HandleSendPush)
-		r.Post("/chat", rt.gatewayHandler.HandleSendChat)
+		// Provider delivery-status webhooks, verified by their signature
+		r.Post("/webhooks/{provider}", rt.webhookHandler.HandleWebhook)
+
+		r.Group(func(r chi.Router) {
+			r.Use(rt.auth.Authenticate)
+			r.Use(rt.tenancy.Select)
+
+			r.Post("/email", rt.gatewayHandler.HandleSendEmail)
+			r.Post("/sms", rt.gatewayHandler.HandleSendSMS)
+			r.Post("/push", rt.gatewayHandler.HandleSendPush)
+			r.Post("/chat", rt.gatewayHandler.HandleSendChat)
+			r.Post("/{channel}/preview", rt.templateHandler.HandlePreview)
+
+			// Message ledger, limited to a key's own messages without admin
+			r.Get("/messages", rt.messageHandler.HandleListMessages)
+			r.Get("/messages/{id}", rt.messageHandler.HandleGetMessage)
+
+			r.Group(rt.adminRoutes)
+		})
 	})
 
-	// DevBox API - for viewing intercepted messages
-	if rt.devboxHandler != nil {
-		r.Mount("/api/v1", rt.devboxRoutes())
-	}
+	// Metrics and the DevBox API, which shows every intercepted message,
+	// need an admin key when keys are configured, and are limited to the
+	// tenant of the key or its X-Tenant-ID header
+	r.Group(func(r chi.Router) {
+		r.Use(rt.auth.Authenticate)
+		r.Use(rt.auth.RequireAdmin)
+		r.Use(rt.tenancy.Select)
+
+		r.Get("/metrics", rt.breakerHandler.HandleMetrics)
+
+		// DevBox API - for viewing intercepted messages
+		if rt.devboxHandler != nil {
+			r.Mount("/api/v1", rt.devboxRoutes())
+		}
+	})
 
 	return r
 }
 
+// adminRoutes adds the /v1 endpoints that need the admin scope.
+func (rt *Router) adminRoutes(r chi.Router) {
+	r.Use(rt.auth.RequireAdmin)
+
+	// Message templates
+	r.Route("/templates", func(r chi.Router) {
+		r.Get("/", rt.templateHandler.HandleList)
+		r.Post("/", rt.templateHandler.HandleCreate)
+		r.Get("/{name}", rt.templateHandler.HandleGet)
+		r.Get("/{name}/versions", rt.templateHandler.HandleVersions)
+		r.Delete("/{name}", rt.templateHandler.HandleDelete)
+	})
+
+	// Provider health
+	r.Get("/breakers", rt.breakerHandler.HandleListBreakers)
+
+	// Scheduled messages
+	if rt.scheduledHandler != nil {
+		r.Route("/scheduled", func(r chi.Router) {
+			r.Get("/", rt.scheduledHandler.HandleList)
+			r.Get("/{id}", rt.scheduledHandler.HandleGet)
+			r.Patch("/{id}", rt.scheduledHandler.HandleReschedule)
+			r.Delete("/{id}", rt.scheduledHandler.HandleCancel)
+		})
+	}
+
+	// Queued messages whose send failed
+	if rt.queueHandler != nil {
+		r.Route("/queue/failed", func(r chi.Router) {
+			r.Get("/", rt.queueHandler.HandleListFailed)
+			r.Post("/requeue", rt.queueHandler.HandleRequeueAll)
+			r.Post("/{id}/requeue", rt.queueHandler.HandleRequeue)
+			r.Delete("/{id}", rt.queueHandler.HandleDiscard)
+		})
+	}
+
+	// Undelivered message events
+	if rt.eventHandler != nil {
+		r.Route("/events/dead-letters", func(r chi.Router) {
+			r.Get("/", rt.eventHandler.HandleListDeadLetters)
+			r.Post("/replay", rt.eventHandler.HandleReplayAll)
+			r.Post("/{id}/replay", rt.eventHandler.HandleReplay)
+			r.Delete("/{id}", rt.eventHandler.HandleDiscard)
+		})
+	}
+}
+
 // devboxRoutes returns a chi router with all devbox API endpoints.
 func (rt *Router) devboxRoutes() chi.Router {
 	r := chi.NewRouter()
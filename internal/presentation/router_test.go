@@ -0,0 +1,189 @@
+package presentation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/provider/memory"
+	"github.com/weprodev/wpd-message-gateway/internal/presentation/handler"
+)
+
+// newTestRouter returns a router whose DevBox shows store, with the
+// tenants shop and acme.
+func newTestRouter(t *testing.T, keys []service.APIKey, store *memory.Store, corsOrigins []string) http.Handler {
+	t.Helper()
+	apiKeys, err := service.NewAPIKeys(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store == nil {
+		store = memory.NewStore()
+	}
+	return NewRouter(
+		nil,
+		handler.NewDevBoxHandler(store, memory.MailpitConfig{}),
+		handler.NewBreakerHandler(nil),
+		nil, nil, nil, nil, nil, nil,
+		handler.NewAuth(apiKeys),
+		handler.NewTenancy(func(id string) bool { return id == "shop" || id == "acme" }),
+		corsOrigins,
+	).Setup()
+}
+
+func TestRouterProtectsMetricsAndDevBox(t *testing.T) {
+	keys := []service.APIKey{
+		{ID: "app", Hash: service.HashAPIKey("app-key"), Scopes: []string{"email"}},
+		{ID: "ops", Hash: service.HashAPIKey("ops-key"), Scopes: []string{"admin"}},
+	}
+
+	tests := []struct {
+		name       string
+		keys       []service.APIKey
+		method     string
+		path       string
+		key        string
+		wantStatus int
+	}{
+		{"metrics without a key", keys, http.MethodGet, "/metrics", "", http.StatusUnauthorized},
+		{"metrics with a channel key", keys, http.MethodGet, "/metrics", "app-key", http.StatusForbidden},
+		{"metrics with an admin key", keys, http.MethodGet, "/metrics", "ops-key", http.StatusOK},
+		{"devbox list without a key", keys, http.MethodGet, "/api/v1/emails", "", http.StatusUnauthorized},
+		{"devbox clear with a channel key", keys, http.MethodDelete, "/api/v1/messages", "app-key", http.StatusForbidden},
+		{"devbox ingest without a key", keys, http.MethodPost, "/api/v1/internal/email", "", http.StatusUnauthorized},
+		{"devbox list with an admin key", keys, http.MethodGet, "/api/v1/emails", "ops-key", http.StatusOK},
+		{"devbox without keys configured", nil, http.MethodGet, "/api/v1/stats", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			rec := httptest.NewRecorder()
+
+			newTestRouter(t, tt.keys, nil, nil).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRouterCORS(t *testing.T) {
+	tests := []struct {
+		name        string
+		corsOrigins []string
+		origin      string
+		wantAllowed string
+	}{
+		{"no origins configured", nil, "https://evil.example.com", ""},
+		{"configured origin", []string{"https://admin.example.com"}, "https://admin.example.com", "https://admin.example.com"},
+		{"other origin", []string{"https://admin.example.com"}, "https://evil.example.com", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/v1/email", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			rec := httptest.NewRecorder()
+
+			newTestRouter(t, nil, nil, tt.corsOrigins).ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowed {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestRouterDevBoxTenants(t *testing.T) {
+	keys := []service.APIKey{
+		{ID: "ops", Hash: service.HashAPIKey("ops-key"), Scopes: []string{"admin"}},
+		{ID: "shop-ops", Hash: service.HashAPIKey("shop-key"), Scopes: []string{"admin"}, Tenant: "shop"},
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		tenant     string
+		wantStatus int
+		// wantLeft are the IDs of the emails left in the store.
+		wantLeft []string
+		// wantListed are the IDs of the emails listed, for list requests.
+		wantListed []string
+	}{
+		{name: "admin key lists every email", method: http.MethodGet, path: "/api/v1/emails", key: "ops-key", wantStatus: http.StatusOK, wantListed: []string{"global-1", "shop-1", "acme-1"}},
+		{name: "admin key lists one tenant's", method: http.MethodGet, path: "/api/v1/emails", key: "ops-key", tenant: "acme", wantStatus: http.StatusOK, wantListed: []string{"acme-1"}},
+		{name: "tenant key lists its own", method: http.MethodGet, path: "/api/v1/emails", key: "shop-key", wantStatus: http.StatusOK, wantListed: []string{"shop-1"}},
+		{name: "tenant key ignores ?tenant=", method: http.MethodGet, path: "/api/v1/emails?tenant=acme", key: "shop-key", wantStatus: http.StatusOK, wantListed: []string{"shop-1"}},
+		{name: "tenant key cannot select another tenant", method: http.MethodGet, path: "/api/v1/emails", key: "shop-key", tenant: "acme", wantStatus: http.StatusForbidden},
+		{name: "tenant key gets its own email", method: http.MethodGet, path: "/api/v1/emails/shop-1", key: "shop-key", wantStatus: http.StatusOK},
+		{name: "tenant key cannot get another tenant's email", method: http.MethodGet, path: "/api/v1/emails/acme-1", key: "shop-key", wantStatus: http.StatusNotFound},
+		{
+			name: "tenant key cannot delete another tenant's email", method: http.MethodDelete, path: "/api/v1/emails/acme-1", key: "shop-key",
+			wantStatus: http.StatusNotFound, wantLeft: []string{"global-1", "shop-1", "acme-1"},
+		},
+		{
+			name: "tenant key clears only its own", method: http.MethodDelete, path: "/api/v1/messages", key: "shop-key",
+			wantStatus: http.StatusNoContent, wantLeft: []string{"global-1", "acme-1"},
+		},
+		{
+			name: "admin key clears every email", method: http.MethodDelete, path: "/api/v1/messages", key: "ops-key",
+			wantStatus: http.StatusNoContent, wantLeft: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewStore()
+			for _, e := range []*memory.StoredEmail{{ID: "global-1"}, {ID: "shop-1", Tenant: "shop"}, {ID: "acme-1", Tenant: "acme"}} {
+				store.AddEmail(e)
+			}
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+			if tt.tenant != "" {
+				req.Header.Set(handler.HeaderTenant, tt.tenant)
+			}
+			rec := httptest.NewRecorder()
+
+			newTestRouter(t, keys, store, nil).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantListed != nil {
+				var listed []*memory.StoredEmail
+				if err := json.NewDecoder(rec.Body).Decode(&listed); err != nil {
+					t.Fatal(err)
+				}
+				if got := emailIDs(listed); !sameIDs(got, tt.wantListed) {
+					t.Errorf("listed %v, want %v", got, tt.wantListed)
+				}
+			}
+			if tt.wantLeft != nil {
+				if got := emailIDs(store.Emails()); !sameIDs(got, tt.wantLeft) {
+					t.Errorf("left %v, want %v", got, tt.wantLeft)
+				}
+			}
+		})
+	}
+}
+
+func emailIDs(emails []*memory.StoredEmail) []string {
+	ids := make([]string, len(emails))
+	for i, e := range emails {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func sameIDs(got, want []string) bool {
+	got, want = slices.Sorted(slices.Values(got)), slices.Sorted(slices.Values(want))
+	return slices.Equal(got, want)
+}
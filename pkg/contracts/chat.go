@@ -15,6 +15,12 @@ type ChatMessage struct {
 	Buttons        []ChatButton      `json:"buttons,omitempty"`
 	ReplyToID      string            `json:"reply_to_id,omitempty"`
 	Metadata       map[string]string `json:"metadata,omitempty"`
+	// Template renders Message from a stored gateway template when Message
+	// is empty. TemplateID and TemplateParams select a platform's own template.
+	Template *TemplateRef `json:"template,omitempty"`
+
+	// Schedule delays delivery through the async queue.
+	Schedule
 }
 
 // ChatButton represents an interactive button in a chat message.
@@ -4,17 +4,27 @@ import "context"
 
 // Email represents an email message to be sent.
 type Email struct {
-	From        string            `json:"from,omitempty"`
-	FromName    string            `json:"from_name,omitempty"`
-	To          []string          `json:"to"`
-	CC          []string          `json:"cc,omitempty"`
-	BCC         []string          `json:"bcc,omitempty"`
-	ReplyTo     string            `json:"reply_to,omitempty"`
-	Subject     string            `json:"subject"`
-	HTML        string            `json:"html,omitempty"`
-	PlainText   string            `json:"plain_text,omitempty"`
+	From      string   `json:"from,omitempty"`
+	FromName  string   `json:"from_name,omitempty"`
+	To        []string `json:"to"`
+	CC        []string `json:"cc,omitempty"`
+	BCC       []string `json:"bcc,omitempty"`
+	ReplyTo   string   `json:"reply_to,omitempty"`
+	Subject   string   `json:"subject"`
+	HTML      string   `json:"html,omitempty"`
+	PlainText string   `json:"plain_text,omitempty"`
+	// Markdown or MJML is rendered into HTML, with its CSS inlined, when
+	// HTML is empty. An empty PlainText is derived from the HTML.
+	Markdown    string            `json:"markdown,omitempty"`
+	MJML        string            `json:"mjml,omitempty"`
 	Attachments []Attachment      `json:"attachments,omitempty"`
 	Headers     map[string]string `json:"headers,omitempty"`
+	// Template renders Subject, the body and PlainText from a stored template.
+	// Fields already set are kept.
+	Template *TemplateRef `json:"template,omitempty"`
+
+	// Schedule delays delivery through the async queue.
+	Schedule
 }
 
 // EmailSender defines the contract for sending emails.
@@ -0,0 +1,51 @@
+package contracts
+
+import "time"
+
+// EventType names a message lifecycle event sent to event subscribers.
+type EventType string
+
+// Event types, one for each ledger status subscribers are notified of.
+const (
+	EventMessageSent      EventType = "message.sent"
+	EventMessageFailed    EventType = "message.failed"
+	EventMessageDelivered EventType = "message.delivered"
+	EventMessageBounced   EventType = "message.bounced"
+)
+
+// EventTypeForStatus returns the event type published when a message
+// reaches status, and false for statuses that are not published.
+func EventTypeForStatus(status MessageStatus) (EventType, bool) {
+	switch status {
+	case StatusSent:
+		return EventMessageSent, true
+	case StatusFailed:
+		return EventMessageFailed, true
+	case StatusDelivered:
+		return EventMessageDelivered, true
+	case StatusBounced:
+		return EventMessageBounced, true
+	default:
+		return "", false
+	}
+}
+
+// Valid reports whether t is one of the event types above.
+func (t EventType) Valid() bool {
+	switch t {
+	case EventMessageSent, EventMessageFailed, EventMessageDelivered, EventMessageBounced:
+		return true
+	default:
+		return false
+	}
+}
+
+// Event is the JSON payload posted to event subscribers.
+type Event struct {
+	// ID is unique per event; subscribers can use it to drop duplicates
+	// after a retry or replay.
+	ID      string         `json:"id"`
+	Type    EventType      `json:"type"`
+	Time    time.Time      `json:"time"`
+	Message *MessageRecord `json:"message"`
+}
@@ -0,0 +1,77 @@
+package contracts
+
+import "time"
+
+// MessageStatus is the delivery state of a message in the ledger.
+type MessageStatus string
+
+// Message statuses. Delivered and bounced are reported by provider webhooks
+// where the provider supports them.
+const (
+	StatusQueued    MessageStatus = "queued"
+	StatusSent      MessageStatus = "sent"
+	StatusDelivered MessageStatus = "delivered"
+	StatusFailed    MessageStatus = "failed"
+	StatusBounced   MessageStatus = "bounced"
+	StatusCancelled MessageStatus = "cancelled"
+
+	// Engagement statuses are recorded as events without changing the
+	// message's status.
+	StatusOpened     MessageStatus = "opened"
+	StatusClicked    MessageStatus = "clicked"
+	StatusComplained MessageStatus = "complained"
+)
+
+// MessageRecord is the ledger entry for one message.
+type MessageRecord struct {
+	// ID is the gateway's message ID: the job ID for queued and scheduled
+	// messages, returned in SendResult.Meta["message_id"] for direct sends.
+	ID      string `json:"id"`
+	Channel string `json:"channel"`
+	// Tenant is the tenant the message was sent for, and APIKey the ID of
+	// the API key it was sent with.
+	Tenant            string   `json:"tenant,omitempty"`
+	APIKey            string   `json:"api_key,omitempty"`
+	Provider          string   `json:"provider,omitempty"`
+	ProviderMessageID string   `json:"provider_message_id,omitempty"`
+	Recipients        []string `json:"recipients"`
+	// RecipientMessageIDs maps each recipient to its provider message ID,
+	// for providers that send one message per recipient.
+	RecipientMessageIDs map[string]string `json:"recipient_message_ids,omitempty"`
+
+	Status      MessageStatus  `json:"status"`
+	Error       string         `json:"error,omitempty"`
+	Attempts    []Attempt      `json:"attempts,omitempty"`
+	Events      []MessageEvent `json:"events"`
+	ScheduledAt *time.Time     `json:"scheduled_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// MessageEvent records a status change.
+type MessageEvent struct {
+	Status MessageStatus `json:"status"`
+	Time   time.Time     `json:"time"`
+	Detail string        `json:"detail,omitempty"`
+}
+
+// SetStatus changes the record's status and appends an event.
+func (r *MessageRecord) SetStatus(status MessageStatus, at time.Time, detail string) {
+	r.Status = status
+	r.UpdatedAt = at
+	r.Events = append(r.Events, MessageEvent{Status: status, Time: at, Detail: detail})
+}
+
+// DeliveryEvent is a provider's report about a sent message, normalised
+// from its webhook.
+type DeliveryEvent struct {
+	Provider          string        `json:"provider"`
+	ProviderMessageID string        `json:"provider_message_id"`
+	Status            MessageStatus `json:"status"`
+	Recipient         string        `json:"recipient,omitempty"`
+	Reason            string        `json:"reason,omitempty"`
+	Time              time.Time     `json:"time"`
+}
+
+// MetaMessageID is the SendResult.Meta key holding the gateway's message ID.
+const MetaMessageID = "message_id"
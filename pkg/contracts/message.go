@@ -1,5 +1,11 @@
 package contracts
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 // Attachment represents a file attachment for messages.
 type Attachment struct {
 	Filename    string `json:"filename"`
@@ -14,4 +20,43 @@ type SendResult struct {
 	StatusCode int               `json:"status_code"`
 	Message    string            `json:"message"`
 	Meta       map[string]string `json:"meta,omitempty"`
+	// Attempts lists every provider tried, in order, when failover is configured.
+	Attempts []Attempt `json:"attempts,omitempty"`
+}
+
+// Attempt records one provider's try at delivering a message.
+type Attempt struct {
+	Provider   string `json:"provider"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// MetaProvider is the SendResult.Meta key holding the provider that delivered the message.
+const MetaProvider = "provider"
+
+// Schedule delays delivery of a message. Set SendAt or Delay, not both.
+type Schedule struct {
+	// SendAt is when to send the message. A time in the past sends it right away.
+	SendAt *time.Time `json:"send_at,omitempty"`
+	// Delay is how long to wait before sending, as a Go duration ("90s", "2h").
+	Delay string `json:"delay,omitempty"`
+}
+
+// SendTime returns when the message should be sent, or the zero time if it
+// is not scheduled.
+func (s Schedule) SendTime(now time.Time) (time.Time, error) {
+	switch {
+	case s.SendAt != nil && s.Delay != "":
+		return time.Time{}, errors.New("set send_at or delay, not both")
+	case s.SendAt != nil:
+		return s.SendAt.UTC(), nil
+	case s.Delay != "":
+		delay, err := time.ParseDuration(s.Delay)
+		if err != nil || delay < 0 {
+			return time.Time{}, fmt.Errorf("invalid delay %q", s.Delay)
+		}
+		return now.Add(delay).UTC(), nil
+	default:
+		return time.Time{}, nil
+	}
 }
@@ -1,6 +1,15 @@
 package contracts
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+// Push notification priorities.
+const (
+	PushPriorityHigh   = "high"
+	PushPriorityNormal = "normal"
+)
 
 // PushNotification represents a push notification to be sent.
 type PushNotification struct {
@@ -10,6 +19,24 @@ type PushNotification struct {
 	Data         map[string]string `json:"data,omitempty"`
 	Badge        *int              `json:"badge,omitempty"`
 	Sound        string            `json:"sound,omitempty"`
+
+	// Priority is "high" (deliver immediately) or "normal" (may be batched to save power).
+	Priority string `json:"priority,omitempty"`
+	// CollapseID groups notifications so that only the latest one is shown.
+	CollapseID string `json:"collapse_id,omitempty"`
+	// ExpiresAt is when the platform should stop trying to deliver the notification.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// ExternalIDs and Segments target audiences instead of devices on
+	// providers that manage their own subscriptions (OneSignal).
+	ExternalIDs []string `json:"external_ids,omitempty"`
+	Segments    []string `json:"segments,omitempty"`
+	// Template renders Title and Body from a stored template. Fields
+	// already set are kept.
+	Template *TemplateRef `json:"template,omitempty"`
+
+	// Schedule delays delivery through the async queue.
+	Schedule
 }
 
 // PushSender defines the contract for sending push notifications.
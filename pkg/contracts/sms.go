@@ -7,6 +7,11 @@ type SMS struct {
 	From    string   `json:"from,omitempty"`
 	To      []string `json:"to"`
 	Message string   `json:"message"`
+	// Template renders Message from a stored template when Message is empty.
+	Template *TemplateRef `json:"template,omitempty"`
+
+	// Schedule delays delivery through the async queue.
+	Schedule
 }
 
 // SMSSender defines the contract for sending SMS messages.
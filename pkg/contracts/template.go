@@ -0,0 +1,43 @@
+package contracts
+
+import "time"
+
+// Template is one version of a stored message template in one locale.
+// Each part is a Go template; HTML is rendered with html/template, the rest
+// with text/template. A template only needs the parts for the channels it is
+// used with.
+type Template struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+	// Locale is a language tag such as "pt-BR", or empty for the default
+	// locale.
+	Locale string `json:"locale,omitempty"`
+
+	// Email parts. Markdown and MJML are used as the email's body when it
+	// has no HTML; MJML is parsed with html/template.
+	Subject  string `json:"subject,omitempty"`
+	HTML     string `json:"html,omitempty"`
+	Markdown string `json:"markdown,omitempty"`
+	MJML     string `json:"mjml,omitempty"`
+	Text     string `json:"text,omitempty"`
+
+	SMS       string `json:"sms,omitempty"`
+	PushTitle string `json:"push_title,omitempty"`
+	PushBody  string `json:"push_body,omitempty"`
+	Chat      string `json:"chat,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TemplateRef selects a stored template to render a message from.
+type TemplateRef struct {
+	Name string `json:"name"`
+	// Version pins a version of the Locale translation, which numbers its
+	// own versions. Zero uses the latest.
+	Version int `json:"version,omitempty"`
+	// Locale, such as "pt-BR", selects the template's translation. Without
+	// one for the locale, its language ("pt") and then the default are used,
+	// unless a Version is pinned.
+	Locale string         `json:"locale,omitempty"`
+	Data   map[string]any `json:"data,omitempty"`
+}
@@ -0,0 +1,114 @@
+// Package errors provides structured error types shared by providers and services.
+//
+// Providers wrap vendor failures in a *ProviderError and classify them with one
+// of the Err* kinds so callers can react without knowing the vendor:
+//
+//	if errors.Is(err, pkgerrors.ErrInvalidRecipient) {
+//	    // drop the recipient, do not retry
+//	}
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Error kinds. A *ProviderError matches its kind with errors.Is.
+var (
+	ErrInvalidRecipient = errors.New("invalid recipient")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrRateLimited      = errors.New("rate limited")
+	ErrRejected         = errors.New("rejected by provider")
+	ErrUnavailable      = errors.New("provider unavailable")
+)
+
+// ProviderError describes a failure reported by an upstream provider.
+type ProviderError struct {
+	Provider   string
+	Message    string
+	StatusCode int
+	// Code is the vendor-specific error code, if any (e.g. Twilio "21211").
+	Code string
+	// Kind is one of the Err* kinds above, or nil when unclassified.
+	Kind error
+	// RetryAfter is how long to wait before sending again, when known.
+	RetryAfter time.Duration
+	Err        error
+}
+
+// NewProviderError creates a ProviderError classified by its HTTP status code.
+func NewProviderError(provider, message string, statusCode int, err error) *ProviderError {
+	return &ProviderError{
+		Provider:   provider,
+		Message:    message,
+		StatusCode: statusCode,
+		Kind:       KindForStatus(statusCode),
+		Err:        err,
+	}
+}
+
+func (e *ProviderError) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Provider, e.Message)
+	if e.Code != "" {
+		msg += fmt.Sprintf(" (code %s)", e.Code)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the error's kind.
+func (e *ProviderError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// IsRetryable reports whether err is transient, so the same message may
+// succeed if sent again later or through another provider.
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable)
+}
+
+// ConfigError describes an invalid or missing provider setting.
+type ConfigError struct {
+	Provider string
+	Field    string
+	Message  string
+}
+
+// NewConfigError creates a ConfigError.
+func NewConfigError(provider, field, message string) *ConfigError {
+	return &ConfigError{
+		Provider: provider,
+		Field:    field,
+		Message:  message,
+	}
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s %s", e.Provider, e.Field, e.Message)
+}
+
+// KindForStatus maps an HTTP status code to an error kind.
+// It returns nil for statuses that carry no useful classification.
+func KindForStatus(status int) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrUnauthorized
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status >= 500:
+		return ErrUnavailable
+	case status >= 400:
+		return ErrRejected
+	default:
+		return nil
+	}
+}
@@ -15,11 +15,23 @@ package gateway
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
 	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
 	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/deadletter"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/eventhook"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/htmlmail"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/idempotency"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/ledger"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/queue"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/schedule"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/template"
 	"github.com/weprodev/wpd-message-gateway/pkg/contracts"
 )
 
@@ -27,96 +39,558 @@ func (c *configAdapter) DefaultEmailProvider() string { return c.cfg.DefaultEmai
 func (c *configAdapter) DefaultSMSProvider() string   { return c.cfg.DefaultSMSProvider }
 func (c *configAdapter) DefaultPushProvider() string  { return c.cfg.DefaultPushProvider }
 func (c *configAdapter) DefaultChatProvider() string  { return c.cfg.DefaultChatProvider }
+func (c *configAdapter) EmailFailover() []string      { return c.cfg.EmailFailover }
+func (c *configAdapter) SMSFailover() []string        { return c.cfg.SMSFailover }
+func (c *configAdapter) PushFailover() []string       { return c.cfg.PushFailover }
+func (c *configAdapter) ChatFailover() []string       { return c.cfg.ChatFailover }
+func (c *configAdapter) RetryPolicies() RetryPolicies { return c.cfg.Retry }
 
 // New creates a new Gateway instance.
 func New(cfg Config) (*Gateway, error) {
 	serviceRegistry := service.NewRegistry()
 
 	gw := &Gateway{cfg: cfg}
+	if !cfg.DisableCircuitBreaker {
+		gw.breakers = resilience.NewBreakers(cfg.CircuitBreaker)
+	}
 
 	if err := gw.initializeProviders(serviceRegistry); err != nil {
 		return nil, err
 	}
 
-	gw.service = service.NewGatewayService(&configAdapter{cfg}, serviceRegistry)
+	messageLedger, err := ledger.Open(cfg.Ledger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	gw.ledger = messageLedger
+
+	var recordLedger port.Ledger = messageLedger
+	if len(cfg.Events.Subscribers) > 0 {
+		store, err := deadletter.Open(cfg.Events.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open dead letter store: %w", err)
+		}
+		events, err := service.NewEventDispatcher(eventhook.New(), store, cfg.Events.Subscribers)
+		if err != nil {
+			return nil, err
+		}
+		events.Start()
+		gw.events, gw.letters = events, store
+		recordLedger = service.NewPublishingLedger(messageLedger, events)
+	}
+
+	gw.service = service.NewGatewayService(&configAdapter{cfg}, serviceRegistry, recordLedger)
+
+	gw.idempotencyStore = idempotency.NewMemoryStore()
+	gw.idempotency = service.NewIdempotency(gw.idempotencyStore, cfg.IdempotencyTTL)
+
+	templateStore, err := template.Open(cfg.Templates.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open template store: %w", err)
+	}
+	gw.templateStore = templateStore
+	gw.templates = service.NewTemplateService(templateStore)
+
+	gw.webhooks = service.NewWebhookService(recordLedger)
+	gw.initializeWebhookParsers()
+
+	if cfg.Queue.Dir != "" {
+		q, err := queue.Open(cfg.Queue.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open queue: %w", err)
+		}
+		store, err := schedule.Open(cfg.Queue.Dir)
+		if err != nil {
+			_ = q.Close()
+			return nil, fmt.Errorf("failed to open schedule store: %w", err)
+		}
+		failed, err := queue.OpenFailed(cfg.Queue.Dir)
+		if err != nil {
+			_ = q.Close()
+			_ = store.Close()
+			return nil, fmt.Errorf("failed to open failed job store: %w", err)
+		}
+		gw.queue, gw.schedules, gw.failedJobs = q, store, failed
+
+		gw.async = service.NewAsyncSender(gw.service, q, failed, cfg.Queue.Workers)
+		gw.async.Start()
+		gw.scheduler = service.NewScheduler(store, q, recordLedger)
+		gw.scheduler.Start()
+	}
 
 	return gw, nil
 }
 
+// Close stops the scheduler and async workers, waiting for sends in progress
+// or for ctx to be done, then delivers pending events, and closes the queue and
+// ledger. Every component is closed even if another fails; the errors are
+// joined. Unsent and scheduled messages are kept on disk and sent after the
+// next New with the same Queue.Dir.
+func (g *Gateway) Close(ctx context.Context) error {
+	var errs []error
+	if g.async != nil {
+		errs = append(errs, g.scheduler.Stop(ctx), g.async.Stop(ctx))
+		errs = append(errs, g.schedules.Close(), g.failedJobs.Close(), g.queue.Close())
+	}
+	if g.events != nil {
+		errs = append(errs, g.events.Stop(ctx), g.letters.Close())
+	}
+	errs = append(errs, g.idempotencyStore.Close(), g.templateStore.Close(), g.ledger.Close())
+	return errors.Join(errs...)
+}
+
 func (g *Gateway) initializeProviders(serviceRegistry *service.Registry) error {
-	if name := g.cfg.DefaultEmailProvider; name != "" {
+	retry := g.cfg.Retry
+
+	for _, name := range service.ProviderChain(g.cfg.DefaultEmailProvider, g.cfg.EmailFailover) {
 		provider, err := g.createEmailProvider(name)
 		if err != nil {
 			return fmt.Errorf("failed to create email provider %s: %w", name, err)
 		}
-		serviceRegistry.RegisterEmailProvider(name, provider)
+		var sender port.EmailSender = resilience.NewRetryEmailSender(provider, retry.For("email"))
+		if g.breakers != nil {
+			sender = resilience.NewBreakerEmailSender(sender, g.breakers.Get("email", name))
+		}
+		serviceRegistry.RegisterEmailProvider(name, htmlmail.NewEmailSender(sender))
 	}
 
-	if name := g.cfg.DefaultSMSProvider; name != "" {
+	for _, name := range service.ProviderChain(g.cfg.DefaultSMSProvider, g.cfg.SMSFailover) {
 		provider, err := g.createSMSProvider(name)
 		if err != nil {
 			return fmt.Errorf("failed to create SMS provider %s: %w", name, err)
 		}
-		serviceRegistry.RegisterSMSProvider(name, provider)
+		var sender port.SMSSender = resilience.NewRetrySMSSender(provider, retry.For("sms"))
+		if g.breakers != nil {
+			sender = resilience.NewBreakerSMSSender(sender, g.breakers.Get("sms", name))
+		}
+		serviceRegistry.RegisterSMSProvider(name, sender)
 	}
 
-	if name := g.cfg.DefaultPushProvider; name != "" {
+	for _, name := range service.ProviderChain(g.cfg.DefaultPushProvider, g.cfg.PushFailover) {
 		provider, err := g.createPushProvider(name)
 		if err != nil {
 			return fmt.Errorf("failed to create push provider %s: %w", name, err)
 		}
-		serviceRegistry.RegisterPushProvider(name, provider)
+		var sender port.PushSender = resilience.NewRetryPushSender(provider, retry.For("push"))
+		if g.breakers != nil {
+			sender = resilience.NewBreakerPushSender(sender, g.breakers.Get("push", name))
+		}
+		serviceRegistry.RegisterPushProvider(name, sender)
 	}
 
-	if name := g.cfg.DefaultChatProvider; name != "" {
+	for _, name := range service.ProviderChain(g.cfg.DefaultChatProvider, g.cfg.ChatFailover) {
 		provider, err := g.createChatProvider(name)
 		if err != nil {
 			return fmt.Errorf("failed to create chat provider %s: %w", name, err)
 		}
-		serviceRegistry.RegisterChatProvider(name, provider)
+		var sender port.ChatSender = resilience.NewRetryChatSender(provider, retry.For("chat"))
+		if g.breakers != nil {
+			sender = resilience.NewBreakerChatSender(sender, g.breakers.Get("chat", name))
+		}
+		serviceRegistry.RegisterChatProvider(name, sender)
 	}
 
 	return nil
 }
 
-// SendEmail sends an email using the default provider.
+// idempotencyKey is the context key for WithIdempotencyKey.
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a context that makes a send with it idempotent:
+// a later send with the same key and the same message returns the first
+// result instead of sending again, for Config.IdempotencyTTL. Reusing a key
+// for a different message fails with ErrIdempotencyKeyReused. A failed send
+// is not remembered, so it can be retried with the same key.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// idempotentRequest is what an idempotency key is bound to.
+type idempotentRequest struct {
+	Channel  string `json:"channel"`
+	Provider string `json:"provider,omitempty"`
+	Async    bool   `json:"async"`
+	Message  any    `json:"message"`
+}
+
+// idempotent renders the request's message from its template, if it has
+// one, and runs send under the context's idempotency key, if any. The
+// message must be a copy of the caller's, since rendering fills it in.
+func (g *Gateway) idempotent(
+	ctx context.Context,
+	request idempotentRequest,
+	send func(context.Context) (*contracts.SendResult, error),
+) (*contracts.SendResult, error) {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	result, _, err := g.idempotency.Do(ctx, key, request, func(ctx context.Context) (*contracts.SendResult, error) {
+		if err := g.templates.Render(ctx, request.Message); err != nil {
+			return nil, err
+		}
+		return send(ctx)
+	})
+	return result, err
+}
+
+// SendEmail sends an email using the default provider. If email.Schedule is
+// set, the email is scheduled as by SendEmailAsync and the result holds its
+// job ID.
 func (g *Gateway) SendEmail(ctx context.Context, email *contracts.Email) (*contracts.SendResult, error) {
-	return g.service.SendEmail(ctx, email)
+	msg := *email
+	if msg.Schedule != (contracts.Schedule{}) {
+		return g.sendScheduled(ctx, "email", &msg, &msg.Schedule)
+	}
+	return g.idempotent(ctx, idempotentRequest{Channel: "email", Message: &msg}, func(ctx context.Context) (*contracts.SendResult, error) {
+		return g.service.SendEmail(ctx, &msg)
+	})
 }
 
-// SendEmailWith sends an email using a specific provider.
+// SendEmailWith sends an email using a specific provider. It returns
+// ErrScheduledWithProvider if email.Schedule is set.
 func (g *Gateway) SendEmailWith(ctx context.Context, provider string, email *contracts.Email) (*contracts.SendResult, error) {
-	return g.service.SendEmailWith(ctx, provider, email)
+	msg := *email
+	if msg.Schedule != (contracts.Schedule{}) {
+		return nil, ErrScheduledWithProvider
+	}
+	return g.idempotent(ctx, idempotentRequest{Channel: "email", Provider: provider, Message: &msg}, func(ctx context.Context) (*contracts.SendResult, error) {
+		return g.service.SendEmailWith(ctx, provider, &msg)
+	})
 }
 
-// SendSMS sends an SMS using the default provider.
+// SendSMS sends an SMS using the default provider. If sms.Schedule is set,
+// the SMS is scheduled as by SendSMSAsync and the result holds its job ID.
 func (g *Gateway) SendSMS(ctx context.Context, sms *contracts.SMS) (*contracts.SendResult, error) {
-	return g.service.SendSMS(ctx, sms)
+	msg := *sms
+	if msg.Schedule != (contracts.Schedule{}) {
+		return g.sendScheduled(ctx, "sms", &msg, &msg.Schedule)
+	}
+	return g.idempotent(ctx, idempotentRequest{Channel: "sms", Message: &msg}, func(ctx context.Context) (*contracts.SendResult, error) {
+		return g.service.SendSMS(ctx, &msg)
+	})
 }
 
-// SendSMSWith sends an SMS using a specific provider.
+// SendSMSWith sends an SMS using a specific provider. It returns
+// ErrScheduledWithProvider if sms.Schedule is set.
 func (g *Gateway) SendSMSWith(ctx context.Context, provider string, sms *contracts.SMS) (*contracts.SendResult, error) {
-	return g.service.SendSMSWith(ctx, provider, sms)
+	msg := *sms
+	if msg.Schedule != (contracts.Schedule{}) {
+		return nil, ErrScheduledWithProvider
+	}
+	return g.idempotent(ctx, idempotentRequest{Channel: "sms", Provider: provider, Message: &msg}, func(ctx context.Context) (*contracts.SendResult, error) {
+		return g.service.SendSMSWith(ctx, provider, &msg)
+	})
 }
 
-// SendPush sends a push notification using the default provider.
+// SendPush sends a push notification using the default provider. If
+// push.Schedule is set, the notification is scheduled as by SendPushAsync
+// and the result holds its job ID.
 func (g *Gateway) SendPush(ctx context.Context, push *contracts.PushNotification) (*contracts.SendResult, error) {
-	return g.service.SendPush(ctx, push)
+	msg := *push
+	if msg.Schedule != (contracts.Schedule{}) {
+		return g.sendScheduled(ctx, "push", &msg, &msg.Schedule)
+	}
+	return g.idempotent(ctx, idempotentRequest{Channel: "push", Message: &msg}, func(ctx context.Context) (*contracts.SendResult, error) {
+		return g.service.SendPush(ctx, &msg)
+	})
 }
 
-// SendPushWith sends a push notification using a specific provider.
+// SendPushWith sends a push notification using a specific provider. It
+// returns ErrScheduledWithProvider if push.Schedule is set.
 func (g *Gateway) SendPushWith(ctx context.Context, provider string, push *contracts.PushNotification) (*contracts.SendResult, error) {
-	return g.service.SendPushWith(ctx, provider, push)
+	msg := *push
+	if msg.Schedule != (contracts.Schedule{}) {
+		return nil, ErrScheduledWithProvider
+	}
+	return g.idempotent(ctx, idempotentRequest{Channel: "push", Provider: provider, Message: &msg}, func(ctx context.Context) (*contracts.SendResult, error) {
+		return g.service.SendPushWith(ctx, provider, &msg)
+	})
 }
 
-// SendChat sends a chat message using the default provider.
+// SendChat sends a chat message using the default provider. If
+// chat.Schedule is set, the message is scheduled as by SendChatAsync and the
+// result holds its job ID.
 func (g *Gateway) SendChat(ctx context.Context, chat *contracts.ChatMessage) (*contracts.SendResult, error) {
-	return g.service.SendChat(ctx, chat)
+	msg := *chat
+	if msg.Schedule != (contracts.Schedule{}) {
+		return g.sendScheduled(ctx, "chat", &msg, &msg.Schedule)
+	}
+	return g.idempotent(ctx, idempotentRequest{Channel: "chat", Message: &msg}, func(ctx context.Context) (*contracts.SendResult, error) {
+		return g.service.SendChat(ctx, &msg)
+	})
 }
 
-// SendChatWith sends a chat message using a specific provider.
+// SendChatWith sends a chat message using a specific provider. It returns
+// ErrScheduledWithProvider if chat.Schedule is set.
 func (g *Gateway) SendChatWith(ctx context.Context, provider string, chat *contracts.ChatMessage) (*contracts.SendResult, error) {
-	return g.service.SendChatWith(ctx, provider, chat)
+	msg := *chat
+	if msg.Schedule != (contracts.Schedule{}) {
+		return nil, ErrScheduledWithProvider
+	}
+	return g.idempotent(ctx, idempotentRequest{Channel: "chat", Provider: provider, Message: &msg}, func(ctx context.Context) (*contracts.SendResult, error) {
+		return g.service.SendChatWith(ctx, provider, &msg)
+	})
+}
+
+// SendEmailAsync queues an email for background sending and returns its job ID.
+// If email.Schedule is set, the email is held until its send time.
+// It returns ErrAsyncDisabled unless Config.Queue is set.
+func (g *Gateway) SendEmailAsync(ctx context.Context, email *contracts.Email) (string, error) {
+	msg := *email
+	return g.enqueue(ctx, "email", &msg, &msg.Schedule)
+}
+
+// SendSMSAsync queues an SMS for background sending and returns its job ID.
+// If sms.Schedule is set, the SMS is held until its send time.
+func (g *Gateway) SendSMSAsync(ctx context.Context, sms *contracts.SMS) (string, error) {
+	msg := *sms
+	return g.enqueue(ctx, "sms", &msg, &msg.Schedule)
+}
+
+// SendPushAsync queues a push notification for background sending and returns its job ID.
+// If push.Schedule is set, the notification is held until its send time.
+func (g *Gateway) SendPushAsync(ctx context.Context, push *contracts.PushNotification) (string, error) {
+	msg := *push
+	return g.enqueue(ctx, "push", &msg, &msg.Schedule)
+}
+
+// SendChatAsync queues a chat message for background sending and returns its job ID.
+// If chat.Schedule is set, the message is held until its send time.
+func (g *Gateway) SendChatAsync(ctx context.Context, chat *contracts.ChatMessage) (string, error) {
+	msg := *chat
+	return g.enqueue(ctx, "chat", &msg, &msg.Schedule)
+}
+
+// enqueue queues or schedules a copy of the caller's message, leaving the
+// schedule out of the stored message.
+func (g *Gateway) enqueue(ctx context.Context, channel string, message any, sched *contracts.Schedule) (string, error) {
+	if g.async == nil {
+		return "", ErrAsyncDisabled
+	}
+
+	sendAt, err := sched.SendTime(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	request := idempotentRequest{Channel: channel, Async: true, Message: message}
+	result, err := g.idempotent(ctx, request, func(ctx context.Context) (*contracts.SendResult, error) {
+		if sendAt.IsZero() {
+			id, err := g.async.Enqueue(ctx, channel, message)
+			if err != nil {
+				return nil, err
+			}
+			return &contracts.SendResult{ID: id}, nil
+		}
+
+		*sched = contracts.Schedule{}
+		job, err := g.scheduler.Schedule(ctx, channel, message, sendAt)
+		if err != nil {
+			return nil, err
+		}
+		return &contracts.SendResult{ID: job.ID}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// sendScheduled schedules a message passed to a SendX method.
+func (g *Gateway) sendScheduled(ctx context.Context, channel string, message any, sched *contracts.Schedule) (*contracts.SendResult, error) {
+	id, err := g.enqueue(ctx, channel, message, sched)
+	if err != nil {
+		return nil, err
+	}
+	return &contracts.SendResult{ID: id, StatusCode: http.StatusAccepted, Message: "Message scheduled"}, nil
+}
+
+// ScheduledMessages returns the messages waiting for their send time, earliest first.
+func (g *Gateway) ScheduledMessages(ctx context.Context) ([]*ScheduledMessage, error) {
+	if g.scheduler == nil {
+		return nil, ErrAsyncDisabled
+	}
+	return g.scheduler.List(ctx)
+}
+
+// CancelScheduled cancels a scheduled message. It returns ErrScheduledNotFound
+// once the message has been queued for sending.
+func (g *Gateway) CancelScheduled(ctx context.Context, id string) error {
+	if g.scheduler == nil {
+		return ErrAsyncDisabled
+	}
+	return g.scheduler.Cancel(ctx, id)
+}
+
+// Reschedule moves a scheduled message to a new send time. A time in the
+// past sends it right away; the zero time returns ErrInvalidMessage.
+func (g *Gateway) Reschedule(ctx context.Context, id string, sendAt time.Time) (*ScheduledMessage, error) {
+	if g.scheduler == nil {
+		return nil, ErrAsyncDisabled
+	}
+	return g.scheduler.Reschedule(ctx, id, sendAt)
+}
+
+// FailedJobs returns the queued messages whose send failed, oldest failure
+// first. They stay there until requeued or discarded.
+func (g *Gateway) FailedJobs(ctx context.Context) ([]*FailedJob, error) {
+	if g.async == nil {
+		return nil, ErrAsyncDisabled
+	}
+	return g.async.FailedJobs(ctx)
+}
+
+// RequeueFailed queues a failed message for sending again.
+func (g *Gateway) RequeueFailed(ctx context.Context, id string) error {
+	if g.async == nil {
+		return ErrAsyncDisabled
+	}
+	return g.async.Requeue(ctx, id)
+}
+
+// RequeueAllFailed queues every failed message again and returns how many
+// were queued.
+func (g *Gateway) RequeueAllFailed(ctx context.Context) (int, error) {
+	if g.async == nil {
+		return 0, ErrAsyncDisabled
+	}
+	return g.async.RequeueAll(ctx)
+}
+
+// DiscardFailed removes a failed message without sending it.
+func (g *Gateway) DiscardFailed(ctx context.Context, id string) error {
+	if g.async == nil {
+		return ErrAsyncDisabled
+	}
+	return g.async.Discard(ctx, id)
+}
+
+// Message returns the ledger record for a message, looked up by the gateway's
+// message ID (SendResult.Meta["message_id"] or an async job ID) or by the
+// provider's message ID.
+func (g *Gateway) Message(ctx context.Context, id string) (*contracts.MessageRecord, error) {
+	return g.service.Message(ctx, id)
+}
+
+// Messages returns the ledger records matching the filter, newest first.
+func (g *Gateway) Messages(ctx context.Context, filter MessageFilter) ([]*contracts.MessageRecord, error) {
+	return g.service.Messages(ctx, filter)
+}
+
+// HandleWebhook verifies and applies a provider's delivery-status webhook,
+// for applications that receive provider webhooks on their own server.
+// It returns the events that matched a message in the ledger.
+func (g *Gateway) HandleWebhook(ctx context.Context, provider string, req *WebhookRequest) ([]contracts.DeliveryEvent, error) {
+	return g.webhooks.Handle(ctx, provider, req)
+}
+
+// DeadLetters returns the events that could not be delivered to a subscriber,
+// oldest first. A non-empty subscriber limits them to that subscriber's.
+func (g *Gateway) DeadLetters(ctx context.Context, subscriber string) ([]*DeadLetter, error) {
+	if g.events == nil {
+		return nil, ErrEventsDisabled
+	}
+	return g.events.DeadLetters(ctx, subscriber)
+}
+
+// ReplayDeadLetter posts a dead letter to its subscriber again and removes it
+// once delivered.
+func (g *Gateway) ReplayDeadLetter(ctx context.Context, id string) error {
+	if g.events == nil {
+		return ErrEventsDisabled
+	}
+	return g.events.Replay(ctx, id)
+}
+
+// ReplayDeadLetters replays every dead letter, or a subscriber's, and returns
+// how many were delivered and how many failed again.
+func (g *Gateway) ReplayDeadLetters(ctx context.Context, subscriber string) (replayed, failed int, err error) {
+	if g.events == nil {
+		return 0, 0, ErrEventsDisabled
+	}
+	return g.events.ReplayAll(ctx, subscriber)
+}
+
+// DiscardDeadLetter removes a dead letter without delivering it.
+func (g *Gateway) DiscardDeadLetter(ctx context.Context, id string) error {
+	if g.events == nil {
+		return ErrEventsDisabled
+	}
+	return g.events.Discard(ctx, id)
+}
+
+// CreateTemplate stores template as the next version of its name in its
+// locale and returns the stored version.
+func (g *Gateway) CreateTemplate(ctx context.Context, template *contracts.Template) (*contracts.Template, error) {
+	return g.templates.Create(ctx, template)
+}
+
+// Template returns a version of a template in a locale, or its latest
+// version when version is zero. The default locale is "".
+func (g *Gateway) Template(ctx context.Context, name, locale string, version int) (*contracts.Template, error) {
+	return g.templates.Get(ctx, name, locale, version)
+}
+
+// TemplateVersions returns every version of a template in every locale.
+func (g *Gateway) TemplateVersions(ctx context.Context, name string) ([]*contracts.Template, error) {
+	return g.templates.Versions(ctx, name)
+}
+
+// Templates returns the latest version of each template in each locale.
+func (g *Gateway) Templates(ctx context.Context) ([]*contracts.Template, error) {
+	return g.templates.List(ctx)
+}
+
+// DeleteTemplate removes every version of a template.
+func (g *Gateway) DeleteTemplate(ctx context.Context, name string) error {
+	return g.templates.Delete(ctx, name)
+}
+
+// RenderTemplate fills in a message from its Template, as a send would,
+// without sending it. message is a *contracts.Email, *contracts.SMS,
+// *contracts.PushNotification or *contracts.ChatMessage.
+func (g *Gateway) RenderTemplate(ctx context.Context, message any) error {
+	return g.templates.Render(ctx, message)
+}
+
+// BreakerStatuses returns the state of each provider's circuit breaker.
+func (g *Gateway) BreakerStatuses() []BreakerStatus {
+	if g.breakers == nil {
+		return nil
+	}
+	return g.breakers.Statuses()
+}
+
+// initializeWebhookParsers registers a webhook parser for each configured
+// provider that has one. A provider missing its webhook secret is skipped.
+func (g *Gateway) initializeWebhookParsers() {
+	for _, name := range registry.WebhookParserNames() {
+		cfg, ok := g.commonProviderConfig(name)
+		if !ok {
+			continue
+		}
+		factory, err := registry.GetWebhookParserFactory(name)
+		if err != nil {
+			continue
+		}
+		if parser, err := factory(cfg); err == nil {
+			g.webhooks.RegisterParser(name, parser)
+		}
+	}
+}
+
+func (g *Gateway) commonProviderConfig(name string) (registry.CommonConfig, bool) {
+	if cfg, ok := g.cfg.EmailProviders[name]; ok {
+		return cfg.CommonConfig, true
+	}
+	if cfg, ok := g.cfg.SMSProviders[name]; ok {
+		return cfg.CommonConfig, true
+	}
+	if cfg, ok := g.cfg.PushProviders[name]; ok {
+		return cfg.CommonConfig, true
+	}
+	if cfg, ok := g.cfg.ChatProviders[name]; ok {
+		return cfg.CommonConfig, true
+	}
+	return registry.CommonConfig{}, false
 }
 
 func (g *Gateway) createEmailProvider(name string) (port.EmailSender, error) {
@@ -1,8 +1,14 @@
 package gateway
 
 import (
+	"errors"
+	"time"
+
 	"github.com/weprodev/wpd-message-gateway/internal/app/registry"
+	"github.com/weprodev/wpd-message-gateway/internal/core/port"
+	"github.com/weprodev/wpd-message-gateway/internal/core/resilience"
 	"github.com/weprodev/wpd-message-gateway/internal/core/service"
+	"github.com/weprodev/wpd-message-gateway/internal/infrastructure/ledger"
 )
 
 // Config holds the gateway configuration.
@@ -12,6 +18,13 @@ type Config struct {
 	DefaultPushProvider  string
 	DefaultChatProvider  string
 
+	// Failover providers are tried, in order, when the default provider fails
+	// with a retryable error. Each must have an entry in the provider configs.
+	EmailFailover []string
+	SMSFailover   []string
+	PushFailover  []string
+	ChatFailover  []string
+
 	// Provider-specific configurations keyed by provider name.
 	// Uses registry types as single source of truth.
 	EmailProviders map[string]registry.EmailConfig
@@ -21,6 +34,56 @@ type Config struct {
 
 	// MailpitEnabled enables SMTP forwarding for the memory provider.
 	MailpitEnabled bool
+
+	// Retry controls retries of transient provider failures. The zero value
+	// retries up to 3 attempts with exponential backoff.
+	Retry RetryPolicies
+
+	// CircuitBreaker controls the breaker guarding each provider. The zero
+	// value opens after 5 consecutive failures for 30 seconds.
+	CircuitBreaker BreakerSettings
+	// DisableCircuitBreaker turns the breakers off.
+	DisableCircuitBreaker bool
+
+	// Ledger configures the record of sent messages, read with Message and
+	// Messages. The zero value keeps 7 days in memory.
+	Ledger LedgerConfig
+
+	// Queue enables the SendXAsync methods and scheduled sends.
+	// Leave Dir empty to disable.
+	Queue QueueConfig
+
+	// Events posts message events to the configured subscribers.
+	Events EventsConfig
+
+	// IdempotencyTTL is how long the result of a send made with
+	// WithIdempotencyKey is replayed. Defaults to 24 hours. Results are kept
+	// in memory, for this Gateway only.
+	IdempotencyTTL time.Duration
+
+	// Templates stores the templates messages can be rendered from.
+	Templates TemplatesConfig
+}
+
+// TemplatesConfig configures message template storage.
+type TemplatesConfig struct {
+	// Dir keeps templates on disk. Empty keeps them in memory.
+	Dir string
+}
+
+// EventsConfig configures the applications notified of message events.
+type EventsConfig struct {
+	Subscribers []EventSubscriber
+	// Dir keeps undelivered events on disk. Empty keeps them in memory.
+	Dir string
+}
+
+// QueueConfig configures the durable queue behind async sends.
+type QueueConfig struct {
+	// Dir is the directory holding the queue log and scheduled messages.
+	Dir string
+	// Workers is the number of concurrent senders. Defaults to 4.
+	Workers int
 }
 
 // Type aliases for SDK users - these reference the canonical registry types.
@@ -31,12 +94,97 @@ type (
 	SMSConfig    = registry.SMSConfig
 	PushConfig   = registry.PushConfig
 	ChatConfig   = registry.ChatConfig
+
+	RetryPolicy   = resilience.RetryPolicy
+	RetryPolicies = resilience.RetryPolicies
+
+	BreakerSettings = resilience.BreakerSettings
+	BreakerStatus   = resilience.BreakerStatus
+
+	LedgerConfig   = ledger.Options
+	MessageFilter  = port.MessageFilter
+	WebhookRequest = port.WebhookRequest
+
+	EventSubscriber = service.Subscriber
+	DeadLetter      = port.DeadLetter
 )
 
+// ErrAsyncDisabled is returned by the SendXAsync methods, and for scheduled
+// messages, when Config.Queue is not set.
+var ErrAsyncDisabled = service.ErrAsyncDisabled
+
+// ErrScheduledWithProvider is returned by the SendXWith methods for a
+// scheduled message: scheduled messages are sent through the default
+// provider and its failover.
+var ErrScheduledWithProvider = errors.New("scheduled messages cannot be sent with a specific provider")
+
+// ErrScheduledNotFound is returned for a scheduled message that does not
+// exist or has already been queued for sending.
+var ErrScheduledNotFound = port.ErrJobNotFound
+
+// ErrFailedJobNotFound is returned for a failed job that does not exist or
+// has already been requeued or discarded.
+var ErrFailedJobNotFound = port.ErrJobNotFound
+
+// ErrInvalidMessage is returned by the SendXAsync methods for a message that
+// could never be sent, such as one without recipients.
+var ErrInvalidMessage = service.ErrInvalidMessage
+
+// ErrMessageNotFound is returned by Message when the ledger has no record.
+var ErrMessageNotFound = port.ErrMessageNotFound
+
+// Webhook errors returned by HandleWebhook.
+var (
+	ErrWebhookNotConfigured = service.ErrWebhookNotConfigured
+	ErrInvalidSignature     = port.ErrInvalidSignature
+)
+
+// Event errors returned by the dead letter methods.
+var (
+	ErrEventsDisabled     = errors.New("event subscribers are not configured")
+	ErrDeadLetterNotFound = port.ErrDeadLetterNotFound
+)
+
+// Idempotency errors returned by sends made with WithIdempotencyKey.
+var (
+	ErrIdempotencyKeyReused   = service.ErrIdempotencyKeyReused
+	ErrIdempotencyKeyInFlight = service.ErrIdempotencyKeyInFlight
+)
+
+// Template errors.
+var (
+	ErrTemplateNotFound = port.ErrTemplateNotFound
+	ErrInvalidTemplate  = service.ErrInvalidTemplate
+	ErrTemplateRender   = service.ErrTemplateRender
+)
+
+// ScheduledMessage is a message waiting for its send time.
+type ScheduledMessage = port.ScheduledJob
+
+// FailedJob is a queued message whose send failed.
+type FailedJob = port.FailedJob
+
 // Gateway is the main entry point for sending messages.
 type Gateway struct {
-	service *service.GatewayService
-	cfg     Config
+	service     *service.GatewayService
+	breakers    *resilience.Breakers
+	async       *service.AsyncSender
+	scheduler   *service.Scheduler
+	queue       port.Queue
+	schedules   port.ScheduleStore
+	failedJobs  port.FailedJobStore
+	ledger      port.Ledger
+	webhooks    *service.WebhookService
+	events      *service.EventDispatcher
+	letters     port.DeadLetterStore
+	idempotency *service.Idempotency
+
+	idempotencyStore port.IdempotencyStore
+
+	templates     *service.TemplateService
+	templateStore port.TemplateStore
+
+	cfg Config
 }
 
 // configAdapter adapts Gateway config to service.GatewayConfig interface.
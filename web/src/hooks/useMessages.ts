@@ -5,8 +5,22 @@ import { API_BASE, REFETCH_INTERVAL_MS } from '@/lib/constants'
 
 // --- Generic Fetcher ---
 
+// Opened with ?tenant=, the DevBox asks for only that tenant's messages.
+const TENANT = new URLSearchParams(window.location.search).get('tenant')
+
+// With API keys configured on the gateway, the DevBox sends the admin key
+// stored with localStorage.setItem('devbox.apiKey', '<key>').
+const API_KEY = window.localStorage.getItem('devbox.apiKey')
+
+function apiFetch(path: string, init: RequestInit = {}): Promise<Response> {
+  const headers = new Headers(init.headers)
+  if (API_KEY) headers.set('Authorization', `Bearer ${API_KEY}`)
+  if (TENANT) headers.set('X-Tenant-ID', TENANT)
+  return fetch(`${API_BASE}/${path}`, { ...init, headers })
+}
+
 async function fetchResource<T>(endpoint: string, errorMessage: string): Promise<T> {
-  const response = await fetch(`${API_BASE}/${endpoint}`)
+  const response = await apiFetch(endpoint)
   if (!response.ok) throw new Error(errorMessage)
   return response.json()
 }
@@ -76,7 +90,7 @@ export function useDeleteMessage() {
 
   return useMutation({
     mutationFn: async ({ type, id }: { type: string; id: string }) => {
-      const response = await fetch(`${API_BASE}/${type}/${id}`, { method: 'DELETE' })
+      const response = await apiFetch(`${type}/${id}`, { method: 'DELETE' })
       if (!response.ok) throw new Error(`Failed to delete ${type}`)
     },
     onSuccess: () => invalidateAllQueries(queryClient),
@@ -88,7 +102,7 @@ export function useClearAll() {
 
   return useMutation({
     mutationFn: async () => {
-      const response = await fetch(`${API_BASE}/messages`, { method: 'DELETE' })
+      const response = await apiFetch('messages', { method: 'DELETE' })
       if (!response.ok) throw new Error('Failed to clear messages')
     },
     onSuccess: () => invalidateAllQueries(queryClient),
@@ -124,6 +138,10 @@ export function useSSE() {
   }, [queryClient])
 
   useEffect(() => {
+    // EventSource cannot send an API key; the queries' polling keeps the
+    // DevBox up to date instead.
+    if (API_KEY) return
+
     const eventSource = new EventSource(`${API_BASE}/events`)
 
     eventSource.addEventListener('message', (event) => {
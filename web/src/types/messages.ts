@@ -3,6 +3,7 @@
 export interface StoredEmail {
     id: string
     created_at: string
+    tenant?: string
     email: {
         from: string
         from_name: string
@@ -27,6 +28,7 @@ export interface Attachment {
 export interface StoredSMS {
     id: string
     created_at: string
+    tenant?: string
     sms: {
         from: string
         to: string[]
@@ -37,6 +39,7 @@ export interface StoredSMS {
 export interface StoredPush {
     id: string
     created_at: string
+    tenant?: string
     push: {
         device_tokens: string[]
         title: string
@@ -44,12 +47,18 @@ export interface StoredPush {
         data?: Record<string, string>
         badge?: number
         sound?: string
+        priority?: 'high' | 'normal'
+        collapse_id?: string
+        expires_at?: string
+        external_ids?: string[]
+        segments?: string[]
     }
 }
 
 export interface StoredChat {
     id: string
     created_at: string
+    tenant?: string
     chat: {
         from: string
         to: string[]